@@ -0,0 +1,132 @@
+package art
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"tunes-service/storage"
+)
+
+const (
+	MAX_ART_BYTES = 4 << 20
+	// MAX_ART_PIXELS caps the size images decode to, which a small file can
+	// still make huge.
+	MAX_ART_PIXELS = 5000 * 5000
+	JPEG_QUALITY   = 85
+)
+
+var ErrUnsupported = errors.New("unsupported image")
+
+type Size string
+
+const (
+	Small  Size = "small"
+	Medium Size = "medium"
+	Large  Size = "large"
+)
+
+// Sizes maps each thumbnail size to the longest side it is scaled down to.
+var Sizes = map[Size]int{
+	Small:  64,
+	Medium: 300,
+	Large:  1000,
+}
+
+func (s Size) IsValid() bool {
+	_, ok := Sizes[s]
+	return ok
+}
+
+// Key is where a thumbnail of the artwork with the given hash is stored.
+func Key(hash string, size Size) string {
+	return hash[:2] + "/" + hash + "/" + string(size) + ".jpg"
+}
+
+// Save stores every thumbnail size of an image and returns its content hash.
+// Artwork is stored by hash, so an image uploaded twice is only processed
+// once.
+func Save(data []byte, store storage.Store) (string, error) {
+	if len(data) > MAX_ART_BYTES {
+		return "", fmt.Errorf("%w: image is larger than %d bytes", ErrUnsupported, MAX_ART_BYTES)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	// The largest size is written last, so it marks complete artwork.
+	if ok, err := store.Exists(Key(hash, Large)); err != nil {
+		return "", err
+	} else if ok {
+		return hash, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, err)
+	}
+	if config.Width*config.Height > MAX_ART_PIXELS {
+		return "", fmt.Errorf("%w: image has more than %d pixels", ErrUnsupported, MAX_ART_PIXELS)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, err)
+	}
+
+	for _, size := range []Size{Small, Medium, Large} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, Resize(img, Sizes[size]), &jpeg.Options{Quality: JPEG_QUALITY}); err != nil {
+			return "", fmt.Errorf("could not encode artwork: %w", err)
+		}
+		if err := store.Put(Key(hash, size), buf.Bytes(), "image/jpeg"); err != nil {
+			return "", fmt.Errorf("could not store artwork: %w", err)
+		}
+	}
+
+	return hash, nil
+}
+
+// Resize scales an image down so its longest side is at most longest pixels,
+// averaging the source pixels each target pixel covers. Smaller images are
+// only copied.
+func Resize(src image.Image, longest int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > longest || h > longest {
+		if w >= h {
+			w, h = longest, (h*longest+w/2)/w
+		} else {
+			w, h = (w*longest+h/2)/h, longest
+		}
+	}
+	w, h = max(w, 1), max(h, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(b.Min.X+(x+1)*b.Dx()/w, x0+1)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,97 @@
+package art
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"tunes-service/storage"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name     string
+		w, h     int
+		longest  int
+		expected image.Point
+	}{
+		{"Should scale landscape", 2000, 1000, 300, image.Pt(300, 150)},
+		{"Should scale portrait", 500, 1000, 64, image.Pt(32, 64)},
+		{"Should not upscale", 40, 40, 300, image.Pt(40, 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			if size := Resize(img, tt.longest).Bounds().Size(); size != tt.expected {
+				t.Fatalf("expected size %v but got %v", tt.expected, size)
+			}
+		})
+	}
+
+	t.Run("Should average pixels", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+		img.Set(0, 0, color.White)
+		img.Set(1, 0, color.Black)
+		r, _, _, _ := Resize(img, 1).At(0, 0).RGBA()
+		if r < 0x7000 || r > 0x9000 {
+			t.Fatalf("expected a gray pixel but got red %x", r)
+		}
+	})
+}
+
+func TestSave(t *testing.T) {
+	store, err := storage.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1200, 1200)))
+
+	hash, err := Save(buf.Bytes(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for size, longest := range Sizes {
+		data, err := store.Get(Key(hash, size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := img.Bounds().Dx(); w != longest {
+			t.Fatalf("expected %s to be %d wide but got %d", size, longest, w)
+		}
+	}
+
+	again, err := Save(buf.Bytes(), store)
+	if err != nil || again != hash {
+		t.Fatalf("expected the same hash %s but got %s, %v", hash, again, err)
+	}
+
+	if _, err := Save([]byte("not an image"), store); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected %v but got %v", ErrUnsupported, err)
+	}
+
+	// A PNG claiming to be 100000 pixels square is refused before decoding.
+	buf.Reset()
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	huge := buf.Bytes()
+	binary.BigEndian.PutUint32(huge[16:], 100000)
+	binary.BigEndian.PutUint32(huge[20:], 100000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if _, err := Save(huge, store); !errors.Is(err, ErrUnsupported) || !strings.Contains(err.Error(), "pixels") {
+		t.Fatalf("expected %v but got %v", ErrUnsupported, err)
+	}
+}
@@ -0,0 +1,101 @@
+package art
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	FETCH_TIMEOUT       = 10 * time.Second
+	MAX_FETCH_REDIRECTS = 5
+)
+
+// ErrForbiddenHost is returned for artwork the server may not fetch, because
+// its host is not an art provider or is not on the public internet.
+var ErrForbiddenHost = errors.New("forbidden artwork host")
+
+// sharedAddressSpace is the carrier-grade NAT range, which some clouds serve
+// their metadata endpoints from.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddr reports whether an address is on the public internet rather
+// than loopback, private, link-local, which includes cloud metadata
+// endpoints, or otherwise special.
+func IsPublicAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	if !a.IsGlobalUnicast() || a.IsPrivate() || sharedAddressSpace.Contains(a) {
+		return false
+	}
+	return !a.Is4() || a.As4()[0] != 0
+}
+
+// AllowedURL accepts absolute http and https URLs on one of the hosts of art
+// providers or their subdomains, or on any host when there are none.
+func AllowedURL(u *url.URL, hosts []string) bool {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	if len(hosts) == 0 {
+		return true
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClient returns a client for downloading artwork from the hosts of art
+// providers, or from any host when there are none. It only connects to public
+// addresses, checked after resolving each host, so links in spins cannot make
+// the server reach internal services, neither directly nor by redirecting.
+func NewClient(hosts []string) *http.Client {
+	dialer := &net.Dialer{Timeout: FETCH_TIMEOUT}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect on our behalf, past the address checks.
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("no addresses for %s", host)
+		}
+		for _, a := range addrs {
+			if !IsPublicAddr(a) {
+				return nil, fmt.Errorf("%w: %s resolves to %s", ErrForbiddenHost, host, a)
+			}
+		}
+		// Dialing the checked address keeps a second lookup from answering
+		// with another one.
+		return dialer.DialContext(ctx, network, net.JoinHostPort(addrs[0].Unmap().String(), port))
+	}
+
+	return &http.Client{
+		Timeout:   FETCH_TIMEOUT,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= MAX_FETCH_REDIRECTS {
+				return fmt.Errorf("stopped after %d redirects", MAX_FETCH_REDIRECTS)
+			}
+			if !AllowedURL(req.URL, hosts) {
+				return fmt.Errorf("%w: redirect to %s", ErrForbiddenHost, req.URL.Host)
+			}
+			return nil
+		},
+	}
+}
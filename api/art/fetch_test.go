@@ -0,0 +1,90 @@
+package art
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.8", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.100.100.200", false},
+		{"fd00:ec2::254", false},
+		{"::ffff:127.0.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if public := IsPublicAddr(netip.MustParseAddr(tt.addr)); public != tt.expected {
+				t.Fatalf("expected public %v but got %v", tt.expected, public)
+			}
+		})
+	}
+}
+
+func TestAllowedURL(t *testing.T) {
+	hosts := []string{"coverartarchive.org"}
+	tests := []struct {
+		name     string
+		url      string
+		hosts    []string
+		expected bool
+	}{
+		{"Should allow any host without providers", "https://example.com/front.jpg", nil, true},
+		{"Should allow provider", "https://coverartarchive.org/release/1/front", hosts, true},
+		{"Should allow subdomain of provider", "http://IA800.coverartarchive.org/front.jpg", hosts, true},
+		{"Other host", "https://example.com/front.jpg", hosts, false},
+		{"Host ending like provider", "https://evilcoverartarchive.org/front.jpg", hosts, false},
+		{"Other scheme", "file:///etc/passwd", nil, false},
+		{"Relative URL", "/front.jpg", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed := AllowedURL(u, tt.hosts); allowed != tt.expected {
+				t.Fatalf("expected allowed %v but got %v", tt.expected, allowed)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(nil).Get(server.URL); !errors.Is(err, ErrForbiddenHost) {
+		t.Fatalf("expected error %v but got %v", ErrForbiddenHost, err)
+	}
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://example.com/front.jpg", http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	client := NewClient([]string{"127.0.0.1"})
+	client.Transport = redirect.Client().Transport
+	if _, err := client.Get(redirect.URL); !errors.Is(err, ErrForbiddenHost) {
+		t.Fatalf("expected redirect to another host to fail with %v but got %v", ErrForbiddenHost, err)
+	}
+}
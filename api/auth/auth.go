@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -29,7 +30,13 @@ func ValidatePassword(unhashed, hashed string) (bool, error) {
 	return true, nil
 }
 
-func CreateAccessToken() (string, error) {
+type Claims struct {
+	UserID uint64
+	Name   string
+	Admin  bool
+}
+
+func CreateAccessToken(claims Claims) (string, error) {
 	secret := os.Getenv("SECRET_TOKEN")
 	if secret == "" {
 		return "", fmt.Errorf("could not create token: missing secret")
@@ -37,9 +44,12 @@ func CreateAccessToken() (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256,
 		jwt.MapClaims{
-			"iss": "tunes-server",
-			"iat": time.Now().Unix(),
-			"exp": time.Now().Add(ACCESS_TOKEN_DURATION).Unix(),
+			"iss":   "tunes-server",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(ACCESS_TOKEN_DURATION).Unix(),
+			"sub":   strconv.FormatUint(claims.UserID, 10),
+			"name":  claims.Name,
+			"admin": claims.Admin,
 		})
 	signed, err := token.SignedString([]byte(secret))
 	if err != nil {
@@ -70,11 +80,34 @@ func ShouldRefresh(accessToken string) bool {
 	}
 }
 
-func parseToken(accessToken string) (t *jwt.Token, err error) {
+// ParseClaims reads the claims of a correctly signed token, expired or not,
+// so that a refreshed token can carry the same identity as the old one.
+func ParseClaims(accessToken string) (Claims, error) {
+	t, err := parseToken(accessToken, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return Claims{}, err
+	}
+	return ClaimsFromToken(t), nil
+}
+
+func ClaimsFromToken(t *jwt.Token) (c Claims) {
+	mc, ok := t.Claims.(jwt.MapClaims)
+	if !ok {
+		return
+	}
+	if sub, err := mc.GetSubject(); err == nil {
+		c.UserID, _ = strconv.ParseUint(sub, 10, 64)
+	}
+	c.Name, _ = mc["name"].(string)
+	c.Admin, _ = mc["admin"].(bool)
+	return
+}
+
+func parseToken(accessToken string, opts ...jwt.ParserOption) (t *jwt.Token, err error) {
 	return jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing algorithm: %v", token.Header["alg"])
 		}
 		return []byte(os.Getenv("SECRET_TOKEN")), nil
-	})
+	}, opts...)
 }
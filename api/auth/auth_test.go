@@ -44,11 +44,35 @@ func TestCreateAccessToken(t *testing.T) {
 		t.Skip("missing secret key")
 	}
 
-	if _, err := CreateAccessToken(); err != nil {
+	if _, err := CreateAccessToken(Claims{}); err != nil {
 		t.Error(err)
 	}
 }
 
+func TestParseClaims(t *testing.T) {
+	if os.Getenv("SECRET_TOKEN") == "" {
+		t.Skip("missing secret key")
+	}
+
+	expected := Claims{UserID: 7, Name: "test", Admin: true}
+	token, err := CreateAccessToken(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ParseClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != expected {
+		t.Fatalf("expected %+v but got %+v", expected, actual)
+	}
+
+	if _, err := ParseClaims("not.a.token"); err == nil {
+		t.Fatalf("expected error but got none")
+	}
+}
+
 func TestValidateToken(t *testing.T) {
 	if os.Getenv("SECRET_TOKEN") == "" {
 		t.Skip("missing secret key")
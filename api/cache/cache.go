@@ -28,3 +28,7 @@ func (c *BC) Get(key string) string {
 func (c *BC) Put(key string, json string) {
 	c.cache.Set(key, []byte(json))
 }
+
+func (c *BC) Delete(key string) {
+	c.cache.Delete(key)
+}
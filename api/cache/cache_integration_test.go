@@ -12,4 +12,10 @@ func TestCacheIntegration(t *testing.T) {
 	if v != "testData" {
 		t.Fatalf("got incorrect value")
 	}
+
+	c.Delete("testKey")
+
+	if v := c.Get("testKey"); v != "" {
+		t.Fatalf("expected deleted key to be empty but got %s", v)
+	}
 }
@@ -3,4 +3,5 @@ package cache
 type Cache interface {
 	Get(key string) string
 	Put(key string, value string)
+	Delete(key string)
 }
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"context"
+	"log"
 	"os"
+	"strings"
+	"time"
+	_ "time/tzdata"
 
 	"tunes-service/cache"
 	"tunes-service/data"
+	"tunes-service/jobs"
+	"tunes-service/metadata"
 	"tunes-service/server"
+	"tunes-service/storage"
 )
 
 func main() {
@@ -16,5 +24,130 @@ func main() {
 	db, _ := data.NewDB(os.Getenv("DATABASE_URL"))
 	adb, _ := data.NewAuthDB(os.Getenv("AUTH_DATABASE_URL"))
 	c := cache.NewCache()
-	server.RunServer(db, adb, c)
+
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-rollups" {
+		rebuildRollups(db, os.Args[2:])
+		return
+	}
+
+	if provider := metadataProvider(); provider != nil {
+		go jobs.NewEnricher(db, provider).Run(context.Background())
+	}
+
+	store := artStore()
+	go jobs.NewArtFetcher(db, store, artHosts()).Run(context.Background())
+	go jobs.NewWrappedGenerator(db).Run(context.Background())
+	go jobs.NewRollupCompactor(db).Run(context.Background())
+	go jobs.NewGroupChartSnapshotter(db).Run(context.Background())
+	go jobs.NewUserChartSnapshotter(db).Run(context.Background())
+	go jobs.NewNeighborBuilder(db, sessionGap()).Run(context.Background())
+	go jobs.NewSessionDetector(db, sessionGap()).Run(context.Background())
+
+	server.RunServer(db, adb, c, store, primaryPolicy(), achievements())
+}
+
+// rebuildRollups recomputes the rollups of the user named in args, or of every
+// user, after backfilling spins.
+func rebuildRollups(db data.DB, args []string) {
+	var userID uint64
+	if len(args) > 0 {
+		u, err := db.GetUser(args[0])
+		if err != nil {
+			panic(err)
+		}
+		userID = u.ID
+	}
+
+	n, err := jobs.NewRollupCompactor(db).Rebuild(userID)
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("rebuilt the rollups of %d users", n)
+}
+
+func metadataProvider() metadata.Provider {
+	if path := os.Getenv("METADATA_DUMP_PATH"); path != "" {
+		p, err := metadata.NewDumpProvider(path)
+		if err != nil {
+			panic(err)
+		}
+		return p
+	}
+	if url := os.Getenv("METADATA_URL"); url != "" {
+		return metadata.NewHTTPProvider(url, metadata.MUSICBRAINZ_RATE_LIMIT)
+	}
+	return nil
+}
+
+func primaryPolicy() data.PrimaryPolicy {
+	names := os.Getenv("PRIMARY_POLICY")
+	if names == "" {
+		return data.DefaultPrimaryPolicy
+	}
+	policy, err := data.ParsePrimaryPolicy(names)
+	if err != nil {
+		panic(err)
+	}
+	return policy
+}
+
+func achievements() data.Achievements {
+	list := os.Getenv("ACHIEVEMENTS")
+	if list == "" {
+		return data.DefaultAchievements
+	}
+	achievements, err := data.ParseAchievements(list)
+	if err != nil {
+		panic(err)
+	}
+	return achievements
+}
+
+// sessionGap is the longest pause within a listening session, as a duration
+// such as 45m.
+func sessionGap() time.Duration {
+	s := os.Getenv("SESSION_GAP")
+	if s == "" {
+		return data.SESSION_GAP
+	}
+	gap, err := time.ParseDuration(s)
+	if err != nil {
+		panic(err)
+	}
+	return gap
+}
+
+// artHosts are the hosts of the art providers artwork is fetched from, as a
+// comma separated list such as coverartarchive.org,archive.org. Subdomains
+// count too. Any public host is fetched from when there are none.
+func artHosts() []string {
+	hosts := []string{}
+	for _, h := range strings.Split(os.Getenv("ART_HOSTS"), ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+func artStore() storage.Store {
+	if endpoint := os.Getenv("ART_S3_ENDPOINT"); endpoint != "" {
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  endpoint,
+			Region:    os.Getenv("ART_S3_REGION"),
+			Bucket:    os.Getenv("ART_S3_BUCKET"),
+			AccessKey: os.Getenv("ART_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("ART_S3_SECRET_KEY"),
+		})
+	}
+
+	path := os.Getenv("ART_STORAGE_PATH")
+	if path == "" {
+		path = "art"
+	}
+	s, err := storage.NewFSStore(path)
+	if err != nil {
+		panic(err)
+	}
+	return s
 }
@@ -0,0 +1,113 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var artTables = map[EntityType]string{
+	ArtistEntity:  "artist",
+	ProjectEntity: "project",
+}
+
+// SetArt points an artist or project at stored artwork. Projects merged into
+// another are found by their old key.
+func (pg *PGDB) SetArt(entity EntityType, key uint64, hash string) error {
+	table, ok := artTables[entity]
+	if !ok {
+		return fmt.Errorf("%s has no artwork", entity)
+	}
+	stmt := fmt.Sprintf(`UPDATE %s SET art_hash=$2 WHERE id=$1`, table)
+	if entity == ProjectEntity {
+		stmt = `UPDATE project SET art_hash=$2, art_fetched_at=now()
+		WHERE id=COALESCE((SELECT project_id FROM project_alias WHERE id=$1), $1)`
+	}
+
+	tag, err := pg.db.Exec(context.Background(), stmt, key, hash)
+	if err != nil {
+		return fmt.Errorf("error updating %s art: %w", entity, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s %d", ErrNotFound, entity, key)
+	}
+
+	return nil
+}
+
+// GetArt returns the artwork hash of an artist or project, which is empty when
+// it has none.
+func (pg *PGDB) GetArt(entity EntityType, key uint64) (string, error) {
+	table, ok := artTables[entity]
+	if !ok {
+		return "", fmt.Errorf("%s has no artwork", entity)
+	}
+	stmt := fmt.Sprintf(`SELECT COALESCE(art_hash, '') FROM %s WHERE id=$1`, table)
+	if entity == ProjectEntity {
+		stmt = `SELECT COALESCE(art_hash, '') FROM project
+		WHERE id=COALESCE((SELECT project_id FROM project_alias WHERE id=$1), $1)`
+	}
+
+	var hash string
+	if err := pg.db.QueryRow(context.Background(), stmt, key).Scan(&hash); errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s %d", ErrNotFound, entity, key)
+	} else if err != nil {
+		return "", fmt.Errorf("error selecting %s art: %w", entity, err)
+	}
+
+	return hash, nil
+}
+
+// SetProjectArtURL remembers where a client found artwork for a project that
+// has none yet, for the artwork fetcher to download. A URL that failed to
+// download gives way to a different one, so one bad URL does not keep the
+// project without artwork.
+func (pg *PGDB) SetProjectArtURL(key uint64, url string) error {
+	const stmt = `UPDATE project SET art_url=$2, art_fetched_at=NULL
+	WHERE id=$1 AND art_hash IS NULL
+	AND (art_url IS NULL OR (art_fetched_at IS NOT NULL AND art_url <> $2))`
+
+	if _, err := pg.db.Exec(context.Background(), stmt, key, url); err != nil {
+		return fmt.Errorf("error updating project art url: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingArt lists projects with an artwork URL that was not fetched yet.
+func (pg *PGDB) GetPendingArt(limit int) ([]ArtSource, error) {
+	const stmt = `SELECT id, art_url FROM project
+	WHERE art_url IS NOT NULL AND art_hash IS NULL AND art_fetched_at IS NULL
+	ORDER BY id
+	LIMIT $1`
+
+	rows, err := pg.db.Query(context.Background(), stmt, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting pending art: %w", err)
+	}
+
+	sources, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ArtSource, error) {
+		var s ArtSource
+		err := row.Scan(&s.Key, &s.URL)
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting pending art: %w", err)
+	}
+
+	return sources, nil
+}
+
+// MarkArtFetched records a failed download so the URL is not retried until a
+// client sends another one.
+func (pg *PGDB) MarkArtFetched(key uint64) error {
+	const stmt = `UPDATE project SET art_fetched_at=now() WHERE id=$1`
+
+	if _, err := pg.db.Exec(context.Background(), stmt, key); err != nil {
+		return fmt.Errorf("error updating project art: %w", err)
+	}
+
+	return nil
+}
@@ -23,9 +23,10 @@ func NewAuthDB(uri string) (*AuthMongoDB, error) {
 	}, nil
 }
 
-func (db *AuthMongoDB) WriteRefreshToken(id string, expires time.Time) (bool, error) {
+func (db *AuthMongoDB) WriteRefreshToken(id string, userID uint64, expires time.Time) (bool, error) {
 	rt := RefreshToken{
 		id,
+		userID,
 		expires,
 	}
 
@@ -14,9 +14,9 @@ func TestAuthDBIntegration(t *testing.T) {
 	}
 
 	expiration := time.Now().Add(time.Hour * 24).Truncate(time.Second)
-	expected := RefreshToken{"test", expiration}
+	expected := RefreshToken{"test", 1, expiration}
 
-	if ok, err := adb.WriteRefreshToken("test", expiration); !ok {
+	if ok, err := adb.WriteRefreshToken("test", 1, expiration); !ok {
 		t.Error(err)
 	}
 
@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const projectColumns = `p.id, p.title, p.form, p.release, COALESCE(p.mbid::text, ''),
+	p.release_precision, COALESCE(p.edition, ''), COALESCE(p.base_project_id, 0)`
+
+const trackColumns = `t.id, t.title, COALESCE(t.primary_project_id, 0), COALESCE(t.mbid::text, ''), COALESCE(t.duration_ms, 0)`
+
+// plays selects the number of spins matching a condition on s, by everyone
+// and by the user in $2. Spins are counted in subqueries rather than joined,
+// so that each is counted once however many junction rows match it.
+func plays(cond string) string {
+	return `(SELECT count(*) FROM spin s WHERE ` + cond + `) AS spins,
+	(SELECT count(*) FROM spin s WHERE s.user_id=$2 AND ` + cond + `) AS user_spins`
+}
+
+// GetArtistPage returns an artist with their projects by release and their
+// tracks by spins. A userID of 0 leaves the user's spins at 0.
+func (pg *PGDB) GetArtistPage(id uint64, userID uint64) (ArtistPage, error) {
+	const artistSelect = `SELECT a.id, a.name, COALESCE(a.mbid::text, '') FROM artist a WHERE a.id=$1`
+	playsSelect := `SELECT ` + plays(`s.track_id IN (SELECT track_id FROM artist_track WHERE artist_id=$1)`)
+	projectsSelect := `SELECT ` + projectColumns + `, ` + plays(`s.project_id=p.id`) + `
+	FROM project p
+	WHERE p.id IN (SELECT project_id FROM artist_project WHERE artist_id=$1)
+	ORDER BY p.release, p.title`
+	tracksSelect := `SELECT ` + trackColumns + `, 0, ` + plays(`s.track_id=t.id`) + `
+	FROM track t
+	WHERE t.id IN (SELECT track_id FROM artist_track WHERE artist_id=$1)
+	ORDER BY spins DESC, t.title`
+
+	page := ArtistPage{}
+	a := &page.Artist
+	if err := pg.db.QueryRow(context.Background(), artistSelect, id).Scan(&a.ID, &a.Name, &a.MBID); errors.Is(err, pgx.ErrNoRows) {
+		return ArtistPage{}, fmt.Errorf("%w: artist %d", ErrNotFound, id)
+	} else if err != nil {
+		return ArtistPage{}, fmt.Errorf("error selecting artist: %w", err)
+	}
+
+	var err error
+	if page.Plays, err = pg.selectPlays(playsSelect, a.ID, userID); err != nil {
+		return ArtistPage{}, err
+	}
+	if page.Projects, err = pg.selectProjectPlays(projectsSelect, a.ID, userID); err != nil {
+		return ArtistPage{}, err
+	}
+	if page.Tracks, err = pg.selectTrackPlays(tracksSelect, a.ID, userID); err != nil {
+		return ArtistPage{}, err
+	}
+
+	return page, nil
+}
+
+// GetProjectPage returns a project with its artists and its tracklist in
+// order. Spins of the project count the spins that came from it, spins of its
+// tracks count every spin of them.
+func (pg *PGDB) GetProjectPage(key uint64, userID uint64) (ProjectPage, error) {
+	const projectSelect = `SELECT ` + projectColumns + ` FROM project p
+	WHERE p.id=COALESCE((SELECT project_id FROM project_alias WHERE id=$1), $1)`
+	const artistsSelect = `SELECT a.id, a.name, COALESCE(a.mbid::text, '') FROM artist a
+	WHERE a.id IN (SELECT artist_id FROM artist_project WHERE project_id=$1)
+	ORDER BY a.name`
+	playsSelect := `SELECT ` + plays(`s.project_id=$1`)
+	tracksSelect := `SELECT ` + trackColumns + `, COALESCE(min(pt.track_number), 0) AS number, ` + plays(`s.track_id=t.id`) + `
+	FROM project_track pt
+	JOIN track t ON pt.track_id = t.id
+	WHERE pt.project_id=$1
+	GROUP BY t.id
+	ORDER BY min(pt.track_number) NULLS LAST, t.title`
+
+	page := ProjectPage{}
+	p := &page.Project
+	err := pg.db.QueryRow(context.Background(), projectSelect, key).
+		Scan(&p.ID, &p.Title, &p.Form, &p.Release, &p.MBID, &p.ReleasePrecision, &p.Edition, &p.BaseProjectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ProjectPage{}, fmt.Errorf("%w: project %d", ErrNotFound, key)
+	} else if err != nil {
+		return ProjectPage{}, fmt.Errorf("error selecting project: %w", err)
+	}
+
+	if page.Artists, err = pg.selectArtists(artistsSelect, p.ID); err != nil {
+		return ProjectPage{}, err
+	}
+	if page.Plays, err = pg.selectPlays(playsSelect, p.ID, userID); err != nil {
+		return ProjectPage{}, err
+	}
+	if page.Tracks, err = pg.selectTrackPlays(tracksSelect, p.ID, userID); err != nil {
+		return ProjectPage{}, err
+	}
+
+	return page, nil
+}
+
+// GetTrackPage returns a track with its artists and every project it is on.
+// Spins of a project count the spins of the track that came from it.
+func (pg *PGDB) GetTrackPage(key uint64, userID uint64) (TrackPage, error) {
+	const trackSelect = `SELECT ` + trackColumns + ` FROM track t
+	WHERE t.id=COALESCE((SELECT track_id FROM track_alias WHERE id=$1), $1)`
+	const artistsSelect = `SELECT a.id, a.name, COALESCE(a.mbid::text, '') FROM artist a
+	WHERE a.id IN (SELECT artist_id FROM artist_track WHERE track_id=$1)
+	ORDER BY a.name`
+	playsSelect := `SELECT ` + plays(`s.track_id=$1`)
+	projectsSelect := `SELECT ` + projectColumns + `, ` + plays(`s.track_id=$1 AND s.project_id=p.id`) + `
+	FROM project p
+	WHERE p.id IN (SELECT project_id FROM project_track WHERE track_id=$1)
+	ORDER BY p.release, p.title`
+
+	page := TrackPage{}
+	t := &page.Track
+	var durationMs int64
+	err := pg.db.QueryRow(context.Background(), trackSelect, key).Scan(&t.ID, &t.Title, &t.PrimaryProjectID, &t.MBID, &durationMs)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TrackPage{}, fmt.Errorf("%w: track %d", ErrNotFound, key)
+	} else if err != nil {
+		return TrackPage{}, fmt.Errorf("error selecting track: %w", err)
+	}
+	t.Duration = time.Duration(durationMs) * time.Millisecond
+
+	if page.Artists, err = pg.selectArtists(artistsSelect, t.ID); err != nil {
+		return TrackPage{}, err
+	}
+	if page.Plays, err = pg.selectPlays(playsSelect, t.ID, userID); err != nil {
+		return TrackPage{}, err
+	}
+	if page.Projects, err = pg.selectProjectPlays(projectsSelect, t.ID, userID); err != nil {
+		return TrackPage{}, err
+	}
+
+	t.ProjectIDs = []uint64{}
+	for _, p := range page.Projects {
+		t.ProjectIDs = append(t.ProjectIDs, p.Project.ID)
+	}
+
+	return page, nil
+}
+
+func (pg *PGDB) selectPlays(stmt string, id, userID uint64) (Plays, error) {
+	var p Plays
+	if err := pg.db.QueryRow(context.Background(), stmt, id, userID).Scan(&p.Spins, &p.UserSpins); err != nil {
+		return Plays{}, fmt.Errorf("error selecting plays: %w", err)
+	}
+	return p, nil
+}
+
+func (pg *PGDB) selectArtists(stmt string, id uint64) ([]Artist, error) {
+	rows, err := pg.db.Query(context.Background(), stmt, id)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting artists: %w", err)
+	}
+
+	artists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Artist, error) {
+		var a Artist
+		err := row.Scan(&a.ID, &a.Name, &a.MBID)
+		return a, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting artists: %w", err)
+	}
+
+	return artists, nil
+}
+
+func (pg *PGDB) selectProjectPlays(stmt string, id, userID uint64) ([]ProjectPlays, error) {
+	rows, err := pg.db.Query(context.Background(), stmt, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting projects: %w", err)
+	}
+
+	projects, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ProjectPlays, error) {
+		var pp ProjectPlays
+		p := &pp.Project
+		err := row.Scan(&p.ID, &p.Title, &p.Form, &p.Release, &p.MBID, &p.ReleasePrecision, &p.Edition, &p.BaseProjectID,
+			&pp.Plays.Spins, &pp.Plays.UserSpins)
+		return pp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+func (pg *PGDB) selectTrackPlays(stmt string, id, userID uint64) ([]TrackPlays, error) {
+	rows, err := pg.db.Query(context.Background(), stmt, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tracks: %w", err)
+	}
+
+	tracks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (TrackPlays, error) {
+		var tp TrackPlays
+		var durationMs int64
+		t := &tp.Track
+		err := row.Scan(&t.ID, &t.Title, &t.PrimaryProjectID, &t.MBID, &durationMs, &tp.Number, &tp.Plays.Spins, &tp.Plays.UserSpins)
+		t.Duration = time.Duration(durationMs) * time.Millisecond
+		return tp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tracks: %w", err)
+	}
+
+	return tracks, nil
+}
@@ -0,0 +1,143 @@
+package data
+
+import (
+	"slices"
+	"time"
+)
+
+// WEEKLY_CHART_SIZE is how many artists, tracks and projects weekly charts
+// rank.
+const WEEKLY_CHART_SIZE = 50
+
+// ChartEntry ranks an artist, track or project on a chart. Contributions
+// split its listening by member on group charts, Movement compares its rank
+// to the chart of the week before on weekly charts, and Run sums up its weeks
+// on a user's weekly charts.
+type ChartEntry struct {
+	Rank          int
+	Entity        EntityType
+	ID            uint64
+	Title         string
+	Spins         uint64
+	MsPlayed      uint64
+	Contributions []MemberListening
+	Movement      *ChartMovement
+	Run           *ChartRun
+}
+
+// Movement is how an entry moved on a chart since the week before.
+type Movement string
+
+const (
+	NewEntry Movement = "new"
+	Climbed  Movement = "up"
+	Fell     Movement = "down"
+	Held     Movement = "same"
+)
+
+// ChartMovement compares an entry's rank to LastRank, its rank of the week
+// before or 0 when it was not on that chart.
+type ChartMovement struct {
+	Movement Movement
+	LastRank int
+}
+
+// NewChartMovement compares a rank to the one of the week before.
+func NewChartMovement(rank, lastRank int) ChartMovement {
+	switch {
+	case lastRank == 0:
+		return ChartMovement{NewEntry, 0}
+	case rank < lastRank:
+		return ChartMovement{Climbed, lastRank}
+	case rank > lastRank:
+		return ChartMovement{Fell, lastRank}
+	default:
+		return ChartMovement{Held, lastRank}
+	}
+}
+
+// CompareCharts sets the movement of each entry of a chart since the chart of
+// the week before.
+func CompareCharts(entries, lastWeek []ChartEntry) {
+	lastRanks := make(map[uint64]int, len(lastWeek))
+	for _, e := range lastWeek {
+		lastRanks[e.ID] = e.Rank
+	}
+	for i := range entries {
+		m := NewChartMovement(entries[i].Rank, lastRanks[entries[i].ID])
+		entries[i].Movement = &m
+	}
+}
+
+// WeekStart returns the Monday starting the week of a day, at midnight UTC.
+// Weeks of charts are days rather than times: each user's week runs from
+// Monday to Sunday in their own time zone.
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// UserWeek is a week of a user's listening, starting on a Monday.
+type UserWeek struct {
+	UserID uint64
+	Week   time.Time
+}
+
+// ChartRun is the history of an entry on a user's weekly charts up to a week:
+// the best rank it reached and how many weeks it was on the chart.
+type ChartRun struct {
+	Peak         int
+	WeeksOnChart int
+}
+
+// Extend counts another week on the chart at a rank.
+func (r ChartRun) Extend(rank int) ChartRun {
+	if r.Peak == 0 || rank < r.Peak {
+		r.Peak = rank
+	}
+	r.WeeksOnChart++
+	return r
+}
+
+// ChartPosition is the rank of an entry on the chart of a week.
+type ChartPosition struct {
+	Week     time.Time
+	Rank     int
+	Spins    uint64
+	MsPlayed uint64
+}
+
+// WeeklyChart ranks what a user listened to in the week starting on a day.
+type WeeklyChart struct {
+	Entity  EntityType
+	Week    time.Time
+	Entries []ChartEntry
+}
+
+// NumberOne is a run of weeks in a row an entry topped a user's chart, from
+// the week starting on a day.
+type NumberOne struct {
+	Entity EntityType
+	ID     uint64
+	Title  string
+	From   time.Time
+	Weeks  int
+}
+
+// mergeNumberOnes joins the number ones of single weeks, in order, into runs
+// of the same entry in weeks in a row, latest first.
+func mergeNumberOnes(weeks []NumberOne) []NumberOne {
+	runs := []NumberOne{}
+	for _, w := range weeks {
+		if n := len(runs); n > 0 {
+			last := &runs[n-1]
+			if last.ID == w.ID && last.From.AddDate(0, 0, 7*last.Weeks).Equal(w.From) {
+				last.Weeks += w.Weeks
+				continue
+			}
+		}
+		runs = append(runs, w)
+	}
+	slices.Reverse(runs)
+	return runs
+}
@@ -0,0 +1,341 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type chartSource struct {
+	rollup  rollup
+	entries string
+	table   string
+	title   string
+}
+
+// chartSources select the listening l by the entity it counts for as e.
+// Listening counts for the project stats attribute its track to.
+var chartSources = map[EntityType]chartSource{
+	ArtistEntity: {artistRollup, `SELECT user_id, id, spins, ms_played FROM l`, "artist", "name"},
+	TrackEntity:  {trackRollup, `SELECT user_id, id, spins, ms_played FROM l`, "track", "title"},
+	ProjectEntity: {trackRollup, `SELECT l.user_id, COALESCE(upp.project_id, t.primary_project_id) AS id, l.spins, l.ms_played
+		FROM l
+		JOIN track t ON l.id = t.id
+		LEFT JOIN user_primary_project upp ON l.user_id = upp.user_id AND t.id = upp.track_id
+		WHERE COALESCE(upp.project_id, t.primary_project_id) IS NOT NULL`, "project", "title"},
+}
+
+// chartStore is where the weekly charts of groups or of users are kept: a
+// table of the weeks stored and one of their entries, both by owner.
+type chartStore struct {
+	snapshots string
+	weeks     string
+	owner     string
+}
+
+var groupCharts = chartStore{"group_chart_snapshot", "group_chart_week", "group_id"}
+var userCharts = chartStore{"user_chart_snapshot", "user_chart_week", "user_id"}
+
+// chartTitle selects the title of the entry of a stored chart w. Entries of
+// artists, tracks or projects deleted since have none.
+const chartTitle = `COALESCE(a.name, t.title, p.title, '')`
+
+const chartTitleJoins = `LEFT JOIN artist a ON w.entity = 'artist' AND w.entity_id = a.id
+	LEFT JOIN track t ON w.entity = 'track' AND w.entity_id = t.id
+	LEFT JOIN project p ON w.entity = 'project' AND w.entity_id = p.id`
+
+func (pg *PGDB) saveChartWeek(cs chartStore, ownerID uint64, week time.Time, entries []ChartEntry) error {
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting chart insert: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := writeChartWeek(tx, cs, ownerID, week, entries); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing chart: %w", err)
+	}
+	return nil
+}
+
+// writeChartWeek stores a chart snapshot and its entries, replacing any
+// stored before.
+func writeChartWeek(tx pgx.Tx, cs chartStore, ownerID uint64, week time.Time, entries []ChartEntry) error {
+	insertSnapshot := `INSERT INTO ` + cs.snapshots + ` (` + cs.owner + `, week) VALUES ($1, $2)
+	ON CONFLICT (` + cs.owner + `, week) DO UPDATE SET taken_at=now()`
+	clearEntries := `DELETE FROM ` + cs.weeks + ` WHERE ` + cs.owner + `=$1 AND week=$2`
+	insertEntry := `INSERT INTO ` + cs.weeks + ` (` + cs.owner + `, week, entity, entity_id, rank, spins, ms_played)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	ctx := context.Background()
+	if _, err := tx.Exec(ctx, insertSnapshot, ownerID, week); err != nil {
+		return fmt.Errorf("error inserting chart snapshot: %w", err)
+	}
+	if _, err := tx.Exec(ctx, clearEntries, ownerID, week); err != nil {
+		return fmt.Errorf("error deleting chart entries: %w", err)
+	}
+	for _, e := range entries {
+		if _, err := tx.Exec(ctx, insertEntry, ownerID, week, string(e.Entity), e.ID, e.Rank, e.Spins, e.MsPlayed); err != nil {
+			return fmt.Errorf("error inserting chart entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// markChartWeeksStale marks the stored charts of the weeks, in their users'
+// time zones, with spins s matching a condition for a snapshot again.
+func markChartWeeksStale(tx pgx.Tx, cond string, args ...any) error {
+	stmt := `UPDATE user_chart_snapshot cs SET stale=true
+	FROM (
+		SELECT DISTINCT s.user_id, date_trunc('week', s.time AT TIME ZONE u.time_zone)::date AS week
+		FROM spin s
+		JOIN "user" u ON s.user_id = u.id
+		WHERE ` + cond + `
+	) w
+	WHERE cs.user_id = w.user_id AND cs.week = w.week`
+
+	if _, err := tx.Exec(context.Background(), stmt, args...); err != nil {
+		return fmt.Errorf("error marking chart weeks stale: %w", err)
+	}
+	return nil
+}
+
+// moveChartWeeks repoints the stored chart entries of a merged artist, track
+// or project to its target, adding them up in the weeks both charted and
+// ranking those weeks again.
+func moveChartWeeks(tx pgx.Tx, cs chartStore, entity EntityType, fromID, intoID uint64) error {
+	move := `INSERT INTO ` + cs.weeks + ` (` + cs.owner + `, week, entity, entity_id, rank, spins, ms_played)
+	SELECT ` + cs.owner + `, week, entity, $3, rank, spins, ms_played FROM ` + cs.weeks + ` WHERE entity=$1 AND entity_id=$2
+	ON CONFLICT (` + cs.owner + `, week, entity, entity_id) DO UPDATE
+	SET rank=LEAST(` + cs.weeks + `.rank, EXCLUDED.rank), spins=` + cs.weeks + `.spins + EXCLUDED.spins, ms_played=` + cs.weeks + `.ms_played + EXCLUDED.ms_played`
+	remove := `DELETE FROM ` + cs.weeks + ` WHERE entity=$1 AND entity_id=$2`
+	rerank := `UPDATE ` + cs.weeks + ` w SET rank=r.rank
+	FROM (
+		SELECT ` + cs.owner + `, week, entity_id, row_number() OVER (
+			PARTITION BY ` + cs.owner + `, week ORDER BY ms_played DESC, spins DESC, entity_id
+		) AS rank
+		FROM ` + cs.weeks + `
+		WHERE entity=$1 AND (` + cs.owner + `, week) IN (SELECT ` + cs.owner + `, week FROM ` + cs.weeks + ` WHERE entity=$1 AND entity_id=$2)
+	) r
+	WHERE w.` + cs.owner + ` = r.` + cs.owner + ` AND w.week = r.week AND w.entity=$1 AND w.entity_id = r.entity_id AND w.rank <> r.rank`
+
+	ctx := context.Background()
+	if _, err := tx.Exec(ctx, move, string(entity), fromID, intoID); err != nil {
+		return fmt.Errorf("error merging %s: %w", cs.weeks, err)
+	}
+	if _, err := tx.Exec(ctx, remove, string(entity), fromID); err != nil {
+		return fmt.Errorf("error merging %s: %w", cs.weeks, err)
+	}
+	if _, err := tx.Exec(ctx, rerank, string(entity), intoID); err != nil {
+		return fmt.Errorf("error ranking %s: %w", cs.weeks, err)
+	}
+	return nil
+}
+
+func (pg *PGDB) getChartWeek(cs chartStore, ownerID uint64, entity EntityType, week time.Time) ([]ChartEntry, error) {
+	snapshotStmt := `SELECT EXISTS (SELECT 1 FROM ` + cs.snapshots + ` WHERE ` + cs.owner + `=$1 AND week=$2)`
+	stmt := `SELECT w.entity_id, ` + chartTitle + `, w.rank, w.spins, w.ms_played
+	FROM ` + cs.weeks + ` w
+	` + chartTitleJoins + `
+	WHERE w.` + cs.owner + `=$1 AND w.week=$2 AND w.entity=$3
+	ORDER BY w.rank`
+
+	ctx := context.Background()
+	var exists bool
+	if err := pg.db.QueryRow(ctx, snapshotStmt, ownerID, week).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error selecting chart snapshot: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: chart of %s %d for week %s", ErrNotFound, cs.owner, ownerID, week.Format(time.DateOnly))
+	}
+
+	rows, err := pg.db.Query(ctx, stmt, ownerID, week, string(entity))
+	if err != nil {
+		return nil, fmt.Errorf("error selecting chart week: %w", err)
+	}
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ChartEntry, error) {
+		e := ChartEntry{Entity: entity}
+		err := row.Scan(&e.ID, &e.Title, &e.Rank, &e.Spins, &e.MsPlayed)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting chart week: %w", err)
+	}
+	return entries, nil
+}
+
+// GetUserChart ranks the artists, tracks or projects a user listened to most
+// on the days from one up to another in their time zone.
+func (pg *PGDB) GetUserChart(userID uint64, entity EntityType, from, to time.Time, limit int) ([]ChartEntry, error) {
+	source, ok := chartSources[entity]
+	if !ok {
+		return nil, fmt.Errorf("unknown chart entity %q", entity)
+	}
+	stmt := `WITH ` + source.rollup.dayListening(`SELECT $1::bigint AS user_id`) + `,
+	e AS (` + source.entries + `)
+	SELECT e.id, x.` + source.title + `, sum(e.spins)::bigint AS spins, sum(e.ms_played)::bigint AS ms_played
+	FROM e
+	JOIN ` + source.table + ` x ON e.id = x.id
+	GROUP BY e.id, x.` + source.title + `
+	ORDER BY ms_played DESC, spins DESC, e.id
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting user chart: %w", err)
+	}
+	rank := 0
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ChartEntry, error) {
+		rank++
+		e := ChartEntry{Rank: rank, Entity: entity}
+		err := row.Scan(&e.ID, &e.Title, &e.Spins, &e.MsPlayed)
+		return e, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting user chart: %w", err)
+	}
+	return entries, nil
+}
+
+// GetUnsnapshottedWeeks returns the weeks up to and including one that users
+// listened in after the last week of theirs with charts stored, or since
+// they started listening when none are, along with the stored weeks whose
+// spins changed since, oldest first.
+func (pg *PGDB) GetUnsnapshottedWeeks(lastWeek time.Time, limit int) ([]UserWeek, error) {
+	const stmt = `WITH s AS (
+		SELECT user_id, max(week) AS week FROM user_chart_snapshot GROUP BY user_id
+	)
+	SELECT r.user_id, date_trunc('week', r.day::timestamp)::date AS week
+	FROM user_track_day r
+	LEFT JOIN s ON r.user_id = s.user_id
+	WHERE r.day < $1 AND (s.week IS NULL OR r.day >= s.week + 7)
+	GROUP BY 1, 2
+	UNION
+	SELECT user_id, week FROM user_chart_snapshot WHERE stale
+	ORDER BY 1, 2
+	LIMIT $2`
+
+	rows, err := pg.db.Query(context.Background(), stmt, lastWeek.AddDate(0, 0, 7), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unsnapshotted weeks: %w", err)
+	}
+	weeks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[UserWeek])
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unsnapshotted weeks: %w", err)
+	}
+	return weeks, nil
+}
+
+// SaveUserWeek stores the charts of a user for the week starting on a day,
+// replacing any stored before.
+func (pg *PGDB) SaveUserWeek(userID uint64, week time.Time, entries []ChartEntry) error {
+	const unmark = `UPDATE user_chart_snapshot SET stale=false WHERE user_id=$1 AND week=$2`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting chart insert: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := writeChartWeek(tx, userCharts, userID, week, entries); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, unmark, userID, week); err != nil {
+		return fmt.Errorf("error updating chart snapshot: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing chart: %w", err)
+	}
+	return nil
+}
+
+// GetUserWeek returns the stored chart of a user for the week starting on a
+// day.
+func (pg *PGDB) GetUserWeek(userID uint64, entity EntityType, week time.Time) ([]ChartEntry, error) {
+	return pg.getChartWeek(userCharts, userID, entity, week)
+}
+
+// GetChartRuns returns the runs of artists, tracks or projects on the stored
+// charts of a user for the weeks before one. Entries that never charted have
+// none.
+func (pg *PGDB) GetChartRuns(userID uint64, entity EntityType, ids []uint64, before time.Time) (map[uint64]ChartRun, error) {
+	const stmt = `SELECT entity_id, min(rank), count(*)
+	FROM user_chart_week
+	WHERE user_id=$1 AND entity=$2 AND entity_id = ANY($3::bigint[]) AND week < $4
+	GROUP BY entity_id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, string(entity), ids, before)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting chart runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := map[uint64]ChartRun{}
+	for rows.Next() {
+		var id uint64
+		var run ChartRun
+		if err := rows.Scan(&id, &run.Peak, &run.WeeksOnChart); err != nil {
+			return nil, fmt.Errorf("error selecting chart runs: %w", err)
+		}
+		runs[id] = run
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error selecting chart runs: %w", err)
+	}
+	return runs, nil
+}
+
+// GetChartPositions returns the ranks of an artist, track or project on the
+// stored charts of a user, by week.
+func (pg *PGDB) GetChartPositions(userID uint64, entity EntityType, id uint64) ([]ChartPosition, error) {
+	const stmt = `SELECT week, rank, spins, ms_played
+	FROM user_chart_week
+	WHERE user_id=$1 AND entity=$2 AND entity_id=$3
+	ORDER BY week`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, string(entity), id)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting chart positions: %w", err)
+	}
+	positions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ChartPosition, error) {
+		var p ChartPosition
+		err := row.Scan(&p.Week, &p.Rank, &p.Spins, &p.MsPlayed)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting chart positions: %w", err)
+	}
+	return positions, nil
+}
+
+// GetNumberOnes returns the runs of artists, tracks or projects at the top of
+// the stored charts of a user, latest first.
+func (pg *PGDB) GetNumberOnes(userID uint64, entity EntityType) ([]NumberOne, error) {
+	const stmt = `SELECT w.entity_id, ` + chartTitle + `, w.week
+	FROM user_chart_week w
+	` + chartTitleJoins + `
+	WHERE w.user_id=$1 AND w.entity=$2 AND w.rank = 1
+	ORDER BY w.week`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, string(entity))
+	if err != nil {
+		return nil, fmt.Errorf("error selecting number ones: %w", err)
+	}
+	weeks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (NumberOne, error) {
+		n := NumberOne{Entity: entity, Weeks: 1}
+		err := row.Scan(&n.ID, &n.Title, &n.From)
+		return n, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting number ones: %w", err)
+	}
+	return mergeNumberOnes(weeks), nil
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		t    time.Time
+	}{
+		{"Monday", monday},
+		{"Wednesday afternoon", time.Date(2024, 3, 13, 15, 30, 0, 0, time.UTC)},
+		{"Sunday night", time.Date(2024, 3, 17, 23, 59, 0, 0, time.UTC)},
+		{"Sunday in another time zone", time.Date(2024, 3, 17, 1, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if week := WeekStart(tt.t); !week.Equal(monday) {
+				t.Fatalf("expected %v but got %v", monday, week)
+			}
+		})
+	}
+}
+
+func TestCompareCharts(t *testing.T) {
+	entries := []ChartEntry{{Rank: 1, ID: 7}, {Rank: 2, ID: 3}, {Rank: 3, ID: 9}, {Rank: 4, ID: 5}}
+	lastWeek := []ChartEntry{{Rank: 1, ID: 3}, {Rank: 2, ID: 8}, {Rank: 3, ID: 9}, {Rank: 4, ID: 7}}
+
+	CompareCharts(entries, lastWeek)
+	expected := []ChartMovement{{Climbed, 4}, {Fell, 1}, {Held, 3}, {NewEntry, 0}}
+	for i, e := range entries {
+		if e.Movement == nil || *e.Movement != expected[i] {
+			t.Fatalf("expected %+v for %d but got %+v", expected[i], e.ID, e.Movement)
+		}
+	}
+}
+
+func TestChartRunExtend(t *testing.T) {
+	run := ChartRun{}.Extend(12)
+	if run != (ChartRun{Peak: 12, WeeksOnChart: 1}) {
+		t.Fatalf("expected a first week at 12 but got %+v", run)
+	}
+	run = run.Extend(3).Extend(7)
+	if run != (ChartRun{Peak: 3, WeeksOnChart: 3}) {
+		t.Fatalf("expected a peak of 3 in three weeks but got %+v", run)
+	}
+}
+
+func TestMergeNumberOnes(t *testing.T) {
+	week := func(w int) time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 7*w)
+	}
+	weeks := []NumberOne{
+		{ID: 1, From: week(0), Weeks: 1},
+		{ID: 1, From: week(1), Weeks: 1},
+		{ID: 2, From: week(2), Weeks: 1},
+		{ID: 1, From: week(4), Weeks: 1},
+		{ID: 1, From: week(5), Weeks: 1},
+		{ID: 1, From: week(6), Weeks: 1},
+	}
+
+	runs := mergeNumberOnes(weeks)
+	expected := []NumberOne{{ID: 1, From: week(4), Weeks: 3}, {ID: 2, From: week(2), Weeks: 1}, {ID: 1, From: week(0), Weeks: 2}}
+	if len(runs) != len(expected) {
+		t.Fatalf("expected %d runs but got %+v", len(expected), runs)
+	}
+	for i := range expected {
+		if runs[i].ID != expected[i].ID || !runs[i].From.Equal(expected[i].From) || runs[i].Weeks != expected[i].Weeks {
+			t.Fatalf("expected %+v at %d but got %+v", expected[i], i, runs[i])
+		}
+	}
+}
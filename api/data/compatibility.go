@@ -0,0 +1,106 @@
+package data
+
+import (
+	"math"
+	"slices"
+)
+
+// COMPATIBILITY_ARTIST_WEIGHT is how much the artists two users share count
+// towards their score, the tracks they share count for the rest.
+const COMPATIBILITY_ARTIST_WEIGHT = 0.6
+
+// Compatibility compares the tastes of a user and another. The scores are the
+// cosine similarities, from 0 to 1, of their log scaled spins of each artist
+// and track, and Score weighs them together. Shared artists and tracks are the
+// ones both users play the most.
+type Compatibility struct {
+	Score         float64
+	ArtistScore   float64
+	TrackScore    float64
+	SharedArtists []SharedArtist
+	SharedTracks  []SharedTrack
+}
+
+// SharedArtist is an artist two users played, Spins times by the user and
+// OtherSpins times by the other.
+type SharedArtist struct {
+	Artist     Artist
+	Spins      uint64
+	OtherSpins uint64
+}
+
+type SharedTrack struct {
+	Track      Track
+	Spins      uint64
+	OtherSpins uint64
+}
+
+// NewCompatibility compares the spins of artists and tracks of a user with the
+// other's and keeps the first limit of the artists and tracks they share.
+func NewCompatibility(artists, otherArtists []ArtistListening, tracks, otherTracks []TrackListening, limit int) Compatibility {
+	c := Compatibility{SharedArtists: []SharedArtist{}, SharedTracks: []SharedTrack{}}
+
+	artistSpins := func(l ArtistListening) (uint64, uint64) { return l.Artist.ID, l.Spins }
+	var sharedArtists []shared[ArtistListening]
+	c.ArtistScore, sharedArtists = compare(artists, otherArtists, artistSpins)
+	for _, s := range sharedArtists[:min(len(sharedArtists), limit)] {
+		c.SharedArtists = append(c.SharedArtists, SharedArtist{s.entry.Artist, s.entry.Spins, s.otherSpins})
+	}
+
+	trackSpins := func(l TrackListening) (uint64, uint64) { return l.Track.ID, l.Spins }
+	var sharedTracks []shared[TrackListening]
+	c.TrackScore, sharedTracks = compare(tracks, otherTracks, trackSpins)
+	for _, s := range sharedTracks[:min(len(sharedTracks), limit)] {
+		c.SharedTracks = append(c.SharedTracks, SharedTrack{s.entry.Track, s.entry.Spins, s.otherSpins})
+	}
+
+	c.Score = COMPATIBILITY_ARTIST_WEIGHT*c.ArtistScore + (1-COMPATIBILITY_ARTIST_WEIGHT)*c.TrackScore
+	return c
+}
+
+type shared[T any] struct {
+	entry      T
+	otherSpins uint64
+	weight     float64
+}
+
+// compare computes the cosine similarity of two users' log scaled spins and
+// returns the entries both played, those both played most first.
+func compare[T any](entries, others []T, spins func(T) (uint64, uint64)) (float64, []shared[T]) {
+	otherWeights := map[uint64]float64{}
+	otherSpins := map[uint64]uint64{}
+	var otherNorm float64
+	for _, e := range others {
+		id, n := spins(e)
+		w := math.Log1p(float64(n))
+		otherWeights[id], otherSpins[id] = w, n
+		otherNorm += w * w
+	}
+
+	sharedEntries := []shared[T]{}
+	var dot, norm float64
+	for _, e := range entries {
+		id, n := spins(e)
+		w := math.Log1p(float64(n))
+		norm += w * w
+		if ow, ok := otherWeights[id]; ok {
+			dot += w * ow
+			sharedEntries = append(sharedEntries, shared[T]{e, otherSpins[id], min(w, ow)})
+		}
+	}
+	slices.SortStableFunc(sharedEntries, func(a, b shared[T]) int {
+		switch {
+		case a.weight > b.weight:
+			return -1
+		case a.weight < b.weight:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	if norm == 0 || otherNorm == 0 {
+		return 0, sharedEntries
+	}
+	return dot / math.Sqrt(norm*otherNorm), sharedEntries
+}
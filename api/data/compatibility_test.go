@@ -0,0 +1,84 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewCompatibility(t *testing.T) {
+	artist := func(id, spins uint64) ArtistListening {
+		return ArtistListening{Artist: Artist{ID: id}, Spins: spins}
+	}
+	track := func(id, spins uint64) TrackListening {
+		return TrackListening{Track: Track{ID: id}, Spins: spins}
+	}
+
+	tests := []struct {
+		name         string
+		artists      []ArtistListening
+		otherArtists []ArtistListening
+		tracks       []TrackListening
+		otherTracks  []TrackListening
+		score        float64
+		shared       []uint64
+	}{
+		{
+			"Same tastes",
+			[]ArtistListening{artist(1, 10), artist(2, 3)},
+			[]ArtistListening{artist(2, 3), artist(1, 10)},
+			[]TrackListening{track(1, 4)},
+			[]TrackListening{track(1, 4)},
+			1,
+			[]uint64{1, 2},
+		},
+		{
+			"Nothing in common",
+			[]ArtistListening{artist(1, 10)},
+			[]ArtistListening{artist(2, 10)},
+			[]TrackListening{track(1, 4)},
+			[]TrackListening{track(2, 4)},
+			0,
+			[]uint64{},
+		},
+		{
+			"Shared artists only",
+			[]ArtistListening{artist(1, 10)},
+			[]ArtistListening{artist(1, 100)},
+			[]TrackListening{track(1, 4)},
+			[]TrackListening{track(2, 4)},
+			COMPATIBILITY_ARTIST_WEIGHT,
+			[]uint64{1},
+		},
+		{
+			"No spins",
+			nil,
+			[]ArtistListening{artist(1, 10)},
+			nil,
+			nil,
+			0,
+			[]uint64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompatibility(tt.artists, tt.otherArtists, tt.tracks, tt.otherTracks, 10)
+			if math.Abs(c.Score-tt.score) > 1e-9 {
+				t.Fatalf("expected score %f but got %f", tt.score, c.Score)
+			}
+			if len(c.SharedArtists) != len(tt.shared) {
+				t.Fatalf("expected shared artists %v but got %+v", tt.shared, c.SharedArtists)
+			}
+			for i, id := range tt.shared {
+				if c.SharedArtists[i].Artist.ID != id {
+					t.Fatalf("expected shared artists %v but got %+v", tt.shared, c.SharedArtists)
+				}
+			}
+		})
+	}
+
+	c := NewCompatibility([]ArtistListening{artist(1, 10), artist(2, 50), artist(3, 5)}, []ArtistListening{artist(1, 10), artist(2, 1), artist(3, 5)}, nil, nil, 2)
+	if len(c.SharedArtists) != 2 || c.SharedArtists[0].Artist.ID != 1 || c.SharedArtists[1].Artist.ID != 3 || c.SharedArtists[0].OtherSpins != 10 {
+		t.Fatalf("expected the two artists both played most but got %+v", c.SharedArtists)
+	}
+}
@@ -2,18 +2,24 @@ package data
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrNotFound is returned by lookups that callers expect to miss, such as
+// lookups by route parameters.
+var ErrNotFound = errors.New("not found")
+
 type PGDB struct {
-	db *pgx.Conn
+	db *pgxpool.Pool
 }
 
 func NewDB(url string) (*PGDB, error) {
-	db, err := pgx.Connect(context.Background(), url)
+	db, err := pgxpool.New(context.Background(), url)
 	if err != nil {
 		return &PGDB{}, err
 	} else {
@@ -24,7 +30,7 @@ func NewDB(url string) (*PGDB, error) {
 }
 
 func (pg *PGDB) CreateUser(name string, email string, password string) (User, error) {
-	const stmt = `INSERT INTO "user" (name, email, password) VALUES ($1, $2, $3) RETURNING (id, name, email, password)`
+	const stmt = `INSERT INTO "user" (name, email, password) VALUES ($1, $2, $3) RETURNING (id, name, email, password, admin)`
 
 	row := pg.db.QueryRow(context.Background(), stmt, name, email, password)
 
@@ -49,11 +55,12 @@ func (pg *PGDB) CreateArtist(name string) (Artist, error) {
 	return a, nil
 }
 
-func (pg *PGDB) CreateProject(key uint64, title string, artistIDs []uint64, form ProjectType, release time.Time) (Project, error) {
-	const stmt = `INSERT INTO project (id, title, form, release) VALUES ($1, $2, $3, $4) RETURNING (id, title, form, release)`
+func (pg *PGDB) CreateProject(key uint64, title string, artistIDs []uint64, form ProjectType, release time.Time, precision DatePrecision) (Project, error) {
+	const stmt = `INSERT INTO project (id, title, form, release, release_precision) VALUES ($1, $2, $3, $4, $5)
+	RETURNING (id, title, form, release, COALESCE(mbid::text, ''), release_precision, COALESCE(edition, ''), COALESCE(base_project_id, 0))`
 	const junctionInsert = `INSERT INTO artist_project (artist_id, project_id) VALUES ($1, $2)`
 
-	row := pg.db.QueryRow(context.Background(), stmt, key, title, form, release)
+	row := pg.db.QueryRow(context.Background(), stmt, key, title, form, release, precision)
 
 	var p Project
 	if err := row.Scan(&p); err != nil {
@@ -67,19 +74,136 @@ func (pg *PGDB) CreateProject(key uint64, title string, artistIDs []uint64, form
 	return p, nil
 }
 
-func (pg *PGDB) CreateSpin(t time.Time, userID uint64, trackID uint64) (Spin, error) {
-	const stmt = `INSERT INTO spin (time, user_id, track_id) VALUES ($1, $2, $3) RETURNING (time, user_id, track_id)`
+func (pg *PGDB) CreateSpin(t time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (Spin, error) {
+	const stmt = `INSERT INTO spin (time, user_id, track_id, project_id, ms_played) VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0))
+	RETURNING (id, user_id, time, track_id, COALESCE(ms_played, 0), COALESCE(project_id, 0))`
 
-	row := pg.db.QueryRow(context.Background(), stmt, t, userID, trackID)
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return Spin{}, fmt.Errorf("error starting spin insert: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	var s Spin
-	if err := row.Scan(&s); err != nil {
+	if err := tx.QueryRow(ctx, stmt, t, userID, trackID, projectID, msPlayed).Scan(&s); err != nil {
 		return Spin{}, fmt.Errorf("error inserting spin: %w", err)
 	}
+	if err := addToRollups(tx, uint64(s.ID)); err != nil {
+		return Spin{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Spin{}, fmt.Errorf("error committing spin: %w", err)
+	}
 
 	return s, nil
 }
 
+// UpdateSpin moves a spin of a user to another track and project and returns
+// it along with the track it was on before. The charts and Wrapped of its week
+// and year are marked for generating again.
+func (pg *PGDB) UpdateSpin(userID, id, trackID, projectID uint64) (Spin, uint64, error) {
+	const stmt = `UPDATE spin s SET track_id=$3, project_id=NULLIF($4, 0)
+	FROM (SELECT id, track_id FROM spin WHERE user_id=$1 AND id=$2 FOR UPDATE) old
+	WHERE s.id = old.id
+	RETURNING (s.id, s.user_id, s.time, s.track_id, COALESCE(s.ms_played, 0), COALESCE(s.project_id, 0)), old.track_id`
+	const cond = `s.user_id=$1 AND s.id=$2`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return Spin{}, 0, fmt.Errorf("error starting spin update: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := removeFromRollups(tx, `s.id=$2`, userID, id); err != nil {
+		return Spin{}, 0, err
+	}
+	var s Spin
+	var previousTrackID uint64
+	err = tx.QueryRow(ctx, stmt, userID, id, trackID, projectID).Scan(&s, &previousTrackID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Spin{}, 0, fmt.Errorf("%w: spin %d", ErrNotFound, id)
+	} else if err != nil {
+		return Spin{}, 0, fmt.Errorf("error updating spin: %w", err)
+	}
+	if err := addToRollups(tx, id); err != nil {
+		return Spin{}, 0, err
+	}
+	if err := markSessionsStale(tx, cond, userID, id); err != nil {
+		return Spin{}, 0, err
+	}
+	if err := markChartWeeksStale(tx, cond, userID, id); err != nil {
+		return Spin{}, 0, err
+	}
+	if err := markWrappedStale(tx, cond, userID, id); err != nil {
+		return Spin{}, 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Spin{}, 0, fmt.Errorf("error committing spin: %w", err)
+	}
+	return s, previousTrackID, nil
+}
+
+// DeleteSpin deletes a spin of a user.
+func (pg *PGDB) DeleteSpin(userID, id uint64) error {
+	n, err := pg.deleteSpins(`s.id=$2`, userID, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: spin %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// DeleteSpins deletes the spins of a user from one time up to another and
+// returns how many there were.
+func (pg *PGDB) DeleteSpins(userID uint64, from, to time.Time) (int64, error) {
+	return pg.deleteSpins(`s.time >= $2 AND s.time < $3`, userID, from, to)
+}
+
+// deleteSpins deletes the spins s of the user in $1 matching a condition,
+// taking them out of the rollups, sessions and streak they counted in and
+// marking the charts and Wrapped of their weeks and years for generating
+// again.
+func (pg *PGDB) deleteSpins(cond string, args ...any) (int64, error) {
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting spin delete: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := removeFromRollups(tx, cond, args...); err != nil {
+		return 0, err
+	}
+	userCond := `s.user_id=$1 AND ` + cond
+	if err := markSessionsStale(tx, userCond, args...); err != nil {
+		return 0, err
+	}
+	if err := markChartWeeksStale(tx, userCond, args...); err != nil {
+		return 0, err
+	}
+	if err := markWrappedStale(tx, userCond, args...); err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM spin s WHERE `+userCond, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting spins: %w", err)
+	}
+	if err := rebuildStreak(tx, args[0].(uint64)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing spin delete: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
 func (pg *PGDB) CreateTrack(key uint64, title string, artistIDs []uint64) (Track, error) {
 	const stmt = `INSERT INTO track (id, title) VALUES ($1, $2) RETURNING (id, title)`
 	const junctionInsert = `INSERT INTO artist_track (artist_id, track_id) VALUES ($1, $2)`
@@ -99,7 +223,7 @@ func (pg *PGDB) CreateTrack(key uint64, title string, artistIDs []uint64) (Track
 }
 
 func (pg *PGDB) GetUser(nameOrEmail string) (User, error) {
-	const stmt = `SELECT (id, name, email, password) FROM "user" WHERE name=$1 OR email=$1`
+	const stmt = `SELECT (id, name, email, password, admin) FROM "user" WHERE name=$1 OR email=$1`
 
 	row := pg.db.QueryRow(context.Background(), stmt, nameOrEmail)
 
@@ -112,7 +236,11 @@ func (pg *PGDB) GetUser(nameOrEmail string) (User, error) {
 }
 
 func (pg *PGDB) GetArtist(name string) (Artist, error) {
-	const stmt = `SELECT (id, name) FROM artist WHERE name=$1`
+	const stmt = `SELECT (a.id, a.name, COALESCE(a.mbid::text, '')) FROM artist a
+	LEFT JOIN artist_alias aa ON a.id = aa.artist_id
+	WHERE a.name=$1 OR aa.name=$1
+	ORDER BY a.name=$1 DESC, a.id
+	LIMIT 1`
 
 	row := pg.db.QueryRow(context.Background(), stmt, name)
 
@@ -124,8 +252,22 @@ func (pg *PGDB) GetArtist(name string) (Artist, error) {
 	return a, nil
 }
 
+func (pg *PGDB) GetArtistByMBID(mbid string) (Artist, error) {
+	const stmt = `SELECT (id, name, mbid::text) FROM artist WHERE mbid=$1`
+
+	row := pg.db.QueryRow(context.Background(), stmt, mbid)
+
+	var a Artist
+	if err := row.Scan(&a); err != nil {
+		return Artist{}, fmt.Errorf("error selecting artist: %w", err)
+	}
+
+	return a, nil
+}
+
 func (pg *PGDB) GetProject(key uint64) (Project, error) {
-	const stmt = `SELECT (id, title, form, release) FROM project WHERE id=$1`
+	const stmt = `SELECT (id, title, form, release, COALESCE(mbid::text, ''), release_precision, COALESCE(edition, ''), COALESCE(base_project_id, 0)) FROM project
+	WHERE id=COALESCE((SELECT project_id FROM project_alias WHERE id=$1), $1)`
 
 	row := pg.db.QueryRow(context.Background(), stmt, key)
 
@@ -137,27 +279,55 @@ func (pg *PGDB) GetProject(key uint64) (Project, error) {
 	return p, nil
 }
 
-// TODO: maybe make this query work for tracks with no projects
-func (pg *PGDB) GetTrack(key uint64) (Track, error) {
-	stmt := `SELECT t.id, t.title, t.primary_project_id, array_agg(p.id) AS project_ids
+func (pg *PGDB) GetProjectByMBID(mbid string) (Project, error) {
+	const stmt = `SELECT (id, title, form, release, COALESCE(mbid::text, ''), release_precision, COALESCE(edition, ''), COALESCE(base_project_id, 0)) FROM project WHERE mbid=$1`
+
+	row := pg.db.QueryRow(context.Background(), stmt, mbid)
+
+	var p Project
+	if err := row.Scan(&p); err != nil {
+		return Project{}, fmt.Errorf("error selecting project: %w", err)
+	}
+
+	return p, nil
+}
+
+const trackSelect = `SELECT t.id, t.title, t.primary_project_id, array_agg(p.id) AS project_ids, COALESCE(t.mbid::text, ''), COALESCE(t.duration_ms, 0)
 	FROM track t
 	JOIN project_track pt ON t.id = pt.track_id
 	JOIN project p ON pt.project_id = p.id
-	WHERE t.id = $1
-	GROUP BY t.id, t.title, t.primary_project_id;`
+	WHERE %s
+	GROUP BY t.id, t.title, t.primary_project_id, t.mbid, t.duration_ms;`
 
-	row := pg.db.QueryRow(context.Background(), stmt, key)
+// TODO: maybe make this query work for tracks with no projects
+func (pg *PGDB) GetTrack(key uint64) (Track, error) {
+	stmt := fmt.Sprintf(trackSelect, `t.id = COALESCE((SELECT track_id FROM track_alias WHERE id = $1), $1)`)
+
+	return pg.selectTrack(stmt, key)
+}
+
+func (pg *PGDB) GetTrackByMBID(mbid string) (Track, error) {
+	stmt := fmt.Sprintf(trackSelect, `t.mbid = $1`)
+
+	return pg.selectTrack(stmt, mbid)
+}
+
+func (pg *PGDB) selectTrack(stmt string, arg any) (Track, error) {
+	row := pg.db.QueryRow(context.Background(), stmt, arg)
 
 	var t Track
-	if err := row.Scan(&t.ID, &t.Title, &t.PrimaryProjectID, &t.ProjectIDs); err != nil {
+	var durationMs int64
+	if err := row.Scan(&t.ID, &t.Title, &t.PrimaryProjectID, &t.ProjectIDs, &t.MBID, &durationMs); err != nil {
 		return Track{}, fmt.Errorf("error selecting track: %w", err)
 	}
+	t.Duration = time.Duration(durationMs) * time.Millisecond
 
 	return t, nil
 }
 
 func (pg *PGDB) UpdateTrack(key uint64, projectID uint64, isPrimary bool) error {
-	const junctionInsert = `INSERT INTO project_track (project_id, track_id) VALUES ($2, $1)`
+	const junctionInsert = `INSERT INTO project_track (project_id, track_id) VALUES ($2, $1)
+	ON CONFLICT (project_id, track_id) DO NOTHING`
 	const primaryProjectUpdate = `UPDATE track SET primary_project_id=$2 WHERE id=$1`
 
 	pg.db.Exec(context.Background(), junctionInsert, key, projectID)
@@ -168,3 +338,69 @@ func (pg *PGDB) UpdateTrack(key uint64, projectID uint64, isPrimary bool) error
 
 	return nil
 }
+
+// SetTrackDuration fills in the duration of a track that has none. Earlier
+// spins without a play time count the new duration, so their rollups and
+// sessions are marked for a rebuild.
+func (pg *PGDB) SetTrackDuration(key uint64, d time.Duration) error {
+	const stmt = `UPDATE track SET duration_ms=$2 WHERE id=$1 AND duration_ms IS NULL`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error setting track duration: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, stmt, key, d.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("error setting track duration: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		if err := markRollupsStale(tx, `s.track_id=$1 AND s.ms_played IS NULL`, key); err != nil {
+			return err
+		}
+		if err := markSessionsStale(tx, `s.track_id=$1 AND s.ms_played IS NULL`, key); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (pg *PGDB) SetArtistMBID(id uint64, mbid string) error {
+	return pg.setMBID(`UPDATE artist SET mbid=$2 WHERE id=$1 AND mbid IS NULL`, id, mbid)
+}
+
+func (pg *PGDB) SetTrackMBID(key uint64, mbid string) error {
+	return pg.setMBID(`UPDATE track SET mbid=$2 WHERE id=$1 AND mbid IS NULL`, key, mbid)
+}
+
+func (pg *PGDB) SetProjectMBID(key uint64, mbid string) error {
+	return pg.setMBID(`UPDATE project SET mbid=$2 WHERE id=$1 AND mbid IS NULL`, key, mbid)
+}
+
+// SetProjectEdition links a project to the original it is an edition of. An
+// empty edition and a base of 0 remove the link.
+func (pg *PGDB) SetProjectEdition(key uint64, edition Edition, baseKey uint64) error {
+	const stmt = `UPDATE project SET edition=NULLIF($2::text, ''), base_project_id=NULLIF($3::bigint, 0)
+	WHERE id=$1 AND ($3::bigint=0 OR EXISTS (SELECT 1 FROM project WHERE id=$3::bigint))`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, key, edition, baseKey)
+	if err != nil {
+		return fmt.Errorf("error updating project edition: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: project %d or base project %d", ErrNotFound, key, baseKey)
+	}
+
+	return nil
+}
+
+func (pg *PGDB) setMBID(stmt string, key uint64, mbid string) error {
+	if _, err := pg.db.Exec(context.Background(), stmt, key, mbid); err != nil {
+		return fmt.Errorf("error setting mbid: %w", err)
+	}
+
+	return nil
+}
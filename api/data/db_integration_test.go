@@ -1,7 +1,9 @@
 package data
 
 import (
+	"errors"
 	"os"
+	"slices"
 	"testing"
 	"time"
 )
@@ -19,7 +21,7 @@ func TestDBIntegration(t *testing.T) {
 		t.Skip("skipping integration test")
 	}
 
-	_, err = db.CreateUser("test", "test@test.com", "hashedpassword")
+	u, err := db.CreateUser("test", "test@test.com", "hashedpassword")
 	if err != nil {
 		t.Fatalf("%s", err.Error())
 	}
@@ -40,7 +42,7 @@ func TestDBIntegration(t *testing.T) {
 	}
 
 	release, _ := time.Parse("02/01/2006", "09/08/2023")
-	p, err := db.CreateProject(CreateHash("GUTS", []string{"Olivia Rodrigo"}), "GUTS", []uint64{a.ID}, Album, release)
+	p, err := db.CreateProject(CreateHash("GUTS", []string{"Olivia Rodrigo"}), "GUTS", []uint64{a.ID}, Album, release, DayPrecision)
 	if err != nil {
 		t.Error(err)
 	}
@@ -64,4 +66,752 @@ func TestDBIntegration(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
+
+	typo, err := db.CreateArtist("Olivia Rodrgio")
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = db.MergeArtists(typo.ID, a.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	aliased, err := db.GetArtist("Olivia Rodrgio")
+	if err != nil {
+		t.Error(err)
+	}
+	if aliased.ID != a.ID {
+		t.Fatalf("expected alias to resolve to %d but got %d", a.ID, aliased.ID)
+	}
+
+	_, err = db.CreateSpin(time.Now(), u.ID, track.ID, p.ID, 90000)
+	if err != nil {
+		t.Error(err)
+	}
+
+	lt, err := db.GetListeningTime(u.ID, Day, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Error(err)
+	}
+	if len(lt) != 1 || lt[0].MsPlayed != 90000 {
+		t.Fatalf("expected one day with 90000ms of listening but got %+v", lt)
+	}
+
+	_, err = db.AddTag(ArtistEntity, a.ID, "pop", 0)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, err = db.AddTag(TrackEntity, track.ID, "pop", u.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	chart, err := db.GetTagChart(u.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 10, true)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(chart) != 2 || chart[0].Spins != 1 {
+		t.Fatalf("expected the global and user tag with one spin each but got %+v", chart)
+	}
+
+	chart, err = db.GetTagChart(u.ID, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 10, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(chart) != 1 || chart[0].Tag.UserID != 0 {
+		t.Fatalf("expected only the global tag but got %+v", chart)
+	}
+
+	if err := db.SetProjectArtURL(p.ID, "https://example.com/guts.jpg"); err != nil {
+		t.Error(err)
+	}
+
+	pending, err := db.GetPendingArt(10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(pending) != 1 || pending[0].Key != p.ID {
+		t.Fatalf("expected pending artwork for project %d but got %+v", p.ID, pending)
+	}
+
+	if err := db.MarkArtFetched(p.ID); err != nil {
+		t.Error(err)
+	}
+	for _, url := range []string{"https://example.com/guts.jpg", "https://example.com/guts.png"} {
+		if err := db.SetProjectArtURL(p.ID, url); err != nil {
+			t.Error(err)
+		}
+	}
+
+	pending, err = db.GetPendingArt(10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(pending) != 1 || pending[0].URL != "https://example.com/guts.png" {
+		t.Fatalf("expected the failed artwork URL to be replaced but got %+v", pending)
+	}
+
+	if err := db.SetArt(ProjectEntity, p.ID, "abcdef"); err != nil {
+		t.Error(err)
+	}
+
+	hash, err := db.GetArt(ProjectEntity, p.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if hash != "abcdef" {
+		t.Fatalf("expected artwork abcdef but got %s", hash)
+	}
+
+	if err := db.SetArt(ProjectEntity, p.ID+1000, "abcdef"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v for an unknown project but got %v", ErrNotFound, err)
+	}
+
+	if err := db.SetPrimaryProject(track.ID, p.ID, true); err != nil {
+		t.Error(err)
+	}
+
+	primary, err := db.GetPrimaryContext(track.ID, u.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if primary.Pinned != p.ID || primary.Spins[p.ID] != 1 {
+		t.Fatalf("expected project %d pinned with one spin but got %+v", p.ID, primary)
+	}
+
+	deluxe, err := db.CreateProject(CreateHash("GUTS (spilled)", []string{"Olivia Rodrigo"}), "GUTS (spilled)", []uint64{a.ID}, Album, release, DayPrecision)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := db.SetProjectEdition(deluxe.ID, Deluxe, p.ID); err != nil {
+		t.Error(err)
+	}
+
+	deluxe, err = db.GetProject(deluxe.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if deluxe.Edition != Deluxe || deluxe.BaseProjectID != p.ID || !deluxe.IsLessPrimaryThan(&p) {
+		t.Fatalf("expected a deluxe edition of %d but got %+v", p.ID, deluxe)
+	}
+
+	artistPage, err := db.GetArtistPage(a.ID, u.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if artistPage.Plays.UserSpins != 1 || len(artistPage.Projects) != 2 || len(artistPage.Tracks) != 1 {
+		t.Fatalf("expected one spin on two projects and one track but got %+v", artistPage)
+	}
+
+	projectPage, err := db.GetProjectPage(p.ID, 0)
+	if err != nil {
+		t.Error(err)
+	}
+	if projectPage.Plays.Spins != 1 || projectPage.Plays.UserSpins != 0 || len(projectPage.Tracks) != 1 || len(projectPage.Artists) != 1 {
+		t.Fatalf("expected one anonymous spin of a one track project but got %+v", projectPage)
+	}
+
+	trackPage, err := db.GetTrackPage(track.ID, u.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if trackPage.Plays.UserSpins != 1 || len(trackPage.Projects) != 1 || trackPage.Projects[0].Plays.Spins != 1 {
+		t.Fatalf("expected one spin from one project but got %+v", trackPage)
+	}
+
+	if _, err := db.GetTrackPage(1, u.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+
+	results, err := db.Search("olivia gut", u.ID, 10, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(results.Projects) != 2 || results.Projects[0].ID != p.ID || results.Projects[0].UserSpins != 1 {
+		t.Fatalf("expected GUTS ranked above its deluxe edition but got %+v", results.Projects)
+	}
+
+	results, err = db.Search("olivia rodrgo", u.ID, 10, true)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(results.Artists) != 1 || results.Artists[0].ID != a.ID {
+		t.Fatalf("expected the misspelled artist to be found but got %+v", results.Artists)
+	}
+
+	spin, err := db.CreateSpin(time.Now(), u.ID, track.ID, p.ID, 1000)
+	if err != nil {
+		t.Error(err)
+	}
+
+	progress, err := db.UpdateMilestoneProgress(spin)
+	if err != nil {
+		t.Error(err)
+	}
+	if progress.Spins != 2 || progress.TrackSpins != 2 || progress.ArtistSpins[a.ID] != 2 || progress.Streak != 1 {
+		t.Fatalf("expected a second spin starting a streak but got %+v", progress)
+	}
+
+	reached := Achievements{{TrackSpinsMilestone, 2}}.Reached(spin, progress)
+	for i, expected := range []int{1, 0} {
+		added, err := db.AddMilestones(reached)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(added) != expected {
+			t.Fatalf("expected %d new milestones on attempt %d but got %+v", expected, i+1, added)
+		}
+	}
+
+	summary, err := db.GetMilestones(u.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if summary.Streak.Current != 1 || len(summary.Milestones) != 1 || summary.Milestones[0].Title != "bad idea right?" {
+		t.Fatalf("expected a one day streak and the second play of a track but got %+v", summary)
+	}
+
+	year := time.Now().UTC().Year()
+	from, to := YearRange(year, time.UTC)
+	tl, err := db.GetTrackListening(u.ID, from, to, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(tl) != 1 || tl[0].Spins != 2 || tl[0].MsPlayed != 91000 {
+		t.Fatalf("expected two spins of one track but got %+v", tl)
+	}
+
+	newArtists, err := db.GetNewArtists(u.ID, from, to)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(newArtists) != 1 || newArtists[0].Artist.ID != a.ID {
+		t.Fatalf("expected the artist to be new this year but got %+v", newArtists)
+	}
+
+	hours, err := db.GetHourlyListening(u.ID, from, to)
+	if err != nil {
+		t.Error(err)
+	}
+	var hourSpins uint64
+	for _, h := range hours {
+		hourSpins += h.Spins
+	}
+	if hourSpins != 2 {
+		t.Fatalf("expected two spins by hour but got %+v", hours)
+	}
+
+	onRepeat, err := db.GetOnRepeat(u.ID, from, to, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(onRepeat) != 0 {
+		t.Fatalf("expected no track on repeat after two spins but got %+v", onRepeat)
+	}
+
+	stale, err := db.GetStaleWrapped(year, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(stale) != 1 || stale[0].UserID != u.ID || stale[0].Year != year {
+		t.Fatalf("expected the user's report to be missing but got %v", stale)
+	}
+
+	if _, err := db.GetWrapped(u.ID, year); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	if err := db.SaveWrapped(u.ID, Wrapped{Year: year, Spins: 2, TopTracks: tl, GeneratedAt: time.Now()}); err != nil {
+		t.Error(err)
+	}
+	wrapped, err := db.GetWrapped(u.ID, year)
+	if err != nil {
+		t.Error(err)
+	}
+	if wrapped.Spins != 2 || len(wrapped.TopTracks) != 1 || wrapped.TopTracks[0].Track.Title != "bad idea right?" {
+		t.Fatalf("expected the saved report but got %+v", wrapped)
+	}
+
+	stale, err = db.GetStaleWrapped(year, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(stale) != 0 {
+		t.Fatalf("expected the user's report to be up to date but got %v", stale)
+	}
+
+	if err := db.UpdateSettings(u.ID, Settings{TimeZone: "Asia/Kolkata", Privacy: PrivatePrivacy}); err != nil {
+		t.Error(err)
+	}
+	settings, err := db.GetSettings(u.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if settings.TimeZone != "Asia/Kolkata" {
+		t.Fatalf("expected the user's time zone but got %+v", settings)
+	}
+	if _, err := db.GetSettings(u.ID + 1000); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	days, err := db.GetListeningTime(u.ID, Day, time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Error(err)
+	}
+	for _, d := range days {
+		if local := d.Start.In(kolkata); local.Hour() != 0 || local.Minute() != 0 {
+			t.Fatalf("expected days to start at midnight in Kolkata but got %v", local)
+		}
+	}
+
+	listens, err := db.GetFirstListens(u.ID, TrackEntity, from, to, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(listens) != 1 || listens[0].ID != track.ID || listens[0].Title != "bad idea right?" {
+		t.Fatalf("expected the first listen of the track but got %+v", listens)
+	}
+
+	discoveries, err := db.GetDiscoveries(u.ID, Month, time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Error(err)
+	}
+	var discovered Discoveries
+	for _, d := range discoveries {
+		discovered.Artists += d.Artists
+		discovered.Tracks += d.Tracks
+		discovered.Projects += d.Projects
+	}
+	if discovered.Artists != 1 || discovered.Tracks != 1 || discovered.Projects != 1 {
+		t.Fatalf("expected one artist, track and project discovered but got %+v", discoveries)
+	}
+
+	artistSpins, err := db.GetArtistSpins(u.ID, from, to)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(artistSpins) != 1 || artistSpins[0] != 2 {
+		t.Fatalf("expected two spins of one artist but got %v", artistSpins)
+	}
+
+	forgotten, err := db.GetForgottenFavorites(u.ID, ArtistEntity, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(forgotten) != 0 {
+		t.Fatalf("expected no favorites after two spins but got %+v", forgotten)
+	}
+
+	// Today lies wholly within the first range and is read from the rollups,
+	// while the second range ends today and reads its spins.
+	for _, end := range []time.Time{time.Now().Add(48 * time.Hour), time.Now().Add(time.Hour)} {
+		days, err := db.GetListeningTime(u.ID, Day, time.Time{}, end)
+		if err != nil {
+			t.Error(err)
+		}
+		var spins uint64
+		for _, d := range days {
+			spins += d.Spins
+		}
+		if spins != 2 {
+			t.Fatalf("expected two spins up to %v but got %+v", end, days)
+		}
+	}
+
+	if err := db.MarkRollupsStale(u.ID); err != nil {
+		t.Error(err)
+	}
+	staleRollups, err := db.GetStaleRollups(10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(staleRollups) != 1 || staleRollups[0] != u.ID {
+		t.Fatalf("expected the user's rollups to be stale but got %v", staleRollups)
+	}
+	if err := db.RebuildRollups(u.ID); err != nil {
+		t.Error(err)
+	}
+	if staleRollups, err = db.GetStaleRollups(10); err != nil || len(staleRollups) != 0 {
+		t.Fatalf("expected no stale rollups but got %v: %v", staleRollups, err)
+	}
+
+	artists, err := db.GetArtistListening(u.ID, time.Time{}, time.Now().Add(48*time.Hour), 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(artists) != 1 || artists[0].Spins != 2 || artists[0].MsPlayed != 91000 {
+		t.Fatalf("expected two rebuilt spins of the artist but got %+v", artists)
+	}
+
+	settings.DisplayName = "Test"
+	settings.Country = "IN"
+	settings.Privacy = PublicPrivacy
+	settings.HideNowPlaying = true
+	if err := db.UpdateSettings(u.ID, settings); err != nil {
+		t.Error(err)
+	}
+	profile, err := db.GetProfile("test")
+	if err != nil {
+		t.Error(err)
+	}
+	if profile.ID != u.ID || profile.Settings != settings {
+		t.Fatalf("expected the user's profile but got %+v", profile)
+	}
+	if _, err := db.GetProfile("nobody"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+
+	history, err := db.GetHistory(u.ID, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(history) != 2 || history[0].Title != "bad idea right?" || len(history[0].Artists) != 1 || !history[0].Time.After(history[1].Time) {
+		t.Fatalf("expected both spins latest first but got %+v", history)
+	}
+	if older, err := db.GetHistory(u.ID, history[0].Time, 10); err != nil || len(older) != 1 || older[0].SpinID != history[1].SpinID {
+		t.Fatalf("expected the spin before the latest but got %+v: %v", older, err)
+	}
+
+	friend, err := db.CreateUser("friend", "friend@test.com", "hashedpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, err := db.Follow(friend.ID, u.ID); err != nil || status != Following {
+		t.Fatalf("expected to follow the public profile but got %q: %v", status, err)
+	}
+	if status, err := db.Follow(u.ID, friend.ID); err != nil || status != PendingFollow {
+		t.Fatalf("expected to request to follow the private profile but got %q: %v", status, err)
+	}
+	requests, err := db.GetFollowers(friend.ID, PendingFollow, time.Now(), 10)
+	if err != nil || len(requests) != 1 || requests[0].ID != u.ID {
+		t.Fatalf("expected the user's follow request but got %+v: %v", requests, err)
+	}
+	if err := db.AcceptFollow(u.ID, friend.ID); err != nil {
+		t.Error(err)
+	}
+	if status, err := db.GetFollowStatus(u.ID, friend.ID); err != nil || status != Following {
+		t.Fatalf("expected the accepted follow but got %q: %v", status, err)
+	}
+	if err := db.AcceptFollow(u.ID, friend.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+
+	settings.HideNowPlaying = false
+	if err := db.UpdateSettings(u.ID, settings); err != nil {
+		t.Error(err)
+	}
+	feed, err := db.GetFeed(friend.ID, FeedCursor{Time: time.Now().Add(time.Hour)}, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	spins := 0
+	for _, item := range feed {
+		if item.User.ID != u.ID {
+			t.Fatalf("expected only the followed user in the feed but got %+v", item)
+		}
+		if item.Kind == SpinFeedItem {
+			spins++
+		}
+	}
+	if spins != 2 || len(feed) <= spins {
+		t.Fatalf("expected both spins and the milestones in the feed but got %+v", feed)
+	}
+
+	last := feed[0]
+	page, err := db.GetFeed(friend.ID, FeedCursor{Time: last.Time, Kind: last.Kind, ID: last.ID}, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(page) != len(feed)-1 || (len(page) > 0 && (page[0].Kind != feed[1].Kind || page[0].ID != feed[1].ID)) {
+		t.Fatalf("expected the feed after %+v to be %+v but got %+v", last, feed[1:], page)
+	}
+
+	settings.HideHistory = true
+	if err := db.UpdateSettings(u.ID, settings); err != nil {
+		t.Error(err)
+	}
+	if feed, err = db.GetFeed(friend.ID, FeedCursor{Time: time.Now().Add(time.Hour)}, 10); err != nil {
+		t.Error(err)
+	}
+	for _, item := range feed {
+		if item.Kind == SpinFeedItem {
+			t.Fatalf("expected the hidden history to be left out but got %+v", item)
+		}
+	}
+
+	if err := db.Unfollow(friend.ID, u.ID); err != nil {
+		t.Error(err)
+	}
+	if feed, err = db.GetFeed(friend.ID, FeedCursor{Time: time.Now().Add(time.Hour)}, 10); err != nil || len(feed) != 0 {
+		t.Fatalf("expected an empty feed after unfollowing but got %+v: %v", feed, err)
+	}
+
+	group, err := db.CreateGroup(friend.ID, Group{Name: "Sad Girls Club"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined, err := db.JoinGroup(group.ID, u.ID); err != nil || joined {
+		t.Fatalf("expected not to join the closed group uninvited but got %v: %v", joined, err)
+	}
+	if err := db.InviteToGroup(group.ID, u.ID, friend.ID); err != nil {
+		t.Error(err)
+	}
+	if invites, err := db.GetGroupInvites(u.ID); err != nil || len(invites) != 1 || invites[0].Group.ID != group.ID || invites[0].InvitedBy.ID != friend.ID {
+		t.Fatalf("expected the invite to the group but got %+v: %v", invites, err)
+	}
+	if joined, err := db.JoinGroup(group.ID, u.ID); err != nil || !joined {
+		t.Fatalf("expected to join the group when invited but got %v: %v", joined, err)
+	}
+	if invites, err := db.GetGroupInvites(u.ID); err != nil || len(invites) != 0 {
+		t.Fatalf("expected joining to use up the invite but got %+v: %v", invites, err)
+	}
+	if err := db.SetGroupRole(group.ID, u.ID, OwnerRole); err != nil {
+		t.Error(err)
+	}
+	members, err := db.GetGroupMembers(group.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(members) != 2 || members[0].ID != u.ID || members[0].Role != OwnerRole || members[1].Role != AdminRole {
+		t.Fatalf("expected the new owner and the old one as admin but got %+v", members)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to = today.AddDate(0, 0, -1), today.AddDate(0, 0, 2)
+	groupChart, err := db.GetGroupChart(group.ID, TrackEntity, from, to, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(groupChart) == 0 || groupChart[0].Rank != 1 || groupChart[0].Title == "" || len(groupChart[0].Contributions) != 1 || groupChart[0].Contributions[0].User.ID != u.ID {
+		t.Fatalf("expected the member's tracks on the group chart but got %+v", groupChart)
+	}
+	if projects, err := db.GetGroupChart(group.ID, ProjectEntity, from, to, 10); err != nil || len(projects) == 0 {
+		t.Fatalf("expected the member's projects on the group chart but got %+v: %v", projects, err)
+	}
+	listening, err := db.GetGroupMemberListening(group.ID, from, to)
+	if err != nil || len(listening) != 2 || listening[0].User.ID != u.ID || listening[0].Spins != 2 || listening[1].Spins != 0 {
+		t.Fatalf("expected both members' listening but got %+v: %v", listening, err)
+	}
+
+	week := WeekStart(today)
+	if _, err := db.GetGroupWeek(group.ID, TrackEntity, week); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	if err := db.SaveGroupWeek(group.ID, week, groupChart); err != nil {
+		t.Error(err)
+	}
+	stored, err := db.GetGroupWeek(group.ID, TrackEntity, week)
+	if err != nil || len(stored) != len(groupChart) || stored[0].ID != groupChart[0].ID || stored[0].Title != groupChart[0].Title {
+		t.Fatalf("expected the stored chart but got %+v: %v", stored, err)
+	}
+	if ids, err := db.GetUnsnapshottedGroups(week, 10); err != nil || len(ids) != 0 {
+		t.Fatalf("expected no group to need the week stored but got %v: %v", ids, err)
+	}
+	if ids, err := db.GetUnsnapshottedGroups(week.AddDate(0, 0, -7), 10); err != nil || len(ids) != 0 {
+		t.Fatalf("expected the group not to need a week before it existed but got %v: %v", ids, err)
+	}
+
+	if err := db.DeleteGroup(group.ID); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.GetGroup(group.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+
+	userChart, err := db.GetUserChart(u.ID, ArtistEntity, from, to, 10)
+	if err != nil || len(userChart) == 0 || userChart[0].Rank != 1 || userChart[0].Title == "" {
+		t.Fatalf("expected the user's artists on their chart but got %+v: %v", userChart, err)
+	}
+	if _, err := db.GetUserWeek(u.ID, ArtistEntity, week); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	lastWeek := week.AddDate(0, 0, -7)
+	if err := db.SaveUserWeek(u.ID, lastWeek, userChart); err != nil {
+		t.Error(err)
+	}
+	if err := db.SaveUserWeek(u.ID, week, userChart); err != nil {
+		t.Error(err)
+	}
+	if stored, err := db.GetUserWeek(u.ID, ArtistEntity, week); err != nil || len(stored) != len(userChart) || stored[0].Title != userChart[0].Title {
+		t.Fatalf("expected the stored chart but got %+v: %v", stored, err)
+	}
+	top := userChart[0].ID
+	runs, err := db.GetChartRuns(u.ID, ArtistEntity, []uint64{top}, week)
+	if err != nil || runs[top] != (ChartRun{Peak: 1, WeeksOnChart: 1}) {
+		t.Fatalf("expected one week at the top before this one but got %+v: %v", runs, err)
+	}
+	if positions, err := db.GetChartPositions(u.ID, ArtistEntity, top); err != nil || len(positions) != 2 || !positions[0].Week.Equal(lastWeek) || positions[1].Rank != 1 {
+		t.Fatalf("expected two weeks at the top but got %+v: %v", positions, err)
+	}
+	if numberOnes, err := db.GetNumberOnes(u.ID, ArtistEntity); err != nil || len(numberOnes) != 1 || numberOnes[0].Weeks != 2 || !numberOnes[0].From.Equal(lastWeek) {
+		t.Fatalf("expected a run of two weeks at number one but got %+v: %v", numberOnes, err)
+	}
+
+	vampire, err := db.CreateTrack(CreateHash("vampire", []string{"Olivia Rodrigo"}), "vampire", []uint64{a.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fan, err := db.CreateUser("fan", "fan@test.com", "hashedpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []struct{ userID, trackID uint64 }{{u.ID, vampire.ID}, {friend.ID, track.ID}, {friend.ID, vampire.ID}, {fan.ID, track.ID}} {
+		if _, err := db.CreateSpin(time.Now(), s.userID, s.trackID, 0, 180000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if builtAt, err := db.GetNeighborsBuiltAt(TrackEntity); err != nil || !builtAt.IsZero() {
+		t.Fatalf("expected no neighbors built yet but got %v: %v", builtAt, err)
+	}
+	if n, err := db.RebuildNeighbors(TrackEntity, time.Now().Add(-time.Hour), SESSION_GAP); err != nil || n != 2 {
+		t.Fatalf("expected the two tracks played together by two users to be neighbors but got %d: %v", n, err)
+	}
+	if builtAt, err := db.GetNeighborsBuiltAt(TrackEntity); err != nil || builtAt.IsZero() {
+		t.Fatalf("expected the neighbors to be built but got %v: %v", builtAt, err)
+	}
+	recommendations, err := db.GetRecommendations(fan.ID, TrackEntity, today.AddDate(0, 0, -1), 10)
+	if err != nil || len(recommendations) != 1 || recommendations[0].ID != vampire.ID || recommendations[0].Because.ID != track.ID {
+		t.Fatalf("expected the track played with the fan's track but got %+v: %v", recommendations, err)
+	}
+	if recommendations, err := db.GetRecommendations(friend.ID, TrackEntity, today.AddDate(0, 0, -1), 10); err != nil || len(recommendations) != 0 {
+		t.Fatalf("expected nothing the friend plays already but got %+v: %v", recommendations, err)
+	}
+
+	if n, err := db.DetectSessions(friend.ID, SESSION_GAP); err != nil || n != 1 {
+		t.Fatalf("expected the friend's spins in one session but got %d: %v", n, err)
+	}
+	if userIDs, err := db.GetUnsessionedUsers(SESSION_GAP, 10); err != nil || slices.Contains(userIDs, friend.ID) {
+		t.Fatalf("expected the friend's sessions to be up to date but got %v: %v", userIDs, err)
+	}
+	if userIDs, err := db.GetUnsessionedUsers(time.Hour, 10); err != nil || !slices.Contains(userIDs, friend.ID) {
+		t.Fatalf("expected the friend's sessions to be detected again with another gap but got %v: %v", userIDs, err)
+	}
+	sessions, err := db.GetSessions(friend.ID, time.Now().Add(time.Hour), 10, false)
+	if err != nil || len(sessions) != 1 || sessions[0].Spins != 2 || sessions[0].Album != nil {
+		t.Fatalf("expected one session of two spins but got %+v: %v", sessions, err)
+	}
+	if albums, err := db.GetSessions(friend.ID, time.Now().Add(time.Hour), 10, true); err != nil || len(albums) != 0 {
+		t.Fatalf("expected no album listened to but got %+v: %v", albums, err)
+	}
+	if session, err := db.GetSession(friend.ID, sessions[0].ID); err != nil || len(session.Entries) != 2 {
+		t.Fatalf("expected the session's spins but got %+v: %v", session, err)
+	}
+	if _, err := db.GetSession(fan.ID, sessions[0].ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+
+	playlist, err := db.CreatePlaylist(u.ID, Playlist{Name: "GUTS", Public: true})
+	if err != nil || playlist.ID == 0 || playlist.Owner != "test" {
+		t.Fatalf("expected a playlist of the user but got %+v: %v", playlist, err)
+	}
+	for _, trackID := range []uint64{track.ID, vampire.ID, track.ID} {
+		if err := db.AddPlaylistTrack(playlist.ID, trackID); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := db.AddPlaylistTrack(playlist.ID, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	if err := db.ReorderPlaylist(playlist.ID, []uint64{vampire.ID, track.ID}); err != nil {
+		t.Error(err)
+	}
+	if tracks, err := db.GetPlaylistTracks(playlist.ID); err != nil || len(tracks) != 2 || tracks[0].ID != vampire.ID || tracks[1].Position != 2 {
+		t.Fatalf("expected both tracks once in their new order but got %+v: %v", tracks, err)
+	}
+	if err := db.RemovePlaylistTrack(playlist.ID, vampire.ID); err != nil {
+		t.Error(err)
+	}
+	if err := db.RemovePlaylistTrack(playlist.ID, vampire.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	smart := Playlist{ID: playlist.ID, Name: "on repeat", Rule: &SmartRule{Days: 30, Order: SpinsOrder, Limit: 10}}
+	if err := db.UpdatePlaylist(smart); err != nil {
+		t.Error(err)
+	}
+	if stored, err := db.GetPlaylist(playlist.ID); err != nil || stored.Public || stored.Rule == nil || *stored.Rule != *smart.Rule {
+		t.Fatalf("expected the smart rule to be stored but got %+v: %v", stored, err)
+	}
+	if public, err := db.GetUserPlaylists(u.ID, false); err != nil || len(public) != 0 {
+		t.Fatalf("expected no public playlists but got %+v: %v", public, err)
+	}
+	if tracks, err := db.GetSmartPlaylistTracks(u.ID, *smart.Rule, time.Now()); err != nil || len(tracks) == 0 || tracks[0].Spins == 0 {
+		t.Fatalf("expected the tracks played this month but got %+v: %v", tracks, err)
+	}
+	if tracks, err := db.GetSmartPlaylistTracks(u.ID, SmartRule{NotHeardDays: 1, Order: TimeOrder, Limit: 10}, time.Now()); err != nil || len(tracks) != 0 {
+		t.Fatalf("expected every track to be heard today but got %+v: %v", tracks, err)
+	}
+	if err := db.DeletePlaylist(playlist.ID); err != nil {
+		t.Error(err)
+	}
+
+	if err := db.SetRating(u.ID, TrackEntity, track.ID, 9); err != nil {
+		t.Error(err)
+	}
+	if err := db.SetFavorite(u.ID, TrackEntity, vampire.ID, true); err != nil {
+		t.Error(err)
+	}
+	if err := db.SetRating(u.ID, TrackEntity, 1, 9); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	if rating, err := db.GetRating(u.ID, TrackEntity, track.ID); err != nil || rating.Stars != 4.5 || rating.Favorite || rating.Spins == 0 {
+		t.Fatalf("expected four and a half stars for a played track but got %+v: %v", rating, err)
+	}
+	ratings, err := db.GetRatings(u.ID, TrackEntity, RatingFilter{Limit: 10})
+	if err != nil || len(ratings) != 2 || ratings[0].ID != track.ID || !ratings[1].Favorite {
+		t.Fatalf("expected the rated track before the favorite but got %+v: %v", ratings, err)
+	}
+	var noSpins uint64
+	if rarely, err := db.GetRatings(u.ID, TrackEntity, RatingFilter{MinStars: 4, MaxSpins: &noSpins, Limit: 10}); err != nil || len(rarely) != 0 {
+		t.Fatalf("expected no highly rated track that was never played but got %+v: %v", rarely, err)
+	}
+	if err := db.SetNote(u.ID, p.ID, "the bridge on logical"); err != nil {
+		t.Error(err)
+	}
+	if rating, err := db.GetRating(u.ID, ProjectEntity, p.ID); err != nil || rating.Note != "the bridge on logical" || rating.Stars != 0 {
+		t.Fatalf("expected notes on the unrated project but got %+v: %v", rating, err)
+	}
+	if err := db.SetNote(u.ID, p.ID, ""); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.GetRating(u.ID, ProjectEntity, p.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the empty rating to be deleted but got %v", err)
+	}
+
+	// Spins long ago are read from the rollups, which have to follow edits.
+	past := time.Date(2001, 6, 15, 12, 0, 0, 0, time.UTC)
+	pastFrom, pastTo := past.AddDate(0, 0, -2), past.AddDate(0, 0, 2)
+	mistake, err := db.CreateSpin(past, u.ID, vampire.ID, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateSpin(past.Add(time.Minute), u.ID, vampire.ID, 0, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if s, previous, err := db.UpdateSpin(u.ID, uint64(mistake.ID), track.ID, p.ID); err != nil || s.TrackID != uint(track.ID) || s.MsPlayed != 1000 || previous != vampire.ID {
+		t.Fatalf("expected the spin to move from %d to the track but got %+v from %d: %v", vampire.ID, s, previous, err)
+	}
+	if _, _, err := db.UpdateSpin(fan.ID, uint64(mistake.ID), vampire.ID, 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	if tl, err := db.GetTrackListening(u.ID, pastFrom, pastTo, 10); err != nil || len(tl) != 2 || tl[0].Spins != 1 || tl[1].Spins != 1 {
+		t.Fatalf("expected one spin of each track but got %+v: %v", tl, err)
+	}
+	if err := db.DeleteSpin(u.ID, uint64(mistake.ID)); err != nil {
+		t.Error(err)
+	}
+	if err := db.DeleteSpin(u.ID, uint64(mistake.ID)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+	if n, err := db.DeleteSpins(u.ID, pastFrom, pastTo); err != nil || n != 1 {
+		t.Fatalf("expected the other spin to be deleted but got %d: %v", n, err)
+	}
+	if tl, err := db.GetTrackListening(u.ID, pastFrom, pastTo, 10); err != nil || len(tl) != 0 {
+		t.Fatalf("expected no listening left but got %+v: %v", tl, err)
+	}
 }
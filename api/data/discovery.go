@@ -0,0 +1,84 @@
+package data
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// FORGOTTEN_MIN_SPINS is how many times a user has to have played an artist,
+// track or project for it to count as a favorite they may have forgotten.
+const FORGOTTEN_MIN_SPINS = 10
+
+// FirstListen is the first time a user played an artist, track or project.
+type FirstListen struct {
+	Entity EntityType
+	ID     uint64
+	Title  string
+	Time   time.Time
+}
+
+// Discoveries counts the artists, tracks and projects a user listened to for
+// the first time in a period.
+type Discoveries struct {
+	Start    time.Time
+	Artists  uint64
+	Tracks   uint64
+	Projects uint64
+}
+
+// ForgottenFavorite is an artist, track or project a user played a lot but
+// not since LastSpin.
+type ForgottenFavorite struct {
+	Entity   EntityType
+	ID       uint64
+	Title    string
+	Spins    uint64
+	LastSpin time.Time
+}
+
+// Diversity measures how evenly a user's spins spread over the artists they
+// listened to. Entropy is in bits; EffectiveArtists is the number of equally
+// played artists with the same entropy. Evenness scales the entropy to 1 for
+// spins spread evenly over all artists, and Gini is 0 for even spins and
+// approaches 1 when few artists get nearly all of them.
+type Diversity struct {
+	Artists          uint64
+	Spins            uint64
+	Entropy          float64
+	EffectiveArtists float64
+	Evenness         float64
+	Gini             float64
+}
+
+// NewDiversity computes the diversity of the spins of each artist.
+func NewDiversity(artistSpins []uint64) Diversity {
+	d := Diversity{}
+	spins := []float64{}
+	for _, n := range artistSpins {
+		if n > 0 {
+			d.Artists++
+			d.Spins += n
+			spins = append(spins, float64(n))
+		}
+	}
+	if d.Spins == 0 {
+		return d
+	}
+
+	total := float64(d.Spins)
+	slices.Sort(spins)
+	var weighted float64
+	for i, n := range spins {
+		p := n / total
+		d.Entropy -= p * math.Log2(p)
+		weighted += float64(i+1) * n
+	}
+	d.EffectiveArtists = math.Exp2(d.Entropy)
+	if d.Artists > 1 {
+		d.Evenness = d.Entropy / math.Log2(float64(d.Artists))
+	}
+	k := float64(d.Artists)
+	d.Gini = 2*weighted/(k*total) - (k+1)/k
+	return d
+}
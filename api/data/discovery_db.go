@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type discoveryTable struct {
+	join  string
+	title string
+}
+
+// discoveryTables join a user's spins, as s with their tracks as t, to the
+// entities they count for as e. Spins count for the project stats attribute
+// them to.
+var discoveryTables = map[EntityType]discoveryTable{
+	ArtistEntity: {
+		`JOIN artist_track at ON t.id = at.track_id
+		JOIN artist e ON at.artist_id = e.id`,
+		"e.name",
+	},
+	ProjectEntity: {
+		`LEFT JOIN user_primary_project upp ON s.user_id = upp.user_id AND t.id = upp.track_id
+		JOIN project e ON COALESCE(upp.project_id, t.primary_project_id) = e.id`,
+		"e.title",
+	},
+	TrackEntity: {
+		`JOIN track e ON t.id = e.id`,
+		"e.title",
+	},
+}
+
+// userSpins selects the spins of the user in $1 grouped by the entity they
+// count for.
+func userSpins(entity EntityType, columns string) string {
+	dt := discoveryTables[entity]
+	return `SELECT e.id, ` + dt.title + ` AS title, ` + columns + `
+	FROM spin s
+	JOIN track t ON s.track_id = t.id
+	` + dt.join + `
+	WHERE s.user_id=$1
+	GROUP BY e.id`
+}
+
+// GetFirstListens returns the artists, tracks or projects a user listened to
+// for the first time within a range, latest first.
+func (pg *PGDB) GetFirstListens(userID uint64, entity EntityType, from, to time.Time, limit int) ([]FirstListen, error) {
+	stmt := `SELECT f.id, f.title, f.first_spin FROM (` + userSpins(entity, `min(s.time) AS first_spin`) + `) f
+	WHERE f.first_spin >= $2 AND f.first_spin < $3
+	ORDER BY f.first_spin DESC, f.id
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting first listens: %w", err)
+	}
+
+	listens, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (FirstListen, error) {
+		f := FirstListen{Entity: entity}
+		err := row.Scan(&f.ID, &f.Title, &f.Time)
+		return f, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting first listens: %w", err)
+	}
+
+	return listens, nil
+}
+
+// GetDiscoveries counts the first listens of a user by periods starting at
+// midnight in their time zone.
+func (pg *PGDB) GetDiscoveries(userID uint64, period Period, from, to time.Time) ([]Discoveries, error) {
+	stmt := `WITH first_spin AS (
+		SELECT 'artist' AS entity, first_spin FROM (` + userSpins(ArtistEntity, `min(s.time) AS first_spin`) + `) a
+		UNION ALL
+		SELECT 'track', first_spin FROM (` + userSpins(TrackEntity, `min(s.time) AS first_spin`) + `) t
+		UNION ALL
+		SELECT 'project', first_spin FROM (` + userSpins(ProjectEntity, `min(s.time) AS first_spin`) + `) p
+	)
+	SELECT date_trunc($2, f.first_spin, u.time_zone) AS start,
+		count(*) FILTER (WHERE f.entity = 'artist'),
+		count(*) FILTER (WHERE f.entity = 'track'),
+		count(*) FILTER (WHERE f.entity = 'project')
+	FROM first_spin f
+	JOIN "user" u ON u.id=$1
+	WHERE f.first_spin >= $3 AND f.first_spin < $4
+	GROUP BY start
+	ORDER BY start`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, string(period), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting discoveries: %w", err)
+	}
+
+	discoveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Discoveries, error) {
+		var d Discoveries
+		err := row.Scan(&d.Start, &d.Artists, &d.Tracks, &d.Projects)
+		return d, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting discoveries: %w", err)
+	}
+
+	return discoveries, nil
+}
+
+// GetArtistSpins returns how many times a user played each artist they
+// listened to within a range.
+func (pg *PGDB) GetArtistSpins(userID uint64, from, to time.Time) ([]uint64, error) {
+	stmt := `WITH ` + artistRollup.listening() + `
+	SELECT sum(l.spins)::bigint
+	FROM l
+	GROUP BY l.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting artist spins: %w", err)
+	}
+
+	spins, err := pgx.CollectRows(rows, pgx.RowTo[uint64])
+	if err != nil {
+		return nil, fmt.Errorf("error selecting artist spins: %w", err)
+	}
+
+	return spins, nil
+}
+
+// GetForgottenFavorites returns the artists, tracks or projects a user played
+// at least FORGOTTEN_MIN_SPINS times but not since a cutoff, most played
+// first.
+func (pg *PGDB) GetForgottenFavorites(userID uint64, entity EntityType, since time.Time, limit int) ([]ForgottenFavorite, error) {
+	stmt := `SELECT f.id, f.title, f.spins, f.last_spin FROM (` + userSpins(entity, `count(*) AS spins, max(s.time) AS last_spin`) + `) f
+	WHERE f.last_spin < $2 AND f.spins >= $3
+	ORDER BY f.spins DESC, f.id
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, since, FORGOTTEN_MIN_SPINS, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting forgotten favorites: %w", err)
+	}
+
+	favorites, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ForgottenFavorite, error) {
+		f := ForgottenFavorite{Entity: entity}
+		err := row.Scan(&f.ID, &f.Title, &f.Spins, &f.LastSpin)
+		return f, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting forgotten favorites: %w", err)
+	}
+
+	return favorites, nil
+}
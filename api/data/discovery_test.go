@@ -0,0 +1,31 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewDiversity(t *testing.T) {
+	tests := []struct {
+		name     string
+		spins    []uint64
+		expected Diversity
+	}{
+		{"No spins", []uint64{}, Diversity{}},
+		{"One artist", []uint64{12}, Diversity{Artists: 1, Spins: 12, EffectiveArtists: 1}},
+		{"Even spins", []uint64{5, 5, 5, 5}, Diversity{Artists: 4, Spins: 20, Entropy: 2, EffectiveArtists: 4, Evenness: 1}},
+		{"Uneven spins", []uint64{0, 1, 3}, Diversity{Artists: 2, Spins: 4, Entropy: 0.811278, EffectiveArtists: 1.754765, Evenness: 0.811278, Gini: 0.25}},
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDiversity(tt.spins)
+			e := tt.expected
+			if d.Artists != e.Artists || d.Spins != e.Spins || !near(d.Entropy, e.Entropy) || !near(d.EffectiveArtists, e.EffectiveArtists) ||
+				!near(d.Evenness, e.Evenness) || !near(d.Gini, e.Gini) {
+				t.Fatalf("expected %+v but got %+v", e, d)
+			}
+		})
+	}
+}
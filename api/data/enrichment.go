@@ -0,0 +1,197 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Entries the provider failed to look up are retried after a delay that
+// doubles with each attempt, up to a day.
+const (
+	ENRICHMENT_RETRY_DELAY     = 10 * time.Minute
+	MAX_ENRICHMENT_RETRY_DELAY = 24 * time.Hour
+)
+
+var enrichmentTables = map[EntityType]string{
+	ProjectEntity: "project",
+	TrackEntity:   "track",
+}
+
+func (pg *PGDB) GetUnenrichedTracks(limit int) ([]EnrichmentCandidate, error) {
+	const stmt = `SELECT t.id, t.title, COALESCE(t.mbid::text, ''), array_remove(array_agg(a.name), NULL)
+	FROM track t
+	LEFT JOIN artist_track at ON t.id = at.track_id
+	LEFT JOIN artist a ON at.artist_id = a.id
+	WHERE t.enriched_at IS NULL AND (t.enrich_retry_at IS NULL OR t.enrich_retry_at <= now())
+	GROUP BY t.id, t.title, t.mbid
+	ORDER BY t.id
+	LIMIT $1`
+
+	rows, err := pg.db.Query(context.Background(), stmt, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unenriched tracks: %w", err)
+	}
+
+	candidates, err := pgx.CollectRows(rows, scanCandidate)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unenriched tracks: %w", err)
+	}
+
+	return candidates, nil
+}
+
+func (pg *PGDB) GetUnenrichedProjects(limit int) ([]EnrichmentCandidate, error) {
+	const stmt = `SELECT p.id, p.title, COALESCE(p.mbid::text, ''), array_remove(array_agg(a.name), NULL)
+	FROM project p
+	LEFT JOIN artist_project ap ON p.id = ap.project_id
+	LEFT JOIN artist a ON ap.artist_id = a.id
+	WHERE p.enriched_at IS NULL AND (p.enrich_retry_at IS NULL OR p.enrich_retry_at <= now())
+	GROUP BY p.id, p.title, p.mbid
+	ORDER BY p.id
+	LIMIT $1`
+	const tracksStmt = `SELECT t.id, t.title, COALESCE(t.mbid::text, ''), '{}'::varchar[]
+	FROM track t
+	JOIN project_track pt ON t.id = pt.track_id
+	WHERE pt.project_id = $1`
+
+	rows, err := pg.db.Query(context.Background(), stmt, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unenriched projects: %w", err)
+	}
+
+	candidates, err := pgx.CollectRows(rows, scanCandidate)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unenriched projects: %w", err)
+	}
+
+	for i, c := range candidates {
+		rows, err := pg.db.Query(context.Background(), tracksStmt, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error selecting project tracks: %w", err)
+		}
+
+		candidates[i].Tracks, err = pgx.CollectRows(rows, scanCandidate)
+		if err != nil {
+			return nil, fmt.Errorf("error selecting project tracks: %w", err)
+		}
+	}
+
+	return candidates, nil
+}
+
+// MarkEnrichmentFailed records a failed lookup of a track or project and
+// holds it back until its next retry, so it does not block the ones after it.
+func (pg *PGDB) MarkEnrichmentFailed(entity EntityType, key uint64) error {
+	table, ok := enrichmentTables[entity]
+	if !ok {
+		return fmt.Errorf("%s is not enriched", entity)
+	}
+	stmt := fmt.Sprintf(`UPDATE %s SET
+	enrich_retry_at=now() + LEAST($2 * power(2, LEAST(enrich_attempts, 20)), $3) * interval '1 millisecond',
+	enrich_attempts=enrich_attempts + 1
+	WHERE id=$1`, table)
+
+	_, err := pg.db.Exec(context.Background(), stmt, key, ENRICHMENT_RETRY_DELAY.Milliseconds(), MAX_ENRICHMENT_RETRY_DELAY.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("error marking %s enrichment failed: %w", entity, err)
+	}
+	return nil
+}
+
+// UpdateTrackMetadata marks a track as enriched, filling in whatever metadata
+// it does not have yet.
+func (pg *PGDB) UpdateTrackMetadata(key uint64, m TrackMetadata) error {
+	const stmt = `UPDATE track SET
+	duration_ms=COALESCE(duration_ms, NULLIF($2, 0)),
+	isrc=COALESCE(isrc, NULLIF($3, '')),
+	enriched_at=now()
+	WHERE id=$1`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error updating track metadata: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, stmt, key, m.Duration.Milliseconds(), m.ISRC); err != nil {
+		return fmt.Errorf("error updating track metadata: %w", err)
+	}
+	if err := linkGlobalTags(tx, TrackEntity, key, m.Genres); err != nil {
+		return err
+	}
+	// Spins without a play time count the track's duration.
+	if m.Duration > 0 {
+		if err := markRollupsStale(tx, `s.track_id=$1 AND s.ms_played IS NULL`, key); err != nil {
+			return err
+		}
+		if err := markSessionsStale(tx, `s.track_id=$1 AND s.ms_played IS NULL`, key); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// refinesRelease holds when the new release date lies in the same year as the
+// stored one but is known to a finer precision.
+const refinesRelease = `(date_part('year', release) = date_part('year', $3::date)
+	AND array_position(ARRAY['year', 'month', 'day'], $4::varchar) > array_position(ARRAY['year', 'month', 'day'], release_precision))`
+
+// UpdateProjectMetadata marks a project as enriched, filling in whatever
+// metadata it does not have yet. Releases stored without a date are dated,
+// and dates the provider knows more precisely in the same year are refined.
+func (pg *PGDB) UpdateProjectMetadata(key uint64, m ProjectMetadata) error {
+	const stmt = `UPDATE project SET
+	label=COALESCE(label, NULLIF($2, '')),
+	release=CASE WHEN $3::date IS NOT NULL AND (release='0001-01-01' OR ` + refinesRelease + `) THEN $3::date ELSE release END,
+	release_precision=CASE WHEN $3::date IS NOT NULL AND (release='0001-01-01' OR ` + refinesRelease + `) THEN $4 ELSE release_precision END,
+	enriched_at=now()
+	WHERE id=$1`
+	const trackNumberUpdate = `UPDATE project_track SET track_number=$3 WHERE project_id=$1 AND track_id=$2`
+
+	var release *time.Time
+	if !m.Release.IsZero() {
+		release = &m.Release
+	}
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error updating project metadata: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	precision := m.ReleasePrecision
+	if precision == "" {
+		precision = DayPrecision
+	}
+
+	if _, err := tx.Exec(ctx, stmt, key, m.Label, release, precision); err != nil {
+		return fmt.Errorf("error updating project metadata: %w", err)
+	}
+	for trackKey, number := range m.TrackNumbers {
+		if _, err := tx.Exec(ctx, trackNumberUpdate, key, trackKey, number); err != nil {
+			return fmt.Errorf("error updating track number: %w", err)
+		}
+	}
+	if len(m.TrackNumbers) > 0 {
+		if err := markSessionsStale(tx, `s.project_id=$1`, key); err != nil {
+			return err
+		}
+	}
+	if err := linkGlobalTags(tx, ProjectEntity, key, m.Genres); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func scanCandidate(row pgx.CollectableRow) (EnrichmentCandidate, error) {
+	var c EnrichmentCandidate
+	err := row.Scan(&c.Key, &c.Title, &c.MBID, &c.ArtistNames)
+	return c, err
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+// FollowStatus is where a user stands towards following another. Follows of
+// public profiles are accepted right away, others wait for the user followed
+// to accept them.
+type FollowStatus string
+
+const (
+	NotFollowing  FollowStatus = "none"
+	PendingFollow FollowStatus = "pending"
+	Following     FollowStatus = "following"
+)
+
+// UserSummary is how users appear in lists of other users.
+type UserSummary struct {
+	ID          uint64
+	Name        string
+	DisplayName string
+	AvatarURL   string
+}
+
+// Follow is a user in a list of followers, followed users or follow requests,
+// with the time the follow was requested.
+type Follow struct {
+	UserSummary
+	Since time.Time
+}
+
+type FeedKind string
+
+const (
+	SpinFeedItem      FeedKind = "spin"
+	MilestoneFeedItem FeedKind = "milestone"
+)
+
+// FeedItem is a spin or a milestone of a followed user, with the ID of
+// either. Spin is set for spins and Milestone for milestones.
+type FeedItem struct {
+	Kind      FeedKind
+	ID        uint64
+	User      UserSummary
+	Time      time.Time
+	Spin      *HistoryEntry
+	Milestone *Milestone
+}
+
+// FeedCursor is the last item of a page of the feed, which the next page
+// starts after. Items at the same time list spins before milestones and each
+// kind by latest ID. Without a kind, the page starts before the time.
+type FeedCursor struct {
+	Time time.Time
+	Kind FeedKind
+	ID   uint64
+}
+
+// ids returns the IDs of the spins and of the milestones at the time of the
+// cursor that the page lists those below.
+func (c FeedCursor) ids() (spinID, milestoneID uint64) {
+	switch c.Kind {
+	case SpinFeedItem:
+		return c.ID, math.MaxInt64
+	case MilestoneFeedItem:
+		return 0, c.ID
+	default:
+		return 0, 0
+	}
+}
+
+// compareFeedItems orders feed items latest first, as FeedCursor does.
+func compareFeedItems(x, y FeedItem) int {
+	if c := y.Time.Compare(x.Time); c != 0 {
+		return c
+	}
+	if x.Kind != y.Kind {
+		if x.Kind == SpinFeedItem {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Compare(y.ID, x.ID)
+}
+
+// mergeFeed merges feed items sorted latest first into the latest of them.
+func mergeFeed(a, b []FeedItem, limit int) []FeedItem {
+	feed := append(slices.Clone(a), b...)
+	slices.SortFunc(feed, compareFeedItems)
+	return feed[:min(len(feed), limit)]
+}
@@ -0,0 +1,199 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Follow requests to follow a user. Following a public profile is accepted
+// right away, following any other waits for the user to accept it. Following
+// again keeps the current status.
+func (pg *PGDB) Follow(followerID, followeeID uint64) (FollowStatus, error) {
+	const stmt = `INSERT INTO follow (follower_id, followee_id, accepted)
+	SELECT $1, id, privacy = 'public' FROM "user" WHERE id=$2
+	ON CONFLICT (follower_id, followee_id) DO UPDATE SET accepted = follow.accepted OR EXCLUDED.accepted
+	RETURNING accepted`
+
+	var accepted bool
+	err := pg.db.QueryRow(context.Background(), stmt, followerID, followeeID).Scan(&accepted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NotFollowing, fmt.Errorf("%w: user %d", ErrNotFound, followeeID)
+	} else if err != nil {
+		return NotFollowing, fmt.Errorf("error inserting follow: %w", err)
+	}
+	return followStatus(accepted), nil
+}
+
+// Unfollow removes a follow or a follow request.
+func (pg *PGDB) Unfollow(followerID, followeeID uint64) error {
+	const stmt = `DELETE FROM follow WHERE follower_id=$1 AND followee_id=$2`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("error deleting follow: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: follow of %d by %d", ErrNotFound, followeeID, followerID)
+	}
+	return nil
+}
+
+func (pg *PGDB) AcceptFollow(followerID, followeeID uint64) error {
+	const stmt = `UPDATE follow SET accepted=true WHERE follower_id=$1 AND followee_id=$2 AND NOT accepted`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("error accepting follow: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: follow request of %d by %d", ErrNotFound, followeeID, followerID)
+	}
+	return nil
+}
+
+func (pg *PGDB) GetFollowStatus(followerID, followeeID uint64) (FollowStatus, error) {
+	const stmt = `SELECT accepted FROM follow WHERE follower_id=$1 AND followee_id=$2`
+
+	var accepted bool
+	err := pg.db.QueryRow(context.Background(), stmt, followerID, followeeID).Scan(&accepted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NotFollowing, nil
+	} else if err != nil {
+		return NotFollowing, fmt.Errorf("error selecting follow: %w", err)
+	}
+	return followStatus(accepted), nil
+}
+
+// GetFollowers returns the users following a user, or requesting to when the
+// status is PendingFollow, latest first among those who followed before a
+// time.
+func (pg *PGDB) GetFollowers(userID uint64, status FollowStatus, before time.Time, limit int) ([]Follow, error) {
+	const stmt = `SELECT u.id, u.name, u.display_name, u.avatar_url, f.created_at
+	FROM follow f
+	JOIN "user" u ON f.follower_id = u.id
+	WHERE f.followee_id=$1 AND f.accepted=$2 AND f.created_at < $3
+	ORDER BY f.created_at DESC, u.id
+	LIMIT $4`
+
+	return pg.getFollows(stmt, userID, status, before, limit)
+}
+
+// GetFollowing returns the users a user follows, or requested to follow when
+// the status is PendingFollow, latest first among those followed before a
+// time.
+func (pg *PGDB) GetFollowing(userID uint64, status FollowStatus, before time.Time, limit int) ([]Follow, error) {
+	const stmt = `SELECT u.id, u.name, u.display_name, u.avatar_url, f.created_at
+	FROM follow f
+	JOIN "user" u ON f.followee_id = u.id
+	WHERE f.follower_id=$1 AND f.accepted=$2 AND f.created_at < $3
+	ORDER BY f.created_at DESC, u.id
+	LIMIT $4`
+
+	return pg.getFollows(stmt, userID, status, before, limit)
+}
+
+func (pg *PGDB) getFollows(stmt string, userID uint64, status FollowStatus, before time.Time, limit int) ([]Follow, error) {
+	rows, err := pg.db.Query(context.Background(), stmt, userID, status == Following, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting follows: %w", err)
+	}
+	follows, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Follow, error) {
+		var f Follow
+		err := row.Scan(&f.ID, &f.Name, &f.DisplayName, &f.AvatarURL, &f.Since)
+		return f, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting follows: %w", err)
+	}
+	return follows, nil
+}
+
+// feedUsers selects the users a user follows whose profile lets followers
+// see their listening data, as Profile.CanView does.
+const feedUsers = `WITH f AS (
+		SELECT u.id, u.name, u.display_name, u.avatar_url, u.hide_history, u.hide_now_playing
+		FROM follow fo
+		JOIN "user" u ON fo.followee_id = u.id
+		WHERE fo.follower_id=$1 AND fo.accepted AND u.privacy IN ('public', 'followers')
+	)`
+
+// GetFeed returns the latest spins and milestones after a cursor of the users
+// a user follows. Spins of users hiding their history are left out, as are the
+// ones still playing of users hiding what they play now.
+func (pg *PGDB) GetFeed(userID uint64, before FeedCursor, limit int) ([]FeedItem, error) {
+	// Each followed user adds at most a page of their latest spins, read
+	// backwards from spin_user_time_idx, so the feed stays cheap however many
+	// users are followed.
+	const selectSpins = feedUsers + `
+	SELECT ` + historyColumns + `, f.id, f.name, f.display_name, f.avatar_url
+	FROM f
+	CROSS JOIN LATERAL (
+		SELECT s.* FROM spin s
+		JOIN track t ON s.track_id = t.id
+		WHERE s.user_id = f.id AND (s.time, s.id) < ($2, $5) AND NOT f.hide_history
+			AND NOT (f.hide_now_playing AND s.time + COALESCE(t.duration_ms, $4::bigint) * interval '1 millisecond' > now())
+		ORDER BY s.time DESC, s.id DESC
+		LIMIT $3
+	) s
+	JOIN track t ON s.track_id = t.id
+	LEFT JOIN project p ON s.project_id = p.id
+	ORDER BY s.time DESC, s.id DESC
+	LIMIT $3`
+	const selectMilestones = feedUsers + `
+	SELECT ` + milestoneColumns + `, f.id, f.name, f.display_name, f.avatar_url
+	FROM f
+	CROSS JOIN LATERAL (
+		SELECT * FROM milestone m
+		WHERE m.user_id = f.id AND (m.achieved_at, m.id) < ($2, $4)
+		ORDER BY m.achieved_at DESC, m.id DESC
+		LIMIT $3
+	) m
+	` + milestoneJoins + `
+	ORDER BY m.achieved_at DESC, m.id DESC
+	LIMIT $3`
+
+	spinID, milestoneID := before.ids()
+	ctx := context.Background()
+	rows, err := pg.db.Query(ctx, selectSpins, userID, before.Time, limit, NOW_PLAYING_WINDOW.Milliseconds(), spinID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting feed spins: %w", err)
+	}
+	spins, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (FeedItem, error) {
+		item := FeedItem{Kind: SpinFeedItem}
+		u := &item.User
+		h, err := scanHistoryEntry(row, &u.ID, &u.Name, &u.DisplayName, &u.AvatarURL)
+		item.ID, item.Time, item.Spin = h.SpinID, h.Time, &h
+		return item, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting feed spins: %w", err)
+	}
+
+	rows, err = pg.db.Query(ctx, selectMilestones, userID, before.Time, limit, milestoneID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting feed milestones: %w", err)
+	}
+	milestones, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (FeedItem, error) {
+		item := FeedItem{Kind: MilestoneFeedItem}
+		u := &item.User
+		m, err := scanMilestone(row, &u.ID, &u.Name, &u.DisplayName, &u.AvatarURL)
+		item.ID, item.Time, item.Milestone = m.ID, m.AchievedAt, &m
+		return item, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting feed milestones: %w", err)
+	}
+
+	return mergeFeed(spins, milestones, limit), nil
+}
+
+func followStatus(accepted bool) FollowStatus {
+	if accepted {
+		return Following
+	}
+	return PendingFollow
+}
@@ -0,0 +1,58 @@
+package data
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMergeFeed(t *testing.T) {
+	at := func(minutes int) time.Time {
+		return time.Date(2024, 3, 10, 12, minutes, 0, 0, time.UTC)
+	}
+	spins := []FeedItem{{Kind: SpinFeedItem, Time: at(30)}, {Kind: SpinFeedItem, Time: at(20)}, {Kind: SpinFeedItem, Time: at(5)}}
+	milestones := []FeedItem{{Kind: MilestoneFeedItem, Time: at(20)}, {Kind: MilestoneFeedItem, Time: at(10)}}
+
+	feed := mergeFeed(spins, milestones, 4)
+	expected := []FeedItem{spins[0], spins[1], milestones[0], milestones[1]}
+	if len(feed) != len(expected) {
+		t.Fatalf("expected %d items but got %+v", len(expected), feed)
+	}
+	for i := range expected {
+		if feed[i].Kind != expected[i].Kind || !feed[i].Time.Equal(expected[i].Time) {
+			t.Fatalf("expected %+v at %d but got %+v", expected[i], i, feed[i])
+		}
+	}
+
+	if feed := mergeFeed(spins, nil, 10); len(feed) != len(spins) {
+		t.Fatalf("expected all spins but got %+v", feed)
+	}
+
+	spins = []FeedItem{{Kind: SpinFeedItem, ID: 8, Time: at(20)}, {Kind: SpinFeedItem, ID: 7, Time: at(20)}}
+	milestones = []FeedItem{{Kind: MilestoneFeedItem, ID: 9, Time: at(20)}}
+	feed = mergeFeed(milestones, spins, 10)
+	if feed[0].ID != 8 || feed[1].ID != 7 || feed[2].ID != 9 {
+		t.Fatalf("expected spins at the same time by latest ID before milestones but got %+v", feed)
+	}
+}
+
+func TestFeedCursorIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		cursor      FeedCursor
+		spinID      uint64
+		milestoneID uint64
+	}{
+		{"Should list milestones at the time of a spin", FeedCursor{Kind: SpinFeedItem, ID: 7}, 7, math.MaxInt64},
+		{"Should leave out spins at the time of a milestone", FeedCursor{Kind: MilestoneFeedItem, ID: 9}, 0, 9},
+		{"Should list both before a time", FeedCursor{}, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if spinID, milestoneID := tt.cursor.ids(); spinID != tt.spinID || milestoneID != tt.milestoneID {
+				t.Fatalf("expected %d and %d but got %d and %d", tt.spinID, tt.milestoneID, spinID, milestoneID)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// groupMembers selects the members of the group in $1 as user_id.
+const groupMembers = `SELECT user_id FROM group_member WHERE group_id=$1`
+
+// GetGroupChart ranks the artists, tracks or projects the members of a group
+// listened to most on the days from one up to another, with each member's
+// contribution to them.
+func (pg *PGDB) GetGroupChart(groupID uint64, entity EntityType, from, to time.Time, limit int) ([]ChartEntry, error) {
+	source, ok := chartSources[entity]
+	if !ok {
+		return nil, fmt.Errorf("unknown chart entity %q", entity)
+	}
+	stmt := `WITH ` + source.rollup.dayListening(groupMembers) + `,
+	e AS (` + source.entries + `),
+	c AS (
+		SELECT user_id, id, sum(spins)::bigint AS spins, sum(ms_played)::bigint AS ms_played
+		FROM e
+		GROUP BY user_id, id
+	),
+	top AS (
+		SELECT id, sum(spins)::bigint AS spins, sum(ms_played)::bigint AS ms_played
+		FROM c
+		GROUP BY id
+		ORDER BY ms_played DESC, spins DESC, id
+		LIMIT $4
+	)
+	SELECT top.id, x.` + source.title + `, top.spins, top.ms_played, u.id, u.name, u.display_name, u.avatar_url, c.spins, c.ms_played
+	FROM top
+	JOIN ` + source.table + ` x ON top.id = x.id
+	JOIN c ON top.id = c.id
+	JOIN "user" u ON c.user_id = u.id
+	ORDER BY top.ms_played DESC, top.spins DESC, top.id, c.ms_played DESC, u.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, groupID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting group chart: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ChartEntry{}
+	for rows.Next() {
+		var e ChartEntry
+		var m MemberListening
+		if err := rows.Scan(&e.ID, &e.Title, &e.Spins, &e.MsPlayed, &m.User.ID, &m.User.Name, &m.User.DisplayName, &m.User.AvatarURL, &m.Spins, &m.MsPlayed); err != nil {
+			return nil, fmt.Errorf("error selecting group chart: %w", err)
+		}
+		if len(entries) == 0 || entries[len(entries)-1].ID != e.ID {
+			e.Rank = len(entries) + 1
+			e.Entity = entity
+			entries = append(entries, e)
+		}
+		last := &entries[len(entries)-1]
+		last.Contributions = append(last.Contributions, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error selecting group chart: %w", err)
+	}
+	return entries, nil
+}
+
+// GetGroupMemberListening sums each member's listening on the days from one up
+// to another, most first. Members who did not listen count zero.
+func (pg *PGDB) GetGroupMemberListening(groupID uint64, from, to time.Time) ([]MemberListening, error) {
+	const stmt = `SELECT u.id, u.name, u.display_name, u.avatar_url, COALESCE(sum(r.spins), 0)::bigint AS spins, COALESCE(sum(r.ms_played), 0)::bigint AS ms_played
+	FROM group_member m
+	JOIN "user" u ON m.user_id = u.id
+	LEFT JOIN user_track_day r ON r.user_id = m.user_id AND r.day >= $2 AND r.day < $3
+	WHERE m.group_id=$1
+	GROUP BY u.id
+	ORDER BY ms_played DESC, spins DESC, u.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, groupID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting group member listening: %w", err)
+	}
+	members, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (MemberListening, error) {
+		var m MemberListening
+		err := row.Scan(&m.User.ID, &m.User.Name, &m.User.DisplayName, &m.User.AvatarURL, &m.Spins, &m.MsPlayed)
+		return m, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting group member listening: %w", err)
+	}
+	return members, nil
+}
+
+// GetUnsnapshottedGroups returns the groups that existed before a week ended
+// and have no charts stored for it.
+func (pg *PGDB) GetUnsnapshottedGroups(week time.Time, limit int) ([]uint64, error) {
+	const stmt = `SELECT g.id
+	FROM user_group g
+	WHERE g.created_at < $2 AND NOT EXISTS (
+		SELECT 1 FROM group_chart_snapshot s WHERE s.group_id = g.id AND s.week=$1
+	)
+	ORDER BY g.id
+	LIMIT $3`
+
+	rows, err := pg.db.Query(context.Background(), stmt, week, week.AddDate(0, 0, 7), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unsnapshotted groups: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uint64])
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unsnapshotted groups: %w", err)
+	}
+	return ids, nil
+}
+
+// SaveGroupWeek stores the charts of a group for the week starting on a day,
+// replacing any stored before.
+func (pg *PGDB) SaveGroupWeek(groupID uint64, week time.Time, entries []ChartEntry) error {
+	return pg.saveChartWeek(groupCharts, groupID, week, entries)
+}
+
+// GetGroupWeek returns the stored chart of a group for the week starting on a
+// day.
+func (pg *PGDB) GetGroupWeek(groupID uint64, entity EntityType, week time.Time) ([]ChartEntry, error) {
+	return pg.getChartWeek(groupCharts, groupID, entity, week)
+}
@@ -0,0 +1,73 @@
+package data
+
+import "time"
+
+// Group is a listening club. Anyone can join open groups, others only when
+// invited.
+type Group struct {
+	ID          uint64
+	Name        string
+	Description string
+	Open        bool
+	Members     uint64
+	CreatedAt   time.Time
+}
+
+// GroupRole is what a member can do in a group. Owners can do anything,
+// admins invite and remove members and edit the group, and members read its
+// charts.
+type GroupRole string
+
+const (
+	OwnerRole  GroupRole = "owner"
+	AdminRole  GroupRole = "admin"
+	MemberRole GroupRole = "member"
+)
+
+var groupRoleRanks = map[GroupRole]int{
+	MemberRole: 1,
+	AdminRole:  2,
+	OwnerRole:  3,
+}
+
+func (r GroupRole) IsValid() bool {
+	_, ok := groupRoleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether the role can do what another role can. The empty
+// role of users outside the group is below every role.
+func (r GroupRole) AtLeast(other GroupRole) bool {
+	return groupRoleRanks[r] >= groupRoleRanks[other]
+}
+
+type GroupMember struct {
+	UserSummary
+	Role     GroupRole
+	JoinedAt time.Time
+}
+
+// GroupInvite invites a user to a group. InvitedBy is empty when the user who
+// sent it deleted their account.
+type GroupInvite struct {
+	Group     Group
+	InvitedBy UserSummary
+	CreatedAt time.Time
+}
+
+// MemberListening is how much a member of a group listened to something.
+type MemberListening struct {
+	User     UserSummary
+	Spins    uint64
+	MsPlayed uint64
+}
+
+// GroupChart ranks what a group listened to between two days and sums each
+// member's listening in that time.
+type GroupChart struct {
+	Entity  EntityType
+	From    time.Time
+	To      time.Time
+	Entries []ChartEntry
+	Members []MemberListening
+}
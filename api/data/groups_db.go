@@ -0,0 +1,254 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const groupColumns = `g.id, g.name, g.description, g.open, (SELECT count(*) FROM group_member gm WHERE gm.group_id = g.id), g.created_at`
+
+func scanGroup(row pgx.Row, dest ...any) (Group, error) {
+	var g Group
+	err := row.Scan(append([]any{&g.ID, &g.Name, &g.Description, &g.Open, &g.Members, &g.CreatedAt}, dest...)...)
+	return g, err
+}
+
+// CreateGroup creates a group owned by a user.
+func (pg *PGDB) CreateGroup(ownerID uint64, g Group) (Group, error) {
+	const insertGroup = `INSERT INTO user_group (name, description, open) VALUES ($1, $2, $3) RETURNING id, created_at`
+	const insertOwner = `INSERT INTO group_member (group_id, user_id, role) VALUES ($1, $2, 'owner')`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return Group{}, fmt.Errorf("error starting group insert: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, insertGroup, g.Name, g.Description, g.Open).Scan(&g.ID, &g.CreatedAt); err != nil {
+		return Group{}, fmt.Errorf("error inserting group: %w", err)
+	}
+	if _, err := tx.Exec(ctx, insertOwner, g.ID, ownerID); err != nil {
+		return Group{}, fmt.Errorf("error inserting group owner: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Group{}, fmt.Errorf("error committing group: %w", err)
+	}
+	g.Members = 1
+	return g, nil
+}
+
+func (pg *PGDB) GetGroup(id uint64) (Group, error) {
+	const stmt = `SELECT ` + groupColumns + ` FROM user_group g WHERE g.id=$1`
+
+	g, err := scanGroup(pg.db.QueryRow(context.Background(), stmt, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Group{}, fmt.Errorf("%w: group %d", ErrNotFound, id)
+	} else if err != nil {
+		return Group{}, fmt.Errorf("error selecting group: %w", err)
+	}
+	return g, nil
+}
+
+func (pg *PGDB) UpdateGroup(g Group) error {
+	const stmt = `UPDATE user_group SET name=$2, description=$3, open=$4 WHERE id=$1`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, g.ID, g.Name, g.Description, g.Open)
+	if err != nil {
+		return fmt.Errorf("error updating group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: group %d", ErrNotFound, g.ID)
+	}
+	return nil
+}
+
+func (pg *PGDB) DeleteGroup(id uint64) error {
+	const stmt = `DELETE FROM user_group WHERE id=$1`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, id)
+	if err != nil {
+		return fmt.Errorf("error deleting group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: group %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// GetUserGroups returns the groups a user is a member of, by name.
+func (pg *PGDB) GetUserGroups(userID uint64) ([]Group, error) {
+	const stmt = `SELECT ` + groupColumns + `
+	FROM user_group g
+	JOIN group_member m ON m.group_id = g.id
+	WHERE m.user_id=$1
+	ORDER BY g.name, g.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting groups: %w", err)
+	}
+	groups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Group, error) {
+		return scanGroup(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting groups: %w", err)
+	}
+	return groups, nil
+}
+
+// GetGroupRole returns the role of a user in a group, or the empty role when
+// they are not a member.
+func (pg *PGDB) GetGroupRole(groupID, userID uint64) (GroupRole, error) {
+	const stmt = `SELECT role FROM group_member WHERE group_id=$1 AND user_id=$2`
+
+	var role GroupRole
+	err := pg.db.QueryRow(context.Background(), stmt, groupID, userID).Scan(&role)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("error selecting group role: %w", err)
+	}
+	return role, nil
+}
+
+// GetGroupMembers returns the members of a group, owner and admins first.
+func (pg *PGDB) GetGroupMembers(groupID uint64) ([]GroupMember, error) {
+	const stmt = `SELECT u.id, u.name, u.display_name, u.avatar_url, m.role, m.joined_at
+	FROM group_member m
+	JOIN "user" u ON m.user_id = u.id
+	WHERE m.group_id=$1
+	ORDER BY m.role = 'owner' DESC, m.role = 'admin' DESC, m.joined_at, u.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting group members: %w", err)
+	}
+	members, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (GroupMember, error) {
+		var m GroupMember
+		err := row.Scan(&m.ID, &m.Name, &m.DisplayName, &m.AvatarURL, &m.Role, &m.JoinedAt)
+		return m, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting group members: %w", err)
+	}
+	return members, nil
+}
+
+// SetGroupRole changes the role of a member. Making a member the owner makes
+// the previous owner an admin.
+func (pg *PGDB) SetGroupRole(groupID, userID uint64, role GroupRole) error {
+	const demoteOwner = `UPDATE group_member SET role='admin' WHERE group_id=$1 AND role='owner' AND user_id<>$2`
+	const stmt = `UPDATE group_member SET role=$3 WHERE group_id=$1 AND user_id=$2`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting group role update: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if role == OwnerRole {
+		if _, err := tx.Exec(ctx, demoteOwner, groupID, userID); err != nil {
+			return fmt.Errorf("error demoting group owner: %w", err)
+		}
+	}
+	tag, err := tx.Exec(ctx, stmt, groupID, userID, string(role))
+	if err != nil {
+		return fmt.Errorf("error updating group role: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: member %d of group %d", ErrNotFound, userID, groupID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing group role: %w", err)
+	}
+	return nil
+}
+
+func (pg *PGDB) RemoveGroupMember(groupID, userID uint64) error {
+	const stmt = `DELETE FROM group_member WHERE group_id=$1 AND user_id=$2`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting group member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: member %d of group %d", ErrNotFound, userID, groupID)
+	}
+	return nil
+}
+
+// InviteToGroup invites a user to a group. Inviting again keeps the first
+// invite.
+func (pg *PGDB) InviteToGroup(groupID, userID, invitedBy uint64) error {
+	const stmt = `INSERT INTO group_invite (group_id, user_id, invited_by) VALUES ($1, $2, $3)
+	ON CONFLICT (group_id, user_id) DO NOTHING`
+
+	if _, err := pg.db.Exec(context.Background(), stmt, groupID, userID, invitedBy); err != nil {
+		return fmt.Errorf("error inserting group invite: %w", err)
+	}
+	return nil
+}
+
+func (pg *PGDB) DeleteGroupInvite(groupID, userID uint64) error {
+	const stmt = `DELETE FROM group_invite WHERE group_id=$1 AND user_id=$2`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("error deleting group invite: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: invite of %d to group %d", ErrNotFound, userID, groupID)
+	}
+	return nil
+}
+
+// GetGroupInvites returns the invites a user has not answered yet, latest
+// first.
+func (pg *PGDB) GetGroupInvites(userID uint64) ([]GroupInvite, error) {
+	const stmt = `SELECT ` + groupColumns + `, COALESCE(u.id, 0), COALESCE(u.name, ''), COALESCE(u.display_name, ''), COALESCE(u.avatar_url, ''), i.created_at
+	FROM group_invite i
+	JOIN user_group g ON i.group_id = g.id
+	LEFT JOIN "user" u ON i.invited_by = u.id
+	WHERE i.user_id=$1
+	ORDER BY i.created_at DESC, g.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting group invites: %w", err)
+	}
+	invites, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (GroupInvite, error) {
+		var i GroupInvite
+		by := &i.InvitedBy
+		g, err := scanGroup(row, &by.ID, &by.Name, &by.DisplayName, &by.AvatarURL, &i.CreatedAt)
+		i.Group = g
+		return i, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting group invites: %w", err)
+	}
+	return invites, nil
+}
+
+// JoinGroup makes a user a member of a group that is open or invited them,
+// and reports whether they joined. Users who are members already do not join
+// again.
+func (pg *PGDB) JoinGroup(groupID, userID uint64) (bool, error) {
+	const stmt = `WITH invite AS (
+		DELETE FROM group_invite WHERE group_id=$1 AND user_id=$2 RETURNING group_id
+	)
+	INSERT INTO group_member (group_id, user_id, role)
+	SELECT g.id, $2, 'member' FROM user_group g
+	WHERE g.id=$1 AND (g.open OR EXISTS (SELECT 1 FROM invite))
+	ON CONFLICT (group_id, user_id) DO NOTHING`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, groupID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error joining group: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
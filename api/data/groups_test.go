@@ -0,0 +1,22 @@
+package data
+
+import "testing"
+
+func TestGroupRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		role     GroupRole
+		other    GroupRole
+		expected bool
+	}{
+		{OwnerRole, AdminRole, true},
+		{AdminRole, AdminRole, true},
+		{MemberRole, AdminRole, false},
+		{"", MemberRole, false},
+	}
+
+	for _, tt := range tests {
+		if tt.role.AtLeast(tt.other) != tt.expected {
+			t.Fatalf("expected %q at least %q to be %v", tt.role, tt.other, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// NOW_PLAYING_WINDOW is how long a spin of a track of unknown length counts
+// as playing.
+const NOW_PLAYING_WINDOW = 10 * time.Minute
+
+// HistoryEntry is a recorded spin with the names of what was played. Duration
+// is the track's length, 0 while it is unknown.
+type HistoryEntry struct {
+	SpinID       uint64
+	Time         time.Time
+	TrackID      uint64
+	Title        string
+	Artists      []string
+	ProjectID    uint64
+	ProjectTitle string
+	MsPlayed     uint64
+	Duration     time.Duration
+}
+
+// IsPlaying reports whether the spin's track is still playing at a time.
+func (h HistoryEntry) IsPlaying(now time.Time) bool {
+	length := h.Duration
+	if length == 0 {
+		length = NOW_PLAYING_WINDOW
+	}
+	return !now.Before(h.Time) && !now.After(h.Time.Add(length))
+}
+
+// historyColumns selects a HistoryEntry from a spin s joined with its track t
+// and left joined with its project p.
+const historyColumns = `s.id, s.time, t.id, t.title,
+	ARRAY(SELECT DISTINCT a.name FROM artist a JOIN artist_track at ON a.id = at.artist_id WHERE at.track_id = t.id ORDER BY a.name),
+	COALESCE(p.id, 0), COALESCE(p.title, ''), COALESCE(s.ms_played, 0), COALESCE(t.duration_ms, 0)`
+
+func scanHistoryEntry(row pgx.Row, dest ...any) (HistoryEntry, error) {
+	var h HistoryEntry
+	var durationMs int64
+	err := row.Scan(append([]any{&h.SpinID, &h.Time, &h.TrackID, &h.Title, &h.Artists, &h.ProjectID, &h.ProjectTitle, &h.MsPlayed, &durationMs}, dest...)...)
+	h.Duration = time.Duration(durationMs) * time.Millisecond
+	return h, err
+}
+
+// GetHistory returns a user's spins before a time, latest first.
+func (pg *PGDB) GetHistory(userID uint64, before time.Time, limit int) ([]HistoryEntry, error) {
+	const stmt = `SELECT ` + historyColumns + `
+	FROM spin s
+	JOIN track t ON s.track_id = t.id
+	LEFT JOIN project p ON s.project_id = p.id
+	WHERE s.user_id=$1 AND s.time < $2
+	ORDER BY s.time DESC, s.id DESC
+	LIMIT $3`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting history: %w", err)
+	}
+	history, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (HistoryEntry, error) {
+		return scanHistoryEntry(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting history: %w", err)
+	}
+	return history, nil
+}
@@ -4,7 +4,26 @@ import "time"
 
 type DB interface {
 	UserDB
+	ProfileDB
+	HistoryDB
+	FollowDB
+	GroupDB
+	ChartDB
+	RecommendationDB
+	PlaylistDB
+	RatingDB
 	TunesDB
+	SpinDB
+	MergeDB
+	EnrichmentDB
+	StatsDB
+	DiscoveryDB
+	RollupDB
+	TagDB
+	ArtDB
+	CatalogDB
+	SearchDB
+	WrappedDB
 }
 
 type UserDB interface {
@@ -12,18 +31,214 @@ type UserDB interface {
 	CreateUser(name, email, password string) (User, error)
 }
 
+type ProfileDB interface {
+	GetProfile(name string) (Profile, error)
+	GetSettings(userID uint64) (Settings, error)
+	UpdateSettings(userID uint64, s Settings) error
+}
+
+type HistoryDB interface {
+	GetHistory(userID uint64, before time.Time, limit int) ([]HistoryEntry, error)
+	SessionDB
+}
+
+type SessionDB interface {
+	GetUnsessionedUsers(gap time.Duration, limit int) ([]uint64, error)
+	DetectSessions(userID uint64, gap time.Duration) (int, error)
+	GetSessions(userID uint64, before time.Time, limit int, albums bool) ([]Session, error)
+	GetSession(userID, sessionID uint64) (Session, error)
+}
+
+type FollowDB interface {
+	Follow(followerID, followeeID uint64) (FollowStatus, error)
+	Unfollow(followerID, followeeID uint64) error
+	AcceptFollow(followerID, followeeID uint64) error
+	GetFollowStatus(followerID, followeeID uint64) (FollowStatus, error)
+	GetFollowers(userID uint64, status FollowStatus, before time.Time, limit int) ([]Follow, error)
+	GetFollowing(userID uint64, status FollowStatus, before time.Time, limit int) ([]Follow, error)
+	GetFeed(userID uint64, before FeedCursor, limit int) ([]FeedItem, error)
+}
+
+type GroupDB interface {
+	CreateGroup(ownerID uint64, g Group) (Group, error)
+	GetGroup(id uint64) (Group, error)
+	UpdateGroup(g Group) error
+	DeleteGroup(id uint64) error
+	GetUserGroups(userID uint64) ([]Group, error)
+	GetGroupRole(groupID, userID uint64) (GroupRole, error)
+	GetGroupMembers(groupID uint64) ([]GroupMember, error)
+	SetGroupRole(groupID, userID uint64, role GroupRole) error
+	RemoveGroupMember(groupID, userID uint64) error
+	InviteToGroup(groupID, userID, invitedBy uint64) error
+	DeleteGroupInvite(groupID, userID uint64) error
+	GetGroupInvites(userID uint64) ([]GroupInvite, error)
+	JoinGroup(groupID, userID uint64) (bool, error)
+	GroupChartDB
+}
+
+type GroupChartDB interface {
+	GetGroupChart(groupID uint64, entity EntityType, from, to time.Time, limit int) ([]ChartEntry, error)
+	GetGroupMemberListening(groupID uint64, from, to time.Time) ([]MemberListening, error)
+	GetUnsnapshottedGroups(week time.Time, limit int) ([]uint64, error)
+	SaveGroupWeek(groupID uint64, week time.Time, entries []ChartEntry) error
+	GetGroupWeek(groupID uint64, entity EntityType, week time.Time) ([]ChartEntry, error)
+}
+
+type ChartDB interface {
+	GetUserChart(userID uint64, entity EntityType, from, to time.Time, limit int) ([]ChartEntry, error)
+	GetUnsnapshottedWeeks(lastWeek time.Time, limit int) ([]UserWeek, error)
+	SaveUserWeek(userID uint64, week time.Time, entries []ChartEntry) error
+	GetUserWeek(userID uint64, entity EntityType, week time.Time) ([]ChartEntry, error)
+	GetChartRuns(userID uint64, entity EntityType, ids []uint64, before time.Time) (map[uint64]ChartRun, error)
+	GetChartPositions(userID uint64, entity EntityType, id uint64) ([]ChartPosition, error)
+	GetNumberOnes(userID uint64, entity EntityType) ([]NumberOne, error)
+}
+
+type RecommendationDB interface {
+	RebuildNeighbors(entity EntityType, since time.Time, gap time.Duration) (int64, error)
+	GetNeighborsBuiltAt(entity EntityType) (time.Time, error)
+	GetRecommendations(userID uint64, entity EntityType, since time.Time, limit int) ([]Recommendation, error)
+}
+
+type PlaylistDB interface {
+	CreatePlaylist(userID uint64, p Playlist) (Playlist, error)
+	GetPlaylist(id uint64) (Playlist, error)
+	UpdatePlaylist(p Playlist) error
+	DeletePlaylist(id uint64) error
+	GetUserPlaylists(userID uint64, private bool) ([]Playlist, error)
+	GetPlaylistTracks(id uint64) ([]PlaylistTrack, error)
+	AddPlaylistTrack(id, trackID uint64) error
+	RemovePlaylistTrack(id, trackID uint64) error
+	ReorderPlaylist(id uint64, trackIDs []uint64) error
+	GetSmartPlaylistTracks(userID uint64, rule SmartRule, now time.Time) ([]PlaylistTrack, error)
+}
+
+type RatingDB interface {
+	GetRating(userID uint64, entity EntityType, id uint64) (Rating, error)
+	GetRatings(userID uint64, entity EntityType, filter RatingFilter) ([]Rating, error)
+	SetRating(userID uint64, entity EntityType, id uint64, halfStars int) error
+	SetFavorite(userID uint64, entity EntityType, id uint64, favorite bool) error
+	SetNote(userID uint64, projectID uint64, note string) error
+}
+
+// AccessDB is what deciding who can see a user's listening data needs.
+type AccessDB interface {
+	ProfileDB
+	GetFollowStatus(followerID, followeeID uint64) (FollowStatus, error)
+}
+
 type TunesDB interface {
 	GetArtist(name string) (Artist, error)
 	CreateArtist(name string) (Artist, error)
 	GetTrack(key uint64) (Track, error)
 	CreateTrack(key uint64, title string, artistIDs []uint64) (Track, error)
 	GetProject(key uint64) (Project, error)
-	CreateProject(key uint64, title string, artistIDs []uint64, form ProjectType, release time.Time) (Project, error)
-	CreateSpin(t time.Time, userID uint64, trackID uint64) (Spin, error)
+	CreateProject(key uint64, title string, artistIDs []uint64, form ProjectType, release time.Time, precision DatePrecision) (Project, error)
+	CreateSpin(t time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (Spin, error)
 	UpdateTrack(key uint64, projectID uint64, isPrimary bool) error
+	GetArtistByMBID(mbid string) (Artist, error)
+	GetTrackByMBID(mbid string) (Track, error)
+	GetProjectByMBID(mbid string) (Project, error)
+	SetArtistMBID(id uint64, mbid string) error
+	SetTrackMBID(key uint64, mbid string) error
+	SetProjectMBID(key uint64, mbid string) error
+	SetProjectEdition(key uint64, edition Edition, baseKey uint64) error
+	SetTrackDuration(key uint64, d time.Duration) error
+	SetProjectArtURL(key uint64, url string) error
+	PrimaryDB
+	MilestoneDB
+}
+
+type SpinDB interface {
+	UpdateSpin(userID, id, trackID, projectID uint64) (Spin, uint64, error)
+	DeleteSpin(userID, id uint64) error
+	DeleteSpins(userID uint64, from, to time.Time) (int64, error)
+}
+
+type PrimaryDB interface {
+	GetTrackProjects(key uint64) ([]Project, error)
+	GetPrimaryContext(key uint64, userID uint64) (PrimaryContext, error)
+	SetPrimaryProject(key uint64, projectID uint64, pinned bool) error
+	UnpinPrimaryProject(key uint64) error
+	SetUserPrimaryProject(userID uint64, key uint64, projectID uint64) error
+	DeleteUserPrimaryProject(userID uint64, key uint64) error
+}
+
+type MilestoneDB interface {
+	UpdateMilestoneProgress(s Spin) (MilestoneProgress, error)
+	AddMilestones(milestones []Milestone) ([]Milestone, error)
+	GetMilestones(userID uint64) (MilestoneSummary, error)
+}
+
+type MergeDB interface {
+	MergeArtists(fromID, intoID uint64) (MergeResult, error)
+	MergeTracks(fromKey, intoKey uint64) (MergeResult, error)
+	MergeProjects(fromKey, intoKey uint64) (MergeResult, error)
+	CreateArtistAlias(name string, artistID uint64) error
+}
+
+type EnrichmentDB interface {
+	GetUnenrichedTracks(limit int) ([]EnrichmentCandidate, error)
+	GetUnenrichedProjects(limit int) ([]EnrichmentCandidate, error)
+	UpdateTrackMetadata(key uint64, m TrackMetadata) error
+	UpdateProjectMetadata(key uint64, m ProjectMetadata) error
+	MarkEnrichmentFailed(entity EntityType, key uint64) error
+}
+
+type StatsDB interface {
+	GetListeningTime(userID uint64, period Period, from, to time.Time) ([]ListeningTime, error)
+	GetArtistListening(userID uint64, from, to time.Time, limit int) ([]ArtistListening, error)
+	GetProjectListening(userID uint64, from, to time.Time, limit int) ([]ProjectListening, error)
+	GetTrackListening(userID uint64, from, to time.Time, limit int) ([]TrackListening, error)
+	GetNewArtists(userID uint64, from, to time.Time) ([]ArtistListening, error)
+	GetHourlyListening(userID uint64, from, to time.Time) ([]HourListening, error)
+	GetOnRepeat(userID uint64, from, to time.Time, limit int) ([]TrackListening, error)
+}
+
+type RollupDB interface {
+	MarkRollupsStale(userID uint64) error
+	GetStaleRollups(limit int) ([]uint64, error)
+	RebuildRollups(userID uint64) error
+}
+
+type DiscoveryDB interface {
+	GetFirstListens(userID uint64, entity EntityType, from, to time.Time, limit int) ([]FirstListen, error)
+	GetDiscoveries(userID uint64, period Period, from, to time.Time) ([]Discoveries, error)
+	GetArtistSpins(userID uint64, from, to time.Time) ([]uint64, error)
+	GetForgottenFavorites(userID uint64, entity EntityType, since time.Time, limit int) ([]ForgottenFavorite, error)
+}
+
+type WrappedDB interface {
+	GetStaleWrapped(lastYear int, limit int) ([]StaleWrapped, error)
+	SaveWrapped(userID uint64, w Wrapped) error
+	GetWrapped(userID uint64, year int) (Wrapped, error)
+}
+
+type TagDB interface {
+	AddTag(entity EntityType, key uint64, name string, userID uint64) (Tag, error)
+	RemoveTag(entity EntityType, key uint64, name string, userID uint64) error
+	GetTags(entity EntityType, key uint64, userID uint64) ([]Tag, error)
+	GetTagChart(userID uint64, from, to time.Time, limit int, private bool) ([]TagListening, error)
+}
+
+type ArtDB interface {
+	SetArt(entity EntityType, key uint64, hash string) error
+	GetArt(entity EntityType, key uint64) (string, error)
+	GetPendingArt(limit int) ([]ArtSource, error)
+	MarkArtFetched(key uint64) error
+}
+
+type CatalogDB interface {
+	GetArtistPage(id uint64, userID uint64) (ArtistPage, error)
+	GetProjectPage(key uint64, userID uint64) (ProjectPage, error)
+	GetTrackPage(key uint64, userID uint64) (TrackPage, error)
+}
+
+type SearchDB interface {
+	Search(query string, userID uint64, limit int, fuzzy bool) (SearchResults, error)
 }
 
 type AuthDB interface {
-	WriteRefreshToken(id string, expires time.Time) (bool, error)
+	WriteRefreshToken(id string, userID uint64, expires time.Time) (bool, error)
 	FindRefreshToken(id string) (RefreshToken, error)
 }
@@ -0,0 +1,407 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (pg *PGDB) MergeArtists(fromID, intoID uint64) (MergeResult, error) {
+	const deleteArtist = `DELETE FROM artist WHERE id=$1 RETURNING name, mbid::text`
+	const inheritMBID = `UPDATE artist SET mbid=$2 WHERE id=$1 AND mbid IS NULL`
+	const repointAliases = `UPDATE artist_alias SET artist_id=$2 WHERE artist_id=$1 RETURNING name`
+	const insertAlias = `INSERT INTO artist_alias (name, artist_id) VALUES ($1, $2)`
+	const inheritArt = `UPDATE artist SET art_hash=(SELECT art_hash FROM artist WHERE id=$1) WHERE id=$2 AND art_hash IS NULL`
+	const selectTracks = `SELECT t.id, t.title, array_agg(a.name)
+	FROM track t
+	JOIN artist_track at ON t.id = at.track_id
+	JOIN artist a ON at.artist_id = a.id
+	WHERE t.id IN (SELECT track_id FROM artist_track WHERE artist_id=$1)
+	GROUP BY t.id, t.title`
+	const selectProjects = `SELECT p.id, p.title, array_agg(a.name)
+	FROM project p
+	JOIN artist_project ap ON p.id = ap.project_id
+	JOIN artist a ON ap.artist_id = a.id
+	WHERE p.id IN (SELECT project_id FROM artist_project WHERE artist_id=$1)
+	GROUP BY p.id, p.title`
+	const insertTrackAlias = `INSERT INTO track_alias (id, track_id)
+	SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM track WHERE id=$1)
+	ON CONFLICT (id) DO NOTHING`
+	const insertProjectAlias = `INSERT INTO project_alias (id, project_id)
+	SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM project WHERE id=$1)
+	ON CONFLICT (id) DO NOTHING`
+
+	if fromID == intoID {
+		return MergeResult{}, fmt.Errorf("cannot merge artist into itself")
+	}
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("error starting merge: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockMerged(tx, "artist", fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+
+	if err := moveJunction(tx, "artist_track", "artist_id", "track_id", fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "artist_project", "artist_id", "project_id", fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "artist_tag", "artist_id", "tag_id", fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "artist_rating", "artist_id", "user_id", fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveMilestones(tx, ArtistSpinsMilestone, fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveChartWeeks(tx, userCharts, ArtistEntity, fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveChartWeeks(tx, groupCharts, ArtistEntity, fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := dropNeighbors(tx, ArtistEntity, fromID); err != nil {
+		return MergeResult{}, err
+	}
+	// Spins of tracks by both artists were counted twice.
+	if err := moveRollups(tx, artistRollup, fromID, intoID); err != nil {
+		return MergeResult{}, err
+	}
+	if err := markRollupsStale(tx, `s.track_id IN (SELECT track_id FROM artist_track WHERE artist_id=$1)`, intoID); err != nil {
+		return MergeResult{}, err
+	}
+
+	if _, err := tx.Exec(ctx, inheritArt, fromID, intoID); err != nil {
+		return MergeResult{}, fmt.Errorf("error updating artist art: %w", err)
+	}
+
+	names, err := collect[string](tx, repointAliases, fromID, intoID)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing artist aliases: %w", err)
+	}
+
+	var fromName string
+	var fromMBID *string
+	if err := tx.QueryRow(ctx, deleteArtist, fromID).Scan(&fromName, &fromMBID); err != nil {
+		return MergeResult{}, fmt.Errorf("error deleting merged artist: %w", err)
+	}
+	if _, err := tx.Exec(ctx, inheritMBID, intoID, fromMBID); err != nil {
+		return MergeResult{}, fmt.Errorf("error updating artist mbid: %w", err)
+	}
+	if _, err := tx.Exec(ctx, insertAlias, fromName, intoID); err != nil {
+		return MergeResult{}, fmt.Errorf("error inserting artist alias: %w", err)
+	}
+	names = append(names, fromName)
+
+	// Hashes of tracks and projects are computed from artist names, so the
+	// canonical names now produce new keys that must resolve to the old rows.
+	for _, q := range []struct{ selectStmt, aliasStmt string }{
+		{selectTracks, insertTrackAlias},
+		{selectProjects, insertProjectAlias},
+	} {
+		keys, err := canonicalKeys(tx, q.selectStmt, intoID)
+		if err != nil {
+			return MergeResult{}, err
+		}
+		for key, hash := range keys {
+			if _, err := tx.Exec(ctx, q.aliasStmt, hash, key); err != nil {
+				return MergeResult{}, fmt.Errorf("error inserting hash alias: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return MergeResult{}, fmt.Errorf("error committing merge: %w", err)
+	}
+
+	return MergeResult{ArtistNames: names, MBIDs: mbids(fromMBID)}, nil
+}
+
+func (pg *PGDB) MergeTracks(fromKey, intoKey uint64) (MergeResult, error) {
+	const repointSpins = `UPDATE spin SET track_id=$2 WHERE track_id=$1`
+	const inheritPrimary = `UPDATE track SET primary_project_id=(SELECT primary_project_id FROM track WHERE id=$1)
+	WHERE id=$2 AND primary_project_id IS NULL`
+	const repointAliases = `UPDATE track_alias SET track_id=$2 WHERE track_id=$1 RETURNING id`
+	const deleteTrack = `DELETE FROM track WHERE id=$1 RETURNING mbid::text`
+	const inheritMBID = `UPDATE track SET mbid=$2 WHERE id=$1 AND mbid IS NULL`
+	const insertAlias = `INSERT INTO track_alias (id, track_id) VALUES ($1, $2)`
+
+	if fromKey == intoKey {
+		return MergeResult{}, fmt.Errorf("cannot merge track into itself")
+	}
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("error starting merge: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockMerged(tx, "track", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+
+	if _, err := tx.Exec(ctx, repointSpins, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing spins: %w", err)
+	}
+	if err := moveJunction(tx, "artist_track", "track_id", "artist_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "project_track", "track_id", "project_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "track_tag", "track_id", "tag_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "user_primary_project", "track_id", "user_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "playlist_track", "track_id", "playlist_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "track_rating", "track_id", "user_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveMilestones(tx, TrackSpinsMilestone, fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveChartWeeks(tx, userCharts, TrackEntity, fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveChartWeeks(tx, groupCharts, TrackEntity, fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := dropNeighbors(tx, TrackEntity, fromKey); err != nil {
+		return MergeResult{}, err
+	}
+	// Spins of either track now count for the artists of both.
+	if err := moveRollups(tx, trackRollup, fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := markRollupsStale(tx, `s.track_id=$1`, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := markSessionsStale(tx, `s.track_id=$1`, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if _, err := tx.Exec(ctx, inheritPrimary, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error updating primary project: %w", err)
+	}
+
+	keys, err := collect[uint64](tx, repointAliases, fromKey, intoKey)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing track aliases: %w", err)
+	}
+
+	var fromMBID *string
+	if err := tx.QueryRow(ctx, deleteTrack, fromKey).Scan(&fromMBID); err != nil {
+		return MergeResult{}, fmt.Errorf("error deleting merged track: %w", err)
+	}
+	if _, err := tx.Exec(ctx, inheritMBID, intoKey, fromMBID); err != nil {
+		return MergeResult{}, fmt.Errorf("error updating track mbid: %w", err)
+	}
+	if _, err := tx.Exec(ctx, insertAlias, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error inserting track alias: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return MergeResult{}, fmt.Errorf("error committing merge: %w", err)
+	}
+
+	return MergeResult{TrackIDs: append(keys, fromKey, intoKey), MBIDs: mbids(fromMBID)}, nil
+}
+
+func (pg *PGDB) MergeProjects(fromKey, intoKey uint64) (MergeResult, error) {
+	const selectTracks = `SELECT track_id FROM project_track WHERE project_id=$1`
+	const repointPrimary = `UPDATE track SET primary_project_id=$2 WHERE primary_project_id=$1`
+	const repointSpins = `UPDATE spin SET project_id=$2 WHERE project_id=$1`
+	const repointOverrides = `UPDATE user_primary_project SET project_id=$2 WHERE project_id=$1`
+	const repointEditions = `UPDATE project SET base_project_id=NULLIF($2, id), edition=CASE WHEN id=$2 THEN NULL ELSE edition END
+	WHERE base_project_id=$1`
+	const repointAliases = `UPDATE project_alias SET project_id=$2 WHERE project_id=$1 RETURNING id`
+	const deleteProject = `DELETE FROM project WHERE id=$1 RETURNING mbid::text`
+	const inheritMBID = `UPDATE project SET mbid=$2 WHERE id=$1 AND mbid IS NULL`
+	const insertAlias = `INSERT INTO project_alias (id, project_id) VALUES ($1, $2)`
+	const inheritArt = `UPDATE project SET art_hash=(SELECT art_hash FROM project WHERE id=$1) WHERE id=$2 AND art_hash IS NULL`
+
+	if fromKey == intoKey {
+		return MergeResult{}, fmt.Errorf("cannot merge project into itself")
+	}
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("error starting merge: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockMerged(tx, "project", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+
+	trackKeys, err := collect[uint64](tx, selectTracks, fromKey)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("error selecting project tracks: %w", err)
+	}
+
+	if err := moveJunction(tx, "project_track", "project_id", "track_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "artist_project", "project_id", "artist_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "project_tag", "project_id", "tag_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveJunction(tx, "project_rating", "project_id", "user_id", fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveChartWeeks(tx, userCharts, ProjectEntity, fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if err := moveChartWeeks(tx, groupCharts, ProjectEntity, fromKey, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if _, err := tx.Exec(ctx, repointPrimary, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing primary projects: %w", err)
+	}
+	if _, err := tx.Exec(ctx, repointSpins, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing spins: %w", err)
+	}
+	// Albums listened to in sessions depend on the projects spins are from.
+	if err := markSessionsStale(tx, `s.project_id=$1`, intoKey); err != nil {
+		return MergeResult{}, err
+	}
+	if _, err := tx.Exec(ctx, repointOverrides, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing primary project overrides: %w", err)
+	}
+	if _, err := tx.Exec(ctx, repointEditions, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing project editions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, inheritArt, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error updating project art: %w", err)
+	}
+
+	keys, err := collect[uint64](tx, repointAliases, fromKey, intoKey)
+	if err != nil {
+		return MergeResult{}, fmt.Errorf("error repointing project aliases: %w", err)
+	}
+
+	var fromMBID *string
+	if err := tx.QueryRow(ctx, deleteProject, fromKey).Scan(&fromMBID); err != nil {
+		return MergeResult{}, fmt.Errorf("error deleting merged project: %w", err)
+	}
+	if _, err := tx.Exec(ctx, inheritMBID, intoKey, fromMBID); err != nil {
+		return MergeResult{}, fmt.Errorf("error updating project mbid: %w", err)
+	}
+	if _, err := tx.Exec(ctx, insertAlias, fromKey, intoKey); err != nil {
+		return MergeResult{}, fmt.Errorf("error inserting project alias: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return MergeResult{}, fmt.Errorf("error committing merge: %w", err)
+	}
+
+	return MergeResult{
+		TrackIDs:   trackKeys,
+		ProjectIDs: append(keys, fromKey, intoKey),
+		MBIDs:      mbids(fromMBID),
+	}, nil
+}
+
+func (pg *PGDB) CreateArtistAlias(name string, artistID uint64) error {
+	const stmt = `INSERT INTO artist_alias (name, artist_id)
+	SELECT $1, id FROM artist WHERE id=$2
+	ON CONFLICT (name) DO UPDATE SET artist_id=EXCLUDED.artist_id`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, name, artistID)
+	if err != nil {
+		return fmt.Errorf("error inserting artist alias: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: artist %d", ErrNotFound, artistID)
+	}
+
+	return nil
+}
+
+// lockMerged locks the rows of the artists, tracks or projects in a table
+// being merged, so that neither is merged elsewhere at the same time.
+func lockMerged(tx pgx.Tx, table string, ids ...uint64) error {
+	stmt := `SELECT id FROM ` + table + ` WHERE id=$1 FOR UPDATE`
+
+	for _, id := range ids {
+		var locked uint64
+		err := tx.QueryRow(context.Background(), stmt, id).Scan(&locked)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %s %d", ErrNotFound, table, id)
+		}
+		if err != nil {
+			return fmt.Errorf("error locking %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// moveJunction repoints the rows of a junction table from one entity to
+// another, dropping rows the target is already linked by.
+func moveJunction(tx pgx.Tx, table, column, other string, fromID, intoID uint64) error {
+	deleteDuplicates := fmt.Sprintf(`DELETE FROM %[1]s WHERE %[2]s=$1 AND %[3]s IN (SELECT %[3]s FROM %[1]s WHERE %[2]s=$2)`, table, column, other)
+	repoint := fmt.Sprintf(`UPDATE %s SET %s=$2 WHERE %[2]s=$1`, table, column)
+
+	if _, err := tx.Exec(context.Background(), deleteDuplicates, fromID, intoID); err != nil {
+		return fmt.Errorf("error merging %s: %w", table, err)
+	}
+	if _, err := tx.Exec(context.Background(), repoint, fromID, intoID); err != nil {
+		return fmt.Errorf("error merging %s: %w", table, err)
+	}
+	return nil
+}
+
+// canonicalKeys maps each row selected by stmt to the hash its title and
+// current artist names produce, when that differs from its key.
+func canonicalKeys(tx pgx.Tx, stmt string, artistID uint64) (map[uint64]uint64, error) {
+	rows, err := tx.Query(context.Background(), stmt, artistID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting merged catalog: %w", err)
+	}
+	defer rows.Close()
+
+	keys := map[uint64]uint64{}
+	for rows.Next() {
+		var key uint64
+		var title string
+		var names []string
+		if err := rows.Scan(&key, &title, &names); err != nil {
+			return nil, fmt.Errorf("error selecting merged catalog: %w", err)
+		}
+		if hash := CreateHash(title, names); hash != key {
+			keys[key] = hash
+		}
+	}
+	return keys, rows.Err()
+}
+
+func mbids(mbid *string) []string {
+	if mbid == nil {
+		return nil
+	}
+	return []string{*mbid}
+}
+
+func collect[T any](tx pgx.Tx, stmt string, args ...any) ([]T, error) {
+	rows, err := tx.Query(context.Background(), stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[T])
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS project_alias;
+DROP TABLE IF EXISTS track_alias;
+DROP TABLE IF EXISTS artist_alias;
+ALTER TABLE "user" DROP COLUMN IF EXISTS admin;
@@ -0,0 +1,20 @@
+ALTER TABLE "user"
+ADD COLUMN admin BOOLEAN NOT NULL DEFAULT false;
+CREATE TABLE artist_alias (
+    name VARCHAR PRIMARY KEY,
+    artist_id BIGINT NOT NULL
+);
+CREATE TABLE track_alias (
+    id BIGINT PRIMARY KEY,
+    track_id BIGINT NOT NULL
+);
+CREATE TABLE project_alias (
+    id BIGINT PRIMARY KEY,
+    project_id BIGINT NOT NULL
+);
+ALTER TABLE artist_alias
+ADD FOREIGN KEY (artist_id) REFERENCES artist (id);
+ALTER TABLE track_alias
+ADD FOREIGN KEY (track_id) REFERENCES track (id);
+ALTER TABLE project_alias
+ADD FOREIGN KEY (project_id) REFERENCES project (id);
@@ -0,0 +1,6 @@
+ALTER TABLE project DROP COLUMN IF EXISTS mbid;
+ALTER TABLE track DROP COLUMN IF EXISTS mbid;
+ALTER TABLE artist DROP COLUMN IF EXISTS mbid;
+DROP INDEX IF EXISTS artist_name_idx;
+ALTER TABLE artist
+ADD CONSTRAINT artist_name_key UNIQUE (name);
@@ -0,0 +1,8 @@
+ALTER TABLE artist DROP CONSTRAINT IF EXISTS artist_name_key;
+CREATE INDEX artist_name_idx ON artist (name);
+ALTER TABLE artist
+ADD COLUMN mbid UUID UNIQUE;
+ALTER TABLE track
+ADD COLUMN mbid UUID UNIQUE;
+ALTER TABLE project
+ADD COLUMN mbid UUID UNIQUE;
@@ -0,0 +1,6 @@
+ALTER TABLE project_track DROP COLUMN IF EXISTS track_number;
+ALTER TABLE project DROP COLUMN IF EXISTS enriched_at;
+ALTER TABLE project DROP COLUMN IF EXISTS label;
+ALTER TABLE track DROP COLUMN IF EXISTS enriched_at;
+ALTER TABLE track DROP COLUMN IF EXISTS isrc;
+ALTER TABLE track DROP COLUMN IF EXISTS duration_ms;
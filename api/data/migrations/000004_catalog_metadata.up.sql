@@ -0,0 +1,12 @@
+ALTER TABLE track
+ADD COLUMN duration_ms INTEGER;
+ALTER TABLE track
+ADD COLUMN isrc VARCHAR;
+ALTER TABLE track
+ADD COLUMN enriched_at TIMESTAMP;
+ALTER TABLE project
+ADD COLUMN label VARCHAR;
+ALTER TABLE project
+ADD COLUMN enriched_at TIMESTAMP;
+ALTER TABLE project_track
+ADD COLUMN track_number INTEGER;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS spin_user_time_idx;
+ALTER TABLE spin DROP COLUMN IF EXISTS ms_played;
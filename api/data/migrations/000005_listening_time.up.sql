@@ -0,0 +1,3 @@
+ALTER TABLE spin
+ADD COLUMN ms_played INTEGER;
+CREATE INDEX spin_user_time_idx ON spin (user_id, time);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS track_tag;
+DROP TABLE IF EXISTS project_tag;
+DROP TABLE IF EXISTS artist_tag;
+DROP TABLE IF EXISTS tag;
@@ -0,0 +1,36 @@
+CREATE TABLE tag (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    user_id BIGINT
+);
+CREATE UNIQUE INDEX tag_global_name_idx ON tag (name) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX tag_user_name_idx ON tag (user_id, name) WHERE user_id IS NOT NULL;
+CREATE TABLE artist_tag (
+    tag_id BIGINT,
+    artist_id BIGINT,
+    PRIMARY KEY (tag_id, artist_id)
+);
+CREATE TABLE project_tag (
+    tag_id BIGINT,
+    project_id BIGINT,
+    PRIMARY KEY (tag_id, project_id)
+);
+CREATE TABLE track_tag (
+    tag_id BIGINT,
+    track_id BIGINT,
+    PRIMARY KEY (tag_id, track_id)
+);
+ALTER TABLE tag
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id);
+ALTER TABLE artist_tag
+ADD FOREIGN KEY (tag_id) REFERENCES tag (id) ON DELETE CASCADE;
+ALTER TABLE artist_tag
+ADD FOREIGN KEY (artist_id) REFERENCES artist (id) ON DELETE CASCADE;
+ALTER TABLE project_tag
+ADD FOREIGN KEY (tag_id) REFERENCES tag (id) ON DELETE CASCADE;
+ALTER TABLE project_tag
+ADD FOREIGN KEY (project_id) REFERENCES project (id) ON DELETE CASCADE;
+ALTER TABLE track_tag
+ADD FOREIGN KEY (tag_id) REFERENCES tag (id) ON DELETE CASCADE;
+ALTER TABLE track_tag
+ADD FOREIGN KEY (track_id) REFERENCES track (id) ON DELETE CASCADE;
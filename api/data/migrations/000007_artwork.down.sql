@@ -0,0 +1,4 @@
+ALTER TABLE project DROP COLUMN IF EXISTS art_fetched_at;
+ALTER TABLE project DROP COLUMN IF EXISTS art_url;
+ALTER TABLE project DROP COLUMN IF EXISTS art_hash;
+ALTER TABLE artist DROP COLUMN IF EXISTS art_hash;
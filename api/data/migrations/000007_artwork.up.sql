@@ -0,0 +1,8 @@
+ALTER TABLE artist
+ADD COLUMN art_hash VARCHAR;
+ALTER TABLE project
+ADD COLUMN art_hash VARCHAR;
+ALTER TABLE project
+ADD COLUMN art_url VARCHAR;
+ALTER TABLE project
+ADD COLUMN art_fetched_at TIMESTAMP;
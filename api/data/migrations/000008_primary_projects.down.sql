@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_primary_project;
+DROP INDEX IF EXISTS spin_track_project_idx;
+ALTER TABLE spin DROP COLUMN IF EXISTS project_id;
+ALTER TABLE track DROP COLUMN IF EXISTS primary_pinned;
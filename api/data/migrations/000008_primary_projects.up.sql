@@ -0,0 +1,23 @@
+ALTER TABLE track
+ADD COLUMN primary_pinned BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE spin
+ADD COLUMN project_id BIGINT;
+ALTER TABLE spin
+ADD FOREIGN KEY (project_id) REFERENCES project (id);
+UPDATE spin s
+SET project_id = t.primary_project_id
+FROM track t
+WHERE s.track_id = t.id;
+CREATE INDEX spin_track_project_idx ON spin (track_id, project_id);
+CREATE TABLE user_primary_project (
+    user_id BIGINT,
+    track_id BIGINT,
+    project_id BIGINT NOT NULL,
+    PRIMARY KEY (user_id, track_id)
+);
+ALTER TABLE user_primary_project
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE user_primary_project
+ADD FOREIGN KEY (track_id) REFERENCES track (id) ON DELETE CASCADE;
+ALTER TABLE user_primary_project
+ADD FOREIGN KEY (project_id) REFERENCES project (id) ON DELETE CASCADE;
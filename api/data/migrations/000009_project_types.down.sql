@@ -0,0 +1,4 @@
+ALTER TABLE project DROP COLUMN IF EXISTS base_project_id;
+ALTER TABLE project DROP COLUMN IF EXISTS edition;
+ALTER TABLE project DROP COLUMN IF EXISTS release_precision;
+ALTER TABLE project DROP CONSTRAINT IF EXISTS project_form_check;
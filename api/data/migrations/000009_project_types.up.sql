@@ -0,0 +1,41 @@
+UPDATE project
+SET form = lower(trim(form));
+UPDATE project
+SET form = 'album'
+WHERE form NOT IN (
+        'album',
+        'ep',
+        'single',
+        'compilation',
+        'live',
+        'soundtrack',
+        'mixtape',
+        'remix',
+        'dj-mix'
+    );
+ALTER TABLE project
+ADD CONSTRAINT project_form_check CHECK (
+        form IN (
+            'album',
+            'ep',
+            'single',
+            'compilation',
+            'live',
+            'soundtrack',
+            'mixtape',
+            'remix',
+            'dj-mix'
+        )
+    );
+ALTER TABLE project
+ADD COLUMN release_precision VARCHAR NOT NULL DEFAULT 'day';
+ALTER TABLE project
+ADD CONSTRAINT project_release_precision_check CHECK (release_precision IN ('year', 'month', 'day'));
+ALTER TABLE project
+ADD COLUMN edition VARCHAR;
+ALTER TABLE project
+ADD CONSTRAINT project_edition_check CHECK (edition IN ('deluxe', 'reissue', 'remaster'));
+ALTER TABLE project
+ADD COLUMN base_project_id BIGINT;
+ALTER TABLE project
+ADD FOREIGN KEY (base_project_id) REFERENCES project (id);
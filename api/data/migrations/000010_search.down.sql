@@ -0,0 +1,21 @@
+DROP TRIGGER IF EXISTS artist_search_text_trigger ON artist;
+DROP TRIGGER IF EXISTS artist_track_search_text_trigger ON artist_track;
+DROP TRIGGER IF EXISTS artist_project_search_text_trigger ON artist_project;
+DROP TRIGGER IF EXISTS track_search_text_trigger ON track;
+DROP TRIGGER IF EXISTS project_search_text_trigger ON project;
+DROP FUNCTION IF EXISTS refresh_artist_search_text;
+DROP FUNCTION IF EXISTS refresh_track_search_text;
+DROP FUNCTION IF EXISTS refresh_project_search_text;
+DROP FUNCTION IF EXISTS set_track_search_text;
+DROP FUNCTION IF EXISTS set_project_search_text;
+DROP FUNCTION IF EXISTS track_search_text;
+DROP FUNCTION IF EXISTS project_search_text;
+ALTER TABLE track DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE track DROP COLUMN IF EXISTS search_text;
+ALTER TABLE project DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE project DROP COLUMN IF EXISTS search_text;
+ALTER TABLE artist DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE artist DROP COLUMN IF EXISTS search_text;
+DROP FUNCTION IF EXISTS search_normalize;
+DROP EXTENSION IF EXISTS unaccent;
+DROP EXTENSION IF EXISTS pg_trgm;
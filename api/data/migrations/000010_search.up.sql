@@ -0,0 +1,126 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+-- unaccent is only stable because its rules could change, so generated
+-- columns and indexes use this immutable wrapper.
+CREATE OR REPLACE FUNCTION search_normalize(value TEXT) RETURNS TEXT AS $$
+SELECT lower(public.unaccent('public.unaccent'::regdictionary, value)) $$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+ALTER TABLE artist
+ADD COLUMN search_text TEXT GENERATED ALWAYS AS (search_normalize(name)) STORED;
+ALTER TABLE artist
+ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', search_normalize(name))) STORED;
+-- Projects and tracks are searched by their title and artist names, which
+-- triggers keep up to date as artists are linked, merged or renamed.
+ALTER TABLE project
+ADD COLUMN search_text TEXT NOT NULL DEFAULT '';
+ALTER TABLE project
+ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', search_text)) STORED;
+ALTER TABLE track
+ADD COLUMN search_text TEXT NOT NULL DEFAULT '';
+ALTER TABLE track
+ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', search_text)) STORED;
+CREATE OR REPLACE FUNCTION project_search_text(project_id BIGINT, title TEXT) RETURNS TEXT AS $$
+SELECT search_normalize(
+        concat_ws(
+            ' ',
+            title,
+            (
+                SELECT string_agg(DISTINCT a.name, ' ')
+                FROM artist a
+                    JOIN artist_project ap ON a.id = ap.artist_id
+                WHERE ap.project_id = project_search_text.project_id
+            )
+        )
+    ) $$ LANGUAGE sql STABLE;
+CREATE OR REPLACE FUNCTION track_search_text(track_id BIGINT, title TEXT) RETURNS TEXT AS $$
+SELECT search_normalize(
+        concat_ws(
+            ' ',
+            title,
+            (
+                SELECT string_agg(DISTINCT a.name, ' ')
+                FROM artist a
+                    JOIN artist_track at ON a.id = at.artist_id
+                WHERE at.track_id = track_search_text.track_id
+            )
+        )
+    ) $$ LANGUAGE sql STABLE;
+CREATE OR REPLACE FUNCTION set_project_search_text() RETURNS TRIGGER AS $$ BEGIN NEW.search_text := project_search_text(NEW.id, NEW.title);
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+CREATE OR REPLACE FUNCTION set_track_search_text() RETURNS TRIGGER AS $$ BEGIN NEW.search_text := track_search_text(NEW.id, NEW.title);
+RETURN NEW;
+END $$ LANGUAGE plpgsql;
+CREATE OR REPLACE FUNCTION refresh_project_search_text() RETURNS TRIGGER AS $$ BEGIN IF TG_OP <> 'INSERT' THEN
+UPDATE project
+SET search_text = project_search_text(id, title)
+WHERE id = OLD.project_id;
+END IF;
+IF TG_OP <> 'DELETE' THEN
+UPDATE project
+SET search_text = project_search_text(id, title)
+WHERE id = NEW.project_id;
+END IF;
+RETURN NULL;
+END $$ LANGUAGE plpgsql;
+CREATE OR REPLACE FUNCTION refresh_track_search_text() RETURNS TRIGGER AS $$ BEGIN IF TG_OP <> 'INSERT' THEN
+UPDATE track
+SET search_text = track_search_text(id, title)
+WHERE id = OLD.track_id;
+END IF;
+IF TG_OP <> 'DELETE' THEN
+UPDATE track
+SET search_text = track_search_text(id, title)
+WHERE id = NEW.track_id;
+END IF;
+RETURN NULL;
+END $$ LANGUAGE plpgsql;
+CREATE OR REPLACE FUNCTION refresh_artist_search_text() RETURNS TRIGGER AS $$ BEGIN
+UPDATE project
+SET search_text = project_search_text(id, title)
+WHERE id IN (
+        SELECT project_id
+        FROM artist_project
+        WHERE artist_id = NEW.id
+    );
+UPDATE track
+SET search_text = track_search_text(id, title)
+WHERE id IN (
+        SELECT track_id
+        FROM artist_track
+        WHERE artist_id = NEW.id
+    );
+RETURN NULL;
+END $$ LANGUAGE plpgsql;
+CREATE TRIGGER project_search_text_trigger BEFORE
+INSERT
+    OR
+UPDATE OF title ON project FOR EACH ROW EXECUTE FUNCTION set_project_search_text();
+CREATE TRIGGER track_search_text_trigger BEFORE
+INSERT
+    OR
+UPDATE OF title ON track FOR EACH ROW EXECUTE FUNCTION set_track_search_text();
+CREATE TRIGGER artist_project_search_text_trigger
+AFTER
+INSERT
+    OR
+UPDATE
+    OR DELETE ON artist_project FOR EACH ROW EXECUTE FUNCTION refresh_project_search_text();
+CREATE TRIGGER artist_track_search_text_trigger
+AFTER
+INSERT
+    OR
+UPDATE
+    OR DELETE ON artist_track FOR EACH ROW EXECUTE FUNCTION refresh_track_search_text();
+CREATE TRIGGER artist_search_text_trigger
+AFTER
+UPDATE OF name ON artist FOR EACH ROW EXECUTE FUNCTION refresh_artist_search_text();
+UPDATE project
+SET search_text = project_search_text(id, title);
+UPDATE track
+SET search_text = track_search_text(id, title);
+CREATE INDEX artist_search_vector_idx ON artist USING GIN (search_vector);
+CREATE INDEX artist_search_text_idx ON artist USING GIN (search_text gin_trgm_ops);
+CREATE INDEX project_search_vector_idx ON project USING GIN (search_vector);
+CREATE INDEX project_search_text_idx ON project USING GIN (search_text gin_trgm_ops);
+CREATE INDEX track_search_vector_idx ON track USING GIN (search_vector);
+CREATE INDEX track_search_text_idx ON track USING GIN (search_text gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_streak;
+DROP TABLE IF EXISTS milestone;
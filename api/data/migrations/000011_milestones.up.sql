@@ -0,0 +1,23 @@
+CREATE TABLE milestone (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    kind VARCHAR NOT NULL,
+    entity_id BIGINT NOT NULL DEFAULT 0,
+    value BIGINT NOT NULL,
+    spin_id BIGINT,
+    achieved_at TIMESTAMP NOT NULL,
+    UNIQUE (user_id, kind, entity_id, value)
+);
+ALTER TABLE milestone
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE milestone
+ADD FOREIGN KEY (spin_id) REFERENCES spin (id) ON DELETE
+SET NULL;
+CREATE TABLE user_streak (
+    user_id BIGINT PRIMARY KEY,
+    current BIGINT NOT NULL,
+    longest BIGINT NOT NULL,
+    last_day DATE NOT NULL
+);
+ALTER TABLE user_streak
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
@@ -0,0 +1,10 @@
+CREATE TABLE wrapped (
+    user_id BIGINT NOT NULL,
+    year INT NOT NULL,
+    spins BIGINT NOT NULL,
+    report JSONB NOT NULL,
+    generated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, year)
+);
+ALTER TABLE wrapped
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
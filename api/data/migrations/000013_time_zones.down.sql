@@ -0,0 +1,3 @@
+ALTER TABLE "user" DROP COLUMN IF EXISTS time_zone;
+ALTER TABLE spin
+ALTER COLUMN time TYPE TIMESTAMP USING time AT TIME ZONE 'UTC';
@@ -0,0 +1,4 @@
+ALTER TABLE spin
+ALTER COLUMN time TYPE TIMESTAMPTZ USING time AT TIME ZONE 'UTC';
+ALTER TABLE "user"
+ADD COLUMN time_zone VARCHAR NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS rollup_stale;
+DROP TABLE IF EXISTS user_artist_day;
+DROP TABLE IF EXISTS user_track_day;
@@ -0,0 +1,59 @@
+CREATE TABLE user_track_day (
+    user_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    track_id BIGINT NOT NULL,
+    spins BIGINT NOT NULL,
+    ms_played BIGINT NOT NULL,
+    PRIMARY KEY (user_id, day, track_id)
+);
+ALTER TABLE user_track_day
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE user_track_day
+ADD FOREIGN KEY (track_id) REFERENCES track (id) ON DELETE CASCADE;
+CREATE INDEX user_track_day_track_idx ON user_track_day (track_id);
+CREATE TABLE user_artist_day (
+    user_id BIGINT NOT NULL,
+    day DATE NOT NULL,
+    artist_id BIGINT NOT NULL,
+    spins BIGINT NOT NULL,
+    ms_played BIGINT NOT NULL,
+    PRIMARY KEY (user_id, day, artist_id)
+);
+ALTER TABLE user_artist_day
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE user_artist_day
+ADD FOREIGN KEY (artist_id) REFERENCES artist (id) ON DELETE CASCADE;
+CREATE INDEX user_artist_day_artist_idx ON user_artist_day (artist_id);
+CREATE TABLE rollup_stale (
+    user_id BIGINT PRIMARY KEY,
+    marked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE rollup_stale
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+INSERT INTO user_track_day (user_id, day, track_id, spins, ms_played)
+SELECT s.user_id,
+    (s.time AT TIME ZONE u.time_zone)::date AS day,
+    s.track_id,
+    count(*),
+    sum(COALESCE(s.ms_played, t.duration_ms, 0))
+FROM spin s
+    JOIN track t ON s.track_id = t.id
+    JOIN "user" u ON s.user_id = u.id
+GROUP BY s.user_id,
+    day,
+    s.track_id;
+INSERT INTO user_artist_day (user_id, day, artist_id, spins, ms_played)
+SELECT r.user_id,
+    r.day,
+    at.artist_id,
+    sum(r.spins),
+    sum(r.ms_played)
+FROM user_track_day r
+    JOIN (
+        SELECT DISTINCT artist_id,
+            track_id
+        FROM artist_track
+    ) at ON r.track_id = at.track_id
+GROUP BY r.user_id,
+    r.day,
+    at.artist_id;
@@ -0,0 +1,7 @@
+ALTER TABLE "user" DROP COLUMN IF EXISTS hide_history,
+    DROP COLUMN IF EXISTS hide_now_playing,
+    DROP COLUMN IF EXISTS privacy,
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS bio,
+    DROP COLUMN IF EXISTS display_name;
@@ -0,0 +1,8 @@
+ALTER TABLE "user"
+ADD COLUMN display_name VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN bio VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN avatar_url VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN country VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN privacy VARCHAR NOT NULL DEFAULT 'private' CHECK (privacy IN ('public', 'followers', 'private')),
+    ADD COLUMN hide_now_playing BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN hide_history BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS milestone_user_achieved_idx;
+DROP TABLE IF EXISTS follow;
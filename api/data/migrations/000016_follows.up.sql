@@ -0,0 +1,14 @@
+CREATE TABLE follow (
+    follower_id BIGINT NOT NULL,
+    followee_id BIGINT NOT NULL,
+    accepted BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+ALTER TABLE follow
+ADD FOREIGN KEY (follower_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE follow
+ADD FOREIGN KEY (followee_id) REFERENCES "user" (id) ON DELETE CASCADE;
+CREATE INDEX follow_followee_idx ON follow (followee_id, accepted, created_at);
+CREATE INDEX milestone_user_achieved_idx ON milestone (user_id, achieved_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS group_chart_week;
+DROP TABLE IF EXISTS group_chart_snapshot;
+DROP TABLE IF EXISTS group_invite;
+DROP TABLE IF EXISTS group_member;
+DROP TABLE IF EXISTS user_group;
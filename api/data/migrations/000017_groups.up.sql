@@ -0,0 +1,56 @@
+CREATE TABLE user_group (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    description VARCHAR NOT NULL DEFAULT '',
+    open BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE group_member (
+    group_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role VARCHAR NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (group_id, user_id)
+);
+ALTER TABLE group_member
+ADD FOREIGN KEY (group_id) REFERENCES user_group (id) ON DELETE CASCADE;
+ALTER TABLE group_member
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+CREATE INDEX group_member_user_idx ON group_member (user_id);
+CREATE UNIQUE INDEX group_member_owner_idx ON group_member (group_id)
+WHERE role = 'owner';
+CREATE TABLE group_invite (
+    group_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    invited_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (group_id, user_id)
+);
+ALTER TABLE group_invite
+ADD FOREIGN KEY (group_id) REFERENCES user_group (id) ON DELETE CASCADE;
+ALTER TABLE group_invite
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE group_invite
+ADD FOREIGN KEY (invited_by) REFERENCES "user" (id) ON DELETE
+SET NULL;
+CREATE INDEX group_invite_user_idx ON group_invite (user_id);
+CREATE TABLE group_chart_snapshot (
+    group_id BIGINT NOT NULL,
+    week DATE NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (group_id, week)
+);
+ALTER TABLE group_chart_snapshot
+ADD FOREIGN KEY (group_id) REFERENCES user_group (id) ON DELETE CASCADE;
+CREATE TABLE group_chart_week (
+    group_id BIGINT NOT NULL,
+    week DATE NOT NULL,
+    entity VARCHAR NOT NULL,
+    entity_id BIGINT NOT NULL,
+    rank INTEGER NOT NULL,
+    spins BIGINT NOT NULL,
+    ms_played BIGINT NOT NULL,
+    PRIMARY KEY (group_id, week, entity, entity_id)
+);
+ALTER TABLE group_chart_week
+ADD FOREIGN KEY (group_id, week) REFERENCES group_chart_snapshot (group_id, week) ON DELETE CASCADE;
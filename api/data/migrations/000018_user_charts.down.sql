@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_chart_week;
+DROP TABLE IF EXISTS user_chart_snapshot;
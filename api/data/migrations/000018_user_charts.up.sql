@@ -0,0 +1,23 @@
+CREATE TABLE user_chart_snapshot (
+    user_id BIGINT NOT NULL,
+    week DATE NOT NULL,
+    taken_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, week)
+);
+ALTER TABLE user_chart_snapshot
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+CREATE TABLE user_chart_week (
+    user_id BIGINT NOT NULL,
+    week DATE NOT NULL,
+    entity VARCHAR NOT NULL,
+    entity_id BIGINT NOT NULL,
+    rank INTEGER NOT NULL,
+    spins BIGINT NOT NULL,
+    ms_played BIGINT NOT NULL,
+    PRIMARY KEY (user_id, week, entity, entity_id)
+);
+ALTER TABLE user_chart_week
+ADD FOREIGN KEY (user_id, week) REFERENCES user_chart_snapshot (user_id, week) ON DELETE CASCADE;
+CREATE INDEX user_chart_week_entity_idx ON user_chart_week (user_id, entity, entity_id, week);
+CREATE INDEX user_chart_week_number_one_idx ON user_chart_week (user_id, entity, week)
+WHERE rank = 1;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS item_neighbor_build;
+DROP TABLE IF EXISTS item_neighbor;
@@ -0,0 +1,11 @@
+CREATE TABLE item_neighbor (
+    entity VARCHAR NOT NULL,
+    item_id BIGINT NOT NULL,
+    neighbor_id BIGINT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (entity, item_id, neighbor_id)
+);
+CREATE TABLE item_neighbor_build (
+    entity VARCHAR PRIMARY KEY,
+    built_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS session_build;
+DROP TABLE IF EXISTS listening_session;
@@ -0,0 +1,22 @@
+CREATE TABLE listening_session (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ NOT NULL,
+    spins BIGINT NOT NULL,
+    ms_played BIGINT NOT NULL,
+    album_id BIGINT
+);
+ALTER TABLE listening_session
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE listening_session
+ADD FOREIGN KEY (album_id) REFERENCES project (id) ON DELETE SET NULL;
+CREATE INDEX listening_session_user_idx ON listening_session (user_id, started_at);
+CREATE TABLE session_build (
+    user_id BIGINT PRIMARY KEY,
+    gap_ms BIGINT NOT NULL,
+    last_spin_id BIGINT NOT NULL,
+    stale_from TIMESTAMPTZ
+);
+ALTER TABLE session_build
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
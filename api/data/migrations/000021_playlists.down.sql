@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS playlist_track;
+DROP TABLE IF EXISTS playlist;
@@ -0,0 +1,23 @@
+CREATE TABLE playlist (
+    id SERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT false,
+    rule JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER TABLE playlist
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+CREATE INDEX playlist_user_idx ON playlist (user_id);
+CREATE TABLE playlist_track (
+    playlist_id BIGINT NOT NULL,
+    track_id BIGINT NOT NULL,
+    position INTEGER NOT NULL,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (playlist_id, track_id)
+);
+ALTER TABLE playlist_track
+ADD FOREIGN KEY (playlist_id) REFERENCES playlist (id) ON DELETE CASCADE;
+ALTER TABLE playlist_track
+ADD FOREIGN KEY (track_id) REFERENCES track (id) ON DELETE CASCADE;
+CREATE INDEX playlist_track_track_idx ON playlist_track (track_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS project_rating;
+DROP TABLE IF EXISTS track_rating;
+DROP TABLE IF EXISTS artist_rating;
@@ -0,0 +1,40 @@
+CREATE TABLE artist_rating (
+    user_id BIGINT NOT NULL,
+    artist_id BIGINT NOT NULL,
+    half_stars SMALLINT CHECK (half_stars BETWEEN 1 AND 10),
+    favorite BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, artist_id)
+);
+ALTER TABLE artist_rating
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE artist_rating
+ADD FOREIGN KEY (artist_id) REFERENCES artist (id) ON DELETE CASCADE;
+CREATE INDEX artist_rating_artist_idx ON artist_rating (artist_id);
+CREATE TABLE track_rating (
+    user_id BIGINT NOT NULL,
+    track_id BIGINT NOT NULL,
+    half_stars SMALLINT CHECK (half_stars BETWEEN 1 AND 10),
+    favorite BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, track_id)
+);
+ALTER TABLE track_rating
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE track_rating
+ADD FOREIGN KEY (track_id) REFERENCES track (id) ON DELETE CASCADE;
+CREATE INDEX track_rating_track_idx ON track_rating (track_id);
+CREATE TABLE project_rating (
+    user_id BIGINT NOT NULL,
+    project_id BIGINT NOT NULL,
+    half_stars SMALLINT CHECK (half_stars BETWEEN 1 AND 10),
+    favorite BOOLEAN NOT NULL DEFAULT false,
+    note VARCHAR,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, project_id)
+);
+ALTER TABLE project_rating
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+ALTER TABLE project_rating
+ADD FOREIGN KEY (project_id) REFERENCES project (id) ON DELETE CASCADE;
+CREATE INDEX project_rating_project_idx ON project_rating (project_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS user_artist_day_user_artist_idx;
+DROP INDEX IF EXISTS user_track_day_user_track_idx;
+DROP TABLE IF EXISTS user_spin_total;
@@ -0,0 +1,13 @@
+CREATE TABLE user_spin_total (
+    user_id BIGINT PRIMARY KEY,
+    spins BIGINT NOT NULL
+);
+ALTER TABLE user_spin_total
+ADD FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE;
+INSERT INTO user_spin_total (user_id, spins)
+SELECT user_id,
+    count(*)
+FROM spin
+GROUP BY user_id;
+CREATE INDEX user_track_day_user_track_idx ON user_track_day (user_id, track_id);
+CREATE INDEX user_artist_day_user_artist_idx ON user_artist_day (user_id, artist_id);
@@ -0,0 +1,4 @@
+ALTER TABLE project DROP COLUMN IF EXISTS enrich_retry_at;
+ALTER TABLE project DROP COLUMN IF EXISTS enrich_attempts;
+ALTER TABLE track DROP COLUMN IF EXISTS enrich_retry_at;
+ALTER TABLE track DROP COLUMN IF EXISTS enrich_attempts;
@@ -0,0 +1,8 @@
+ALTER TABLE track
+ADD COLUMN enrich_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE track
+ADD COLUMN enrich_retry_at TIMESTAMPTZ;
+ALTER TABLE project
+ADD COLUMN enrich_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE project
+ADD COLUMN enrich_retry_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS spin_project_idx;
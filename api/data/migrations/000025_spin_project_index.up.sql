@@ -0,0 +1 @@
+CREATE INDEX spin_project_idx ON spin (project_id);
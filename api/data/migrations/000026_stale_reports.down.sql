@@ -0,0 +1,3 @@
+ALTER TABLE wrapped DROP COLUMN IF EXISTS stale;
+DROP INDEX IF EXISTS user_chart_snapshot_stale_idx;
+ALTER TABLE user_chart_snapshot DROP COLUMN IF EXISTS stale;
@@ -0,0 +1,6 @@
+ALTER TABLE user_chart_snapshot
+ADD COLUMN stale BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX user_chart_snapshot_stale_idx ON user_chart_snapshot (user_id, week)
+WHERE stale;
+ALTER TABLE wrapped
+ADD COLUMN stale BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE project_track DROP CONSTRAINT IF EXISTS project_track_key;
@@ -0,0 +1,11 @@
+DELETE FROM project_track pt
+WHERE pt.ctid <> (
+        SELECT d.ctid
+        FROM project_track d
+        WHERE d.project_id = pt.project_id
+            AND d.track_id = pt.track_id
+        ORDER BY d.track_number NULLS LAST
+        LIMIT 1
+    );
+ALTER TABLE project_track
+ADD CONSTRAINT project_track_key UNIQUE (project_id, track_id);
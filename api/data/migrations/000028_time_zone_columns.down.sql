@@ -0,0 +1,10 @@
+ALTER TABLE project
+ALTER COLUMN art_fetched_at TYPE TIMESTAMP USING art_fetched_at AT TIME ZONE 'UTC';
+ALTER TABLE project
+ALTER COLUMN enriched_at TYPE TIMESTAMP USING enriched_at AT TIME ZONE 'UTC';
+ALTER TABLE track
+ALTER COLUMN enriched_at TYPE TIMESTAMP USING enriched_at AT TIME ZONE 'UTC';
+ALTER TABLE wrapped
+ALTER COLUMN generated_at TYPE TIMESTAMP USING generated_at AT TIME ZONE 'UTC';
+ALTER TABLE milestone
+ALTER COLUMN achieved_at TYPE TIMESTAMP USING achieved_at AT TIME ZONE 'UTC';
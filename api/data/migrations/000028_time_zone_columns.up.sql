@@ -0,0 +1,10 @@
+ALTER TABLE milestone
+ALTER COLUMN achieved_at TYPE TIMESTAMPTZ USING achieved_at AT TIME ZONE 'UTC';
+ALTER TABLE wrapped
+ALTER COLUMN generated_at TYPE TIMESTAMPTZ USING generated_at AT TIME ZONE 'UTC';
+ALTER TABLE track
+ALTER COLUMN enriched_at TYPE TIMESTAMPTZ USING enriched_at AT TIME ZONE 'UTC';
+ALTER TABLE project
+ALTER COLUMN enriched_at TYPE TIMESTAMPTZ USING enriched_at AT TIME ZONE 'UTC';
+ALTER TABLE project
+ALTER COLUMN art_fetched_at TYPE TIMESTAMPTZ USING art_fetched_at AT TIME ZONE 'UTC';
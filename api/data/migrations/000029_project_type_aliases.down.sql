@@ -0,0 +1 @@
+-- Types mapped from their aliases are valid types and stay as they are.
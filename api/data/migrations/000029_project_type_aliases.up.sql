@@ -0,0 +1,8 @@
+-- Project types stored under an alias map to the type they stand for, as
+-- new projects do, instead of to album.
+UPDATE project
+SET form = CASE
+        WHEN lower(trim(form)) IN ('dj mix', 'djmix') THEN 'dj-mix'
+        WHEN lower(trim(form)) IN ('street', 'mixtape/street') THEN 'mixtape'
+    END
+WHERE lower(trim(form)) IN ('dj mix', 'djmix', 'street', 'mixtape/street');
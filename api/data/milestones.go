@@ -0,0 +1,162 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type MilestoneKind string
+
+const (
+	// SpinsMilestone counts all spins of a user.
+	SpinsMilestone MilestoneKind = "spins"
+	// TrackSpinsMilestone counts the spins of one track.
+	TrackSpinsMilestone MilestoneKind = "track-spins"
+	// ArtistSpinsMilestone counts the spins of one artist's tracks, so a
+	// threshold of 1 is the first listen of an artist.
+	ArtistSpinsMilestone MilestoneKind = "artist-spins"
+	// StreakMilestone counts consecutive days with at least one spin.
+	StreakMilestone MilestoneKind = "streak"
+)
+
+func (k MilestoneKind) IsValid() bool {
+	return k == SpinsMilestone || k == TrackSpinsMilestone || k == ArtistSpinsMilestone || k == StreakMilestone
+}
+
+// Milestone is an achievement a user reached with a spin. EntityID is the
+// track or artist of per-entity kinds and 0 otherwise; Title is its title or
+// name.
+type Milestone struct {
+	ID         uint64
+	UserID     uint64
+	Kind       MilestoneKind
+	EntityID   uint64
+	Title      string
+	Value      uint64
+	SpinID     uint64
+	AchievedAt time.Time
+}
+
+// Streak counts the consecutive days, in their time zone, a user listened to
+// something.
+type Streak struct {
+	Current uint64
+	Longest uint64
+	LastDay time.Time
+}
+
+// Extend adds the day of a spin, in the location of its time, to the streak
+// and reports whether the streak changed. Days before the last one, such as
+// imported history, leave it as is.
+func (s Streak) Extend(t time.Time) (Streak, bool) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case s.LastDay.IsZero() || day.After(s.LastDay.AddDate(0, 0, 1)):
+		s.Current = 1
+	case day.Equal(s.LastDay.AddDate(0, 0, 1)):
+		s.Current++
+	default:
+		return s, false
+	}
+	s.LastDay = day
+	s.Longest = max(s.Longest, s.Current)
+	return s, true
+}
+
+// MilestoneProgress holds a user's counters right after a spin. Streak is 0
+// when the spin did not extend the user's streak.
+type MilestoneProgress struct {
+	Spins       uint64
+	TrackSpins  uint64
+	ArtistSpins map[uint64]uint64
+	Streak      uint64
+}
+
+type MilestoneSummary struct {
+	Streak     Streak
+	Milestones []Milestone
+}
+
+// Achievement is reached by the spin that takes a counter of its kind to or
+// past its threshold.
+type Achievement struct {
+	Kind      MilestoneKind
+	Threshold uint64
+}
+
+type Achievements []Achievement
+
+var DefaultAchievements = Achievements{
+	{SpinsMilestone, 1},
+	{SpinsMilestone, 100},
+	{SpinsMilestone, 1000},
+	{SpinsMilestone, 10000},
+	{SpinsMilestone, 100000},
+	{TrackSpinsMilestone, 100},
+	{TrackSpinsMilestone, 1000},
+	{ArtistSpinsMilestone, 1},
+	{ArtistSpinsMilestone, 1000},
+	{StreakMilestone, 7},
+	{StreakMilestone, 30},
+	{StreakMilestone, 100},
+	{StreakMilestone, 365},
+}
+
+// ParseAchievements builds achievements from a comma separated list of kinds
+// and thresholds, e.g. "spins:1000,track-spins:100,artist-spins:1,streak:7".
+func ParseAchievements(s string) (Achievements, error) {
+	achievements := Achievements{}
+	for _, a := range strings.Split(s, ",") {
+		kind, threshold, _ := strings.Cut(strings.TrimSpace(a), ":")
+		n, err := strconv.ParseUint(threshold, 10, 64)
+		if !MilestoneKind(kind).IsValid() || err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid achievement %q", a)
+		}
+		achievements = append(achievements, Achievement{MilestoneKind(kind), n})
+	}
+	return achievements, nil
+}
+
+// Reached returns the milestones a spin reached given the counters right after
+// it. Counters past a threshold reach it again, so milestones the counters
+// skipped, e.g. through imports or merges, are not lost; recording them drops
+// the ones the user already has.
+func (achievements Achievements) Reached(s Spin, p MilestoneProgress) []Milestone {
+	milestones := []Milestone{}
+	reach := func(kind MilestoneKind, entityID, value uint64) {
+		milestones = append(milestones, Milestone{
+			UserID:     uint64(s.UserID),
+			Kind:       kind,
+			EntityID:   entityID,
+			Value:      value,
+			SpinID:     uint64(s.ID),
+			AchievedAt: s.Time,
+		})
+	}
+
+	for _, a := range achievements {
+		switch a.Kind {
+		case SpinsMilestone:
+			if p.Spins >= a.Threshold {
+				reach(a.Kind, 0, a.Threshold)
+			}
+		case TrackSpinsMilestone:
+			if p.TrackSpins >= a.Threshold {
+				reach(a.Kind, uint64(s.TrackID), a.Threshold)
+			}
+		case ArtistSpinsMilestone:
+			for artistID, spins := range p.ArtistSpins {
+				if spins >= a.Threshold {
+					reach(a.Kind, artistID, a.Threshold)
+				}
+			}
+		case StreakMilestone:
+			if p.Streak >= a.Threshold {
+				reach(a.Kind, 0, a.Threshold)
+			}
+		}
+	}
+	return milestones
+}
@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// milestoneColumns selects a Milestone from a milestone m joined with
+// milestoneJoins.
+const milestoneColumns = `m.id, m.user_id, m.kind, m.entity_id, COALESCE(t.title, a.name, ''), m.value, COALESCE(m.spin_id, 0), m.achieved_at`
+
+const milestoneJoins = `LEFT JOIN track t ON m.kind = 'track-spins' AND m.entity_id = t.id
+	LEFT JOIN artist a ON m.kind = 'artist-spins' AND m.entity_id = a.id`
+
+func scanMilestone(row pgx.Row, dest ...any) (Milestone, error) {
+	var m Milestone
+	err := row.Scan(append([]any{&m.ID, &m.UserID, &m.Kind, &m.EntityID, &m.Title, &m.Value, &m.SpinID, &m.AchievedAt}, dest...)...)
+	return m, err
+}
+
+// UpdateMilestoneProgress extends the streak of the user of a recorded spin
+// and reads their spins overall, of the spin's track and of its artists from
+// their total and rollups, which already count the spin.
+func (pg *PGDB) UpdateMilestoneProgress(s Spin) (MilestoneProgress, error) {
+	const selectTimeZone = `SELECT time_zone FROM "user" WHERE id=$1`
+	const selectStreak = `SELECT current, longest, last_day FROM user_streak WHERE user_id=$1 FOR UPDATE`
+	const upsertStreak = `INSERT INTO user_streak (user_id, current, longest, last_day) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE SET current=EXCLUDED.current, longest=EXCLUDED.longest, last_day=EXCLUDED.last_day`
+	const selectSpins = `SELECT
+	(SELECT COALESCE(sum(spins), 0) FROM user_spin_total WHERE user_id=$1),
+	(SELECT COALESCE(sum(spins), 0) FROM user_track_day WHERE user_id=$1 AND track_id=$2)`
+	const selectArtistSpins = `SELECT artist_id, sum(spins) FROM user_artist_day
+	WHERE user_id=$1 AND artist_id IN (SELECT artist_id FROM artist_track WHERE track_id=$2)
+	GROUP BY artist_id`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return MilestoneProgress{}, fmt.Errorf("error starting milestone update: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	p := MilestoneProgress{ArtistSpins: map[uint64]uint64{}}
+
+	var timeZone string
+	if err := tx.QueryRow(ctx, selectTimeZone, s.UserID).Scan(&timeZone); err != nil {
+		return MilestoneProgress{}, fmt.Errorf("error selecting time zone: %w", err)
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return MilestoneProgress{}, fmt.Errorf("error loading time zone: %w", err)
+	}
+
+	var streak Streak
+	err = tx.QueryRow(ctx, selectStreak, s.UserID).Scan(&streak.Current, &streak.Longest, &streak.LastDay)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return MilestoneProgress{}, fmt.Errorf("error selecting streak: %w", err)
+	}
+	if extended, ok := streak.Extend(s.Time.In(loc)); ok {
+		if _, err := tx.Exec(ctx, upsertStreak, s.UserID, extended.Current, extended.Longest, extended.LastDay); err != nil {
+			return MilestoneProgress{}, fmt.Errorf("error updating streak: %w", err)
+		}
+		p.Streak = extended.Current
+	}
+
+	if err := tx.QueryRow(ctx, selectSpins, s.UserID, s.TrackID).Scan(&p.Spins, &p.TrackSpins); err != nil {
+		return MilestoneProgress{}, fmt.Errorf("error counting spins: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, selectArtistSpins, s.UserID, s.TrackID)
+	if err != nil {
+		return MilestoneProgress{}, fmt.Errorf("error counting artist spins: %w", err)
+	}
+	var artistID, spins uint64
+	if _, err := pgx.ForEachRow(rows, []any{&artistID, &spins}, func() error {
+		p.ArtistSpins[artistID] = spins
+		return nil
+	}); err != nil {
+		return MilestoneProgress{}, fmt.Errorf("error counting artist spins: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return MilestoneProgress{}, fmt.Errorf("error committing milestone update: %w", err)
+	}
+
+	return p, nil
+}
+
+// AddMilestones records milestones and returns the ones the user had not
+// reached before.
+func (pg *PGDB) AddMilestones(milestones []Milestone) ([]Milestone, error) {
+	const stmt = `INSERT INTO milestone (user_id, kind, entity_id, value, spin_id, achieved_at) VALUES ($1, $2, $3, $4, NULLIF($5::bigint, 0), $6)
+	ON CONFLICT (user_id, kind, entity_id, value) DO NOTHING
+	RETURNING id`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting milestone insert: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	added := []Milestone{}
+	for _, m := range milestones {
+		err := tx.QueryRow(ctx, stmt, m.UserID, string(m.Kind), m.EntityID, m.Value, m.SpinID, m.AchievedAt).Scan(&m.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("error inserting milestone: %w", err)
+		}
+		added = append(added, m)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing milestones: %w", err)
+	}
+
+	return added, nil
+}
+
+// GetMilestones returns a user's streak and their milestones, latest first. A
+// streak without a spin today or yesterday in their time zone is broken and
+// counts 0.
+func (pg *PGDB) GetMilestones(userID uint64) (MilestoneSummary, error) {
+	const selectStreak = `SELECT CASE WHEN st.last_day >= (now() AT TIME ZONE u.time_zone)::date - 1 THEN st.current ELSE 0 END, st.longest, st.last_day
+	FROM user_streak st
+	JOIN "user" u ON st.user_id = u.id
+	WHERE st.user_id=$1`
+	const selectMilestones = `SELECT ` + milestoneColumns + `
+	FROM milestone m
+	` + milestoneJoins + `
+	WHERE m.user_id=$1
+	ORDER BY m.achieved_at DESC, m.id DESC`
+
+	summary := MilestoneSummary{}
+	st := &summary.Streak
+	err := pg.db.QueryRow(context.Background(), selectStreak, userID).Scan(&st.Current, &st.Longest, &st.LastDay)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return MilestoneSummary{}, fmt.Errorf("error selecting streak: %w", err)
+	}
+
+	rows, err := pg.db.Query(context.Background(), selectMilestones, userID)
+	if err != nil {
+		return MilestoneSummary{}, fmt.Errorf("error selecting milestones: %w", err)
+	}
+	summary.Milestones, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Milestone, error) {
+		return scanMilestone(row)
+	})
+	if err != nil {
+		return MilestoneSummary{}, fmt.Errorf("error selecting milestones: %w", err)
+	}
+
+	return summary, nil
+}
+
+// rebuildStreak recomputes the streak of a user from the days of their
+// rollups, after spins were taken out of them or their days moved to another
+// time zone. The current streak is the one ending on the last day they
+// listened.
+func rebuildStreak(tx pgx.Tx, userID uint64) error {
+	const selectStreak = `WITH d AS (
+		SELECT DISTINCT day FROM user_track_day WHERE user_id=$1
+	),
+	runs AS (
+		SELECT max(day) AS last_day, count(*) AS days
+		FROM (SELECT day, day - (row_number() OVER (ORDER BY day))::int AS run FROM d) g
+		GROUP BY run
+	)
+	SELECT days, (SELECT max(days) FROM runs), last_day FROM runs ORDER BY last_day DESC LIMIT 1`
+	const upsertStreak = `INSERT INTO user_streak (user_id, current, longest, last_day) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE SET current=EXCLUDED.current, longest=EXCLUDED.longest, last_day=EXCLUDED.last_day`
+	const deleteStreak = `DELETE FROM user_streak WHERE user_id=$1`
+
+	ctx := context.Background()
+	var streak Streak
+	err := tx.QueryRow(ctx, selectStreak, userID).Scan(&streak.Current, &streak.Longest, &streak.LastDay)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := tx.Exec(ctx, deleteStreak, userID); err != nil {
+			return fmt.Errorf("error deleting streak: %w", err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error selecting streak: %w", err)
+	}
+	if _, err := tx.Exec(ctx, upsertStreak, userID, streak.Current, streak.Longest, streak.LastDay); err != nil {
+		return fmt.Errorf("error updating streak: %w", err)
+	}
+	return nil
+}
+
+// moveMilestones repoints the per-entity milestones of a merged track or
+// artist, dropping the ones the target already has.
+func moveMilestones(tx pgx.Tx, kind MilestoneKind, fromID, intoID uint64) error {
+	const deleteDuplicates = `DELETE FROM milestone m WHERE kind=$1 AND entity_id=$2 AND EXISTS (
+		SELECT 1 FROM milestone o WHERE o.user_id=m.user_id AND o.kind=$1 AND o.entity_id=$3 AND o.value=m.value)`
+	const repoint = `UPDATE milestone SET entity_id=$3 WHERE kind=$1 AND entity_id=$2`
+
+	if _, err := tx.Exec(context.Background(), deleteDuplicates, string(kind), fromID, intoID); err != nil {
+		return fmt.Errorf("error merging milestones: %w", err)
+	}
+	if _, err := tx.Exec(context.Background(), repoint, string(kind), fromID, intoID); err != nil {
+		return fmt.Errorf("error merging milestones: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreakExtend(t *testing.T) {
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	streak := Streak{Current: 3, Longest: 5, LastDay: day}
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		streak   Streak
+		spin     time.Time
+		expected Streak
+		changed  bool
+	}{
+		{"Should start first streak", Streak{}, day.Add(20 * time.Hour), Streak{1, 1, day}, true},
+		{"Should extend on next day", streak, day.AddDate(0, 0, 1).Add(time.Hour), Streak{4, 5, day.AddDate(0, 0, 1)}, true},
+		{"Should keep streak on same day", streak, day.Add(23 * time.Hour), streak, false},
+		{"Should restart after a missed day", streak, day.AddDate(0, 0, 2), Streak{1, 5, day.AddDate(0, 0, 2)}, true},
+		{"Should ignore earlier days", streak, day.AddDate(0, 0, -4), streak, false},
+		{"Should raise longest streak", Streak{5, 5, day}, day.AddDate(0, 0, 1), Streak{6, 6, day.AddDate(0, 0, 1)}, true},
+		{"Should extend on next day in time zone", streak, day.Add(23 * time.Hour).In(kolkata), Streak{4, 5, day.AddDate(0, 0, 1)}, true},
+		{"Should keep streak on same day in time zone", streak, day.AddDate(0, 0, 1).Add(2 * time.Hour).In(losAngeles), streak, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, changed := tt.streak.Extend(tt.spin)
+			if actual != tt.expected || changed != tt.changed {
+				t.Fatalf("expected %+v (changed %t) but got %+v (changed %t)", tt.expected, tt.changed, actual, changed)
+			}
+		})
+	}
+}
+
+func TestAchievements(t *testing.T) {
+	achievements, err := ParseAchievements("spins:1000, track-spins:100,artist-spins:1,streak:7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spin := Spin{ID: 9, UserID: 2, TrackID: 30, Time: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name     string
+		progress MilestoneProgress
+		expected []Milestone
+	}{
+		{"Nothing reached", MilestoneProgress{Spins: 999, TrackSpins: 99, Streak: 6}, []Milestone{}},
+		{"Should reach 1000th spin", MilestoneProgress{Spins: 1000, TrackSpins: 3}, []Milestone{
+			{UserID: 2, Kind: SpinsMilestone, Value: 1000, SpinID: 9, AchievedAt: spin.Time},
+		}},
+		{"Should reach first listen and 100th play", MilestoneProgress{Spins: 5, TrackSpins: 100, ArtistSpins: map[uint64]uint64{4: 1}}, []Milestone{
+			{UserID: 2, Kind: TrackSpinsMilestone, EntityID: 30, Value: 100, SpinID: 9, AchievedAt: spin.Time},
+			{UserID: 2, Kind: ArtistSpinsMilestone, EntityID: 4, Value: 1, SpinID: 9, AchievedAt: spin.Time},
+		}},
+		{"Should reach week long streak", MilestoneProgress{Spins: 5, Streak: 7}, []Milestone{
+			{UserID: 2, Kind: StreakMilestone, Value: 7, SpinID: 9, AchievedAt: spin.Time},
+		}},
+		{"Should reach skipped thresholds", MilestoneProgress{Spins: 1002, TrackSpins: 3, ArtistSpins: map[uint64]uint64{4: 3}}, []Milestone{
+			{UserID: 2, Kind: SpinsMilestone, Value: 1000, SpinID: 9, AchievedAt: spin.Time},
+			{UserID: 2, Kind: ArtistSpinsMilestone, EntityID: 4, Value: 1, SpinID: 9, AchievedAt: spin.Time},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := achievements.Reached(spin, tt.progress)
+			if len(actual) != len(tt.expected) {
+				t.Fatalf("expected %+v but got %+v", tt.expected, actual)
+			}
+			for i := range actual {
+				if actual[i] != tt.expected[i] {
+					t.Fatalf("expected %+v but got %+v", tt.expected[i], actual[i])
+				}
+			}
+		})
+	}
+
+	for _, invalid := range []string{"plays:10", "spins", "streak:0", "spins:-1"} {
+		if _, err := ParseAchievements(invalid); err == nil {
+			t.Fatalf("expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -13,11 +14,27 @@ type User struct {
 	Name     string
 	Email    string
 	Password string
+	Admin    bool
+}
+
+// Settings are a user's profile and preferences. TimeZone is an IANA time zone
+// name, the zone statistics bucket the user's spins by days and hours in, and
+// Country an ISO 3166-1 alpha-2 code.
+type Settings struct {
+	DisplayName    string
+	Bio            string
+	AvatarURL      string
+	TimeZone       string
+	Country        string
+	Privacy        Privacy
+	HideNowPlaying bool
+	HideHistory    bool
 }
 
 type Artist struct {
 	ID   uint64
 	Name string
+	MBID string
 }
 
 func (a *Artist) IsEmpty() bool {
@@ -31,41 +48,130 @@ const (
 	EP          ProjectType = "ep"
 	Single      ProjectType = "single"
 	Compilation ProjectType = "compilation"
+	Live        ProjectType = "live"
+	Soundtrack  ProjectType = "soundtrack"
+	Mixtape     ProjectType = "mixtape"
+	Remix       ProjectType = "remix"
+	DJMix       ProjectType = "dj-mix"
 )
 
+// projectTypeRanks orders project types from most to least primary.
+var projectTypeRanks = map[ProjectType]int{
+	Album:       0,
+	Mixtape:     1,
+	EP:          2,
+	Single:      3,
+	Soundtrack:  4,
+	Live:        5,
+	Remix:       6,
+	DJMix:       7,
+	Compilation: 8,
+}
+
+// projectTypeAliases maps the spellings clients send to project types.
+var projectTypeAliases = map[string]ProjectType{
+	"":               Album,
+	"lp":             Album,
+	"studio":         Album,
+	"dj mix":         DJMix,
+	"djmix":          DJMix,
+	"street":         Mixtape,
+	"mixtape/street": Mixtape,
+}
+
+func (t ProjectType) IsValid() bool {
+	_, ok := projectTypeRanks[t]
+	return ok
+}
+
+// ParseProjectType normalizes a project type sent by a client, treating a
+// missing type as an album.
+func ParseProjectType(s string) (ProjectType, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if t, ok := projectTypeAliases[s]; ok {
+		return t, nil
+	}
+	if t := ProjectType(s); t.IsValid() {
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown project type %q", s)
+}
+
+// Edition marks a project as another edition of its base project.
+type Edition string
+
+const (
+	Deluxe   Edition = "deluxe"
+	Reissue  Edition = "reissue"
+	Remaster Edition = "remaster"
+)
+
+func (e Edition) IsValid() bool {
+	return e == Deluxe || e == Reissue || e == Remaster
+}
+
+// DatePrecision is how much of a release date is known. Dates are stored as
+// the first day of their year or month when only those are known.
+type DatePrecision string
+
+const (
+	YearPrecision  DatePrecision = "year"
+	MonthPrecision DatePrecision = "month"
+	DayPrecision   DatePrecision = "day"
+)
+
+func (p DatePrecision) IsValid() bool {
+	return p == YearPrecision || p == MonthPrecision || p == DayPrecision
+}
+
+// Truncate drops the parts of a date the precision does not know.
+func (p DatePrecision) Truncate(t time.Time) time.Time {
+	switch p {
+	case YearPrecision:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case MonthPrecision:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
 type Project struct {
-	ID      uint64
-	Title   string
-	Form    ProjectType
-	Release time.Time
+	ID               uint64
+	Title            string
+	Form             ProjectType
+	Release          time.Time
+	MBID             string
+	ReleasePrecision DatePrecision
+	Edition          Edition
+	BaseProjectID    uint64
 }
 
 func (p *Project) IsEmpty() bool {
 	return p.ID == 0 && p.Title == "" && p.Form == "" && p.Release == time.Time{}
 }
 
+// IsLessPrimaryThan ranks projects by type, then prefers original releases
+// over other editions and finally the earliest release. Unknown types rank
+// below every known one.
 func (a *Project) IsLessPrimaryThan(b *Project) bool {
-	if a.Form == b.Form {
-		return a.Release.Before(b.Release)
+	if a.Form != b.Form {
+		return rank(a.Form) > rank(b.Form)
 	}
+	if a.IsEdition() != b.IsEdition() {
+		return a.IsEdition()
+	}
+	if a.Release.IsZero() || b.Release.IsZero() {
+		return a.Release.IsZero() && !b.Release.IsZero()
+	}
+	return b.Release.Before(a.Release)
+}
 
-	if a.Form == Album {
-		return false
-	} else if a.Form == Compilation {
-		return true
-	} else if a.Form == EP {
-		if b.Form == Album {
-			return true
-		} else {
-			return false
-		}
-	} else {
-		if b.Form == Compilation {
-			return false
-		} else {
-			return true
-		}
+func rank(t ProjectType) int {
+	if r, ok := projectTypeRanks[t]; ok {
+		return r
 	}
+	return len(projectTypeRanks)
 }
 
 type Track struct {
@@ -73,6 +179,8 @@ type Track struct {
 	Title            string
 	ProjectIDs       []uint64
 	PrimaryProjectID uint64
+	MBID             string
+	Duration         time.Duration
 }
 
 func (t *Track) IsEmpty() bool {
@@ -80,10 +188,185 @@ func (t *Track) IsEmpty() bool {
 }
 
 type Spin struct {
-	ID      uint
-	UserID  uint
-	Time    time.Time
-	TrackID uint
+	ID        uint
+	UserID    uint
+	Time      time.Time
+	TrackID   uint
+	MsPlayed  uint
+	ProjectID uint
+}
+
+type EntityType string
+
+const (
+	ArtistEntity  EntityType = "artist"
+	ProjectEntity EntityType = "project"
+	TrackEntity   EntityType = "track"
+)
+
+func (e EntityType) IsValid() bool {
+	return e == ArtistEntity || e == ProjectEntity || e == TrackEntity
+}
+
+// Tag is a global genre tag when it has no user, and private to its user
+// otherwise.
+type Tag struct {
+	ID     uint64
+	Name   string
+	UserID uint64
+}
+
+// ArtSource is an artwork URL a client reported for a project.
+type ArtSource struct {
+	Key uint64
+	URL string
+}
+
+type TagListening struct {
+	Tag   Tag
+	Spins uint64
+}
+
+type Period string
+
+const (
+	Day   Period = "day"
+	Week  Period = "week"
+	Month Period = "month"
+)
+
+func (p Period) IsValid() bool {
+	return p == Day || p == Week || p == Month
+}
+
+// ListeningTime sums the time a user listened within one period. Spins
+// without a play time count the full duration of their track.
+type ListeningTime struct {
+	Start    time.Time
+	Spins    uint64
+	MsPlayed uint64
+}
+
+type ArtistListening struct {
+	Artist   Artist
+	Spins    uint64
+	MsPlayed uint64
+}
+
+type ProjectListening struct {
+	Project  Project
+	Spins    uint64
+	MsPlayed uint64
+}
+
+type TrackListening struct {
+	Track    Track
+	Spins    uint64
+	MsPlayed uint64
+}
+
+// HourListening sums the listening within one hour of one weekday, counting
+// weekdays from Sunday as 0.
+type HourListening struct {
+	Weekday  int
+	Hour     int
+	Spins    uint64
+	MsPlayed uint64
+}
+
+// Wrapped is a user's year in review. The heatmap counts spins by weekday,
+// Sunday first, and hour in the user's time zone.
+type Wrapped struct {
+	Year           int
+	Minutes        uint64
+	Spins          uint64
+	TopArtists     []ArtistListening
+	TopTracks      []TrackListening
+	TopProjects    []ProjectListening
+	TopGenres      []TagListening
+	MostPlayedDay  ListeningTime
+	NewArtists     []ArtistListening
+	NewArtistCount uint64
+	LongestStreak  uint64
+	Heatmap        [7][24]uint64
+	OnRepeat       []TrackListening
+	GeneratedAt    time.Time
+}
+
+// StaleWrapped is a year a user listened in whose report is missing or counts
+// a different number of spins. Years are the ones of the user's time zone.
+type StaleWrapped struct {
+	UserID   uint64
+	Year     int
+	TimeZone string
+}
+
+// CalendarDay is a day of listening starting at midnight in the user's time
+// zone. Level grades the day's spins relative to the busiest day in a range,
+// from 0 for none up to 4 for the busiest.
+type CalendarDay struct {
+	Date     time.Time
+	Spins    uint64
+	MsPlayed uint64
+	Level    int
+}
+
+// Plays counts the spins of a catalog entry by everyone and by the user who
+// is browsing it.
+type Plays struct {
+	Spins     uint64
+	UserSpins uint64
+}
+
+type ProjectPlays struct {
+	Project Project
+	Plays   Plays
+}
+
+// TrackPlays is a track of an artist or project page. Number is its position
+// on the tracklist of a project page and 0 elsewhere.
+type TrackPlays struct {
+	Track  Track
+	Number int
+	Plays  Plays
+}
+
+// SearchHit is an artist, project or track matching a search. Artists holds
+// the artist names of projects and tracks.
+type SearchHit struct {
+	Entity    EntityType
+	ID        uint64
+	Title     string
+	Artists   []string
+	Score     float64
+	UserSpins uint64
+}
+
+type SearchResults struct {
+	Artists  []SearchHit
+	Projects []SearchHit
+	Tracks   []SearchHit
+}
+
+type ArtistPage struct {
+	Artist   Artist
+	Plays    Plays
+	Projects []ProjectPlays
+	Tracks   []TrackPlays
+}
+
+type ProjectPage struct {
+	Project Project
+	Artists []Artist
+	Plays   Plays
+	Tracks  []TrackPlays
+}
+
+type TrackPage struct {
+	Track    Track
+	Artists  []Artist
+	Plays    Plays
+	Projects []ProjectPlays
 }
 
 func CreateHash(title string, artistNames []string) uint64 {
@@ -94,7 +377,42 @@ func CreateHash(title string, artistNames []string) uint64 {
 	return xxhash.Sum64String(prehash)
 }
 
+// MergeResult lists the catalog entries whose cached copies are stale after a
+// merge: artist names (including aliases), track and project keys and the
+// MusicBrainz identifier of the merged entry.
+type MergeResult struct {
+	ArtistNames []string
+	TrackIDs    []uint64
+	ProjectIDs  []uint64
+	MBIDs       []string
+}
+
+// EnrichmentCandidate is a track or project the enrichment worker has not
+// looked up yet. Projects list their tracks so they can be numbered.
+type EnrichmentCandidate struct {
+	Key         uint64
+	Title       string
+	MBID        string
+	ArtistNames []string
+	Tracks      []EnrichmentCandidate
+}
+
+type TrackMetadata struct {
+	Duration time.Duration
+	ISRC     string
+	Genres   []string
+}
+
+type ProjectMetadata struct {
+	Release          time.Time
+	ReleasePrecision DatePrecision
+	Label            string
+	TrackNumbers     map[uint64]int
+	Genres           []string
+}
+
 type RefreshToken struct {
 	ID         string `bson:"_id"`
+	UserID     uint64
 	Expiration time.Time
 }
@@ -1,6 +1,9 @@
 package data
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 type input struct {
 	titleInput       string
@@ -68,3 +71,91 @@ func TestCreateHash(t *testing.T) {
 		})
 	}
 }
+
+func TestParseProjectType(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ProjectType
+		valid    bool
+	}{
+		{"Album", Album, true},
+		{" EP ", EP, true},
+		{"", Album, true},
+		{"DJ Mix", DJMix, true},
+		{"Mixtape/Street", Mixtape, true},
+		{"box set", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := ParseProjectType(tt.input)
+			if (err == nil) != tt.valid || actual != tt.expected {
+				t.Fatalf("expected %q (valid %t) but got %q (%v)", tt.expected, tt.valid, actual, err)
+			}
+		})
+	}
+}
+
+func TestIsLessPrimaryThan(t *testing.T) {
+	release := time.Date(2021, 5, 21, 0, 0, 0, 0, time.UTC)
+	album := Project{Title: "SOUR", Form: Album, Release: release}
+
+	tests := []struct {
+		name     string
+		project  Project
+		expected bool
+	}{
+		{"Single is less primary than album", Project{Title: "drivers license", Form: Single}, true},
+		{"Mixtape is less primary than album", Project{Title: "SOUR", Form: Mixtape, Release: release}, true},
+		{"Edition is less primary than original", Project{Title: "SOUR (Deluxe)", Form: Album, Release: release}, true},
+		{"Unknown release is less primary", Project{Title: "SOUR", Form: Album}, true},
+		{"Later release is less primary", Project{Title: "SOUR", Form: Album, Release: release.AddDate(1, 0, 0)}, true},
+		{"Earlier release is more primary", Project{Title: "SOUR", Form: Album, Release: release.AddDate(0, -1, 0)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.project.IsLessPrimaryThan(&album); actual != tt.expected {
+				t.Fatalf("expected %t but got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDatePrecisionTruncate(t *testing.T) {
+	release := time.Date(2023, 9, 8, 0, 0, 0, 0, time.UTC)
+	tests := map[DatePrecision]time.Time{
+		YearPrecision:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		MonthPrecision: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+		DayPrecision:   release,
+	}
+
+	for precision, expected := range tests {
+		if actual := precision.Truncate(release); !actual.Equal(expected) {
+			t.Fatalf("expected %s truncated to %s but got %s", precision, expected, actual)
+		}
+	}
+}
+
+func TestParseEdition(t *testing.T) {
+	tests := []struct {
+		input   string
+		title   string
+		edition Edition
+	}{
+		{"GUTS (Deluxe)", "GUTS", Deluxe},
+		{"Abbey Road - Remastered 2009", "Abbey Road", Remaster},
+		{"Rumours [Super Deluxe Edition]", "Rumours", Deluxe},
+		{"good 4 u (Live)", "good 4 u (Live)", ""},
+		{"GUTS", "GUTS", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			title, edition := ParseEdition(tt.input)
+			if title != tt.title || edition != tt.edition {
+				t.Fatalf("expected %q (%s) but got %q (%s)", tt.title, tt.edition, title, edition)
+			}
+		})
+	}
+}
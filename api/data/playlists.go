@@ -0,0 +1,53 @@
+package data
+
+import "time"
+
+// Playlist is a list of tracks a user curates, or a smart playlist whose
+// tracks Rule picks from their listening whenever it is read. Tracks are only
+// filled in when a single playlist is read.
+type Playlist struct {
+	ID        uint64
+	OwnerID   uint64
+	Owner     string
+	Name      string
+	Public    bool
+	Rule      *SmartRule
+	CreatedAt time.Time
+	Tracks    []PlaylistTrack
+}
+
+// PlaylistTrack is a track at a position of a playlist, counting from 1.
+// Spins and MsPlayed are the listening a smart playlist picked it by.
+type PlaylistTrack struct {
+	Position int
+	ID       uint64
+	Title    string
+	Artists  []string
+	Spins    uint64
+	MsPlayed uint64
+}
+
+// SmartOrder is what the tracks of a smart playlist are ranked by.
+type SmartOrder string
+
+const (
+	TimeOrder  SmartOrder = "time"
+	SpinsOrder SmartOrder = "spins"
+)
+
+func (o SmartOrder) IsValid() bool {
+	return o == TimeOrder || o == SpinsOrder
+}
+
+// SmartRule picks the tracks of a smart playlist from its owner's listening
+// on the last Days days in their time zone, or all of it when Days is 0.
+// Tracks need at least MinSpins spins in that time and, when NotHeardDays is
+// set, none in the last NotHeardDays days. The most played by Order come
+// first, up to Limit of them.
+type SmartRule struct {
+	Days         int
+	MinSpins     uint64
+	NotHeardDays int
+	Order        SmartOrder
+	Limit        int
+}
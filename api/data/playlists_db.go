@@ -0,0 +1,263 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const playlistColumns = `pl.id, pl.user_id, u.name, pl.name, pl.public, pl.rule, pl.created_at`
+
+func scanPlaylist(row pgx.Row) (Playlist, error) {
+	var p Playlist
+	var rule []byte
+	if err := row.Scan(&p.ID, &p.OwnerID, &p.Owner, &p.Name, &p.Public, &rule, &p.CreatedAt); err != nil {
+		return Playlist{}, err
+	}
+	if rule != nil {
+		p.Rule = &SmartRule{}
+		if err := json.Unmarshal(rule, p.Rule); err != nil {
+			return Playlist{}, fmt.Errorf("error decoding smart rule: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// encodeRule encodes the rule of a smart playlist, or nil for a curated one.
+func encodeRule(rule *SmartRule) ([]byte, error) {
+	if rule == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding smart rule: %w", err)
+	}
+	return b, nil
+}
+
+// playlistTrackColumns selects a PlaylistTrack but for its position from a
+// track t.
+const playlistTrackColumns = `t.id, t.title,
+	ARRAY(SELECT DISTINCT a.name FROM artist a JOIN artist_track at ON a.id = at.artist_id WHERE at.track_id = t.id ORDER BY a.name)`
+
+func collectPlaylistTracks(rows pgx.Rows) ([]PlaylistTrack, error) {
+	position := 0
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (PlaylistTrack, error) {
+		position++
+		pt := PlaylistTrack{Position: position}
+		err := row.Scan(&pt.ID, &pt.Title, &pt.Artists, &pt.Spins, &pt.MsPlayed)
+		return pt, err
+	})
+}
+
+// CreatePlaylist creates a playlist owned by a user.
+func (pg *PGDB) CreatePlaylist(userID uint64, p Playlist) (Playlist, error) {
+	const stmt = `INSERT INTO playlist (user_id, name, public, rule) VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at, (SELECT name FROM "user" WHERE id=$1)`
+
+	rule, err := encodeRule(p.Rule)
+	if err != nil {
+		return Playlist{}, err
+	}
+	p.OwnerID = userID
+	if err := pg.db.QueryRow(context.Background(), stmt, userID, p.Name, p.Public, rule).Scan(&p.ID, &p.CreatedAt, &p.Owner); err != nil {
+		return Playlist{}, fmt.Errorf("error inserting playlist: %w", err)
+	}
+	return p, nil
+}
+
+func (pg *PGDB) GetPlaylist(id uint64) (Playlist, error) {
+	const stmt = `SELECT ` + playlistColumns + ` FROM playlist pl JOIN "user" u ON pl.user_id = u.id WHERE pl.id=$1`
+
+	p, err := scanPlaylist(pg.db.QueryRow(context.Background(), stmt, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Playlist{}, fmt.Errorf("%w: playlist %d", ErrNotFound, id)
+	} else if err != nil {
+		return Playlist{}, fmt.Errorf("error selecting playlist: %w", err)
+	}
+	return p, nil
+}
+
+// UpdatePlaylist saves the name, visibility and rule of a playlist.
+func (pg *PGDB) UpdatePlaylist(p Playlist) error {
+	const stmt = `UPDATE playlist SET name=$2, public=$3, rule=$4 WHERE id=$1`
+
+	rule, err := encodeRule(p.Rule)
+	if err != nil {
+		return err
+	}
+	tag, err := pg.db.Exec(context.Background(), stmt, p.ID, p.Name, p.Public, rule)
+	if err != nil {
+		return fmt.Errorf("error updating playlist: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: playlist %d", ErrNotFound, p.ID)
+	}
+	return nil
+}
+
+func (pg *PGDB) DeletePlaylist(id uint64) error {
+	const stmt = `DELETE FROM playlist WHERE id=$1`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, id)
+	if err != nil {
+		return fmt.Errorf("error deleting playlist: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: playlist %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+// GetUserPlaylists returns the playlists of a user, by name, or only their
+// public ones unless private is set.
+func (pg *PGDB) GetUserPlaylists(userID uint64, private bool) ([]Playlist, error) {
+	const stmt = `SELECT ` + playlistColumns + `
+	FROM playlist pl
+	JOIN "user" u ON pl.user_id = u.id
+	WHERE pl.user_id=$1 AND (pl.public OR $2)
+	ORDER BY pl.name, pl.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, private)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting playlists: %w", err)
+	}
+	playlists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Playlist, error) {
+		return scanPlaylist(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting playlists: %w", err)
+	}
+	return playlists, nil
+}
+
+// GetPlaylistTracks returns the tracks of a curated playlist in order.
+func (pg *PGDB) GetPlaylistTracks(id uint64) ([]PlaylistTrack, error) {
+	const stmt = `SELECT ` + playlistTrackColumns + `, 0, 0
+	FROM playlist_track pt
+	JOIN track t ON pt.track_id = t.id
+	WHERE pt.playlist_id=$1
+	ORDER BY pt.position, pt.added_at`
+
+	rows, err := pg.db.Query(context.Background(), stmt, id)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting playlist tracks: %w", err)
+	}
+	tracks, err := collectPlaylistTracks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting playlist tracks: %w", err)
+	}
+	return tracks, nil
+}
+
+// AddPlaylistTrack adds a track to the end of a curated playlist. Adding a
+// track it has already changes nothing.
+func (pg *PGDB) AddPlaylistTrack(id, trackID uint64) error {
+	const stmt = `INSERT INTO playlist_track (playlist_id, track_id, position)
+	SELECT $1, t.id, (SELECT COALESCE(max(position), 0) + 1 FROM playlist_track WHERE playlist_id=$1)
+	FROM track t
+	WHERE t.id=$2
+	ON CONFLICT (playlist_id, track_id) DO NOTHING`
+	const exists = `SELECT EXISTS (SELECT 1 FROM track WHERE id=$1)`
+
+	ctx := context.Background()
+	tag, err := pg.db.Exec(ctx, stmt, id, trackID)
+	if err != nil {
+		return fmt.Errorf("error inserting playlist track: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		var found bool
+		if err := pg.db.QueryRow(ctx, exists, trackID).Scan(&found); err != nil {
+			return fmt.Errorf("error selecting track: %w", err)
+		}
+		if !found {
+			return fmt.Errorf("%w: track %d", ErrNotFound, trackID)
+		}
+	}
+	return nil
+}
+
+func (pg *PGDB) RemovePlaylistTrack(id, trackID uint64) error {
+	const stmt = `DELETE FROM playlist_track WHERE playlist_id=$1 AND track_id=$2`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, id, trackID)
+	if err != nil {
+		return fmt.Errorf("error deleting playlist track: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: track %d in playlist %d", ErrNotFound, trackID, id)
+	}
+	return nil
+}
+
+// ReorderPlaylist puts the tracks of a curated playlist in the order of their
+// IDs.
+func (pg *PGDB) ReorderPlaylist(id uint64, trackIDs []uint64) error {
+	const stmt = `UPDATE playlist_track SET position=$3 WHERE playlist_id=$1 AND track_id=$2`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting playlist reorder: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i, trackID := range trackIDs {
+		if _, err := tx.Exec(ctx, stmt, id, trackID, i+1); err != nil {
+			return fmt.Errorf("error reordering playlist: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing playlist reorder: %w", err)
+	}
+	return nil
+}
+
+// smartOrders rank the listening r of the tracks of smart playlists.
+var smartOrders = map[SmartOrder]string{
+	TimeOrder:  `r.ms_played DESC, r.spins DESC`,
+	SpinsOrder: `r.spins DESC, r.ms_played DESC`,
+}
+
+// GetSmartPlaylistTracks returns the tracks a smart rule picks from a user's
+// listening as of a time.
+func (pg *PGDB) GetSmartPlaylistTracks(userID uint64, rule SmartRule, now time.Time) ([]PlaylistTrack, error) {
+	order, ok := smartOrders[rule.Order]
+	if !ok {
+		return nil, fmt.Errorf("unknown smart playlist order %q", rule.Order)
+	}
+	stmt := `WITH b AS (
+		SELECT ($2::timestamptz AT TIME ZONE time_zone)::date AS today FROM "user" WHERE id=$1
+	),
+	r AS (
+		SELECT r.track_id,
+			COALESCE(sum(r.spins) FILTER (WHERE $3 = 0 OR r.day > b.today - $3), 0)::bigint AS spins,
+			COALESCE(sum(r.ms_played) FILTER (WHERE $3 = 0 OR r.day > b.today - $3), 0)::bigint AS ms_played,
+			max(r.day) AS last_day
+		FROM b
+		JOIN user_track_day r ON r.user_id=$1
+		GROUP BY r.track_id
+	)
+	SELECT ` + playlistTrackColumns + `, r.spins, r.ms_played
+	FROM r
+	JOIN track t ON r.track_id = t.id
+	CROSS JOIN b
+	WHERE r.spins >= GREATEST($4::bigint, 1) AND ($5 = 0 OR r.last_day <= b.today - $5)
+	ORDER BY ` + order + `, t.id
+	LIMIT $6`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, now, rule.Days, rule.MinSpins, rule.NotHeardDays, rule.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting smart playlist tracks: %w", err)
+	}
+	tracks, err := collectPlaylistTracks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting smart playlist tracks: %w", err)
+	}
+	return tracks, nil
+}
@@ -0,0 +1,238 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrimaryRule compares two projects a track appears on, returning a positive
+// number when a is more primary than b, a negative one when b is, and zero
+// when the rule does not tell them apart.
+type PrimaryRule struct {
+	Name    string
+	Compare func(a, b *Project, ctx PrimaryContext) int
+}
+
+// PrimaryContext is what rules know besides the projects themselves.
+type PrimaryContext struct {
+	// Pinned is the project an admin pinned as the track's primary project.
+	Pinned uint64
+	// Override is the project a user chose as the track's primary project for
+	// their own listening.
+	Override uint64
+	// Spins counts the spins of the track by the project they came from.
+	Spins map[uint64]uint64
+}
+
+// PrimaryPolicy ranks the projects of a track by applying its rules in order
+// until one of them tells two projects apart.
+type PrimaryPolicy []PrimaryRule
+
+// PrimaryExplanation describes why a project is a track's primary project.
+// Reason is "pinned" or "override" when someone chose the project and
+// "policy" when the policy did. Each other candidate names the rule that
+// ranked the chosen project above it.
+type PrimaryExplanation struct {
+	Chosen     Project
+	Reason     string
+	Rules      []string
+	Candidates []PrimaryCandidate
+}
+
+type PrimaryCandidate struct {
+	Project Project
+	Spins   uint64
+	Rule    string
+}
+
+var PrimaryRules = map[string]PrimaryRule{
+	"original": {"original", compareOriginal},
+	"spins":    {"spins", compareSpins},
+	"form":     {"form", compareForm},
+	"release":  {"release", compareRelease},
+}
+
+var DefaultPrimaryPolicy = PrimaryPolicy{
+	PrimaryRules["original"],
+	PrimaryRules["form"],
+	PrimaryRules["release"],
+}
+
+// ParsePrimaryPolicy builds a policy from a comma separated list of rule
+// names, e.g. "spins,original,form,release".
+func ParsePrimaryPolicy(names string) (PrimaryPolicy, error) {
+	policy := PrimaryPolicy{}
+	for _, name := range strings.Split(names, ",") {
+		rule, ok := PrimaryRules[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown primary project rule %q", name)
+		}
+		policy = append(policy, rule)
+	}
+	return policy, nil
+}
+
+// CountsSpins reports whether the policy ranks projects by their spins, so
+// spins can change its choice without any project being added.
+func (policy PrimaryPolicy) CountsSpins() bool {
+	for _, rule := range policy {
+		if rule.Name == "spins" {
+			return true
+		}
+	}
+	return false
+}
+
+// Choose returns the primary project among a track's projects.
+func (policy PrimaryPolicy) Choose(candidates []Project, ctx PrimaryContext) Project {
+	return policy.Explain(candidates, ctx).Chosen
+}
+
+func (policy PrimaryPolicy) Explain(candidates []Project, ctx PrimaryContext) PrimaryExplanation {
+	e := PrimaryExplanation{Reason: "policy", Rules: []string{}, Candidates: []PrimaryCandidate{}}
+	for _, rule := range policy {
+		e.Rules = append(e.Rules, rule.Name)
+	}
+	if len(candidates) == 0 {
+		return e
+	}
+
+	chosen := -1
+	for i, c := range candidates {
+		switch {
+		case c.ID == ctx.Override:
+			chosen, e.Reason = i, "override"
+		case c.ID == ctx.Pinned && e.Reason != "override":
+			chosen, e.Reason = i, "pinned"
+		}
+	}
+	if chosen < 0 {
+		chosen = 0
+		for i := range candidates {
+			if cmp, _ := policy.compare(&candidates[i], &candidates[chosen], ctx); cmp > 0 {
+				chosen = i
+			}
+		}
+	}
+	e.Chosen = candidates[chosen]
+
+	for i := range candidates {
+		c := PrimaryCandidate{Project: candidates[i], Spins: ctx.Spins[candidates[i].ID]}
+		if i != chosen {
+			c.Rule = e.Reason
+			if e.Reason == "policy" {
+				_, c.Rule = policy.compare(&candidates[chosen], &candidates[i], ctx)
+			}
+		}
+		e.Candidates = append(e.Candidates, c)
+	}
+	return e
+}
+
+// compare applies the rules in order, falling back to the lowest ID so the
+// choice never depends on the order of the candidates.
+func (policy PrimaryPolicy) compare(a, b *Project, ctx PrimaryContext) (int, string) {
+	for _, rule := range policy {
+		if cmp := rule.Compare(a, b, ctx); cmp != 0 {
+			return cmp, rule.Name
+		}
+	}
+	switch {
+	case a.ID < b.ID:
+		return 1, "id"
+	case a.ID > b.ID:
+		return -1, "id"
+	default:
+		return 0, ""
+	}
+}
+
+// editionMarkers are the title words of deluxe editions, reissues and
+// remasters.
+var editionMarkers = []struct {
+	word    string
+	edition Edition
+}{
+	{"deluxe", Deluxe},
+	{"expanded", Deluxe},
+	{"anniversary", Deluxe},
+	{"special edition", Deluxe},
+	{"bonus track", Deluxe},
+	{"remaster", Remaster},
+	{"reissue", Reissue},
+}
+
+// ParseEdition splits a title like "GUTS (Deluxe)" or "Abbey Road - Remastered
+// 2009" into the title of the original and the kind of edition. Titles of
+// originals come back unchanged with no edition.
+func ParseEdition(title string) (string, Edition) {
+	i := max(strings.LastIndexAny(title, "(["), strings.LastIndex(title, " - "))
+	if i <= 0 {
+		return title, ""
+	}
+
+	suffix := strings.ToLower(title[i:])
+	for _, marker := range editionMarkers {
+		if strings.Contains(suffix, marker.word) {
+			return strings.TrimSpace(title[:i]), marker.edition
+		}
+	}
+	return title, ""
+}
+
+// IsEdition reports whether a project is a deluxe edition, reissue or
+// remaster of another release, either by its link to the original or by its
+// title.
+func (p *Project) IsEdition() bool {
+	if p.Edition != "" || p.BaseProjectID != 0 {
+		return true
+	}
+	_, edition := ParseEdition(p.Title)
+	return edition != ""
+}
+
+func compareOriginal(a, b *Project, _ PrimaryContext) int {
+	return boolCompare(!a.IsEdition(), !b.IsEdition())
+}
+
+func compareSpins(a, b *Project, ctx PrimaryContext) int {
+	switch sa, sb := ctx.Spins[a.ID], ctx.Spins[b.ID]; {
+	case sa > sb:
+		return 1
+	case sa < sb:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func compareForm(a, b *Project, _ PrimaryContext) int {
+	if a.Form == b.Form {
+		return 0
+	}
+	return boolCompare(b.IsLessPrimaryThan(a), a.IsLessPrimaryThan(b))
+}
+
+// compareRelease prefers the earlier release, treating unknown release dates
+// as the latest.
+func compareRelease(a, b *Project, _ PrimaryContext) int {
+	switch {
+	case a.Release.Equal(b.Release):
+		return 0
+	case b.Release.IsZero() || (!a.Release.IsZero() && a.Release.Before(b.Release)):
+		return 1
+	default:
+		return -1
+	}
+}
+
+func boolCompare(a, b bool) int {
+	switch {
+	case a && !b:
+		return 1
+	case b && !a:
+		return -1
+	default:
+		return 0
+	}
+}
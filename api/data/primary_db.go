@@ -0,0 +1,121 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (pg *PGDB) GetTrackProjects(key uint64) ([]Project, error) {
+	const stmt = `SELECT DISTINCT p.id, p.title, p.form, p.release, COALESCE(p.mbid::text, ''),
+	p.release_precision, COALESCE(p.edition, ''), COALESCE(p.base_project_id, 0)
+	FROM project p
+	JOIN project_track pt ON p.id = pt.project_id
+	WHERE pt.track_id=$1
+	ORDER BY p.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, key)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting track projects: %w", err)
+	}
+
+	projects, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Project, error) {
+		var p Project
+		err := row.Scan(&p.ID, &p.Title, &p.Form, &p.Release, &p.MBID, &p.ReleasePrecision, &p.Edition, &p.BaseProjectID)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting track projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+// GetPrimaryContext collects the pin, the user's override and the spin counts
+// of a track. A userID of 0 leaves out the override.
+func (pg *PGDB) GetPrimaryContext(key uint64, userID uint64) (PrimaryContext, error) {
+	const choices = `SELECT
+	COALESCE((SELECT primary_project_id FROM track WHERE id=$1 AND primary_pinned), 0),
+	COALESCE((SELECT project_id FROM user_primary_project WHERE user_id=$2 AND track_id=$1), 0)`
+	const spins = `SELECT project_id, count(*) FROM spin
+	WHERE track_id=$1 AND project_id IS NOT NULL
+	GROUP BY project_id`
+
+	ctx := PrimaryContext{Spins: map[uint64]uint64{}}
+	if err := pg.db.QueryRow(context.Background(), choices, key, userID).Scan(&ctx.Pinned, &ctx.Override); err != nil {
+		return PrimaryContext{}, fmt.Errorf("error selecting primary project choices: %w", err)
+	}
+
+	rows, err := pg.db.Query(context.Background(), spins, key)
+	if err != nil {
+		return PrimaryContext{}, fmt.Errorf("error selecting project spins: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var projectID, n uint64
+		if err := rows.Scan(&projectID, &n); err != nil {
+			return PrimaryContext{}, fmt.Errorf("error selecting project spins: %w", err)
+		}
+		ctx.Spins[projectID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return PrimaryContext{}, fmt.Errorf("error selecting project spins: %w", err)
+	}
+
+	return ctx, nil
+}
+
+// SetPrimaryProject makes a project the primary project of a track it
+// appears on. Pinned projects are kept until they are unpinned.
+func (pg *PGDB) SetPrimaryProject(key uint64, projectID uint64, pinned bool) error {
+	const stmt = `UPDATE track SET primary_project_id=$2, primary_pinned=$3
+	WHERE id=$1 AND EXISTS (SELECT 1 FROM project_track WHERE track_id=$1 AND project_id=$2)`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, key, projectID, pinned)
+	if err != nil {
+		return fmt.Errorf("error updating primary project: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: track %d is not on project %d", ErrNotFound, key, projectID)
+	}
+
+	return nil
+}
+
+func (pg *PGDB) UnpinPrimaryProject(key uint64) error {
+	const stmt = `UPDATE track SET primary_pinned=false WHERE id=$1`
+
+	if _, err := pg.db.Exec(context.Background(), stmt, key); err != nil {
+		return fmt.Errorf("error unpinning primary project: %w", err)
+	}
+
+	return nil
+}
+
+func (pg *PGDB) SetUserPrimaryProject(userID uint64, key uint64, projectID uint64) error {
+	const stmt = `INSERT INTO user_primary_project (user_id, track_id, project_id)
+	SELECT $1, $2, $3 WHERE EXISTS (SELECT 1 FROM project_track WHERE track_id=$2 AND project_id=$3)
+	ON CONFLICT (user_id, track_id) DO UPDATE SET project_id=EXCLUDED.project_id`
+
+	tag, err := pg.db.Exec(context.Background(), stmt, userID, key, projectID)
+	if err != nil {
+		return fmt.Errorf("error inserting primary project override: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: track %d is not on project %d", ErrNotFound, key, projectID)
+	}
+
+	return nil
+}
+
+func (pg *PGDB) DeleteUserPrimaryProject(userID uint64, key uint64) error {
+	const stmt = `DELETE FROM user_primary_project WHERE user_id=$1 AND track_id=$2`
+
+	if _, err := pg.db.Exec(context.Background(), stmt, userID, key); err != nil {
+		return fmt.Errorf("error deleting primary project override: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPrimaryPolicyCountsSpins(t *testing.T) {
+	if DefaultPrimaryPolicy.CountsSpins() {
+		t.Fatalf("expected the default policy not to count spins")
+	}
+	policy, err := ParsePrimaryPolicy("original,spins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.CountsSpins() {
+		t.Fatalf("expected the policy to count spins")
+	}
+}
+
+func TestPrimaryPolicy(t *testing.T) {
+	release := time.Date(2023, 9, 8, 0, 0, 0, 0, time.UTC)
+	album := Project{ID: 1, Title: "GUTS", Form: Album, Release: release}
+	deluxe := Project{ID: 2, Title: "GUTS (Deluxe)", Form: Album, Release: release}
+	single := Project{ID: 3, Title: "vampire", Form: Single, Release: release.AddDate(0, -3, 0)}
+	reissue := Project{ID: 4, Title: "GUTS", Form: Album, Release: release.AddDate(1, 0, 0)}
+	candidates := []Project{deluxe, single, reissue, album}
+
+	spinPolicy, err := ParsePrimaryPolicy("spins, original, form, release")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   PrimaryPolicy
+		ctx      PrimaryContext
+		expected uint64
+		reason   string
+	}{
+		{"Should prefer earliest original album", DefaultPrimaryPolicy, PrimaryContext{}, 1, "policy"},
+		{"Should prefer most spun project", spinPolicy, PrimaryContext{Spins: map[uint64]uint64{3: 5, 1: 2}}, 3, "policy"},
+		{"Should keep pinned project", DefaultPrimaryPolicy, PrimaryContext{Pinned: 2}, 2, "pinned"},
+		{"Should prefer override over pin", DefaultPrimaryPolicy, PrimaryContext{Pinned: 2, Override: 4}, 4, "override"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.policy.Explain(candidates, tt.ctx)
+			if e.Chosen.ID != tt.expected || e.Reason != tt.reason {
+				t.Fatalf("expected project %d by %s but got %d by %s", tt.expected, tt.reason, e.Chosen.ID, e.Reason)
+			}
+		})
+	}
+
+	t.Run("Should name the deciding rule", func(t *testing.T) {
+		rules := map[uint64]string{}
+		for _, c := range DefaultPrimaryPolicy.Explain(candidates, PrimaryContext{}).Candidates {
+			rules[c.Project.ID] = c.Rule
+		}
+		expected := map[uint64]string{1: "", 2: "original", 3: "form", 4: "release"}
+		for id, rule := range expected {
+			if rules[id] != rule {
+				t.Fatalf("expected project %d to lose by %q but got %q", id, rule, rules[id])
+			}
+		}
+	})
+
+	t.Run("Should reject unknown rules", func(t *testing.T) {
+		if _, err := ParsePrimaryPolicy("original,popularity"); err == nil {
+			t.Fatalf("expected unknown rule to fail")
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package data
+
+// Privacy is who besides the user can see their listening data.
+type Privacy string
+
+const (
+	PublicPrivacy    Privacy = "public"
+	FollowersPrivacy Privacy = "followers"
+	PrivatePrivacy   Privacy = "private"
+)
+
+func (p Privacy) IsValid() bool {
+	return p == PublicPrivacy || p == FollowersPrivacy || p == PrivatePrivacy
+}
+
+// Feature is a part of a user's listening data others may be allowed to see.
+type Feature string
+
+const (
+	// StatsFeature covers statistics, charts, milestones and Wrapped reports.
+	StatsFeature Feature = "stats"
+	// HistoryFeature covers the spins a user recorded.
+	HistoryFeature Feature = "history"
+	// NowPlayingFeature covers the spin a user is listening to.
+	NowPlayingFeature Feature = "now-playing"
+	// FollowsFeature covers who a user follows and who follows them.
+	FollowsFeature Feature = "follows"
+)
+
+// Profile is a user with the settings that decide who sees their listening
+// data.
+type Profile struct {
+	ID   uint64
+	Name string
+	Settings
+}
+
+// PublicProfile is what anyone can see of a user, whatever their privacy.
+type PublicProfile struct {
+	ID          uint64
+	Name        string
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+	TimeZone    string
+	Country     string
+}
+
+// Public leaves out the settings of a profile that only the user sees.
+func (p Profile) Public() PublicProfile {
+	return PublicProfile{
+		ID:          p.ID,
+		Name:        p.Name,
+		DisplayName: p.DisplayName,
+		Bio:         p.Bio,
+		AvatarURL:   p.AvatarURL,
+		TimeZone:    p.TimeZone,
+		Country:     p.Country,
+	}
+}
+
+// CanView reports whether a viewer, 0 when anonymous, with a follow status
+// towards the user can see a feature of the user's listening data. Users
+// always see their own. Hiding the history also hides what the user is
+// playing now.
+func (p Profile) CanView(viewerID uint64, status FollowStatus, f Feature) bool {
+	if viewerID != 0 && viewerID == p.ID {
+		return true
+	}
+	switch p.Privacy {
+	case PublicPrivacy:
+	case FollowersPrivacy:
+		if status != Following {
+			return false
+		}
+	default:
+		return false
+	}
+	switch f {
+	case HistoryFeature:
+		return !p.HideHistory
+	case NowPlayingFeature:
+		return !p.HideHistory && !p.HideNowPlaying
+	default:
+		return true
+	}
+}
@@ -0,0 +1,91 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const settingsColumns = `display_name, bio, avatar_url, time_zone, country, privacy, hide_now_playing, hide_history`
+
+func scanSettings(row pgx.Row, dest ...any) (Settings, error) {
+	var s Settings
+	err := row.Scan(append([]any{&s.DisplayName, &s.Bio, &s.AvatarURL, &s.TimeZone, &s.Country, &s.Privacy, &s.HideNowPlaying, &s.HideHistory}, dest...)...)
+	return s, err
+}
+
+func (pg *PGDB) GetProfile(name string) (Profile, error) {
+	const stmt = `SELECT ` + settingsColumns + `, id, name FROM "user" WHERE name=$1`
+
+	var p Profile
+	s, err := scanSettings(pg.db.QueryRow(context.Background(), stmt, name), &p.ID, &p.Name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Profile{}, fmt.Errorf("%w: user %q", ErrNotFound, name)
+	} else if err != nil {
+		return Profile{}, fmt.Errorf("error selecting profile: %w", err)
+	}
+	p.Settings = s
+	return p, nil
+}
+
+func (pg *PGDB) GetSettings(userID uint64) (Settings, error) {
+	const stmt = `SELECT ` + settingsColumns + ` FROM "user" WHERE id=$1`
+
+	s, err := scanSettings(pg.db.QueryRow(context.Background(), stmt, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Settings{}, fmt.Errorf("%w: user %d", ErrNotFound, userID)
+	} else if err != nil {
+		return Settings{}, fmt.Errorf("error selecting settings: %w", err)
+	}
+	return s, nil
+}
+
+// UpdateSettings saves a user's settings. Their rollups and streak count days
+// in their time zone, so a new time zone rebuilds them. Making the profile public
+// accepts the follow requests waiting for the user.
+func (pg *PGDB) UpdateSettings(userID uint64, s Settings) error {
+	const selectTimeZone = `SELECT time_zone FROM "user" WHERE id=$1 FOR UPDATE`
+	const stmt = `UPDATE "user" SET display_name=$2, bio=$3, avatar_url=$4, time_zone=$5, country=$6, privacy=$7, hide_now_playing=$8, hide_history=$9
+	WHERE id=$1`
+	const acceptFollows = `UPDATE follow SET accepted=true WHERE followee_id=$1 AND NOT accepted`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting settings update: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var timeZone string
+	err = tx.QueryRow(ctx, selectTimeZone, userID).Scan(&timeZone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: user %d", ErrNotFound, userID)
+	} else if err != nil {
+		return fmt.Errorf("error selecting settings: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, stmt, userID, s.DisplayName, s.Bio, s.AvatarURL, s.TimeZone, s.Country, string(s.Privacy), s.HideNowPlaying, s.HideHistory)
+	if err != nil {
+		return fmt.Errorf("error updating settings: %w", err)
+	}
+	if s.Privacy == PublicPrivacy {
+		if _, err := tx.Exec(ctx, acceptFollows, userID); err != nil {
+			return fmt.Errorf("error accepting follows: %w", err)
+		}
+	}
+	if s.TimeZone != timeZone {
+		if err := rebuildRollups(tx, userID); err != nil {
+			return err
+		}
+		if err := rebuildStreak(tx, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package data
+
+import "testing"
+
+func TestProfileCanView(t *testing.T) {
+	public := Profile{ID: 1, Settings: Settings{Privacy: PublicPrivacy}}
+	noHistory := Profile{ID: 1, Settings: Settings{Privacy: PublicPrivacy, HideHistory: true}}
+	noNowPlaying := Profile{ID: 1, Settings: Settings{Privacy: PublicPrivacy, HideNowPlaying: true}}
+	followers := Profile{ID: 1, Settings: Settings{Privacy: FollowersPrivacy}}
+	private := Profile{ID: 1, Settings: Settings{Privacy: PrivatePrivacy, HideHistory: true}}
+
+	tests := []struct {
+		name     string
+		profile  Profile
+		viewerID uint64
+		status   FollowStatus
+		feature  Feature
+		expected bool
+	}{
+		{"Should show public stats to anyone", public, 0, NotFollowing, StatsFeature, true},
+		{"Should show public history to others", public, 2, NotFollowing, HistoryFeature, true},
+		{"Should hide history", noHistory, 2, Following, HistoryFeature, false},
+		{"Should keep stats when hiding history", noHistory, 2, NotFollowing, StatsFeature, true},
+		{"Should hide now playing with history", noHistory, 2, NotFollowing, NowPlayingFeature, false},
+		{"Should hide now playing", noNowPlaying, 2, NotFollowing, NowPlayingFeature, false},
+		{"Should keep history when hiding now playing", noNowPlaying, 2, NotFollowing, HistoryFeature, true},
+		{"Should hide followers-only stats from others", followers, 2, NotFollowing, StatsFeature, false},
+		{"Should hide followers-only stats from pending followers", followers, 2, PendingFollow, StatsFeature, false},
+		{"Should show followers-only stats to followers", followers, 2, Following, StatsFeature, true},
+		{"Should hide private stats from anonymous", private, 0, NotFollowing, StatsFeature, false},
+		{"Should hide private stats from followers", private, 2, Following, StatsFeature, false},
+		{"Should show private history to owner", private, 1, NotFollowing, HistoryFeature, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.profile.CanView(tt.viewerID, tt.status, tt.feature); actual != tt.expected {
+				t.Fatalf("expected %t but got %t", tt.expected, actual)
+			}
+		})
+	}
+}
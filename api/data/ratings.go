@@ -0,0 +1,32 @@
+package data
+
+import "time"
+
+// MAX_STARS is the highest rating, given in half stars from 0.5 up.
+const MAX_STARS = 5
+
+// Rating is what a user thinks of an artist, track or project: Stars from 0.5
+// to MAX_STARS, 0 when unrated, whether it is one of their favorites and,
+// for projects, their notes on it. Spins and MsPlayed are all the user's
+// listening to it.
+type Rating struct {
+	Entity    EntityType
+	ID        uint64
+	Title     string
+	Stars     float64
+	Favorite  bool
+	Note      string
+	Spins     uint64
+	MsPlayed  uint64
+	UpdatedAt time.Time
+}
+
+// RatingFilter picks the ratings of a user rated at least MinStars, only
+// favorites when Favorites is set, and played at most MaxSpins times unless
+// it is nil.
+type RatingFilter struct {
+	MinStars  float64
+	MaxSpins  *uint64
+	Favorites bool
+	Limit     int
+}
@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ratingTable is where users' ratings of an entity are kept, by the column
+// of its ID. note selects the notes of a rating r, which only projects have.
+type ratingTable struct {
+	table  string
+	column string
+	note   string
+}
+
+var ratingTables = map[EntityType]ratingTable{
+	ArtistEntity:  {"artist_rating", "artist_id", `''`},
+	TrackEntity:   {"track_rating", "track_id", `''`},
+	ProjectEntity: {"project_rating", "project_id", `COALESCE(r.note, '')`},
+}
+
+// ratingsQuery selects the ratings r of the user in $1 for an entity along
+// with their listening to each, matching a condition.
+func ratingsQuery(entity EntityType, cond string) (string, error) {
+	t, ok := ratingTables[entity]
+	source, sourceOK := chartSources[entity]
+	if !ok || !sourceOK {
+		return "", fmt.Errorf("unknown rating entity %q", entity)
+	}
+	return `WITH ` + source.rollup.userListening() + `,
+	e AS (` + source.entries + `),
+	p AS (
+		SELECT id, sum(spins)::bigint AS spins, sum(ms_played)::bigint AS ms_played FROM e GROUP BY id
+	)
+	SELECT r.` + t.column + `, x.` + source.title + `, COALESCE(r.half_stars, 0), r.favorite, ` + t.note + `,
+		COALESCE(p.spins, 0), COALESCE(p.ms_played, 0), r.updated_at
+	FROM ` + t.table + ` r
+	JOIN ` + source.table + ` x ON r.` + t.column + ` = x.id
+	LEFT JOIN p ON r.` + t.column + ` = p.id
+	WHERE r.user_id=$1 AND ` + cond, nil
+}
+
+func scanRating(entity EntityType, row pgx.Row) (Rating, error) {
+	r := Rating{Entity: entity}
+	var halfStars int
+	err := row.Scan(&r.ID, &r.Title, &halfStars, &r.Favorite, &r.Note, &r.Spins, &r.MsPlayed, &r.UpdatedAt)
+	r.Stars = float64(halfStars) / 2
+	return r, err
+}
+
+// GetRating returns what a user thinks of an artist, track or project.
+func (pg *PGDB) GetRating(userID uint64, entity EntityType, id uint64) (Rating, error) {
+	t := ratingTables[entity]
+	stmt, err := ratingsQuery(entity, `r.`+t.column+`=$2`)
+	if err != nil {
+		return Rating{}, err
+	}
+
+	r, err := scanRating(entity, pg.db.QueryRow(context.Background(), stmt, userID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Rating{}, fmt.Errorf("%w: rating of %s %d", ErrNotFound, entity, id)
+	} else if err != nil {
+		return Rating{}, fmt.Errorf("error selecting rating: %w", err)
+	}
+	return r, nil
+}
+
+// GetRatings returns the ratings of a user for artists, tracks or projects
+// that a filter picks, best rated and then most played first.
+func (pg *PGDB) GetRatings(userID uint64, entity EntityType, filter RatingFilter) ([]Rating, error) {
+	stmt, err := ratingsQuery(entity, `COALESCE(r.half_stars, 0) >= $2 AND (r.favorite OR NOT $3)
+		AND ($4::bigint IS NULL OR COALESCE(p.spins, 0) <= $4)
+	ORDER BY r.half_stars DESC NULLS LAST, COALESCE(p.spins, 0) DESC, r.updated_at DESC
+	LIMIT $5`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, int(filter.MinStars*2), filter.Favorites, filter.MaxSpins, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting ratings: %w", err)
+	}
+	ratings, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Rating, error) {
+		return scanRating(entity, row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting ratings: %w", err)
+	}
+	return ratings, nil
+}
+
+// SetRating rates an artist, track or project in half stars, or takes the
+// rating back when halfStars is 0.
+func (pg *PGDB) SetRating(userID uint64, entity EntityType, id uint64, halfStars int) error {
+	var value *int
+	if halfStars != 0 {
+		value = &halfStars
+	}
+	return pg.setRating(userID, entity, id, "half_stars", value)
+}
+
+// SetFavorite adds an artist, track or project to a user's favorites, or
+// removes it.
+func (pg *PGDB) SetFavorite(userID uint64, entity EntityType, id uint64, favorite bool) error {
+	return pg.setRating(userID, entity, id, "favorite", favorite)
+}
+
+// SetNote saves a user's notes on a project, or deletes them when empty.
+func (pg *PGDB) SetNote(userID uint64, projectID uint64, note string) error {
+	var value *string
+	if note != "" {
+		value = &note
+	}
+	return pg.setRating(userID, ProjectEntity, projectID, "note", value)
+}
+
+// setRating sets a column of a user's rating of an artist, track or project,
+// deleting the rating once nothing of it is left.
+func (pg *PGDB) setRating(userID uint64, entity EntityType, id uint64, column string, value any) error {
+	t, ok := ratingTables[entity]
+	if !ok {
+		return fmt.Errorf("unknown rating entity %q", entity)
+	}
+	upsert := `INSERT INTO ` + t.table + ` (user_id, ` + t.column + `, ` + column + `)
+	SELECT $1, x.id, $3 FROM ` + chartSources[entity].table + ` x WHERE x.id=$2
+	ON CONFLICT (user_id, ` + t.column + `) DO UPDATE SET ` + column + `=EXCLUDED.` + column + `, updated_at=now()`
+	clear := `DELETE FROM ` + t.table + ` r
+	WHERE r.user_id=$1 AND r.` + t.column + `=$2 AND r.half_stars IS NULL AND NOT r.favorite AND ` + t.note + ` = ''`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting rating update: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, upsert, userID, id, value)
+	if err != nil {
+		return fmt.Errorf("error updating rating: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s %d", ErrNotFound, entity, id)
+	}
+	if _, err := tx.Exec(ctx, clear, userID, id); err != nil {
+		return fmt.Errorf("error deleting rating: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing rating: %w", err)
+	}
+	return nil
+}
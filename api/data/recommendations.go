@@ -0,0 +1,33 @@
+package data
+
+const (
+	// NEIGHBOR_LIMIT is how many of the most similar artists or tracks are
+	// kept for each.
+	NEIGHBOR_LIMIT = 50
+	// NEIGHBOR_MIN_USERS is how many users have to have played two artists
+	// or tracks in the same session for them to be neighbors, so that no
+	// recommendation gives away a single user's listening.
+	NEIGHBOR_MIN_USERS = 2
+	// RECOMMENDATION_SEEDS is how many of a user's most played artists or
+	// tracks recommendations start from.
+	RECOMMENDATION_SEEDS = 50
+	// RECOMMENDATION_HEAVY_SPINS is how many times a user has to have played
+	// an artist or track for it to be left out of their recommendations.
+	RECOMMENDATION_HEAVY_SPINS = 10
+)
+
+// Recommendation is an artist or track a user might like, because it is
+// played in the same sessions as what they listen to. Because is what they
+// listen to that it is most similar to.
+type Recommendation struct {
+	Entity  EntityType
+	ID      uint64
+	Title   string
+	Score   float64
+	Because RecommendationSeed
+}
+
+type RecommendationSeed struct {
+	ID    uint64
+	Title string
+}
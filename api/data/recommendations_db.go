@@ -0,0 +1,189 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type neighborSource struct {
+	rollup rollup
+	items  string
+	table  string
+	title  string
+}
+
+// neighborSources select the artists or tracks of the sessions ss as id, once
+// per session.
+var neighborSources = map[EntityType]neighborSource{
+	ArtistEntity: {
+		artistRollup,
+		`SELECT DISTINCT ss.user_id, ss.session, at.artist_id AS id FROM ss JOIN artist_track at ON ss.track_id = at.track_id`,
+		"artist",
+		"name",
+	},
+	TrackEntity: {
+		trackRollup,
+		`SELECT DISTINCT user_id, session, track_id AS id FROM ss`,
+		"track",
+		"title",
+	},
+}
+
+// RebuildNeighbors recomputes the artists or tracks most often played in the
+// same sessions as each, from the spins since a time split wherever a user
+// paused for longer than a gap, and returns how many pairs of neighbors were
+// stored. Neighbors are scored by the cosine of the
+// users who played them in a session together and of those who played each.
+func (pg *PGDB) RebuildNeighbors(entity EntityType, since time.Time, gap time.Duration) (int64, error) {
+	source, ok := neighborSources[entity]
+	if !ok {
+		return 0, fmt.Errorf("unknown neighbor entity %q", entity)
+	}
+	const clearNeighbors = `DELETE FROM item_neighbor WHERE entity=$1`
+	insertNeighbors := `WITH s AS (
+		SELECT s.user_id, s.track_id, s.time,
+			CASE WHEN s.time <= lag(s.time + ` + msPlayed + ` * interval '1 millisecond') OVER w + $3::bigint * interval '1 millisecond'
+				THEN 0 ELSE 1 END AS starts
+		FROM spin s
+		JOIN track t ON s.track_id = t.id
+		WHERE s.time >= $2
+		WINDOW w AS (PARTITION BY s.user_id ORDER BY s.time)
+	),
+	ss AS (
+		SELECT user_id, track_id, sum(starts) OVER (PARTITION BY user_id ORDER BY time) AS session FROM s
+	),
+	i AS (` + source.items + `),
+	users AS (
+		SELECT id, count(DISTINCT user_id) AS users FROM i GROUP BY id
+	),
+	pairs AS (
+		SELECT a.id, b.id AS neighbor_id, count(DISTINCT a.user_id) AS users
+		FROM i a
+		JOIN i b ON a.user_id = b.user_id AND a.session = b.session AND a.id <> b.id
+		GROUP BY a.id, b.id
+		HAVING count(DISTINCT a.user_id) >= $4
+	),
+	scored AS (
+		SELECT p.id, p.neighbor_id, p.users / sqrt(ua.users * ub.users) AS score
+		FROM pairs p
+		JOIN users ua ON p.id = ua.id
+		JOIN users ub ON p.neighbor_id = ub.id
+	),
+	ranked AS (
+		SELECT id, neighbor_id, score, row_number() OVER (PARTITION BY id ORDER BY score DESC, neighbor_id) AS n FROM scored
+	)
+	INSERT INTO item_neighbor (entity, item_id, neighbor_id, score)
+	SELECT $1, id, neighbor_id, score FROM ranked WHERE n <= $5`
+	const markBuilt = `INSERT INTO item_neighbor_build (entity, built_at) VALUES ($1, now())
+	ON CONFLICT (entity) DO UPDATE SET built_at=EXCLUDED.built_at`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting neighbor rebuild: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, clearNeighbors, string(entity)); err != nil {
+		return 0, fmt.Errorf("error deleting neighbors: %w", err)
+	}
+	tag, err := tx.Exec(ctx, insertNeighbors, string(entity), since, gap.Milliseconds(), NEIGHBOR_MIN_USERS, NEIGHBOR_LIMIT)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting neighbors: %w", err)
+	}
+	if _, err := tx.Exec(ctx, markBuilt, string(entity)); err != nil {
+		return 0, fmt.Errorf("error marking neighbors built: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing neighbors: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// dropNeighbors deletes the neighbors of a merged artist or track and its
+// place among those of others. Their scores cannot be added up, so the
+// target only gets them back at the next rebuild.
+func dropNeighbors(tx pgx.Tx, entity EntityType, id uint64) error {
+	const stmt = `DELETE FROM item_neighbor WHERE entity=$1 AND (item_id=$2 OR neighbor_id=$2)`
+
+	if _, err := tx.Exec(context.Background(), stmt, string(entity), id); err != nil {
+		return fmt.Errorf("error deleting neighbors: %w", err)
+	}
+	return nil
+}
+
+// GetNeighborsBuiltAt returns when the neighbors of artists or tracks were
+// last rebuilt, or the zero time when they never were.
+func (pg *PGDB) GetNeighborsBuiltAt(entity EntityType) (time.Time, error) {
+	const stmt = `SELECT built_at FROM item_neighbor_build WHERE entity=$1`
+
+	var builtAt time.Time
+	err := pg.db.QueryRow(context.Background(), stmt, string(entity)).Scan(&builtAt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, fmt.Errorf("error selecting neighbor build: %w", err)
+	}
+	return builtAt, nil
+}
+
+// GetRecommendations returns the artists or tracks most similar to what a
+// user played most on the days since one, weighted by how often they played
+// it. What the user already played heavily is left out.
+func (pg *PGDB) GetRecommendations(userID uint64, entity EntityType, since time.Time, limit int) ([]Recommendation, error) {
+	source, ok := neighborSources[entity]
+	if !ok {
+		return nil, fmt.Errorf("unknown recommendation entity %q", entity)
+	}
+	r := source.rollup
+	stmt := `WITH seeds AS (
+		SELECT r.` + r.column + ` AS id, sum(r.spins)::float8 AS spins
+		FROM ` + r.table + ` r
+		WHERE r.user_id=$1 AND r.day >= $2
+		GROUP BY r.` + r.column + `
+		ORDER BY sum(r.ms_played) DESC, spins DESC, id
+		LIMIT $3
+	),
+	played AS (
+		SELECT r.` + r.column + ` AS id
+		FROM ` + r.table + ` r
+		WHERE r.user_id=$1
+		GROUP BY r.` + r.column + `
+		HAVING sum(r.spins) >= $4
+	),
+	c AS (
+		SELECT n.neighbor_id AS id, n.item_id AS seed_id, n.score * s.spins / (SELECT sum(spins) FROM seeds) AS score
+		FROM seeds s
+		JOIN item_neighbor n ON n.entity=$5 AND n.item_id = s.id
+		WHERE n.neighbor_id NOT IN (SELECT id FROM seeds) AND n.neighbor_id NOT IN (SELECT id FROM played)
+	),
+	top AS (
+		SELECT id, sum(score) AS score, (array_agg(seed_id ORDER BY score DESC))[1] AS seed_id
+		FROM c
+		GROUP BY id
+		ORDER BY score DESC, id
+		LIMIT $6
+	)
+	SELECT top.id, x.` + source.title + `, top.score, top.seed_id, y.` + source.title + `
+	FROM top
+	JOIN ` + source.table + ` x ON top.id = x.id
+	JOIN ` + source.table + ` y ON top.seed_id = y.id
+	ORDER BY top.score DESC, top.id`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, since, RECOMMENDATION_SEEDS, RECOMMENDATION_HEAVY_SPINS, string(entity), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting recommendations: %w", err)
+	}
+	recommendations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Recommendation, error) {
+		rec := Recommendation{Entity: entity}
+		err := row.Scan(&rec.ID, &rec.Title, &rec.Score, &rec.Because.ID, &rec.Because.Title)
+		return rec, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting recommendations: %w", err)
+	}
+	return recommendations, nil
+}
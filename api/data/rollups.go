@@ -0,0 +1,250 @@
+package data
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// rollup is a table summing each user's spins by day in their time zone and
+// by track or artist. spinID selects the entity of a spin, as s joined with
+// its track as t and whatever spinJoin joins.
+type rollup struct {
+	table    string
+	column   string
+	spinID   string
+	spinJoin string
+}
+
+var trackRollup = rollup{"user_track_day", "track_id", "t.id", ""}
+
+// Spins count once for each artist of their track, however often the
+// junction table lists them.
+var artistRollup = rollup{"user_artist_day", "artist_id", "at.artist_id", `
+		JOIN LATERAL (SELECT DISTINCT artist_id FROM artist_track WHERE track_id = t.id) at ON true`}
+
+// rollupBounds selects as b the time zone of the user in $1 and the days in
+// it that lie wholly between $2 and $3, from first_day up to end_day, along
+// with the times they start at.
+const rollupBounds = `b AS (
+		SELECT u.time_zone, d.first_day, d.end_day,
+			d.first_day::timestamp AT TIME ZONE u.time_zone AS first_start,
+			d.end_day::timestamp AT TIME ZONE u.time_zone AS end_start
+		FROM "user" u, LATERAL (SELECT
+			(($2::timestamptz AT TIME ZONE u.time_zone) + interval '1 day' - interval '1 microsecond')::date AS first_day,
+			($3::timestamptz AT TIME ZONE u.time_zone)::date AS end_day
+		) d
+		WHERE u.id=$1
+	)`
+
+// listening selects, after rollupBounds, the listening of the user in $1
+// between $2 and $3 as l, with the day it happened on in their time zone and
+// the ID of the track or artist. Whole days are read from the rollup and only
+// the partial days at the edges of the range from spin.
+func (r rollup) listening() string {
+	return rollupBounds + `, l AS (
+		SELECT r.day::timestamp AS day, r.` + r.column + ` AS id, r.spins, r.ms_played
+		FROM b
+		JOIN ` + r.table + ` r ON r.user_id=$1 AND r.day >= b.first_day AND r.day < b.end_day
+		UNION ALL
+		SELECT date_trunc('day', s.time AT TIME ZONE b.time_zone), ` + r.spinID + `, 1, ` + msPlayed + `
+		FROM b
+		JOIN spin s ON s.user_id=$1 AND (
+			(s.time >= $2 AND s.time < LEAST(b.first_start, $3)) OR
+			(s.time >= GREATEST(b.end_start, b.first_start, $2) AND s.time < $3))
+		JOIN track t ON s.track_id = t.id` + r.spinJoin + `
+	)`
+}
+
+// dayListening selects the listening of the users that users selects as
+// user_id on the days from $2 up to $3 as l, with the user and the ID of the
+// track or artist. Each user's days are the ones of their time zone, so a week
+// is every user's own Monday to Sunday.
+func (r rollup) dayListening(users string) string {
+	return `l AS (
+		SELECT r.user_id, r.` + r.column + ` AS id, r.spins, r.ms_played
+		FROM (` + users + `) m
+		JOIN ` + r.table + ` r ON r.user_id = m.user_id AND r.day >= $2 AND r.day < $3
+	)`
+}
+
+// userListening selects all the listening of the user in $1 as l, with the
+// user and the ID of the track or artist.
+func (r rollup) userListening() string {
+	return `l AS (
+		SELECT r.user_id, r.` + r.column + ` AS id, r.spins, r.ms_played
+		FROM ` + r.table + ` r
+		WHERE r.user_id=$1
+	)`
+}
+
+// addToRollups counts a new spin in the rollups and the total of its user.
+func addToRollups(tx pgx.Tx, spinID uint64) error {
+	const addTotal = `INSERT INTO user_spin_total (user_id, spins)
+	SELECT user_id, 1 FROM spin WHERE id=$1
+	ON CONFLICT (user_id) DO UPDATE SET spins=user_spin_total.spins + 1`
+
+	for _, r := range []rollup{trackRollup, artistRollup} {
+		stmt := `INSERT INTO ` + r.table + ` (user_id, day, ` + r.column + `, spins, ms_played)
+		SELECT s.user_id, (s.time AT TIME ZONE u.time_zone)::date, ` + r.spinID + `, 1, ` + msPlayed + `
+		FROM spin s
+		JOIN "user" u ON s.user_id = u.id
+		JOIN track t ON s.track_id = t.id` + r.spinJoin + `
+		WHERE s.id=$1
+		ON CONFLICT (user_id, day, ` + r.column + `) DO UPDATE
+		SET spins=` + r.table + `.spins + EXCLUDED.spins, ms_played=` + r.table + `.ms_played + EXCLUDED.ms_played`
+
+		if _, err := tx.Exec(context.Background(), stmt, spinID); err != nil {
+			return fmt.Errorf("error updating %s: %w", r.table, err)
+		}
+	}
+	if _, err := tx.Exec(context.Background(), addTotal, spinID); err != nil {
+		return fmt.Errorf("error updating user_spin_total: %w", err)
+	}
+	return nil
+}
+
+// removeFromRollups takes the spins s of the user in $1 matching a condition
+// back out of their rollups and total, dropping the days left without any
+// spins.
+func removeFromRollups(tx pgx.Tx, cond string, args ...any) error {
+	subtractTotal := `UPDATE user_spin_total SET spins=spins - (SELECT count(*) FROM spin s WHERE s.user_id=$1 AND ` + cond + `)
+	WHERE user_id=$1`
+
+	for _, r := range []rollup{trackRollup, artistRollup} {
+		subtract := `UPDATE ` + r.table + ` r SET spins=r.spins - d.spins, ms_played=r.ms_played - d.ms_played
+		FROM (
+			SELECT (s.time AT TIME ZONE u.time_zone)::date AS day, ` + r.spinID + ` AS entity_id, count(*) AS spins, sum(` + msPlayed + `) AS ms_played
+			FROM spin s
+			JOIN "user" u ON s.user_id = u.id
+			JOIN track t ON s.track_id = t.id` + r.spinJoin + `
+			WHERE s.user_id=$1 AND ` + cond + `
+			GROUP BY 1, 2
+		) d
+		WHERE r.user_id=$1 AND r.day = d.day AND r.` + r.column + ` = d.entity_id`
+		clear := `DELETE FROM ` + r.table + ` WHERE user_id=$1 AND spins <= 0`
+
+		if _, err := tx.Exec(context.Background(), subtract, args...); err != nil {
+			return fmt.Errorf("error updating %s: %w", r.table, err)
+		}
+		if _, err := tx.Exec(context.Background(), clear, args[0]); err != nil {
+			return fmt.Errorf("error updating %s: %w", r.table, err)
+		}
+	}
+	if _, err := tx.Exec(context.Background(), subtractTotal, args...); err != nil {
+		return fmt.Errorf("error updating user_spin_total: %w", err)
+	}
+	return nil
+}
+
+// moveRollups adds the rollups of a merged track or artist to the ones of
+// its target.
+func moveRollups(tx pgx.Tx, r rollup, fromID, intoID uint64) error {
+	move := `INSERT INTO ` + r.table + ` (user_id, day, ` + r.column + `, spins, ms_played)
+	SELECT user_id, day, $2, spins, ms_played FROM ` + r.table + ` WHERE ` + r.column + `=$1
+	ON CONFLICT (user_id, day, ` + r.column + `) DO UPDATE
+	SET spins=` + r.table + `.spins + EXCLUDED.spins, ms_played=` + r.table + `.ms_played + EXCLUDED.ms_played`
+	remove := `DELETE FROM ` + r.table + ` WHERE ` + r.column + `=$1`
+
+	if _, err := tx.Exec(context.Background(), move, fromID, intoID); err != nil {
+		return fmt.Errorf("error merging %s: %w", r.table, err)
+	}
+	if _, err := tx.Exec(context.Background(), remove, fromID); err != nil {
+		return fmt.Errorf("error merging %s: %w", r.table, err)
+	}
+	return nil
+}
+
+// markRollupsStale marks the rollups of the users with spins s matching a
+// condition for a rebuild.
+func markRollupsStale(tx pgx.Tx, cond string, args ...any) error {
+	stmt := `INSERT INTO rollup_stale (user_id)
+	SELECT DISTINCT s.user_id FROM spin s WHERE ` + cond + `
+	ON CONFLICT (user_id) DO UPDATE SET marked_at=now()`
+
+	if _, err := tx.Exec(context.Background(), stmt, args...); err != nil {
+		return fmt.Errorf("error marking rollups stale: %w", err)
+	}
+	return nil
+}
+
+// MarkRollupsStale marks the rollups of a user for a rebuild, or the ones of
+// every user who listened to something when userID is 0.
+func (pg *PGDB) MarkRollupsStale(userID uint64) error {
+	const stmt = `INSERT INTO rollup_stale (user_id)
+	SELECT id FROM "user" u WHERE id=$1 OR ($1=0 AND EXISTS (SELECT 1 FROM spin WHERE user_id=u.id))
+	ON CONFLICT (user_id) DO UPDATE SET marked_at=now()`
+
+	if _, err := pg.db.Exec(context.Background(), stmt, userID); err != nil {
+		return fmt.Errorf("error marking rollups stale: %w", err)
+	}
+	return nil
+}
+
+// GetStaleRollups returns the users whose rollups need a rebuild, longest
+// waiting first.
+func (pg *PGDB) GetStaleRollups(limit int) ([]uint64, error) {
+	const stmt = `SELECT user_id FROM rollup_stale ORDER BY marked_at, user_id LIMIT $1`
+
+	rows, err := pg.db.Query(context.Background(), stmt, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting stale rollups: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uint64])
+	if err != nil {
+		return nil, fmt.Errorf("error selecting stale rollups: %w", err)
+	}
+
+	return ids, nil
+}
+
+// RebuildRollups recomputes the rollups and total of a user from their
+// spins.
+func (pg *PGDB) RebuildRollups(userID uint64) error {
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting rollup rebuild: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := rebuildRollups(tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing rollup rebuild: %w", err)
+	}
+	return nil
+}
+
+func rebuildRollups(tx pgx.Tx, userID uint64) error {
+	const deleteTracks = `DELETE FROM user_track_day WHERE user_id=$1`
+	const deleteArtists = `DELETE FROM user_artist_day WHERE user_id=$1`
+	const insertTracks = `INSERT INTO user_track_day (user_id, day, track_id, spins, ms_played)
+	SELECT s.user_id, (s.time AT TIME ZONE u.time_zone)::date AS day, s.track_id, count(*), sum(` + msPlayed + `)
+	FROM spin s
+	JOIN "user" u ON s.user_id = u.id
+	JOIN track t ON s.track_id = t.id
+	WHERE s.user_id=$1
+	GROUP BY s.user_id, day, s.track_id`
+	const insertArtists = `INSERT INTO user_artist_day (user_id, day, artist_id, spins, ms_played)
+	SELECT r.user_id, r.day, at.artist_id, sum(r.spins), sum(r.ms_played)
+	FROM user_track_day r
+	JOIN LATERAL (SELECT DISTINCT artist_id FROM artist_track WHERE track_id = r.track_id) at ON true
+	WHERE r.user_id=$1
+	GROUP BY r.user_id, r.day, at.artist_id`
+	const upsertTotal = `INSERT INTO user_spin_total (user_id, spins)
+	SELECT $1, count(*) FROM spin WHERE user_id=$1
+	ON CONFLICT (user_id) DO UPDATE SET spins=EXCLUDED.spins`
+	const unmark = `DELETE FROM rollup_stale WHERE user_id=$1 AND marked_at <= now()`
+
+	for _, stmt := range []string{deleteTracks, deleteArtists, insertTracks, insertArtists, upsertTotal, unmark} {
+		if _, err := tx.Exec(context.Background(), stmt, userID); err != nil {
+			return fmt.Errorf("error rebuilding rollups: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SEARCH_CANDIDATE_FACTOR is how many times the limit of the best text
+// matches are ranked again by the user's plays.
+const SEARCH_CANDIDATE_FACTOR = 5
+
+type searchTable struct {
+	table   string
+	title   string
+	artists string
+	spins   string
+}
+
+var searchTables = map[EntityType]searchTable{
+	ArtistEntity: {
+		"artist", "name",
+		`'{}'::text[]`,
+		`s.track_id IN (SELECT track_id FROM artist_track WHERE artist_id=c.id)`,
+	},
+	ProjectEntity: {
+		"project", "title",
+		`ARRAY(SELECT DISTINCT a.name FROM artist a JOIN artist_project ap ON a.id = ap.artist_id WHERE ap.project_id=c.id ORDER BY a.name)`,
+		`s.project_id=c.id`,
+	},
+	TrackEntity: {
+		"track", "title",
+		`ARRAY(SELECT DISTINCT a.name FROM artist a JOIN artist_track at ON a.id = at.artist_id WHERE at.track_id=c.id ORDER BY a.name)`,
+		`s.track_id=c.id`,
+	},
+}
+
+// Search finds artists, projects and tracks whose names, titles or artist
+// names start with the words of the query, ignoring case and accents. Fuzzy
+// searches also find entries whose words are similar to the query, to
+// tolerate typos. Matches are ranked by how well they match and boosted by how
+// often the user played them.
+func (pg *PGDB) Search(query string, userID uint64, limit int, fuzzy bool) (SearchResults, error) {
+	var err error
+	r := SearchResults{}
+	if r.Artists, err = pg.search(ArtistEntity, query, userID, limit, fuzzy); err != nil {
+		return SearchResults{}, err
+	}
+	if r.Projects, err = pg.search(ProjectEntity, query, userID, limit, fuzzy); err != nil {
+		return SearchResults{}, err
+	}
+	if r.Tracks, err = pg.search(TrackEntity, query, userID, limit, fuzzy); err != nil {
+		return SearchResults{}, err
+	}
+	return r, nil
+}
+
+func (pg *PGDB) search(entity EntityType, query string, userID uint64, limit int, fuzzy bool) ([]SearchHit, error) {
+	st := searchTables[entity]
+	match := `e.search_vector @@ q.tsq`
+	if fuzzy {
+		match += ` OR q.text <% e.search_text`
+	}
+	stmt := `WITH q AS (SELECT to_tsquery('simple', search_normalize($1)) AS tsq, search_normalize($2) AS text),
+	candidates AS (
+		SELECT e.id, e.` + st.title + ` AS title, (ts_rank(e.search_vector, q.tsq) + word_similarity(q.text, e.search_text))::float8 AS score
+		FROM ` + st.table + ` e, q
+		WHERE ` + match + `
+		ORDER BY score DESC
+		LIMIT $4::int * ` + fmt.Sprint(SEARCH_CANDIDATE_FACTOR) + `
+	)
+	SELECT r.id, r.title, r.artists, r.score, r.user_spins FROM (
+		SELECT c.*, ` + st.artists + ` AS artists, (SELECT count(*) FROM spin s WHERE s.user_id=$3 AND ` + st.spins + `) AS user_spins
+		FROM candidates c
+	) r
+	ORDER BY r.score * (1 + ln(1 + r.user_spins)) DESC, r.id
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, tsQuery(query), query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching %ss: %w", entity, err)
+	}
+
+	hits, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (SearchHit, error) {
+		h := SearchHit{Entity: entity}
+		err := row.Scan(&h.ID, &h.Title, &h.Artists, &h.Score, &h.UserSpins)
+		return h, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error searching %ss: %w", entity, err)
+	}
+
+	return hits, nil
+}
+
+// tsQuery turns the words of a search into a prefix query, so "olivia gut"
+// matches "GUTS" by Olivia Rodrigo while the user is still typing.
+func tsQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
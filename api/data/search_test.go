@@ -0,0 +1,19 @@
+package data
+
+import "testing"
+
+func TestTSQuery(t *testing.T) {
+	tests := map[string]string{
+		"olivia gut":         "olivia:* & gut:*",
+		"  bad idea right?!": "bad:* & idea:* & right:*",
+		"Beyoncé":            "Beyoncé:*",
+		"a & b | !c:*":       "a:* & b:* & c:*",
+		"?!":                 "",
+	}
+
+	for query, expected := range tests {
+		if actual := tsQuery(query); actual != expected {
+			t.Fatalf("expected %q for %q but got %q", expected, query, actual)
+		}
+	}
+}
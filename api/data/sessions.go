@@ -0,0 +1,97 @@
+package data
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	// SESSION_GAP is the longest pause between the end of one spin and the
+	// next that keeps them in the same listening session.
+	SESSION_GAP = 30 * time.Minute
+	// FULL_ALBUM_MIN_TRACKS is how many tracks a project needs for playing it
+	// through to be an album listen rather than a spin of a single.
+	FULL_ALBUM_MIN_TRACKS = 2
+)
+
+// Session is a run of a user's spins with pauses no longer than the session
+// gap between them. End is when its last spin stopped playing. Album is the
+// project whose whole tracklist was played in order during it, if any, and
+// Entries are its spins, oldest first, when a single session is read.
+type Session struct {
+	ID       uint64
+	Start    time.Time
+	End      time.Time
+	Spins    uint64
+	MsPlayed uint64
+	Album    *SessionAlbum
+	Entries  []HistoryEntry
+}
+
+type SessionAlbum struct {
+	ID    uint64
+	Title string
+}
+
+// sessionSpin is a spin as sessions are detected from, with the project it
+// was played from.
+type sessionSpin struct {
+	Time      time.Time
+	MsPlayed  uint64
+	TrackID   uint64
+	ProjectID uint64
+}
+
+func (s sessionSpin) end() time.Time {
+	return s.Time.Add(time.Duration(s.MsPlayed) * time.Millisecond)
+}
+
+// detectSessions splits spins ordered by time into sessions wherever the
+// pause after a spin stopped playing is longer than a gap, and finds the
+// album listened to in each from the tracklists of the projects played.
+func detectSessions(spins []sessionSpin, gap time.Duration, tracklists map[uint64][]uint64) []Session {
+	sessions := []Session{}
+	for start := 0; start < len(spins); {
+		s := Session{Start: spins[start].Time, End: spins[start].end()}
+		end := start
+		for ; end < len(spins) && !spins[end].Time.After(s.End.Add(gap)); end++ {
+			if e := spins[end].end(); e.After(s.End) {
+				s.End = e
+			}
+			s.Spins++
+			s.MsPlayed += spins[end].MsPlayed
+		}
+		if id := fullAlbum(spins[start:end], tracklists); id != 0 {
+			s.Album = &SessionAlbum{ID: id}
+		}
+		sessions = append(sessions, s)
+		start = end
+	}
+	return sessions
+}
+
+// fullAlbum returns the project of the spins whose tracklist they played
+// through in order without anything in between, preferring the longest, or 0
+// when they played none.
+func fullAlbum(spins []sessionSpin, tracklists map[uint64][]uint64) uint64 {
+	tracks := make([]uint64, len(spins))
+	for i, s := range spins {
+		tracks[i] = s.TrackID
+	}
+
+	var album uint64
+	longest := 0
+	for _, s := range spins {
+		tracklist := tracklists[s.ProjectID]
+		if len(tracklist) < max(FULL_ALBUM_MIN_TRACKS, longest+1) {
+			continue
+		}
+		for i := 0; i+len(tracklist) <= len(tracks); i++ {
+			if slices.Equal(tracks[i:i+len(tracklist)], tracklist) {
+				album, longest = s.ProjectID, len(tracklist)
+				break
+			}
+		}
+	}
+	return album
+}
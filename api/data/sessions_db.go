@@ -0,0 +1,237 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// sessionColumns selects a Session from a listening_session ls left joined
+// with its album p.
+const sessionColumns = `ls.id, ls.started_at, ls.ended_at, ls.spins, ls.ms_played, COALESCE(p.id, 0), COALESCE(p.title, '')`
+
+func scanSession(row pgx.Row) (Session, error) {
+	var s Session
+	var album SessionAlbum
+	err := row.Scan(&s.ID, &s.Start, &s.End, &s.Spins, &s.MsPlayed, &album.ID, &album.Title)
+	if album.ID != 0 {
+		s.Album = &album
+	}
+	return s, err
+}
+
+// markSessionsStale marks the sessions of the users with spins s matching a
+// condition for detection again from the earliest of those spins on.
+func markSessionsStale(tx pgx.Tx, cond string, args ...any) error {
+	stmt := `UPDATE session_build b SET stale_from = LEAST(b.stale_from, m.first)
+	FROM (SELECT s.user_id, min(s.time) AS first FROM spin s WHERE ` + cond + ` GROUP BY s.user_id) m
+	WHERE b.user_id = m.user_id`
+
+	if _, err := tx.Exec(context.Background(), stmt, args...); err != nil {
+		return fmt.Errorf("error marking sessions stale: %w", err)
+	}
+	return nil
+}
+
+// GetUnsessionedUsers returns the users with spins their sessions were not
+// detected from yet, or detected with another gap.
+func (pg *PGDB) GetUnsessionedUsers(gap time.Duration, limit int) ([]uint64, error) {
+	const stmt = `SELECT u.id
+	FROM "user" u
+	LEFT JOIN session_build b ON u.id = b.user_id
+	WHERE CASE WHEN b.user_id IS NULL THEN EXISTS (SELECT 1 FROM spin s WHERE s.user_id = u.id)
+		ELSE b.gap_ms <> $1 OR b.stale_from IS NOT NULL OR EXISTS (SELECT 1 FROM spin s WHERE s.id > b.last_spin_id AND s.user_id = u.id)
+	END
+	ORDER BY u.id
+	LIMIT $2`
+
+	rows, err := pg.db.Query(context.Background(), stmt, gap.Milliseconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unsessioned users: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uint64])
+	if err != nil {
+		return nil, fmt.Errorf("error selecting unsessioned users: %w", err)
+	}
+	return ids, nil
+}
+
+// DetectSessions splits the spins of a user into sessions separated by pauses
+// longer than a gap and returns how many were stored. Only the sessions from
+// the one the earliest new or changed spin falls in on are detected again,
+// unless the gap changed since the last time.
+func (pg *PGDB) DetectSessions(userID uint64, gap time.Duration) (int, error) {
+	const selectBuild = `SELECT gap_ms, last_spin_id, stale_from FROM session_build WHERE user_id=$1 FOR UPDATE`
+	const selectFirstNew = `SELECT min(time) FROM spin WHERE user_id=$1 AND id > $2`
+	const selectSessionStart = `SELECT COALESCE(max(started_at), $2) FROM listening_session WHERE user_id=$1 AND started_at <= $2`
+	const clearSessions = `DELETE FROM listening_session WHERE user_id=$1 AND ($2::timestamptz IS NULL OR started_at >= $2)`
+	const selectLastSpin = `SELECT COALESCE(max(id), 0) FROM spin WHERE user_id=$1`
+	const selectSpins = `SELECT s.time, ` + msPlayed + `, s.track_id, COALESCE(s.project_id, 0)
+	FROM spin s
+	JOIN track t ON s.track_id = t.id
+	WHERE s.user_id=$1 AND ($2::timestamptz IS NULL OR s.time >= $2)
+	ORDER BY s.time, s.id`
+	const selectTracklists = `SELECT project_id, array_agg(track_id ORDER BY track_number)
+	FROM project_track
+	WHERE project_id = ANY($1::bigint[])
+	GROUP BY project_id
+	HAVING count(*) = count(track_number)`
+	const insertSession = `INSERT INTO listening_session (user_id, started_at, ended_at, spins, ms_played, album_id)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+	const markBuilt = `INSERT INTO session_build (user_id, gap_ms, last_spin_id) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET gap_ms=EXCLUDED.gap_ms, last_spin_id=EXCLUDED.last_spin_id, stale_from=NULL`
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting session detection: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// from is nil when every session is detected again.
+	var from *time.Time
+	var gapMs int64
+	var lastSpinID uint64
+	var staleFrom *time.Time
+	err = tx.QueryRow(ctx, selectBuild, userID).Scan(&gapMs, &lastSpinID, &staleFrom)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("error selecting session build: %w", err)
+	}
+	if err == nil && gapMs == gap.Milliseconds() {
+		if err := tx.QueryRow(ctx, selectFirstNew, userID, lastSpinID).Scan(&from); err != nil {
+			return 0, fmt.Errorf("error selecting new spins: %w", err)
+		}
+		if staleFrom != nil && (from == nil || staleFrom.Before(*from)) {
+			from = staleFrom
+		}
+		if from == nil {
+			return 0, nil
+		}
+		if err := tx.QueryRow(ctx, selectSessionStart, userID, *from).Scan(&from); err != nil {
+			return 0, fmt.Errorf("error selecting session start: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, clearSessions, userID, from); err != nil {
+		return 0, fmt.Errorf("error deleting sessions: %w", err)
+	}
+	if err := tx.QueryRow(ctx, selectLastSpin, userID).Scan(&lastSpinID); err != nil {
+		return 0, fmt.Errorf("error selecting last spin: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, selectSpins, userID, from)
+	if err != nil {
+		return 0, fmt.Errorf("error selecting session spins: %w", err)
+	}
+	spins, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (sessionSpin, error) {
+		var s sessionSpin
+		err := row.Scan(&s.Time, &s.MsPlayed, &s.TrackID, &s.ProjectID)
+		return s, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error selecting session spins: %w", err)
+	}
+
+	projectIDs := []uint64{}
+	for _, s := range spins {
+		if s.ProjectID != 0 {
+			projectIDs = append(projectIDs, s.ProjectID)
+		}
+	}
+	rows, err = tx.Query(ctx, selectTracklists, projectIDs)
+	if err != nil {
+		return 0, fmt.Errorf("error selecting tracklists: %w", err)
+	}
+	defer rows.Close()
+	tracklists := map[uint64][]uint64{}
+	for rows.Next() {
+		var id uint64
+		var tracks []uint64
+		if err := rows.Scan(&id, &tracks); err != nil {
+			return 0, fmt.Errorf("error selecting tracklists: %w", err)
+		}
+		tracklists[id] = tracks
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error selecting tracklists: %w", err)
+	}
+
+	sessions := detectSessions(spins, gap, tracklists)
+	for _, s := range sessions {
+		var albumID *uint64
+		if s.Album != nil {
+			albumID = &s.Album.ID
+		}
+		if _, err := tx.Exec(ctx, insertSession, userID, s.Start, s.End, s.Spins, s.MsPlayed, albumID); err != nil {
+			return 0, fmt.Errorf("error inserting session: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, markBuilt, userID, gap.Milliseconds(), lastSpinID); err != nil {
+		return 0, fmt.Errorf("error updating session build: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing sessions: %w", err)
+	}
+	return len(sessions), nil
+}
+
+// GetSessions returns a user's sessions that started before a time, latest
+// first, or only the ones they listened to an album in.
+func (pg *PGDB) GetSessions(userID uint64, before time.Time, limit int, albums bool) ([]Session, error) {
+	const stmt = `SELECT ` + sessionColumns + `
+	FROM listening_session ls
+	LEFT JOIN project p ON ls.album_id = p.id
+	WHERE ls.user_id=$1 AND ls.started_at < $2 AND (NOT $4 OR ls.album_id IS NOT NULL)
+	ORDER BY ls.started_at DESC
+	LIMIT $3`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, before, limit, albums)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting sessions: %w", err)
+	}
+	sessions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Session, error) {
+		return scanSession(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// GetSession returns a session of a user with its spins.
+func (pg *PGDB) GetSession(userID, sessionID uint64) (Session, error) {
+	const stmt = `SELECT ` + sessionColumns + `
+	FROM listening_session ls
+	LEFT JOIN project p ON ls.album_id = p.id
+	WHERE ls.id=$1 AND ls.user_id=$2`
+	const entriesStmt = `SELECT ` + historyColumns + `
+	FROM spin s
+	JOIN track t ON s.track_id = t.id
+	LEFT JOIN project p ON s.project_id = p.id
+	WHERE s.user_id=$1 AND s.time >= $2 AND s.time <= $3
+	ORDER BY s.time, s.id`
+
+	ctx := context.Background()
+	s, err := scanSession(pg.db.QueryRow(ctx, stmt, sessionID, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Session{}, fmt.Errorf("%w: session %d", ErrNotFound, sessionID)
+	} else if err != nil {
+		return Session{}, fmt.Errorf("error selecting session: %w", err)
+	}
+
+	rows, err := pg.db.Query(ctx, entriesStmt, userID, s.Start, s.End)
+	if err != nil {
+		return Session{}, fmt.Errorf("error selecting session spins: %w", err)
+	}
+	s.Entries, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (HistoryEntry, error) {
+		return scanHistoryEntry(row)
+	})
+	if err != nil {
+		return Session{}, fmt.Errorf("error selecting session spins: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectSessions(t *testing.T) {
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	minute := uint64(time.Minute.Milliseconds())
+	spin := func(offset time.Duration, minutes, trackID, projectID uint64) sessionSpin {
+		return sessionSpin{start.Add(offset), minutes * minute, trackID, projectID}
+	}
+
+	spins := []sessionSpin{
+		spin(0, 4, 1, 10),
+		spin(4*time.Minute, 3, 2, 10),
+		spin(7*time.Minute, 5, 3, 10),
+		// Paused for exactly the gap after the album ended.
+		spin(42*time.Minute, 3, 4, 20),
+		// Started a minute past the gap after the last spin ended.
+		spin(76*time.Minute, 3, 1, 10),
+		spin(79*time.Minute, 3, 3, 10),
+	}
+	tracklists := map[uint64][]uint64{10: {1, 2, 3}}
+
+	sessions := detectSessions(spins, SESSION_GAP, tracklists)
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions but got %+v", sessions)
+	}
+	if s := sessions[0]; !s.Start.Equal(start) || !s.End.Equal(start.Add(45*time.Minute)) || s.Spins != 4 || s.MsPlayed != 15*minute {
+		t.Fatalf("expected the album and the track after it in the first session but got %+v", s)
+	}
+	if s := sessions[0]; s.Album == nil || s.Album.ID != 10 {
+		t.Fatalf("expected the album to be listened to but got %+v", s.Album)
+	}
+	if s := sessions[1]; s.Spins != 2 || s.Album != nil {
+		t.Fatalf("expected the skipping session not to listen to the album but got %+v", s)
+	}
+}
+
+func TestFullAlbum(t *testing.T) {
+	tracklists := map[uint64][]uint64{
+		1: {1, 2, 3},
+		2: {1, 2, 3, 4},
+		3: {5},
+	}
+	spin := func(trackID, projectID uint64) sessionSpin {
+		return sessionSpin{TrackID: trackID, ProjectID: projectID}
+	}
+
+	tests := []struct {
+		name     string
+		spins    []sessionSpin
+		expected uint64
+	}{
+		{"Should find album", []sessionSpin{spin(9, 0), spin(1, 1), spin(2, 1), spin(3, 1), spin(9, 0)}, 1},
+		{"Should prefer the longest tracklist", []sessionSpin{spin(1, 1), spin(2, 1), spin(3, 2), spin(4, 2)}, 2},
+		{"Out of order", []sessionSpin{spin(1, 1), spin(3, 1), spin(2, 1)}, 0},
+		{"Interrupted", []sessionSpin{spin(1, 1), spin(2, 1), spin(9, 0), spin(3, 1)}, 0},
+		{"Single", []sessionSpin{spin(5, 3)}, 0},
+		{"No spins", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if album := fullAlbum(tt.spins, tracklists); album != tt.expected {
+				t.Fatalf("expected album %d but got %d", tt.expected, album)
+			}
+		})
+	}
+}
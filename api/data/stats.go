@@ -0,0 +1,231 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// msPlayed is the listened time of a spin joined with its track, falling back
+// to the track's duration when the client did not report a play time.
+const msPlayed = `COALESCE(s.ms_played, t.duration_ms, 0)`
+
+// ON_REPEAT_MIN_SPINS is how many times a user has to play a track on one day
+// for it to be on repeat.
+const ON_REPEAT_MIN_SPINS = 3
+
+// GetListeningTime sums a user's listening by periods starting at midnight in
+// their time zone.
+func (pg *PGDB) GetListeningTime(userID uint64, period Period, from, to time.Time) ([]ListeningTime, error) {
+	stmt := `WITH ` + trackRollup.listening() + `
+	SELECT date_trunc($4, l.day) AT TIME ZONE b.time_zone AS start, sum(l.spins)::bigint, sum(l.ms_played)::bigint
+	FROM l, b
+	GROUP BY start
+	ORDER BY start`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, string(period))
+	if err != nil {
+		return nil, fmt.Errorf("error selecting listening time: %w", err)
+	}
+
+	lt, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ListeningTime, error) {
+		var l ListeningTime
+		err := row.Scan(&l.Start, &l.Spins, &l.MsPlayed)
+		return l, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting listening time: %w", err)
+	}
+
+	return lt, nil
+}
+
+func (pg *PGDB) GetArtistListening(userID uint64, from, to time.Time, limit int) ([]ArtistListening, error) {
+	stmt := `WITH ` + artistRollup.listening() + `
+	SELECT a.id, a.name, COALESCE(a.mbid::text, ''), sum(l.spins)::bigint AS spins, sum(l.ms_played)::bigint AS ms_played
+	FROM l
+	JOIN artist a ON l.id = a.id
+	GROUP BY a.id
+	ORDER BY ms_played DESC, spins DESC
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting artist listening time: %w", err)
+	}
+
+	al, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ArtistListening, error) {
+		var l ArtistListening
+		err := row.Scan(&l.Artist.ID, &l.Artist.Name, &l.Artist.MBID, &l.Spins, &l.MsPlayed)
+		return l, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting artist listening time: %w", err)
+	}
+
+	return al, nil
+}
+
+// GetProjectListening attributes each spin to the primary project of its
+// track, or to the project the user chose as primary instead. Since either
+// can change, it sums the rollups by track rather than by project.
+func (pg *PGDB) GetProjectListening(userID uint64, from, to time.Time, limit int) ([]ProjectListening, error) {
+	stmt := `WITH ` + trackRollup.listening() + `
+	SELECT p.id, p.title, p.form, p.release, COALESCE(p.mbid::text, ''), sum(l.spins)::bigint AS spins, sum(l.ms_played)::bigint AS ms_played
+	FROM l
+	JOIN track t ON l.id = t.id
+	LEFT JOIN user_primary_project upp ON upp.user_id=$1 AND t.id = upp.track_id
+	JOIN project p ON COALESCE(upp.project_id, t.primary_project_id) = p.id
+	GROUP BY p.id
+	ORDER BY ms_played DESC, spins DESC
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting project listening time: %w", err)
+	}
+
+	pl, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ProjectListening, error) {
+		var l ProjectListening
+		err := row.Scan(&l.Project.ID, &l.Project.Title, &l.Project.Form, &l.Project.Release, &l.Project.MBID, &l.Spins, &l.MsPlayed)
+		return l, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting project listening time: %w", err)
+	}
+
+	return pl, nil
+}
+
+func (pg *PGDB) GetTrackListening(userID uint64, from, to time.Time, limit int) ([]TrackListening, error) {
+	stmt := `WITH ` + trackRollup.listening() + `
+	SELECT ` + trackColumns + `, sum(l.spins)::bigint AS spins, sum(l.ms_played)::bigint AS ms_played
+	FROM l
+	JOIN track t ON l.id = t.id
+	GROUP BY t.id
+	ORDER BY ms_played DESC, spins DESC
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting track listening time: %w", err)
+	}
+
+	tl, err := collectTrackListening(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting track listening time: %w", err)
+	}
+
+	return tl, nil
+}
+
+// GetNewArtists returns the artists a user first listened to within a range,
+// with their listening time in it.
+func (pg *PGDB) GetNewArtists(userID uint64, from, to time.Time) ([]ArtistListening, error) {
+	const stmt = `WITH first_spin AS (
+		SELECT at.artist_id FROM spin s
+		JOIN artist_track at ON s.track_id = at.track_id
+		WHERE s.user_id=$1 AND s.time < $3
+		GROUP BY at.artist_id
+		HAVING min(s.time) >= $2
+	)
+	SELECT a.id, a.name, COALESCE(a.mbid::text, ''), count(*) AS spins, sum(` + msPlayed + `) AS ms_played
+	FROM spin s
+	JOIN track t ON s.track_id = t.id
+	JOIN artist_track at ON t.id = at.track_id
+	JOIN artist a ON at.artist_id = a.id
+	WHERE s.user_id=$1 AND s.time >= $2 AND s.time < $3 AND a.id IN (SELECT artist_id FROM first_spin)
+	GROUP BY a.id, a.name, a.mbid
+	ORDER BY ms_played DESC, spins DESC`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting new artists: %w", err)
+	}
+
+	al, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ArtistListening, error) {
+		var l ArtistListening
+		err := row.Scan(&l.Artist.ID, &l.Artist.Name, &l.Artist.MBID, &l.Spins, &l.MsPlayed)
+		return l, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting new artists: %w", err)
+	}
+
+	return al, nil
+}
+
+// GetHourlyListening sums a user's listening by weekday and hour of the day in
+// their time zone, leaving out the hours without spins.
+func (pg *PGDB) GetHourlyListening(userID uint64, from, to time.Time) ([]HourListening, error) {
+	const stmt = `SELECT extract(dow FROM s.time AT TIME ZONE u.time_zone)::int AS weekday,
+		extract(hour FROM s.time AT TIME ZONE u.time_zone)::int AS hour, count(*), sum(` + msPlayed + `)
+	FROM spin s
+	JOIN track t ON s.track_id = t.id
+	JOIN "user" u ON s.user_id = u.id
+	WHERE s.user_id=$1 AND s.time >= $2 AND s.time < $3
+	GROUP BY weekday, hour
+	ORDER BY weekday, hour`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting hourly listening: %w", err)
+	}
+
+	hl, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (HourListening, error) {
+		var l HourListening
+		err := row.Scan(&l.Weekday, &l.Hour, &l.Spins, &l.MsPlayed)
+		return l, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting hourly listening: %w", err)
+	}
+
+	return hl, nil
+}
+
+// GetOnRepeat ranks the tracks a user played at least ON_REPEAT_MIN_SPINS
+// times on a single day in their time zone by their most spins on one day,
+// along with the listening time of that day.
+func (pg *PGDB) GetOnRepeat(userID uint64, from, to time.Time, limit int) ([]TrackListening, error) {
+	stmt := `WITH ` + trackRollup.listening() + `, daily AS (
+		SELECT l.id AS track_id, l.day, sum(l.spins) AS spins, sum(l.ms_played) AS ms_played
+		FROM l
+		GROUP BY l.id, l.day
+	), best_day AS (
+		SELECT DISTINCT ON (track_id) track_id, spins::bigint, ms_played::bigint
+		FROM daily
+		ORDER BY track_id, spins DESC, ms_played DESC
+	)
+	SELECT ` + trackColumns + `, bd.spins, bd.ms_played
+	FROM best_day bd
+	JOIN track t ON bd.track_id = t.id
+	WHERE bd.spins >= $5
+	ORDER BY bd.spins DESC, bd.ms_played DESC, t.id
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, limit, ON_REPEAT_MIN_SPINS)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tracks on repeat: %w", err)
+	}
+
+	tl, err := collectTrackListening(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tracks on repeat: %w", err)
+	}
+
+	return tl, nil
+}
+
+func collectTrackListening(rows pgx.Rows) ([]TrackListening, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (TrackListening, error) {
+		var l TrackListening
+		var durationMs int64
+		t := &l.Track
+		err := row.Scan(&t.ID, &t.Title, &t.PrimaryProjectID, &t.MBID, &durationMs, &l.Spins, &l.MsPlayed)
+		t.Duration = time.Duration(durationMs) * time.Millisecond
+		return l, err
+	})
+}
@@ -0,0 +1,169 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// entityTables maps an entity to its table and the junction table linking it
+// to tags.
+var entityTables = map[EntityType]struct{ table, tagTable string }{
+	ArtistEntity:  {"artist", "artist_tag"},
+	ProjectEntity: {"project", "project_tag"},
+	TrackEntity:   {"track", "track_tag"},
+}
+
+// AddTag attaches a tag to an entity, creating the tag when needed. Tags with
+// no user are global.
+func (pg *PGDB) AddTag(entity EntityType, key uint64, name string, userID uint64) (Tag, error) {
+	const upsertTag = `INSERT INTO tag (name) VALUES ($1)
+	ON CONFLICT (name) WHERE user_id IS NULL DO UPDATE SET name=EXCLUDED.name
+	RETURNING id, name, COALESCE(user_id, 0)`
+	const upsertUserTag = `INSERT INTO tag (name, user_id) VALUES ($1, $2)
+	ON CONFLICT (user_id, name) WHERE user_id IS NOT NULL DO UPDATE SET name=EXCLUDED.name
+	RETURNING id, name, user_id`
+
+	tables, ok := entityTables[entity]
+	if !ok {
+		return Tag{}, fmt.Errorf("unknown entity %q", entity)
+	}
+	link := fmt.Sprintf(`INSERT INTO %s (tag_id, %s_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, tables.tagTable, tables.table)
+
+	ctx := context.Background()
+	tx, err := pg.db.Begin(ctx)
+	if err != nil {
+		return Tag{}, fmt.Errorf("error inserting tag: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	stmt, args := upsertTag, []any{name}
+	if userID != 0 {
+		stmt, args = upsertUserTag, []any{name, userID}
+	}
+
+	var t Tag
+	if err := tx.QueryRow(ctx, stmt, args...).Scan(&t.ID, &t.Name, &t.UserID); err != nil {
+		return Tag{}, fmt.Errorf("error inserting tag: %w", err)
+	}
+	if _, err := tx.Exec(ctx, link, t.ID, key); err != nil {
+		return Tag{}, fmt.Errorf("error tagging %s: %w", entity, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Tag{}, fmt.Errorf("error inserting tag: %w", err)
+	}
+
+	return t, nil
+}
+
+func (pg *PGDB) RemoveTag(entity EntityType, key uint64, name string, userID uint64) error {
+	tables, ok := entityTables[entity]
+	if !ok {
+		return fmt.Errorf("unknown entity %q", entity)
+	}
+	stmt := fmt.Sprintf(`DELETE FROM %s WHERE %s_id=$1
+	AND tag_id=(SELECT id FROM tag WHERE name=$2 AND user_id IS NOT DISTINCT FROM NULLIF($3, 0))`, tables.tagTable, tables.table)
+
+	if _, err := pg.db.Exec(context.Background(), stmt, key, name, userID); err != nil {
+		return fmt.Errorf("error removing tag: %w", err)
+	}
+
+	return nil
+}
+
+// GetTags lists the global tags of an entity and the ones the user added.
+func (pg *PGDB) GetTags(entity EntityType, key uint64, userID uint64) ([]Tag, error) {
+	tables, ok := entityTables[entity]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity %q", entity)
+	}
+	stmt := fmt.Sprintf(`SELECT t.id, t.name, COALESCE(t.user_id, 0)
+	FROM tag t
+	JOIN %s et ON t.id = et.tag_id
+	WHERE et.%s_id=$1 AND (t.user_id IS NULL OR t.user_id=$2)
+	ORDER BY t.user_id NULLS FIRST, t.name`, tables.tagTable, tables.table)
+
+	rows, err := pg.db.Query(context.Background(), stmt, key, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tags: %w", err)
+	}
+
+	tags, err := pgx.CollectRows(rows, scanTag)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetTagChart ranks the tags a user listened to by spins. A spin counts for
+// the tags of its track and of the track's artists and projects, but only
+// once per tag. The user's own tags are only ranked when private is set, so
+// that charts others can see hold global tags alone.
+func (pg *PGDB) GetTagChart(userID uint64, from, to time.Time, limit int, private bool) ([]TagListening, error) {
+	stmt := `WITH ` + trackRollup.listening() + `, tl AS (
+		SELECT l.id AS track_id, sum(l.spins) AS spins FROM l GROUP BY l.id
+	), track_tags AS (
+		SELECT tl.track_id, tt.tag_id FROM tl
+		JOIN track_tag tt ON tl.track_id = tt.track_id
+		UNION
+		SELECT tl.track_id, art.tag_id FROM tl
+		JOIN artist_track at ON tl.track_id = at.track_id
+		JOIN artist_tag art ON at.artist_id = art.artist_id
+		UNION
+		SELECT tl.track_id, prt.tag_id FROM tl
+		JOIN project_track pt ON tl.track_id = pt.track_id
+		JOIN project_tag prt ON pt.project_id = prt.project_id
+	)
+	SELECT t.id, t.name, COALESCE(t.user_id, 0), sum(tl.spins)::bigint AS spins
+	FROM track_tags tg
+	JOIN tl ON tg.track_id = tl.track_id
+	JOIN tag t ON tg.tag_id = t.id
+	WHERE t.user_id IS NULL OR ($5 AND t.user_id=$1)
+	GROUP BY t.id, t.name, t.user_id
+	ORDER BY spins DESC, t.name
+	LIMIT $4`
+
+	rows, err := pg.db.Query(context.Background(), stmt, userID, from, to, limit, private)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tag chart: %w", err)
+	}
+
+	chart, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (TagListening, error) {
+		var l TagListening
+		err := row.Scan(&l.Tag.ID, &l.Tag.Name, &l.Tag.UserID, &l.Spins)
+		return l, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error selecting tag chart: %w", err)
+	}
+
+	return chart, nil
+}
+
+// linkGlobalTags attaches global tags to an entity within a transaction.
+func linkGlobalTags(tx pgx.Tx, entity EntityType, key uint64, names []string) error {
+	tables := entityTables[entity]
+	stmt := fmt.Sprintf(`WITH t AS (
+		INSERT INTO tag (name) VALUES ($1)
+		ON CONFLICT (name) WHERE user_id IS NULL DO UPDATE SET name=EXCLUDED.name
+		RETURNING id
+	)
+	INSERT INTO %s (tag_id, %s_id) SELECT id, $2 FROM t ON CONFLICT DO NOTHING`, tables.tagTable, tables.table)
+
+	for _, name := range names {
+		if _, err := tx.Exec(context.Background(), stmt, name, key); err != nil {
+			return fmt.Errorf("error tagging %s: %w", entity, err)
+		}
+	}
+	return nil
+}
+
+func scanTag(row pgx.CollectableRow) (Tag, error) {
+	var t Tag
+	err := row.Scan(&t.ID, &t.Name, &t.UserID)
+	return t, err
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// YearRange returns the start of a year in a time zone and of the next one.
+func YearRange(year int, loc *time.Location) (time.Time, time.Time) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(1, 0, 0)
+}
+
+// GetStaleWrapped returns the years up to and including one that users
+// listened in and have no report of yet, or one counting a different number
+// of spins or marked stale. Spins are counted from the rollups, whose days are
+// the ones of each user's time zone.
+func (pg *PGDB) GetStaleWrapped(lastYear int, limit int) ([]StaleWrapped, error) {
+	const stmt = `SELECT u.id, COALESCE(r.year, w.year), u.time_zone
+	FROM (
+		SELECT user_id, extract(year FROM day)::int AS year, sum(spins) AS spins
+		FROM user_track_day
+		WHERE day < make_date($1 + 1, 1, 1)
+		GROUP BY 1, 2
+	) r
+	FULL JOIN (SELECT user_id, year, spins, stale FROM wrapped WHERE year <= $1) w ON r.user_id = w.user_id AND r.year = w.year
+	JOIN "user" u ON u.id = COALESCE(r.user_id, w.user_id)
+	WHERE w.spins IS DISTINCT FROM COALESCE(r.spins, 0) OR w.stale
+	ORDER BY 1, 2
+	LIMIT $2`
+
+	rows, err := pg.db.Query(context.Background(), stmt, lastYear, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting stale wrapped reports: %w", err)
+	}
+
+	stale, err := pgx.CollectRows(rows, pgx.RowToStructByPos[StaleWrapped])
+	if err != nil {
+		return nil, fmt.Errorf("error selecting stale wrapped reports: %w", err)
+	}
+
+	return stale, nil
+}
+
+// markWrappedStale marks the reports of the years, in their users' time
+// zones, with spins s matching a condition for generation again.
+func markWrappedStale(tx pgx.Tx, cond string, args ...any) error {
+	stmt := `UPDATE wrapped w SET stale=true
+	FROM (
+		SELECT DISTINCT s.user_id, extract(year FROM s.time AT TIME ZONE u.time_zone)::int AS year
+		FROM spin s
+		JOIN "user" u ON s.user_id = u.id
+		WHERE ` + cond + `
+	) y
+	WHERE w.user_id = y.user_id AND w.year = y.year`
+
+	if _, err := tx.Exec(context.Background(), stmt, args...); err != nil {
+		return fmt.Errorf("error marking wrapped reports stale: %w", err)
+	}
+	return nil
+}
+
+func (pg *PGDB) SaveWrapped(userID uint64, w Wrapped) error {
+	const stmt = `INSERT INTO wrapped (user_id, year, spins, report, generated_at) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (user_id, year) DO UPDATE SET spins=EXCLUDED.spins, report=EXCLUDED.report, generated_at=EXCLUDED.generated_at, stale=false`
+
+	report, err := json.Marshal(w)
+	if err != nil {
+		return fmt.Errorf("error encoding wrapped report: %w", err)
+	}
+	if _, err := pg.db.Exec(context.Background(), stmt, userID, w.Year, w.Spins, report, w.GeneratedAt); err != nil {
+		return fmt.Errorf("error saving wrapped report: %w", err)
+	}
+	return nil
+}
+
+func (pg *PGDB) GetWrapped(userID uint64, year int) (Wrapped, error) {
+	const stmt = `SELECT report FROM wrapped WHERE user_id=$1 AND year=$2`
+
+	var report []byte
+	err := pg.db.QueryRow(context.Background(), stmt, userID, year).Scan(&report)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Wrapped{}, fmt.Errorf("%w: wrapped report of %d", ErrNotFound, year)
+	} else if err != nil {
+		return Wrapped{}, fmt.Errorf("error selecting wrapped report: %w", err)
+	}
+
+	var w Wrapped
+	if err := json.Unmarshal(report, &w); err != nil {
+		return Wrapped{}, fmt.Errorf("error decoding wrapped report: %w", err)
+	}
+	return w, nil
+}
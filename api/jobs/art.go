@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"tunes-service/art"
+	"tunes-service/data"
+	"tunes-service/storage"
+)
+
+const (
+	ART_FETCH_INTERVAL   = time.Minute
+	ART_FETCH_BATCH_SIZE = 20
+)
+
+// ArtFetcher downloads the artwork clients link to in their spins and stores
+// it for the projects that have none. Only the hosts of art providers are
+// fetched from, or any public host when there are none.
+type ArtFetcher struct {
+	db     data.ArtDB
+	store  storage.Store
+	hosts  []string
+	client *http.Client
+}
+
+func NewArtFetcher(db data.ArtDB, store storage.Store, hosts []string) *ArtFetcher {
+	return &ArtFetcher{db, store, hosts, art.NewClient(hosts)}
+}
+
+func (f *ArtFetcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(ART_FETCH_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := f.FetchOnce(); err != nil {
+			log.Printf("artwork fetch failed after %d projects: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FetchOnce fetches one batch of artwork and returns how many projects were
+// handled. URLs that do not serve an image are marked as fetched, so they are
+// not tried again; storage failures leave them for a retry.
+func (f *ArtFetcher) FetchOnce() (int, error) {
+	n := 0
+
+	sources, err := f.db.GetPendingArt(ART_FETCH_BATCH_SIZE)
+	if err != nil {
+		return n, err
+	}
+	for _, s := range sources {
+		image, err := f.download(s.URL)
+		if err != nil {
+			log.Printf("could not download artwork of project %d: %v", s.Key, err)
+			if err := f.db.MarkArtFetched(s.Key); err != nil {
+				return n, err
+			}
+			n++
+			continue
+		}
+
+		hash, err := art.Save(image, f.store)
+		if errors.Is(err, art.ErrUnsupported) {
+			log.Printf("could not save artwork of project %d: %v", s.Key, err)
+			if err := f.db.MarkArtFetched(s.Key); err != nil {
+				return n, err
+			}
+			n++
+			continue
+		} else if err != nil {
+			return n, err
+		}
+
+		if err := f.db.SetArt(data.ProjectEntity, s.Key, hash); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+func (f *ArtFetcher) download(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if !art.AllowedURL(u, f.hosts) {
+		return nil, fmt.Errorf("%w: %s", art.ErrForbiddenHost, u.Host)
+	}
+
+	res, err := f.client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", res.StatusCode)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(res.Body, art.MAX_ART_BYTES+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(image) > art.MAX_ART_BYTES {
+		return nil, fmt.Errorf("image is larger than %d bytes", art.MAX_ART_BYTES)
+	}
+	return image, nil
+}
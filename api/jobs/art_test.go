@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"tunes-service/data"
+	"tunes-service/storage"
+)
+
+type artDBMock struct {
+	setArt         func(data.EntityType, uint64, string) error
+	getArt         func(data.EntityType, uint64) (string, error)
+	getPendingArt  func(int) ([]data.ArtSource, error)
+	markArtFetched func(uint64) error
+}
+
+func (db *artDBMock) SetArt(entity data.EntityType, key uint64, hash string) error {
+	return db.setArt(entity, key, hash)
+}
+
+func (db *artDBMock) GetArt(entity data.EntityType, key uint64) (string, error) {
+	return db.getArt(entity, key)
+}
+
+func (db *artDBMock) GetPendingArt(limit int) ([]data.ArtSource, error) {
+	return db.getPendingArt(limit)
+}
+
+func (db *artDBMock) MarkArtFetched(key uint64) error {
+	return db.markArtFetched(key)
+}
+
+func TestFetchOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/front.png":
+			png.Encode(w, image.NewRGBA(image.Rect(0, 0, 500, 500)))
+		case "/page.html":
+			w.Write([]byte("<html></html>"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store, err := storage.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	art := map[uint64]string{}
+	fetched := map[uint64]bool{}
+	db := &artDBMock{
+		func(entity data.EntityType, key uint64, hash string) error {
+			if entity != data.ProjectEntity {
+				t.Fatalf("expected project artwork but got %s", entity)
+			}
+			art[key] = hash
+			return nil
+		},
+		nil,
+		func(limit int) ([]data.ArtSource, error) {
+			return []data.ArtSource{
+				{Key: 1, URL: server.URL + "/front.png"},
+				{Key: 2, URL: server.URL + "/page.html"},
+				{Key: 3, URL: server.URL + "/missing.png"},
+			}, nil
+		},
+		func(key uint64) error {
+			fetched[key] = true
+			return nil
+		},
+	}
+
+	// The test server listens on loopback, which the fetcher's own client
+	// refuses to connect to.
+	f := NewArtFetcher(db, store, nil)
+	f.client = server.Client()
+	n, err := f.FetchOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 projects handled but got %d", n)
+	}
+	if art[1] == "" || len(art) != 1 {
+		t.Fatalf("expected artwork only for project 1 but got %v", art)
+	}
+	if !fetched[2] || !fetched[3] || fetched[1] {
+		t.Fatalf("expected failed downloads to be marked but got %v", fetched)
+	}
+}
@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/metadata"
+)
+
+const (
+	ENRICHMENT_INTERVAL   = 5 * time.Minute
+	ENRICHMENT_BATCH_SIZE = 50
+)
+
+// Enricher periodically looks up tracks and projects that were added to the
+// catalog since its last run and fills in the metadata clients do not send.
+type Enricher struct {
+	db       data.EnrichmentDB
+	provider metadata.Provider
+}
+
+func NewEnricher(db data.EnrichmentDB, provider metadata.Provider) *Enricher {
+	return &Enricher{db, provider}
+}
+
+func (e *Enricher) Run(ctx context.Context) {
+	ticker := time.NewTicker(ENRICHMENT_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := e.EnrichOnce(); err != nil {
+			log.Printf("enrichment failed after %d entries: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnrichOnce enriches one batch of projects and tracks and returns how many
+// were marked as enriched. Entries the provider does not know are marked too,
+// so they are not looked up again; provider failures hold them back for a
+// later retry and the batch goes on.
+func (e *Enricher) EnrichOnce() (int, error) {
+	n := 0
+
+	projects, err := e.db.GetUnenrichedProjects(ENRICHMENT_BATCH_SIZE)
+	if err != nil {
+		return n, err
+	}
+	for _, p := range projects {
+		rel, err := e.provider.Release(metadata.Query{MBID: p.MBID, Title: p.Title, ArtistNames: p.ArtistNames})
+		if err != nil && !errors.Is(err, metadata.ErrNotFound) {
+			log.Printf("could not look up project %d: %v", p.Key, err)
+			if err := e.db.MarkEnrichmentFailed(data.ProjectEntity, p.Key); err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		if err := e.db.UpdateProjectMetadata(p.Key, projectMetadata(p, rel)); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	tracks, err := e.db.GetUnenrichedTracks(ENRICHMENT_BATCH_SIZE)
+	if err != nil {
+		return n, err
+	}
+	for _, t := range tracks {
+		rec, err := e.provider.Recording(metadata.Query{MBID: t.MBID, Title: t.Title, ArtistNames: t.ArtistNames})
+		if err != nil && !errors.Is(err, metadata.ErrNotFound) {
+			log.Printf("could not look up track %d: %v", t.Key, err)
+			if err := e.db.MarkEnrichmentFailed(data.TrackEntity, t.Key); err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		if err := e.db.UpdateTrackMetadata(t.Key, data.TrackMetadata{Duration: rec.Duration, ISRC: rec.ISRC, Genres: rec.Genres}); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// projectMetadata numbers the project's tracks by matching them against the
+// release tracklist, by recording identifier first and title second.
+func projectMetadata(p data.EnrichmentCandidate, rel metadata.Release) data.ProjectMetadata {
+	m := data.ProjectMetadata{
+		Release:          rel.Date,
+		ReleasePrecision: data.DatePrecision(rel.DatePrecision),
+		Label:            rel.Label,
+		TrackNumbers:     map[uint64]int{},
+		Genres:           rel.Genres,
+	}
+
+	for _, t := range p.Tracks {
+		for _, rec := range rel.Tracks {
+			if (t.MBID != "" && t.MBID == rec.MBID) || strings.EqualFold(strings.TrimSpace(t.Title), strings.TrimSpace(rec.Title)) {
+				m.TrackNumbers[t.Key] = rec.Number
+				break
+			}
+		}
+	}
+
+	return m
+}
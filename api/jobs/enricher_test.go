@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/metadata"
+)
+
+type enrichmentDBMock struct {
+	getUnenrichedTracks   func(int) ([]data.EnrichmentCandidate, error)
+	getUnenrichedProjects func(int) ([]data.EnrichmentCandidate, error)
+	updateTrackMetadata   func(uint64, data.TrackMetadata) error
+	updateProjectMetadata func(uint64, data.ProjectMetadata) error
+	markEnrichmentFailed  func(data.EntityType, uint64) error
+}
+
+func (db *enrichmentDBMock) GetUnenrichedTracks(limit int) ([]data.EnrichmentCandidate, error) {
+	return db.getUnenrichedTracks(limit)
+}
+
+func (db *enrichmentDBMock) GetUnenrichedProjects(limit int) ([]data.EnrichmentCandidate, error) {
+	return db.getUnenrichedProjects(limit)
+}
+
+func (db *enrichmentDBMock) UpdateTrackMetadata(key uint64, m data.TrackMetadata) error {
+	return db.updateTrackMetadata(key, m)
+}
+
+func (db *enrichmentDBMock) UpdateProjectMetadata(key uint64, m data.ProjectMetadata) error {
+	return db.updateProjectMetadata(key, m)
+}
+
+func (db *enrichmentDBMock) MarkEnrichmentFailed(entity data.EntityType, key uint64) error {
+	return db.markEnrichmentFailed(entity, key)
+}
+
+type providerMock struct {
+	recording func(metadata.Query) (metadata.Recording, error)
+	release   func(metadata.Query) (metadata.Release, error)
+}
+
+func (p *providerMock) Recording(q metadata.Query) (metadata.Recording, error) {
+	return p.recording(q)
+}
+
+func (p *providerMock) Release(q metadata.Query) (metadata.Release, error) {
+	return p.release(q)
+}
+
+func TestEnrichOnce(t *testing.T) {
+	release := time.Date(2023, 9, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		provider *providerMock
+		tracks   map[uint64]data.TrackMetadata
+		projects map[uint64]data.ProjectMetadata
+		failed   []string
+		n        int
+	}{
+		{
+			"Should fill in metadata",
+			&providerMock{
+				func(q metadata.Query) (metadata.Recording, error) {
+					return metadata.Recording{Duration: 184 * time.Second, ISRC: "USUG12305014"}, nil
+				},
+				func(q metadata.Query) (metadata.Release, error) {
+					return metadata.Release{
+						Date:          release,
+						DatePrecision: "day",
+						Label:         "Geffen",
+						Tracks: []metadata.Recording{
+							{MBID: "rec-1", Title: "all-american bitch", Number: 1},
+							{MBID: "rec-2", Title: "Bad Idea Right?", Number: 2},
+						},
+					}, nil
+				},
+			},
+			map[uint64]data.TrackMetadata{
+				2: {Duration: 184 * time.Second, ISRC: "USUG12305014"},
+			},
+			map[uint64]data.ProjectMetadata{
+				1: {Release: release, ReleasePrecision: data.DayPrecision, Label: "Geffen", TrackNumbers: map[uint64]int{2: 2, 3: 1}},
+			},
+			[]string{},
+			2,
+		},
+		{
+			"Should mark unknown entries",
+			&providerMock{
+				func(q metadata.Query) (metadata.Recording, error) {
+					return metadata.Recording{}, metadata.ErrNotFound
+				},
+				func(q metadata.Query) (metadata.Release, error) {
+					return metadata.Release{}, metadata.ErrNotFound
+				},
+			},
+			map[uint64]data.TrackMetadata{
+				2: {},
+			},
+			map[uint64]data.ProjectMetadata{
+				1: {TrackNumbers: map[uint64]int{}},
+			},
+			[]string{},
+			2,
+		},
+		{
+			"Should hold entries back for a retry when the provider fails",
+			&providerMock{
+				func(q metadata.Query) (metadata.Recording, error) {
+					return metadata.Recording{ISRC: "USUG12305014"}, nil
+				},
+				func(q metadata.Query) (metadata.Release, error) {
+					return metadata.Release{}, fmt.Errorf("metadata request failed with status 503")
+				},
+			},
+			map[uint64]data.TrackMetadata{
+				2: {ISRC: "USUG12305014"},
+			},
+			map[uint64]data.ProjectMetadata{},
+			[]string{"project 1"},
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracks := map[uint64]data.TrackMetadata{}
+			projects := map[uint64]data.ProjectMetadata{}
+			failed := []string{}
+			db := &enrichmentDBMock{
+				func(int) ([]data.EnrichmentCandidate, error) {
+					return []data.EnrichmentCandidate{
+						{Key: 2, Title: "bad idea right?", ArtistNames: []string{"Olivia Rodrigo"}},
+					}, nil
+				},
+				func(int) ([]data.EnrichmentCandidate, error) {
+					return []data.EnrichmentCandidate{
+						{
+							Key:         1,
+							Title:       "GUTS",
+							ArtistNames: []string{"Olivia Rodrigo"},
+							Tracks: []data.EnrichmentCandidate{
+								{Key: 2, Title: "bad idea right?"},
+								{Key: 3, Title: "abcdefu", MBID: "rec-1"},
+							},
+						},
+					}, nil
+				},
+				func(key uint64, m data.TrackMetadata) error {
+					tracks[key] = m
+					return nil
+				},
+				func(key uint64, m data.ProjectMetadata) error {
+					projects[key] = m
+					return nil
+				},
+				func(entity data.EntityType, key uint64) error {
+					failed = append(failed, fmt.Sprintf("%s %d", entity, key))
+					return nil
+				},
+			}
+
+			n, err := NewEnricher(db, tt.provider).EnrichOnce()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != tt.n {
+				t.Fatalf("expected %d entries to be enriched but got %d", tt.n, n)
+			}
+			if fmt.Sprint(tracks) != fmt.Sprint(tt.tracks) {
+				t.Fatalf("expected tracks %v but got %v", tt.tracks, tracks)
+			}
+			if fmt.Sprint(projects) != fmt.Sprint(tt.projects) {
+				t.Fatalf("expected projects %v but got %v", tt.projects, projects)
+			}
+			if fmt.Sprint(failed) != fmt.Sprint(tt.failed) {
+				t.Fatalf("expected failed lookups %v but got %v", tt.failed, failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tunes-service/data"
+)
+
+const (
+	GROUP_CHART_INTERVAL   = time.Hour
+	GROUP_CHART_BATCH_SIZE = 20
+	// WEEKLY_CHART_DELAY is how long after Monday midnight UTC the week before
+	// is charted. The last time zones end their Sunday twelve hours later.
+	WEEKLY_CHART_DELAY = 14 * time.Hour
+)
+
+// chartEntities are what weekly charts rank.
+var chartEntities = []data.EntityType{data.ArtistEntity, data.TrackEntity, data.ProjectEntity}
+
+// GroupChartSnapshotter stores the weekly charts of every group once the week
+// ended, so later weeks compare against what the group listened to then.
+type GroupChartSnapshotter struct {
+	db  data.GroupChartDB
+	now func() time.Time
+}
+
+func NewGroupChartSnapshotter(db data.GroupChartDB) *GroupChartSnapshotter {
+	return &GroupChartSnapshotter{db, time.Now}
+}
+
+func (s *GroupChartSnapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(GROUP_CHART_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.SnapshotOnce(); err != nil {
+			log.Printf("group chart snapshots failed after %d groups: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SnapshotOnce stores the charts of the last complete week for one batch of
+// groups and returns how many were stored.
+func (s *GroupChartSnapshotter) SnapshotOnce() (int, error) {
+	week := lastChartWeek(s.now())
+
+	groupIDs, err := s.db.GetUnsnapshottedGroups(week, GROUP_CHART_BATCH_SIZE)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, groupID := range groupIDs {
+		var entries []data.ChartEntry
+		for _, entity := range chartEntities {
+			chart, err := s.db.GetGroupChart(groupID, entity, week, week.AddDate(0, 0, 7), data.WEEKLY_CHART_SIZE)
+			if err != nil {
+				return n, err
+			}
+			entries = append(entries, chart...)
+		}
+		if err := s.db.SaveGroupWeek(groupID, week, entries); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// lastChartWeek returns the start of the last week that ended in every time
+// zone.
+func lastChartWeek(now time.Time) time.Time {
+	return data.WeekStart(now.UTC().Add(-WEEKLY_CHART_DELAY)).AddDate(0, 0, -7)
+}
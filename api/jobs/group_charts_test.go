@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type groupChartDBMock struct {
+	getGroupChart           func(uint64, data.EntityType, time.Time, time.Time, int) ([]data.ChartEntry, error)
+	getGroupMemberListening func(uint64, time.Time, time.Time) ([]data.MemberListening, error)
+	getUnsnapshottedGroups  func(time.Time, int) ([]uint64, error)
+	saveGroupWeek           func(uint64, time.Time, []data.ChartEntry) error
+	getGroupWeek            func(uint64, data.EntityType, time.Time) ([]data.ChartEntry, error)
+}
+
+func (db *groupChartDBMock) GetGroupChart(groupID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+	return db.getGroupChart(groupID, entity, from, to, limit)
+}
+
+func (db *groupChartDBMock) GetGroupMemberListening(groupID uint64, from, to time.Time) ([]data.MemberListening, error) {
+	return db.getGroupMemberListening(groupID, from, to)
+}
+
+func (db *groupChartDBMock) GetUnsnapshottedGroups(week time.Time, limit int) ([]uint64, error) {
+	return db.getUnsnapshottedGroups(week, limit)
+}
+
+func (db *groupChartDBMock) SaveGroupWeek(groupID uint64, week time.Time, entries []data.ChartEntry) error {
+	return db.saveGroupWeek(groupID, week, entries)
+}
+
+func (db *groupChartDBMock) GetGroupWeek(groupID uint64, entity data.EntityType, week time.Time) ([]data.ChartEntry, error) {
+	return db.getGroupWeek(groupID, entity, week)
+}
+
+func TestSnapshotOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		week time.Time
+	}{
+		{"Monday morning", time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC), time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)},
+		{"Monday night", time.Date(2024, 3, 11, 20, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"Sunday", time.Date(2024, 3, 17, 12, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := map[uint64][]data.ChartEntry{}
+			db := &groupChartDBMock{
+				getUnsnapshottedGroups: func(week time.Time, limit int) ([]uint64, error) {
+					if !week.Equal(tt.week) {
+						t.Fatalf("expected the week of %v but got %v", tt.week, week)
+					}
+					return []uint64{1, 2}, nil
+				},
+				getGroupChart: func(groupID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+					if !from.Equal(tt.week) || !to.Equal(tt.week.AddDate(0, 0, 7)) {
+						t.Fatalf("expected the week of %v but got %v to %v", tt.week, from, to)
+					}
+					return []data.ChartEntry{{Rank: 1, Entity: entity, ID: groupID}}, nil
+				},
+				saveGroupWeek: func(groupID uint64, week time.Time, entries []data.ChartEntry) error {
+					saved[groupID] = entries
+					return nil
+				},
+			}
+
+			s := NewGroupChartSnapshotter(db)
+			s.now = func() time.Time { return tt.now }
+			n, err := s.SnapshotOnce()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 2 || len(saved[1]) != 3 || len(saved[2]) != 3 {
+				t.Fatalf("expected both groups with three charts but got %d: %+v", n, saved)
+			}
+		})
+	}
+}
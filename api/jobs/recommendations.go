@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tunes-service/data"
+)
+
+const (
+	NEIGHBOR_INTERVAL = time.Hour
+	// NEIGHBOR_MAX_AGE is how long neighbors are kept before they are
+	// rebuilt from the latest spins.
+	NEIGHBOR_MAX_AGE = 24 * time.Hour
+	// NEIGHBOR_WINDOW is how far back the spins neighbors are built from
+	// reach.
+	NEIGHBOR_WINDOW = 365 * 24 * time.Hour
+)
+
+// NeighborBuilder keeps the artists and tracks played in the same sessions as
+// each other, which recommendations are made from, up to date. Sessions split
+// wherever a user paused for longer than a gap, as the ones users see do.
+type NeighborBuilder struct {
+	db  data.RecommendationDB
+	gap time.Duration
+	now func() time.Time
+}
+
+func NewNeighborBuilder(db data.RecommendationDB, gap time.Duration) *NeighborBuilder {
+	return &NeighborBuilder{db, gap, time.Now}
+}
+
+func (b *NeighborBuilder) Run(ctx context.Context) {
+	ticker := time.NewTicker(NEIGHBOR_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := b.BuildOnce(); err != nil {
+			log.Printf("neighbor rebuild failed after %d entities: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// BuildOnce rebuilds the neighbors of artists and tracks that are older than
+// NEIGHBOR_MAX_AGE and returns how many of the two it rebuilt.
+func (b *NeighborBuilder) BuildOnce() (int, error) {
+	now := b.now()
+	n := 0
+	for _, entity := range []data.EntityType{data.ArtistEntity, data.TrackEntity} {
+		builtAt, err := b.db.GetNeighborsBuiltAt(entity)
+		if err != nil {
+			return n, err
+		}
+		if now.Sub(builtAt) < NEIGHBOR_MAX_AGE {
+			continue
+		}
+		if _, err := b.db.RebuildNeighbors(entity, now.Add(-NEIGHBOR_WINDOW), b.gap); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
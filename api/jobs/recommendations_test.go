@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type recommendationDBMock struct {
+	rebuildNeighbors    func(data.EntityType, time.Time, time.Duration) (int64, error)
+	getNeighborsBuiltAt func(data.EntityType) (time.Time, error)
+	getRecommendations  func(uint64, data.EntityType, time.Time, int) ([]data.Recommendation, error)
+}
+
+func (db *recommendationDBMock) RebuildNeighbors(entity data.EntityType, since time.Time, gap time.Duration) (int64, error) {
+	return db.rebuildNeighbors(entity, since, gap)
+}
+
+func (db *recommendationDBMock) GetNeighborsBuiltAt(entity data.EntityType) (time.Time, error) {
+	return db.getNeighborsBuiltAt(entity)
+}
+
+func (db *recommendationDBMock) GetRecommendations(userID uint64, entity data.EntityType, since time.Time, limit int) ([]data.Recommendation, error) {
+	return db.getRecommendations(userID, entity, since, limit)
+}
+
+func TestBuildOnce(t *testing.T) {
+	now := time.Date(2024, 3, 31, 18, 0, 0, 0, time.UTC)
+	builtAt := map[data.EntityType]time.Time{
+		data.ArtistEntity: now.Add(-time.Hour),
+	}
+	db := &recommendationDBMock{
+		getNeighborsBuiltAt: func(entity data.EntityType) (time.Time, error) {
+			return builtAt[entity], nil
+		},
+		rebuildNeighbors: func(entity data.EntityType, since time.Time, gap time.Duration) (int64, error) {
+			if !since.Equal(now.Add(-NEIGHBOR_WINDOW)) {
+				t.Fatalf("expected spins since %v but got %v", now.Add(-NEIGHBOR_WINDOW), since)
+			}
+			if gap != time.Hour {
+				t.Fatalf("expected the configured session gap but got %v", gap)
+			}
+			builtAt[entity] = now
+			return 10, nil
+		},
+	}
+
+	b := NewNeighborBuilder(db, time.Hour)
+	b.now = func() time.Time { return now }
+	if n, err := b.BuildOnce(); err != nil || n != 1 || !builtAt[data.TrackEntity].Equal(now) {
+		t.Fatalf("expected only the tracks never built to be built but got %d: %v", n, err)
+	}
+	if n, err := b.BuildOnce(); err != nil || n != 0 {
+		t.Fatalf("expected nothing to rebuild but got %d: %v", n, err)
+	}
+
+	now = now.Add(NEIGHBOR_MAX_AGE)
+	if n, err := b.BuildOnce(); err != nil || n != 2 {
+		t.Fatalf("expected both to be rebuilt a day later but got %d: %v", n, err)
+	}
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tunes-service/data"
+)
+
+const (
+	ROLLUP_COMPACT_INTERVAL   = time.Minute
+	ROLLUP_COMPACT_BATCH_SIZE = 20
+)
+
+// RollupCompactor rebuilds the rollups of users whose spins changed in ways
+// spins being recorded do not keep up with, such as merges.
+type RollupCompactor struct {
+	db data.RollupDB
+}
+
+func NewRollupCompactor(db data.RollupDB) *RollupCompactor {
+	return &RollupCompactor{db}
+}
+
+func (c *RollupCompactor) Run(ctx context.Context) {
+	ticker := time.NewTicker(ROLLUP_COMPACT_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := c.CompactOnce(); err != nil {
+			log.Printf("rollup compaction failed after %d users: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CompactOnce rebuilds one batch of stale rollups and returns how many users
+// they belonged to.
+func (c *RollupCompactor) CompactOnce() (int, error) {
+	n := 0
+
+	userIDs, err := c.db.GetStaleRollups(ROLLUP_COMPACT_BATCH_SIZE)
+	if err != nil {
+		return n, err
+	}
+	for _, userID := range userIDs {
+		if err := c.db.RebuildRollups(userID); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// Rebuild marks the rollups of a user, or of every user when userID is 0, as
+// stale and rebuilds them along with any others that are, returning how many
+// users' rollups were rebuilt.
+func (c *RollupCompactor) Rebuild(userID uint64) (int, error) {
+	if err := c.db.MarkRollupsStale(userID); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		n, err := c.CompactOnce()
+		total += n
+		if err != nil || n == 0 {
+			return total, err
+		}
+	}
+}
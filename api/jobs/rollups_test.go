@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"fmt"
+	"testing"
+)
+
+type rollupDBMock struct {
+	markRollupsStale func(uint64) error
+	getStaleRollups  func(int) ([]uint64, error)
+	rebuildRollups   func(uint64) error
+}
+
+func (db *rollupDBMock) MarkRollupsStale(userID uint64) error {
+	return db.markRollupsStale(userID)
+}
+
+func (db *rollupDBMock) GetStaleRollups(limit int) ([]uint64, error) {
+	return db.getStaleRollups(limit)
+}
+
+func (db *rollupDBMock) RebuildRollups(userID uint64) error {
+	return db.rebuildRollups(userID)
+}
+
+// newRollupDBMock returns a mock whose stale users are the given ones until
+// their rollups are rebuilt.
+func newRollupDBMock(t *testing.T, stale map[uint64]bool) *rollupDBMock {
+	return &rollupDBMock{
+		markRollupsStale: func(userID uint64) error {
+			stale[userID] = true
+			return nil
+		},
+		getStaleRollups: func(limit int) ([]uint64, error) {
+			if limit != ROLLUP_COMPACT_BATCH_SIZE {
+				t.Fatalf("expected limit %d but got %d", ROLLUP_COMPACT_BATCH_SIZE, limit)
+			}
+			ids := []uint64{}
+			for id := range stale {
+				if len(ids) < limit {
+					ids = append(ids, id)
+				}
+			}
+			return ids, nil
+		},
+		rebuildRollups: func(userID uint64) error {
+			if userID == 13 {
+				return fmt.Errorf("connection refused")
+			}
+			delete(stale, userID)
+			return nil
+		},
+	}
+}
+
+func TestCompactOnce(t *testing.T) {
+	stale := map[uint64]bool{}
+	for id := uint64(1); id <= ROLLUP_COMPACT_BATCH_SIZE+5; id++ {
+		stale[id+100] = true
+	}
+
+	n, err := NewRollupCompactor(newRollupDBMock(t, stale)).CompactOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != ROLLUP_COMPACT_BATCH_SIZE || len(stale) != 5 {
+		t.Fatalf("expected a batch of %d rebuilt and 5 left but got %d and %d", ROLLUP_COMPACT_BATCH_SIZE, n, len(stale))
+	}
+
+	if _, err := NewRollupCompactor(newRollupDBMock(t, map[uint64]bool{13: true})).CompactOnce(); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	stale := map[uint64]bool{}
+	for id := uint64(1); id <= 2*ROLLUP_COMPACT_BATCH_SIZE; id++ {
+		stale[id+100] = true
+	}
+
+	n, err := NewRollupCompactor(newRollupDBMock(t, stale)).Rebuild(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2*ROLLUP_COMPACT_BATCH_SIZE+1 || len(stale) != 0 {
+		t.Fatalf("expected every stale user rebuilt but got %d with %v left", n, stale)
+	}
+}
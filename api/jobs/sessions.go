@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tunes-service/data"
+)
+
+const (
+	SESSION_INTERVAL   = time.Minute
+	SESSION_BATCH_SIZE = 50
+)
+
+// SessionDetector keeps the listening sessions of users up to date with their
+// spins, splitting them wherever they paused for longer than a gap.
+type SessionDetector struct {
+	db  data.SessionDB
+	gap time.Duration
+}
+
+func NewSessionDetector(db data.SessionDB, gap time.Duration) *SessionDetector {
+	return &SessionDetector{db, gap}
+}
+
+func (d *SessionDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(SESSION_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := d.DetectOnce(); err != nil {
+			log.Printf("session detection failed after %d users: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DetectOnce detects the sessions of one batch of users with new or changed
+// spins and returns how many users they belonged to.
+func (d *SessionDetector) DetectOnce() (int, error) {
+	n := 0
+
+	userIDs, err := d.db.GetUnsessionedUsers(d.gap, SESSION_BATCH_SIZE)
+	if err != nil {
+		return n, err
+	}
+	for _, userID := range userIDs {
+		if _, err := d.db.DetectSessions(userID, d.gap); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type sessionDBMock struct {
+	getUnsessionedUsers func(time.Duration, int) ([]uint64, error)
+	detectSessions      func(uint64, time.Duration) (int, error)
+	getSessions         func(uint64, time.Time, int, bool) ([]data.Session, error)
+	getSession          func(uint64, uint64) (data.Session, error)
+}
+
+func (db *sessionDBMock) GetUnsessionedUsers(gap time.Duration, limit int) ([]uint64, error) {
+	return db.getUnsessionedUsers(gap, limit)
+}
+
+func (db *sessionDBMock) DetectSessions(userID uint64, gap time.Duration) (int, error) {
+	return db.detectSessions(userID, gap)
+}
+
+func (db *sessionDBMock) GetSessions(userID uint64, before time.Time, limit int, albums bool) ([]data.Session, error) {
+	return db.getSessions(userID, before, limit, albums)
+}
+
+func (db *sessionDBMock) GetSession(userID, sessionID uint64) (data.Session, error) {
+	return db.getSession(userID, sessionID)
+}
+
+func TestDetectOnce(t *testing.T) {
+	gap := 45 * time.Minute
+	detected := []uint64{}
+	db := &sessionDBMock{
+		getUnsessionedUsers: func(g time.Duration, limit int) ([]uint64, error) {
+			if g != gap || limit != SESSION_BATCH_SIZE {
+				t.Fatalf("expected %d users for a %v gap but got %d for %v", SESSION_BATCH_SIZE, gap, limit, g)
+			}
+			return []uint64{1, 2, 3}, nil
+		},
+		detectSessions: func(userID uint64, g time.Duration) (int, error) {
+			if g != gap {
+				t.Fatalf("expected a %v gap but got %v", gap, g)
+			}
+			if userID == 3 {
+				return 0, fmt.Errorf("connection refused")
+			}
+			detected = append(detected, userID)
+			return 4, nil
+		},
+	}
+
+	n, err := NewSessionDetector(db, gap).DetectOnce()
+	if err == nil || n != 2 || len(detected) != 2 {
+		t.Fatalf("expected to stop after 2 users but got %d: %v", n, err)
+	}
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tunes-service/data"
+)
+
+const (
+	USER_CHART_INTERVAL   = time.Hour
+	USER_CHART_BATCH_SIZE = 100
+)
+
+// UserChartSnapshotter stores the weekly charts of every user who listened in
+// a week once it ended, building the history of their charts. Weeks it missed,
+// e.g. while the server was down, are caught up on.
+type UserChartSnapshotter struct {
+	db  data.ChartDB
+	now func() time.Time
+}
+
+func NewUserChartSnapshotter(db data.ChartDB) *UserChartSnapshotter {
+	return &UserChartSnapshotter{db, time.Now}
+}
+
+func (s *UserChartSnapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(USER_CHART_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.SnapshotOnce(); err != nil {
+			log.Printf("user chart snapshots failed after %d users: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SnapshotOnce stores the charts of one batch of the complete weeks users
+// have none stored for and returns how many were stored.
+func (s *UserChartSnapshotter) SnapshotOnce() (int, error) {
+	weeks, err := s.db.GetUnsnapshottedWeeks(lastChartWeek(s.now()), USER_CHART_BATCH_SIZE)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, w := range weeks {
+		var entries []data.ChartEntry
+		for _, entity := range chartEntities {
+			chart, err := s.db.GetUserChart(w.UserID, entity, w.Week, w.Week.AddDate(0, 0, 7), data.WEEKLY_CHART_SIZE)
+			if err != nil {
+				return n, err
+			}
+			entries = append(entries, chart...)
+		}
+		if err := s.db.SaveUserWeek(w.UserID, w.Week, entries); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type chartDBMock struct {
+	getUserChart          func(uint64, data.EntityType, time.Time, time.Time, int) ([]data.ChartEntry, error)
+	getUnsnapshottedWeeks func(time.Time, int) ([]data.UserWeek, error)
+	saveUserWeek          func(uint64, time.Time, []data.ChartEntry) error
+	getUserWeek           func(uint64, data.EntityType, time.Time) ([]data.ChartEntry, error)
+	getChartRuns          func(uint64, data.EntityType, []uint64, time.Time) (map[uint64]data.ChartRun, error)
+	getChartPositions     func(uint64, data.EntityType, uint64) ([]data.ChartPosition, error)
+	getNumberOnes         func(uint64, data.EntityType) ([]data.NumberOne, error)
+}
+
+func (db *chartDBMock) GetUserChart(userID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+	return db.getUserChart(userID, entity, from, to, limit)
+}
+
+func (db *chartDBMock) GetUnsnapshottedWeeks(lastWeek time.Time, limit int) ([]data.UserWeek, error) {
+	return db.getUnsnapshottedWeeks(lastWeek, limit)
+}
+
+func (db *chartDBMock) SaveUserWeek(userID uint64, week time.Time, entries []data.ChartEntry) error {
+	return db.saveUserWeek(userID, week, entries)
+}
+
+func (db *chartDBMock) GetUserWeek(userID uint64, entity data.EntityType, week time.Time) ([]data.ChartEntry, error) {
+	return db.getUserWeek(userID, entity, week)
+}
+
+func (db *chartDBMock) GetChartRuns(userID uint64, entity data.EntityType, ids []uint64, before time.Time) (map[uint64]data.ChartRun, error) {
+	return db.getChartRuns(userID, entity, ids, before)
+}
+
+func (db *chartDBMock) GetChartPositions(userID uint64, entity data.EntityType, id uint64) ([]data.ChartPosition, error) {
+	return db.getChartPositions(userID, entity, id)
+}
+
+func (db *chartDBMock) GetNumberOnes(userID uint64, entity data.EntityType) ([]data.NumberOne, error) {
+	return db.getNumberOnes(userID, entity)
+}
+
+func TestUserChartSnapshotOnce(t *testing.T) {
+	week := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	missed := week.AddDate(0, 0, -14)
+	saved := map[string][]data.ChartEntry{}
+	db := &chartDBMock{
+		getUnsnapshottedWeeks: func(w time.Time, limit int) ([]data.UserWeek, error) {
+			if !w.Equal(week) || limit != USER_CHART_BATCH_SIZE {
+				t.Fatalf("expected a batch up to the week of %v but got %v, limit %d", week, w, limit)
+			}
+			return []data.UserWeek{{UserID: 3, Week: missed}, {UserID: 3, Week: week}}, nil
+		},
+		getUserChart: func(userID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+			if !to.Equal(from.AddDate(0, 0, 7)) || limit != data.WEEKLY_CHART_SIZE {
+				t.Fatalf("expected a week but got %v to %v, limit %d", from, to, limit)
+			}
+			return []data.ChartEntry{{Rank: 1, Entity: entity, ID: 1}, {Rank: 2, Entity: entity, ID: 2}}, nil
+		},
+		saveUserWeek: func(userID uint64, w time.Time, entries []data.ChartEntry) error {
+			saved[fmt.Sprintf("%d/%s", userID, w.Format(time.DateOnly))] = entries
+			return nil
+		},
+	}
+
+	s := NewUserChartSnapshotter(db)
+	s.now = func() time.Time { return time.Date(2024, 3, 12, 8, 0, 0, 0, time.UTC) }
+	n, err := s.SnapshotOnce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(saved["3/2024-02-19"]) != 6 || len(saved["3/2024-03-04"]) != 6 {
+		t.Fatalf("expected the user's three charts of the missed and the last week but got %d: %+v", n, saved)
+	}
+}
@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tunes-service/data"
+)
+
+const (
+	WRAPPED_INTERVAL   = time.Hour
+	WRAPPED_BATCH_SIZE = 50
+	WRAPPED_TOP_LIMIT  = 5
+)
+
+// WrappedDB is where the Wrapped generator reads a year of listening from and
+// stores the reports.
+type WrappedDB interface {
+	data.StatsDB
+	data.WrappedDB
+	GetTagChart(userID uint64, from, to time.Time, limit int, private bool) ([]data.TagListening, error)
+}
+
+// WrappedGenerator keeps the users' year in review reports up to date: the
+// reports of every past year, and in December already the one of the current
+// year.
+type WrappedGenerator struct {
+	db  WrappedDB
+	now func() time.Time
+}
+
+func NewWrappedGenerator(db WrappedDB) *WrappedGenerator {
+	return &WrappedGenerator{db, time.Now}
+}
+
+func (g *WrappedGenerator) Run(ctx context.Context) {
+	ticker := time.NewTicker(WRAPPED_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		if n, err := g.GenerateOnce(); err != nil {
+			log.Printf("wrapped generation failed after %d reports: %v", n, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GenerateOnce generates one batch of the missing or outdated reports and
+// returns how many were generated. Users whose report fails are skipped until
+// the next run.
+func (g *WrappedGenerator) GenerateOnce() (int, error) {
+	n := 0
+
+	now := g.now().UTC()
+	lastYear := now.Year() - 1
+	if now.Month() == time.December {
+		lastYear = now.Year()
+	}
+
+	stale, err := g.db.GetStaleWrapped(lastYear, WRAPPED_BATCH_SIZE)
+	if err != nil {
+		return n, err
+	}
+	for _, s := range stale {
+		if err := g.generate(s, now); err != nil {
+			log.Printf("could not generate wrapped report of %d for user %d: %v", s.Year, s.UserID, err)
+			continue
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+func (g *WrappedGenerator) generate(s data.StaleWrapped, now time.Time) error {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return err
+	}
+	w, err := BuildWrapped(g.db, s.UserID, s.Year, loc)
+	if err != nil {
+		return err
+	}
+	w.GeneratedAt = now
+	return g.db.SaveWrapped(s.UserID, w)
+}
+
+// BuildWrapped computes a user's year in review, from New Year to New Year in
+// their time zone.
+func BuildWrapped(db WrappedDB, userID uint64, year int, loc *time.Location) (data.Wrapped, error) {
+	from, to := data.YearRange(year, loc)
+	w := data.Wrapped{Year: year}
+
+	days, err := db.GetListeningTime(userID, data.Day, from, to)
+	if err != nil {
+		return data.Wrapped{}, err
+	}
+	var msPlayed uint64
+	streak := data.Streak{}
+	for _, day := range days {
+		w.Spins += day.Spins
+		msPlayed += day.MsPlayed
+		if day.MsPlayed > w.MostPlayedDay.MsPlayed {
+			w.MostPlayedDay = day
+		}
+		streak, _ = streak.Extend(day.Start.In(loc))
+	}
+	w.Minutes = msPlayed / uint64(time.Minute/time.Millisecond)
+	w.LongestStreak = streak.Longest
+
+	if w.TopArtists, err = db.GetArtistListening(userID, from, to, WRAPPED_TOP_LIMIT); err != nil {
+		return data.Wrapped{}, err
+	}
+	if w.TopTracks, err = db.GetTrackListening(userID, from, to, WRAPPED_TOP_LIMIT); err != nil {
+		return data.Wrapped{}, err
+	}
+	if w.TopProjects, err = db.GetProjectListening(userID, from, to, WRAPPED_TOP_LIMIT); err != nil {
+		return data.Wrapped{}, err
+	}
+	if w.TopGenres, err = db.GetTagChart(userID, from, to, WRAPPED_TOP_LIMIT, false); err != nil {
+		return data.Wrapped{}, err
+	}
+
+	newArtists, err := db.GetNewArtists(userID, from, to)
+	if err != nil {
+		return data.Wrapped{}, err
+	}
+	w.NewArtistCount = uint64(len(newArtists))
+	w.NewArtists = newArtists[:min(len(newArtists), WRAPPED_TOP_LIMIT)]
+
+	hours, err := db.GetHourlyListening(userID, from, to)
+	if err != nil {
+		return data.Wrapped{}, err
+	}
+	for _, h := range hours {
+		w.Heatmap[h.Weekday][h.Hour] = h.Spins
+	}
+
+	if w.OnRepeat, err = db.GetOnRepeat(userID, from, to, WRAPPED_TOP_LIMIT); err != nil {
+		return data.Wrapped{}, err
+	}
+
+	return w, nil
+}
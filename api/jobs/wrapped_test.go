@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type wrappedDBMock struct {
+	getListeningTime    func(uint64, data.Period, time.Time, time.Time) ([]data.ListeningTime, error)
+	getArtistListening  func(uint64, time.Time, time.Time, int) ([]data.ArtistListening, error)
+	getProjectListening func(uint64, time.Time, time.Time, int) ([]data.ProjectListening, error)
+	getTrackListening   func(uint64, time.Time, time.Time, int) ([]data.TrackListening, error)
+	getNewArtists       func(uint64, time.Time, time.Time) ([]data.ArtistListening, error)
+	getHourlyListening  func(uint64, time.Time, time.Time) ([]data.HourListening, error)
+	getOnRepeat         func(uint64, time.Time, time.Time, int) ([]data.TrackListening, error)
+	getTagChart         func(uint64, time.Time, time.Time, int, bool) ([]data.TagListening, error)
+	getStaleWrapped     func(int, int) ([]data.StaleWrapped, error)
+	saveWrapped         func(uint64, data.Wrapped) error
+	getWrapped          func(uint64, int) (data.Wrapped, error)
+}
+
+func (db *wrappedDBMock) GetListeningTime(userID uint64, period data.Period, from, to time.Time) ([]data.ListeningTime, error) {
+	return db.getListeningTime(userID, period, from, to)
+}
+
+func (db *wrappedDBMock) GetArtistListening(userID uint64, from, to time.Time, limit int) ([]data.ArtistListening, error) {
+	return db.getArtistListening(userID, from, to, limit)
+}
+
+func (db *wrappedDBMock) GetProjectListening(userID uint64, from, to time.Time, limit int) ([]data.ProjectListening, error) {
+	return db.getProjectListening(userID, from, to, limit)
+}
+
+func (db *wrappedDBMock) GetTrackListening(userID uint64, from, to time.Time, limit int) ([]data.TrackListening, error) {
+	return db.getTrackListening(userID, from, to, limit)
+}
+
+func (db *wrappedDBMock) GetNewArtists(userID uint64, from, to time.Time) ([]data.ArtistListening, error) {
+	return db.getNewArtists(userID, from, to)
+}
+
+func (db *wrappedDBMock) GetHourlyListening(userID uint64, from, to time.Time) ([]data.HourListening, error) {
+	return db.getHourlyListening(userID, from, to)
+}
+
+func (db *wrappedDBMock) GetOnRepeat(userID uint64, from, to time.Time, limit int) ([]data.TrackListening, error) {
+	return db.getOnRepeat(userID, from, to, limit)
+}
+
+func (db *wrappedDBMock) GetTagChart(userID uint64, from, to time.Time, limit int, private bool) ([]data.TagListening, error) {
+	return db.getTagChart(userID, from, to, limit, private)
+}
+
+func (db *wrappedDBMock) GetStaleWrapped(lastYear int, limit int) ([]data.StaleWrapped, error) {
+	return db.getStaleWrapped(lastYear, limit)
+}
+
+func (db *wrappedDBMock) SaveWrapped(userID uint64, w data.Wrapped) error {
+	return db.saveWrapped(userID, w)
+}
+
+func (db *wrappedDBMock) GetWrapped(userID uint64, year int) (data.Wrapped, error) {
+	return db.getWrapped(userID, year)
+}
+
+// newWrappedDBMock returns a mock of a user who listened on three days in a
+// row and once more a week later in 2023, and discovered six artists.
+func newWrappedDBMock(t *testing.T) *wrappedDBMock {
+	day := func(d int) time.Time { return time.Date(2023, 3, d, 0, 0, 0, 0, time.UTC) }
+	checkRange := func(from, to time.Time) {
+		if !from.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("expected the range of 2023 but got %v to %v", from, to)
+		}
+	}
+
+	return &wrappedDBMock{
+		getListeningTime: func(userID uint64, period data.Period, from, to time.Time) ([]data.ListeningTime, error) {
+			checkRange(from, to)
+			if period != data.Day {
+				t.Fatalf("expected daily listening time but got %s", period)
+			}
+			return []data.ListeningTime{
+				{Start: day(1), Spins: 2, MsPlayed: 360000},
+				{Start: day(2), Spins: 10, MsPlayed: 1800000},
+				{Start: day(3), Spins: 1, MsPlayed: 180000},
+				{Start: day(10), Spins: 3, MsPlayed: 540000},
+			}, nil
+		},
+		getArtistListening: func(userID uint64, from, to time.Time, limit int) ([]data.ArtistListening, error) {
+			return []data.ArtistListening{{Artist: data.Artist{ID: 1, Name: "Olivia Rodrigo"}, Spins: 16}}, nil
+		},
+		getProjectListening: func(userID uint64, from, to time.Time, limit int) ([]data.ProjectListening, error) {
+			return []data.ProjectListening{}, nil
+		},
+		getTrackListening: func(userID uint64, from, to time.Time, limit int) ([]data.TrackListening, error) {
+			return []data.TrackListening{}, nil
+		},
+		getNewArtists: func(userID uint64, from, to time.Time) ([]data.ArtistListening, error) {
+			checkRange(from, to)
+			return make([]data.ArtistListening, 6), nil
+		},
+		getHourlyListening: func(userID uint64, from, to time.Time) ([]data.HourListening, error) {
+			return []data.HourListening{{Weekday: 3, Hour: 22, Spins: 10}}, nil
+		},
+		getOnRepeat: func(userID uint64, from, to time.Time, limit int) ([]data.TrackListening, error) {
+			return []data.TrackListening{{Track: data.Track{ID: 7, Title: "vampire"}, Spins: 8}}, nil
+		},
+		getTagChart: func(userID uint64, from, to time.Time, limit int, private bool) ([]data.TagListening, error) {
+			if private {
+				t.Fatalf("expected Wrapped to rank global tags only")
+			}
+			return []data.TagListening{{Tag: data.Tag{ID: 1, Name: "pop"}, Spins: 16}}, nil
+		},
+	}
+}
+
+func TestBuildWrapped(t *testing.T) {
+	w, err := BuildWrapped(newWrappedDBMock(t), 1, 2023, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Year != 2023 || w.Spins != 16 || w.Minutes != 48 {
+		t.Fatalf("expected 16 spins and 48 minutes in 2023 but got %d and %d in %d", w.Spins, w.Minutes, w.Year)
+	}
+	if !w.MostPlayedDay.Start.Equal(time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected March 2nd to be the most played day but got %v", w.MostPlayedDay.Start)
+	}
+	if w.LongestStreak != 3 {
+		t.Fatalf("expected a longest streak of 3 days but got %d", w.LongestStreak)
+	}
+	if w.NewArtistCount != 6 || len(w.NewArtists) != WRAPPED_TOP_LIMIT {
+		t.Fatalf("expected 6 new artists with the top %d listed but got %d with %d", WRAPPED_TOP_LIMIT, w.NewArtistCount, len(w.NewArtists))
+	}
+	if w.Heatmap[3][22] != 10 {
+		t.Fatalf("expected 10 spins on Wednesdays at 10pm but got %d", w.Heatmap[3][22])
+	}
+	if len(w.TopArtists) != 1 || len(w.TopGenres) != 1 || len(w.OnRepeat) != 1 {
+		t.Fatalf("expected the top artist, genre and track on repeat but got %+v", w)
+	}
+}
+
+func TestGenerateOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		lastYear int
+	}{
+		{"last year", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), 2023},
+		{"december", time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), 2023},
+		{"january", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 2023},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := map[string]data.Wrapped{}
+			db := newWrappedDBMock(t)
+			db.getListeningTime = func(userID uint64, period data.Period, from, to time.Time) ([]data.ListeningTime, error) {
+				if userID == 3 {
+					return nil, fmt.Errorf("connection reset")
+				}
+				if userID == 2 && from.Location().String() != "America/New_York" {
+					t.Fatalf("expected the year in the user's time zone but got %v", from)
+				}
+				return []data.ListeningTime{}, nil
+			}
+			db.getStaleWrapped = func(lastYear int, limit int) ([]data.StaleWrapped, error) {
+				if lastYear != tt.lastYear {
+					t.Fatalf("expected reports up to %d but got %d", tt.lastYear, lastYear)
+				}
+				return []data.StaleWrapped{
+					{UserID: 1, Year: 2021, TimeZone: "UTC"},
+					{UserID: 1, Year: 2022, TimeZone: "UTC"},
+					{UserID: 2, Year: 2022, TimeZone: "America/New_York"},
+					{UserID: 3, Year: 2022, TimeZone: "UTC"},
+					{UserID: 4, Year: 2022, TimeZone: "UTC"},
+				}, nil
+			}
+			db.getNewArtists = func(userID uint64, from, to time.Time) ([]data.ArtistListening, error) {
+				return []data.ArtistListening{}, nil
+			}
+			db.saveWrapped = func(userID uint64, w data.Wrapped) error {
+				saved[fmt.Sprintf("%d/%d", userID, w.Year)] = w
+				return nil
+			}
+
+			g := NewWrappedGenerator(db)
+			g.now = func() time.Time { return tt.now }
+			n, err := g.GenerateOnce()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if n != 4 || len(saved) != 4 || saved["1/2021"].Year != 2021 || !saved["4/2022"].GeneratedAt.Equal(tt.now) {
+				t.Fatalf("expected every report but the failing one generated at %v but got %d: %+v", tt.now, n, saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DumpProvider answers lookups from a local MusicBrainz release dump, one
+// release JSON document per line, optionally gzipped. The dump is indexed in
+// memory when the provider is created.
+type DumpProvider struct {
+	releases        map[string]Release
+	releasesByKey   map[string]Release
+	recordings      map[string]Recording
+	recordingsByKey map[string]Recording
+}
+
+func NewDumpProvider(path string) (*DumpProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open dump: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read dump: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return readDump(r)
+}
+
+func readDump(r io.Reader) (*DumpProvider, error) {
+	p := &DumpProvider{
+		releases:        map[string]Release{},
+		releasesByKey:   map[string]Release{},
+		recordings:      map[string]Recording{},
+		recordingsByKey: map[string]Recording{},
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var mbr mbRelease
+		if err := json.Unmarshal(scanner.Bytes(), &mbr); err != nil {
+			return nil, fmt.Errorf("could not parse dump line %d: %w", line, err)
+		}
+		p.add(mbr)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read dump: %w", err)
+	}
+
+	return p, nil
+}
+
+func (p *DumpProvider) add(mbr mbRelease) {
+	rel := mbr.release()
+	releaseArtists := creditNames(mbr.ArtistCredit)
+
+	p.releases[rel.MBID] = rel
+	key := matchKey(rel.Title, releaseArtists)
+	// The earliest release is the best guess for a title shared by reissues.
+	if existing, ok := p.releasesByKey[key]; !ok || (!rel.Date.IsZero() && rel.Date.Before(existing.Date)) {
+		p.releasesByKey[key] = rel
+	}
+
+	i := 0
+	for _, m := range mbr.Media {
+		for _, t := range m.Tracks {
+			rec := rel.Tracks[i]
+			rec.Number = 0
+			i++
+
+			p.recordings[rec.MBID] = rec
+			artists := creditNames(t.Recording.ArtistCredit)
+			if len(artists) == 0 {
+				artists = releaseArtists
+			}
+			if key := matchKey(rec.Title, artists); p.recordingsByKey[key].MBID == "" {
+				p.recordingsByKey[key] = rec
+			}
+		}
+	}
+}
+
+func (p *DumpProvider) Recording(q Query) (Recording, error) {
+	if rec, ok := p.recordings[q.MBID]; ok && q.MBID != "" {
+		return rec, nil
+	}
+	if rec, ok := p.recordingsByKey[matchKey(q.Title, q.ArtistNames)]; ok {
+		return rec, nil
+	}
+	return Recording{}, ErrNotFound
+}
+
+func (p *DumpProvider) Release(q Query) (Release, error) {
+	if rel, ok := p.releases[q.MBID]; ok && q.MBID != "" {
+		return rel, nil
+	}
+	if rel, ok := p.releasesByKey[matchKey(q.Title, q.ArtistNames)]; ok {
+		return rel, nil
+	}
+	return Release{}, ErrNotFound
+}
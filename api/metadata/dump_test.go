@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDumpProvider(t *testing.T) {
+	p, err := NewDumpProvider("testdata/releases.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Release by title and artists", func(t *testing.T) {
+		rel, err := p.Release(Query{Title: "guts", ArtistNames: []string{"olivia rodrigo "}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rel.Label != "Geffen" || len(rel.Tracks) != 2 || rel.Tracks[1].Number != 2 {
+			t.Fatalf("unexpected release %+v", rel)
+		}
+		if len(rel.Genres) != 2 || rel.Genres[0] != "pop rock" {
+			t.Fatalf("unexpected genres %v", rel.Genres)
+		}
+		if expected := time.Date(2023, 9, 8, 0, 0, 0, 0, time.UTC); !rel.Date.Equal(expected) {
+			t.Fatalf("expected release date %s but got %s", expected, rel.Date)
+		}
+	})
+
+	t.Run("Release with partial date", func(t *testing.T) {
+		rel, err := p.Release(Query{MBID: "2f6b3ba0-1e3b-4b3f-9b0a-8b9c8f1c1a03"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC); !rel.Date.Equal(expected) || rel.DatePrecision != "month" {
+			t.Fatalf("expected release month %s but got %s (%s)", expected, rel.Date, rel.DatePrecision)
+		}
+	})
+
+	t.Run("Recording by MBID", func(t *testing.T) {
+		rec, err := p.Recording(Query{MBID: "a1a1a1a1-0000-4000-8000-000000000002"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Duration != 184*time.Second || rec.ISRC != "USUG12305014" || rec.Number != 0 {
+			t.Fatalf("unexpected recording %+v", rec)
+		}
+	})
+
+	t.Run("Recording length falls back to the recording", func(t *testing.T) {
+		rec, err := p.Recording(Query{Title: "obsessed", ArtistNames: []string{"Olivia Rodrigo"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Duration != 170*time.Second {
+			t.Fatalf("expected 170s but got %s", rec.Duration)
+		}
+	})
+
+	t.Run("Unknown recording", func(t *testing.T) {
+		if _, err := p.Recording(Query{Title: "drivers license", ArtistNames: []string{"Olivia Rodrigo"}}); err != ErrNotFound {
+			t.Fatalf("expected ErrNotFound but got %v", err)
+		}
+	})
+}
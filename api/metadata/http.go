@@ -0,0 +1,136 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	USER_AGENT             = "tunes-server/1.0"
+	MUSICBRAINZ_RATE_LIMIT = time.Second
+	// MIN_SEARCH_SCORE is the lowest score out of 100 a search result needs to
+	// be taken as the entity searched for. Weaker matches are often another
+	// recording or release with a similar title.
+	MIN_SEARCH_SCORE = 90
+)
+
+// HTTPProvider answers lookups from a MusicBrainz compatible web service,
+// searching by title and artists when no identifier is known.
+type HTTPProvider struct {
+	baseURL   string
+	client    *http.Client
+	rateLimit time.Duration
+
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+func NewHTTPProvider(baseURL string, rateLimit time.Duration) *HTTPProvider {
+	return &HTTPProvider{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+		rateLimit: rateLimit,
+	}
+}
+
+func (p *HTTPProvider) Recording(q Query) (Recording, error) {
+	id := q.MBID
+	if id == "" {
+		results := struct {
+			Recordings []mbRecording `json:"recordings"`
+		}{}
+		if err := p.get("recording", url.Values{"query": {searchQuery("recording", q)}}, &results); err != nil {
+			return Recording{}, err
+		}
+		if len(results.Recordings) == 0 || results.Recordings[0].Score < MIN_SEARCH_SCORE {
+			return Recording{}, ErrNotFound
+		}
+		id = results.Recordings[0].ID
+	}
+
+	var rec mbRecording
+	if err := p.get("recording/"+id, url.Values{"inc": {"isrcs artist-credits genres"}}, &rec); err != nil {
+		return Recording{}, err
+	}
+	return rec.recording(), nil
+}
+
+func (p *HTTPProvider) Release(q Query) (Release, error) {
+	id := q.MBID
+	if id == "" {
+		results := struct {
+			Releases []mbRelease `json:"releases"`
+		}{}
+		if err := p.get("release", url.Values{"query": {searchQuery("release", q)}}, &results); err != nil {
+			return Release{}, err
+		}
+		if len(results.Releases) == 0 || results.Releases[0].Score < MIN_SEARCH_SCORE {
+			return Release{}, ErrNotFound
+		}
+		id = results.Releases[0].ID
+	}
+
+	var rel mbRelease
+	if err := p.get("release/"+id, url.Values{"inc": {"recordings isrcs labels artist-credits genres"}}, &rel); err != nil {
+		return Release{}, err
+	}
+	return rel.release(), nil
+}
+
+func (p *HTTPProvider) get(path string, params url.Values, v any) error {
+	p.wait()
+
+	params.Set("fmt", "json")
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/"+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create metadata request: %w", err)
+	}
+	req.Header.Set("User-Agent", USER_AGENT)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("metadata request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("metadata request failed with status %d", res.StatusCode)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not decode metadata response: %w", err)
+	}
+	return nil
+}
+
+// wait spaces requests out by the provider's rate limit.
+func (p *HTTPProvider) wait() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if next := p.lastRequest.Add(p.rateLimit); time.Now().Before(next) {
+		time.Sleep(time.Until(next))
+	}
+	p.lastRequest = time.Now()
+}
+
+func searchQuery(field string, q Query) string {
+	terms := []string{fmt.Sprintf(`%s:"%s"`, field, escape(q.Title))}
+	for _, name := range q.ArtistNames {
+		terms = append(terms, fmt.Sprintf(`artist:"%s"`, escape(name)))
+	}
+	return strings.Join(terms, " AND ")
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
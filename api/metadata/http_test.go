@@ -0,0 +1,78 @@
+package metadata
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fmt") != "json" || r.Header.Get("User-Agent") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/release" && strings.Contains(r.URL.Query().Get("query"), `release:"GUTS"`):
+			fmt.Fprint(w, `{"releases":[{"id":"rel-1","title":"GUTS","score":100}]}`)
+		case r.URL.Path == "/release" && strings.Contains(r.URL.Query().Get("query"), `release:"GUT"`):
+			fmt.Fprint(w, `{"releases":[{"id":"rel-1","title":"GUTS","score":62}]}`)
+		case r.URL.Path == "/recording" && strings.Contains(r.URL.Query().Get("query"), `recording:"all-american bitch"`):
+			fmt.Fprint(w, `{"recordings":[{"id":"rec-1","title":"all-american bitch","score":98}]}`)
+		case r.URL.Path == "/recording":
+			fmt.Fprint(w, `{"recordings":[{"id":"rec-2","title":"all american","score":40}]}`)
+		case r.URL.Path == "/release":
+			fmt.Fprint(w, `{"releases":[]}`)
+		case r.URL.Path == "/release/rel-1":
+			fmt.Fprint(w, `{"id":"rel-1","title":"GUTS","date":"2023-09-08","label-info":[{"label":{"name":"Geffen"}}],"media":[{"tracks":[{"position":1,"title":"all-american bitch","length":165000,"recording":{"id":"rec-1","title":"all-american bitch"}}]}]}`)
+		case r.URL.Path == "/recording/rec-1":
+			fmt.Fprint(w, `{"id":"rec-1","title":"all-american bitch","length":165000,"isrcs":["USUG12304771"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := NewHTTPProvider(server.URL, 0)
+
+	rel, err := p.Release(Query{Title: "GUTS", ArtistNames: []string{"Olivia Rodrigo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Label != "Geffen" || len(rel.Tracks) != 1 || rel.Tracks[0].Duration != 165*time.Second {
+		t.Fatalf("unexpected release %+v", rel)
+	}
+
+	rec, err := p.Recording(Query{MBID: "rec-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.ISRC != "USUG12304771" {
+		t.Fatalf("unexpected recording %+v", rec)
+	}
+
+	rec, err = p.Recording(Query{Title: "all-american bitch", ArtistNames: []string{"Olivia Rodrigo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.MBID != "rec-1" {
+		t.Fatalf("unexpected recording %+v", rec)
+	}
+
+	if _, err := p.Release(Query{Title: "SOUR"}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound but got %v", err)
+	}
+	if _, err := p.Release(Query{Title: "GUT"}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a weak match but got %v", err)
+	}
+	if _, err := p.Recording(Query{Title: "all american"}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a weak match but got %v", err)
+	}
+	if _, err := p.Recording(Query{MBID: "missing"}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound but got %v", err)
+	}
+}
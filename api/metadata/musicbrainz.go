@@ -0,0 +1,135 @@
+package metadata
+
+import (
+	"time"
+)
+
+// The types below mirror the JSON documents of the MusicBrainz web service,
+// which the JSON data dumps share.
+
+type mbArtistCredit struct {
+	Name   string `json:"name"`
+	Artist struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"artist"`
+}
+
+type mbGenre struct {
+	Name string `json:"name"`
+}
+
+// Score is only set on search results, where it rates the match out of 100.
+type mbRecording struct {
+	ID           string           `json:"id"`
+	Score        int              `json:"score"`
+	Title        string           `json:"title"`
+	Length       int              `json:"length"`
+	ISRCs        []string         `json:"isrcs"`
+	ArtistCredit []mbArtistCredit `json:"artist-credit"`
+	Genres       []mbGenre        `json:"genres"`
+}
+
+type mbTrack struct {
+	Position  int         `json:"position"`
+	Title     string      `json:"title"`
+	Length    int         `json:"length"`
+	Recording mbRecording `json:"recording"`
+}
+
+type mbMedium struct {
+	Position int       `json:"position"`
+	Tracks   []mbTrack `json:"tracks"`
+}
+
+type mbLabelInfo struct {
+	Label struct {
+		Name string `json:"name"`
+	} `json:"label"`
+}
+
+type mbRelease struct {
+	ID           string           `json:"id"`
+	Score        int              `json:"score"`
+	Title        string           `json:"title"`
+	Date         string           `json:"date"`
+	ArtistCredit []mbArtistCredit `json:"artist-credit"`
+	LabelInfo    []mbLabelInfo    `json:"label-info"`
+	Media        []mbMedium       `json:"media"`
+	Genres       []mbGenre        `json:"genres"`
+}
+
+func (r mbRecording) recording() Recording {
+	rec := Recording{
+		MBID:     r.ID,
+		Title:    r.Title,
+		Duration: time.Duration(r.Length) * time.Millisecond,
+		Genres:   genreNames(r.Genres),
+	}
+	if len(r.ISRCs) > 0 {
+		rec.ISRC = r.ISRCs[0]
+	}
+	return rec
+}
+
+func (r mbRelease) release() Release {
+	rel := Release{
+		MBID:   r.ID,
+		Title:  r.Title,
+		Tracks: []Recording{},
+		Genres: genreNames(r.Genres),
+	}
+	rel.Date, rel.DatePrecision = parseDate(r.Date)
+	if len(r.LabelInfo) > 0 {
+		rel.Label = r.LabelInfo[0].Label.Name
+	}
+
+	number := 0
+	for _, m := range r.Media {
+		for _, t := range m.Tracks {
+			number++
+			rec := t.Recording.recording()
+			if t.Length != 0 {
+				rec.Duration = time.Duration(t.Length) * time.Millisecond
+			}
+			rec.Number = number
+			rel.Tracks = append(rel.Tracks, rec)
+		}
+	}
+	return rel
+}
+
+func creditNames(credits []mbArtistCredit) []string {
+	names := []string{}
+	for _, c := range credits {
+		if c.Artist.Name != "" {
+			names = append(names, c.Artist.Name)
+		} else {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+func genreNames(genres []mbGenre) []string {
+	names := []string{}
+	for _, g := range genres {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// parseDate accepts the year, year-month and full dates MusicBrainz uses and
+// reports how precise the date is.
+func parseDate(date string) (time.Time, string) {
+	for _, f := range []struct{ layout, precision string }{
+		{"2006-01-02", "day"},
+		{"2006-01", "month"},
+		{"2006", "year"},
+	} {
+		if t, err := time.Parse(f.layout, date); err == nil {
+			return t, f.precision
+		}
+	}
+	return time.Time{}, ""
+}
@@ -0,0 +1,59 @@
+package metadata
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("metadata not found")
+
+// Query identifies a recording or release, by MusicBrainz identifier when
+// one is known and by title and artist names otherwise.
+type Query struct {
+	MBID        string
+	Title       string
+	ArtistNames []string
+}
+
+type Recording struct {
+	MBID     string
+	Title    string
+	Duration time.Duration
+	ISRC     string
+	Number   int
+	Genres   []string
+}
+
+type Release struct {
+	MBID   string
+	Title  string
+	Date   time.Time
+	Label  string
+	Tracks []Recording
+	Genres []string
+	// DatePrecision is "year", "month" or "day" depending on how much of the
+	// release date is known.
+	DatePrecision string
+}
+
+type Provider interface {
+	Recording(q Query) (Recording, error)
+	Release(q Query) (Release, error)
+}
+
+// matchKey normalizes a title and its artists so lookups ignore case,
+// surrounding whitespace and artist order.
+func matchKey(title string, artistNames []string) string {
+	names := []string{}
+	for _, name := range artistNames {
+		names = append(names, normalize(name))
+	}
+	sort.Strings(names)
+	return strings.Join(append([]string{normalize(title)}, names...), "|")
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"strings"
+
+	"tunes-service/art"
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+	"tunes-service/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const ART_MAX_AGE = "86400"
+
+func registerArtRoutes(app *fiber.App, db data.ArtDB, store storage.Store) {
+	for path, entity := range map[string]data.EntityType{
+		"artists":  data.ArtistEntity,
+		"projects": data.ProjectEntity,
+	} {
+		entity := entity
+
+		// Artwork is public so clients can load it without a token.
+		app.Get("/api/"+path+"/:id/art/:size", func(c *fiber.Ctx) error {
+			key, err := parseID(c, "id")
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			size := art.Size(c.Params("size"))
+			image, hash, err := handlers.HandleGetArt(entity, key, size, db, store)
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			etag := `"` + hash + "-" + string(size) + `"`
+			c.Set(fiber.HeaderCacheControl, "public, max-age="+ART_MAX_AGE)
+			c.Set(fiber.HeaderETag, etag)
+			if strings.Contains(c.Get(fiber.HeaderIfNoneMatch), etag) {
+				return c.SendStatus(fiber.StatusNotModified)
+			}
+
+			c.Set(fiber.HeaderContentType, "image/jpeg")
+			return c.Send(image)
+		})
+
+		app.Put("/api/admin/"+path+"/:id/art", func(c *fiber.Ctx) error {
+			key, err := parseID(c, "id")
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			hash, err := handlers.HandleUploadArt(entity, key, c.Body(), db, store)
+			if err != nil {
+				return sendError(c, err)
+			}
+			return c.JSON(struct {
+				Hash string
+			}{hash})
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+	"tunes-service/server/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerCatalogRoutes serves the artist, project and track pages. They are
+// public, and visitors who send a token also get their own play counts.
+func registerCatalogRoutes(app *fiber.App, db data.CatalogDB) {
+	app.Get("/api/artists/:id", middleware.OptionalJWTMiddleware(), func(c *fiber.Ctx) error {
+		id, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		page, err := handlers.HandleGetArtist(id, middleware.Claims(c).UserID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(page)
+	})
+
+	app.Get("/api/projects/:id", middleware.OptionalJWTMiddleware(), func(c *fiber.Ctx) error {
+		key, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		page, err := handlers.HandleGetProject(key, middleware.Claims(c).UserID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(page)
+	})
+
+	app.Get("/api/tracks/:id", middleware.OptionalJWTMiddleware(), func(c *fiber.Ctx) error {
+		key, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		page, err := handlers.HandleGetTrack(key, middleware.Claims(c).UserID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(page)
+	})
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerChartRoutes(app *fiber.App, db data.ChartDB, pdb data.AccessDB) {
+	app.Get("/api/users/:name/charts/:entity/weekly", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		week, err := parseTime(c.Query("week"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		chart, err := handlers.HandleWeeklyChart(userID, routeEntity(c), week, time.Now(), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(chart)
+	})
+
+	app.Get("/api/users/:name/charts/:entity/number-ones", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		numberOnes, err := handlers.HandleNumberOnes(userID, routeEntity(c), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(numberOnes)
+	})
+
+	app.Get("/api/users/:name/charts/:entity/:id/positions", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		id, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		positions, err := handlers.HandleChartPositions(userID, routeEntity(c), id, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(positions)
+	})
+}
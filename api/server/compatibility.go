@@ -0,0 +1,29 @@
+package server
+
+import (
+	"tunes-service/cache"
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+	"tunes-service/server/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerCompatibilityRoutes(app *fiber.App, db data.StatsDB, pdb data.AccessDB, cache cache.Cache) {
+	app.Get("/api/users/:name/compatibility/:other", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		otherID, err := handlers.HandleAccess(c.Params("other"), middleware.Claims(c).UserID, data.StatsFeature, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		compatibility, err := handlers.HandleCompatibility(userID, otherID, c.QueryInt("months"), db, cache)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(compatibility)
+	})
+}
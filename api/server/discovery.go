@@ -0,0 +1,76 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerDiscoveryRoutes(app *fiber.App, db data.DiscoveryDB, pdb data.AccessDB) {
+	app.Get("/api/users/:name/stats/discovery/first-listens", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		entity := data.EntityType(c.Query("type", string(data.ArtistEntity)))
+		listens, err := handlers.HandleFirstListens(userID, entity, from, to, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(listens)
+	})
+
+	app.Get("/api/users/:name/stats/discovery/new", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		discoveries, err := handlers.HandleDiscoveries(userID, data.Period(c.Query("period", string(data.Month))), from, to, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(discoveries)
+	})
+
+	app.Get("/api/users/:name/stats/discovery/diversity", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		diversity, err := handlers.HandleDiversity(userID, from, to, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(diversity)
+	})
+
+	app.Get("/api/users/:name/stats/discovery/forgotten", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		entity := data.EntityType(c.Query("type", string(data.ArtistEntity)))
+		favorites, err := handlers.HandleForgottenFavorites(userID, entity, c.QueryInt("months"), c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(favorites)
+	})
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerFollowRoutes(app *fiber.App, db data.FollowDB, pdb data.AccessDB) {
+	app.Get("/api/users/:name/follow", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		status, err := handlers.HandleFollowStatus(userID, c.Params("name"), db, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(struct{ Status data.FollowStatus }{status})
+	})
+
+	app.Post("/api/users/:name/follow", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		status, err := handlers.HandleFollow(userID, c.Params("name"), db, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(struct{ Status data.FollowStatus }{status})
+	})
+
+	app.Delete("/api/users/:name/follow", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleUnfollow(userID, c.Params("name"), db, pdb); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Get("/api/users/:name/followers", func(c *fiber.Ctx) error {
+		status, userID, before, err := followsQuery(c, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		follows, err := handlers.HandleFollowers(userID, status, before, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(follows)
+	})
+
+	app.Get("/api/users/:name/following", func(c *fiber.Ctx) error {
+		status, userID, before, err := followsQuery(c, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		follows, err := handlers.HandleFollowing(userID, status, before, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(follows)
+	})
+
+	app.Put("/api/users/:name/followers/:follower", func(c *fiber.Ctx) error {
+		userID, err := routeSelf(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleAcceptFollower(userID, c.Params("follower"), db, pdb); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Delete("/api/users/:name/followers/:follower", func(c *fiber.Ctx) error {
+		userID, err := routeSelf(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleRemoveFollower(userID, c.Params("follower"), db, pdb); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Get("/api/feed", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		before, err := parseTime(c.Query("before"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+		beforeID, err := parseQueryID(c, "before_id")
+		if err != nil {
+			return sendError(c, err)
+		}
+		cursor := data.FeedCursor{Time: before, Kind: data.FeedKind(c.Query("before_kind")), ID: beforeID}
+
+		feed, err := handlers.HandleFeed(userID, cursor, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(feed)
+	})
+}
+
+// followsQuery reads the status and before query parameters of a list of
+// follows. Pending follows are only listed to the user named in the route.
+func followsQuery(c *fiber.Ctx, pdb data.AccessDB) (status data.FollowStatus, userID uint64, before time.Time, err error) {
+	status = data.FollowStatus(c.Query("status", string(data.Following)))
+	if status == data.PendingFollow {
+		userID, err = routeSelf(c)
+	} else {
+		userID, err = routeUserID(c, pdb, data.FollowsFeature)
+	}
+	if err != nil {
+		return
+	}
+	before, err = parseTime(c.Query("before"), time.Time{})
+	return
+}
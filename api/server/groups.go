@@ -0,0 +1,247 @@
+package server
+
+import (
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type groupPayload struct {
+	Name        string
+	Description string
+	Open        bool
+}
+
+func registerGroupRoutes(app *fiber.App, db data.GroupDB, pdb data.AccessDB) {
+	app.Post("/api/groups", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := groupPayload{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		g, err := handlers.HandleCreateGroup(userID, data.Group{Name: payload.Name, Description: payload.Description, Open: payload.Open}, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(g)
+	})
+
+	app.Get("/api/groups", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		groups, err := handlers.HandleUserGroups(userID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(groups)
+	})
+
+	app.Get("/api/groups/invites", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		invites, err := handlers.HandleGroupInvites(userID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(invites)
+	})
+
+	app.Get("/api/groups/:id", func(c *fiber.Ctx) error {
+		groupID, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		g, err := handlers.HandleGroup(groupID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(g)
+	})
+
+	app.Put("/api/groups/:id", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := groupPayload{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		g := data.Group{ID: groupID, Name: payload.Name, Description: payload.Description, Open: payload.Open}
+		if err := handlers.HandleUpdateGroup(userID, g, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Delete("/api/groups/:id", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleDeleteGroup(userID, groupID, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Get("/api/groups/:id/members", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		members, err := handlers.HandleGroupMembers(userID, groupID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(members)
+	})
+
+	app.Put("/api/groups/:id/members/:name", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := struct {
+			Role data.GroupRole
+		}{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleSetGroupRole(userID, groupID, c.Params("name"), payload.Role, db, pdb); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Delete("/api/groups/:id/members/:name", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleRemoveGroupMember(userID, groupID, c.Params("name"), db, pdb); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Post("/api/groups/:id/invites", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := struct {
+			Name string
+		}{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleInviteToGroup(userID, groupID, payload.Name, db, pdb); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Delete("/api/groups/:id/invites/:name", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleDeleteGroupInvite(userID, groupID, c.Params("name"), db, pdb); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Post("/api/groups/:id/join", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleJoinGroup(userID, groupID, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Post("/api/groups/:id/leave", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleLeaveGroup(userID, groupID, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Get("/api/groups/:id/charts/:entity", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, err := parseTime(c.Query("from"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+		to, err := parseTime(c.Query("to"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		chart, err := handlers.HandleGroupChart(userID, groupID, routeEntity(c), from, to, time.Now(), c.QueryInt("limit"), db, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(chart)
+	})
+
+	app.Get("/api/groups/:id/charts/:entity/weekly", func(c *fiber.Ctx) error {
+		userID, groupID, err := groupRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		week, err := parseTime(c.Query("week"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		chart, err := handlers.HandleGroupWeeklyChart(userID, groupID, routeEntity(c), week, time.Now(), db, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(chart)
+	})
+}
+
+// groupRoute returns the ID of the requester and of the group in the route.
+func groupRoute(c *fiber.Ctx) (userID, groupID uint64, err error) {
+	if userID, err = requestUserID(c); err != nil {
+		return
+	}
+	groupID, err = parseID(c, "id")
+	return
+}
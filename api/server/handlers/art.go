@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"tunes-service/art"
+	d "tunes-service/data"
+	"tunes-service/storage"
+)
+
+// HandleUploadArt stores an image as the artwork of an artist or project and
+// returns its content hash.
+func HandleUploadArt(entity d.EntityType, key uint64, image []byte, db d.ArtDB, store storage.Store) (string, error) {
+	if entity != d.ArtistEntity && entity != d.ProjectEntity {
+		return "", fmt.Errorf("%w: %s has no artwork", ErrBadRequest, entity)
+	}
+	if _, err := getArt(entity, key, db); err != nil {
+		return "", err
+	}
+
+	hash, err := art.Save(image, store)
+	if errors.Is(err, art.ErrUnsupported) {
+		return "", fmt.Errorf("%w: %s", ErrBadRequest, err)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to save artwork: %w", err)
+	}
+
+	if err := db.SetArt(entity, key, hash); errors.Is(err, d.ErrNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to set artwork: %w", err)
+	}
+	return hash, nil
+}
+
+// HandleGetArt returns a thumbnail of an artist's or project's artwork along
+// with the artwork hash, which changes whenever the artwork does.
+func HandleGetArt(entity d.EntityType, key uint64, size art.Size, db d.ArtDB, store storage.Store) ([]byte, string, error) {
+	if !size.IsValid() {
+		return nil, "", fmt.Errorf("%w: unknown size %q", ErrBadRequest, size)
+	}
+
+	hash, err := getArt(entity, key, db)
+	if err != nil {
+		return nil, "", err
+	}
+	if hash == "" {
+		return nil, "", fmt.Errorf("%w: %s %d has no artwork", ErrNotFound, entity, key)
+	}
+
+	image, err := store.Get(art.Key(hash, size))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", fmt.Errorf("%w: artwork %s is missing", ErrNotFound, hash)
+	} else if err != nil {
+		return nil, "", fmt.Errorf("failed to get artwork: %w", err)
+	}
+	return image, hash, nil
+}
+
+func getArt(entity d.EntityType, key uint64, db d.ArtDB) (string, error) {
+	hash, err := db.GetArt(entity, key)
+	if errors.Is(err, d.ErrNotFound) {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get artwork: %w", err)
+	}
+	return hash, nil
+}
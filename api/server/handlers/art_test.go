@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"testing"
+
+	"tunes-service/art"
+	"tunes-service/data"
+	"tunes-service/storage"
+)
+
+type artDBMock struct {
+	setArt         func(data.EntityType, uint64, string) error
+	getArt         func(data.EntityType, uint64) (string, error)
+	getPendingArt  func(int) ([]data.ArtSource, error)
+	markArtFetched func(uint64) error
+}
+
+func (db *artDBMock) SetArt(entity data.EntityType, key uint64, hash string) error {
+	return db.setArt(entity, key, hash)
+}
+
+func (db *artDBMock) GetArt(entity data.EntityType, key uint64) (string, error) {
+	return db.getArt(entity, key)
+}
+
+func (db *artDBMock) GetPendingArt(limit int) ([]data.ArtSource, error) {
+	return db.getPendingArt(limit)
+}
+
+func (db *artDBMock) MarkArtFetched(key uint64) error {
+	return db.markArtFetched(key)
+}
+
+func TestHandleArt(t *testing.T) {
+	store, err := storage.NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 400)))
+
+	hashes := map[uint64]string{1: ""}
+	db := &artDBMock{
+		func(entity data.EntityType, key uint64, hash string) error {
+			hashes[key] = hash
+			return nil
+		},
+		func(entity data.EntityType, key uint64) (string, error) {
+			hash, ok := hashes[key]
+			if !ok {
+				return "", fmt.Errorf("%w: %s %d", data.ErrNotFound, entity, key)
+			}
+			return hash, nil
+		},
+		nil,
+		nil,
+	}
+
+	if _, _, err := HandleGetArt(data.ProjectEntity, 1, art.Medium, db, store); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v before upload but got %v", ErrNotFound, err)
+	}
+
+	uploads := []struct {
+		name   string
+		entity data.EntityType
+		key    uint64
+		image  []byte
+		err    error
+	}{
+		{"Should upload artwork", data.ProjectEntity, 1, buf.Bytes(), nil},
+		{"Tracks have no artwork", data.TrackEntity, 1, buf.Bytes(), ErrBadRequest},
+		{"Unknown project", data.ProjectEntity, 2, buf.Bytes(), ErrNotFound},
+		{"Not an image", data.ProjectEntity, 1, []byte("<html>"), ErrBadRequest},
+	}
+
+	for _, tt := range uploads {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HandleUploadArt(tt.entity, tt.key, tt.image, db, store)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+
+	image, hash, err := HandleGetArt(data.ProjectEntity, 1, art.Medium, db, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != hashes[1] || len(image) == 0 {
+		t.Fatalf("expected artwork %s but got %s", hashes[1], hash)
+	}
+
+	if _, _, err := HandleGetArt(data.ProjectEntity, 1, art.Size("huge"), db, store); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected %v for unknown size but got %v", ErrBadRequest, err)
+	}
+}
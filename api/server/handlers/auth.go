@@ -29,15 +29,18 @@ func HandleLogin(usernameOrEmail string, password string, udb data.UserDB, adb d
 		return accessToken, refreshToken, fmt.Errorf("incorrect password")
 	}
 
-	accessToken, _ = auth.CreateAccessToken()
+	accessToken, _ = auth.CreateAccessToken(auth.Claims{UserID: u.ID, Name: u.Name, Admin: u.Admin})
 	refreshToken, refreshTokenExpiration := auth.CreateRefreshToken()
 
-	adb.WriteRefreshToken(refreshToken, refreshTokenExpiration)
+	adb.WriteRefreshToken(refreshToken, u.ID, refreshTokenExpiration)
 
 	return accessToken, refreshToken, nil
 }
 
-func HandleRefresh(oldAccessToken, refreshToken string, adb data.AuthDB) (newAccessToken string, err error) {
+// HandleRefresh signs a new access token for the user an expired one was
+// issued to, when the refresh token belongs to the same user. The user's name
+// and admin flag are read again so that revoked rights do not carry over.
+func HandleRefresh(oldAccessToken, refreshToken string, udb data.UserDB, adb data.AuthDB) (newAccessToken string, err error) {
 	if !auth.ShouldRefresh(oldAccessToken) {
 		return "", fmt.Errorf("access token is invalid or does not need to be refreshed")
 	}
@@ -51,5 +54,24 @@ func HandleRefresh(oldAccessToken, refreshToken string, adb data.AuthDB) (newAcc
 		return "", fmt.Errorf("refresh token is expired")
 	}
 
-	return auth.CreateAccessToken()
+	claims, err := auth.ParseClaims(oldAccessToken)
+	if err != nil {
+		return "", fmt.Errorf("could not read access token claims: %w", err)
+	}
+	// Tokens issued before users were identified by ID carry no subject or
+	// name. Re-signing one would mint a token for no one, so those clients
+	// have to log in again.
+	if claims.UserID == 0 || claims.Name == "" {
+		return "", fmt.Errorf("access token does not identify a user")
+	}
+	if rt.UserID != claims.UserID {
+		return "", fmt.Errorf("refresh token belongs to another user")
+	}
+
+	u, err := udb.GetUser(claims.Name)
+	if err != nil || u.ID != claims.UserID {
+		return "", fmt.Errorf("user was not found")
+	}
+
+	return auth.CreateAccessToken(auth.Claims{UserID: u.ID, Name: u.Name, Admin: u.Admin})
 }
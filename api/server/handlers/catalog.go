@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	d "tunes-service/data"
+)
+
+// HandleGetArtist returns an artist page. A userID of 0 is an anonymous
+// visitor, whose own play counts stay at 0.
+func HandleGetArtist(id, userID uint64, db d.CatalogDB) (d.ArtistPage, error) {
+	page, err := db.GetArtistPage(id, userID)
+	return page, catalogError("artist", err)
+}
+
+func HandleGetProject(key, userID uint64, db d.CatalogDB) (d.ProjectPage, error) {
+	page, err := db.GetProjectPage(key, userID)
+	return page, catalogError("project", err)
+}
+
+func HandleGetTrack(key, userID uint64, db d.CatalogDB) (d.TrackPage, error) {
+	page, err := db.GetTrackPage(key, userID)
+	return page, catalogError("track", err)
+}
+
+func catalogError(entity string, err error) error {
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s: %w", entity, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"tunes-service/data"
+)
+
+type catalogDBMock struct {
+	getArtistPage  func(uint64, uint64) (data.ArtistPage, error)
+	getProjectPage func(uint64, uint64) (data.ProjectPage, error)
+	getTrackPage   func(uint64, uint64) (data.TrackPage, error)
+}
+
+func (db *catalogDBMock) GetArtistPage(id uint64, userID uint64) (data.ArtistPage, error) {
+	return db.getArtistPage(id, userID)
+}
+
+func (db *catalogDBMock) GetProjectPage(key uint64, userID uint64) (data.ProjectPage, error) {
+	return db.getProjectPage(key, userID)
+}
+
+func (db *catalogDBMock) GetTrackPage(key uint64, userID uint64) (data.TrackPage, error) {
+	return db.getTrackPage(key, userID)
+}
+
+func TestHandleCatalog(t *testing.T) {
+	db := &catalogDBMock{
+		func(id, userID uint64) (data.ArtistPage, error) {
+			if id != 1 {
+				return data.ArtistPage{}, fmt.Errorf("%w: artist %d", data.ErrNotFound, id)
+			}
+			return data.ArtistPage{Artist: data.Artist{ID: id, Name: "Olivia Rodrigo"}, Plays: data.Plays{Spins: 12, UserSpins: userID}}, nil
+		},
+		func(key, userID uint64) (data.ProjectPage, error) {
+			if key != 2 {
+				return data.ProjectPage{}, fmt.Errorf("%w: project %d", data.ErrNotFound, key)
+			}
+			return data.ProjectPage{Project: data.Project{ID: key, Title: "GUTS"}, Plays: data.Plays{Spins: 8, UserSpins: userID}}, nil
+		},
+		func(key, userID uint64) (data.TrackPage, error) {
+			if key != 3 {
+				return data.TrackPage{}, fmt.Errorf("connection refused")
+			}
+			return data.TrackPage{Track: data.Track{ID: key, Title: "vampire"}, Plays: data.Plays{Spins: 4, UserSpins: userID}}, nil
+		},
+	}
+
+	tests := []struct {
+		name   string
+		get    func() (data.Plays, error)
+		spins  uint64
+		userID uint64
+		err    error
+	}{
+		{"Should get artist", func() (data.Plays, error) {
+			page, err := HandleGetArtist(1, 7, db)
+			return page.Plays, err
+		}, 12, 7, nil},
+		{"Should get project for anonymous visitor", func() (data.Plays, error) {
+			page, err := HandleGetProject(2, 0, db)
+			return page.Plays, err
+		}, 8, 0, nil},
+		{"Should get track", func() (data.Plays, error) {
+			page, err := HandleGetTrack(3, 7, db)
+			return page.Plays, err
+		}, 4, 7, nil},
+		{"Unknown artist", func() (data.Plays, error) {
+			page, err := HandleGetArtist(9, 7, db)
+			return page.Plays, err
+		}, 0, 0, ErrNotFound},
+		{"Unknown project", func() (data.Plays, error) {
+			page, err := HandleGetProject(9, 7, db)
+			return page.Plays, err
+		}, 0, 0, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plays, err := tt.get()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v but got %v", tt.err, err)
+			}
+			if plays.Spins != tt.spins || plays.UserSpins != tt.userID {
+				t.Fatalf("expected %d spins and %d user spins but got %+v", tt.spins, tt.userID, plays)
+			}
+		})
+	}
+
+	t.Run("Should not hide failures as missing", func(t *testing.T) {
+		if _, err := HandleGetTrack(9, 7, db); err == nil || errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected a failure but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	d "tunes-service/data"
+)
+
+// HandleWeeklyChart returns a user's chart for the week of a day, defaulting
+// to the current one. Each entry comes with how it moved since the week
+// before, its peak and its weeks on the chart so far. Past weeks are read from
+// their snapshots where there are any.
+func HandleWeeklyChart(userID uint64, entity d.EntityType, week, now time.Time, db d.ChartDB) (d.WeeklyChart, error) {
+	if !entity.IsValid() {
+		return d.WeeklyChart{}, fmt.Errorf("%w: unknown chart entity %q", ErrBadRequest, entity)
+	}
+	week, err := chartWeek(week, now)
+	if err != nil {
+		return d.WeeklyChart{}, err
+	}
+
+	entries, err := userWeek(userID, entity, week, db)
+	if err != nil {
+		return d.WeeklyChart{}, err
+	}
+	lastWeek, err := userWeek(userID, entity, week.AddDate(0, 0, -7), db)
+	if err != nil {
+		return d.WeeklyChart{}, err
+	}
+	d.CompareCharts(entries, lastWeek)
+
+	ids := make([]uint64, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	runs, err := db.GetChartRuns(userID, entity, ids, week)
+	if err != nil {
+		return d.WeeklyChart{}, fmt.Errorf("failed to get chart runs: %w", err)
+	}
+	for i := range entries {
+		run := runs[entries[i].ID].Extend(entries[i].Rank)
+		entries[i].Run = &run
+	}
+
+	return d.WeeklyChart{Entity: entity, Week: week, Entries: entries}, nil
+}
+
+// HandleChartPositions returns the ranks of an artist, track or project on a
+// user's weekly charts, oldest first.
+func HandleChartPositions(userID uint64, entity d.EntityType, id uint64, db d.ChartDB) ([]d.ChartPosition, error) {
+	if !entity.IsValid() {
+		return nil, fmt.Errorf("%w: unknown chart entity %q", ErrBadRequest, entity)
+	}
+
+	positions, err := db.GetChartPositions(userID, entity, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chart positions: %w", err)
+	}
+	return positions, nil
+}
+
+// HandleNumberOnes returns the artists, tracks or projects that topped a
+// user's weekly charts, latest first.
+func HandleNumberOnes(userID uint64, entity d.EntityType, db d.ChartDB) ([]d.NumberOne, error) {
+	if !entity.IsValid() {
+		return nil, fmt.Errorf("%w: unknown chart entity %q", ErrBadRequest, entity)
+	}
+
+	numberOnes, err := db.GetNumberOnes(userID, entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get number ones: %w", err)
+	}
+	return numberOnes, nil
+}
+
+// userWeek returns the stored chart of a user for a week, or ranks the week's
+// listening when there is none yet.
+func userWeek(userID uint64, entity d.EntityType, week time.Time, db d.ChartDB) ([]d.ChartEntry, error) {
+	entries, err := db.GetUserWeek(userID, entity, week)
+	if errors.Is(err, d.ErrNotFound) {
+		entries, err = db.GetUserChart(userID, entity, week, week.AddDate(0, 0, 7), d.WEEKLY_CHART_SIZE)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chart: %w", err)
+	}
+	return entries, nil
+}
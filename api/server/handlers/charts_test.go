@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type chartDBMock struct {
+	getUserChart          func(uint64, data.EntityType, time.Time, time.Time, int) ([]data.ChartEntry, error)
+	getUnsnapshottedWeeks func(time.Time, int) ([]data.UserWeek, error)
+	saveUserWeek          func(uint64, time.Time, []data.ChartEntry) error
+	getUserWeek           func(uint64, data.EntityType, time.Time) ([]data.ChartEntry, error)
+	getChartRuns          func(uint64, data.EntityType, []uint64, time.Time) (map[uint64]data.ChartRun, error)
+	getChartPositions     func(uint64, data.EntityType, uint64) ([]data.ChartPosition, error)
+	getNumberOnes         func(uint64, data.EntityType) ([]data.NumberOne, error)
+}
+
+func (db *chartDBMock) GetUserChart(userID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+	return db.getUserChart(userID, entity, from, to, limit)
+}
+
+func (db *chartDBMock) GetUnsnapshottedWeeks(lastWeek time.Time, limit int) ([]data.UserWeek, error) {
+	return db.getUnsnapshottedWeeks(lastWeek, limit)
+}
+
+func (db *chartDBMock) SaveUserWeek(userID uint64, week time.Time, entries []data.ChartEntry) error {
+	return db.saveUserWeek(userID, week, entries)
+}
+
+func (db *chartDBMock) GetUserWeek(userID uint64, entity data.EntityType, week time.Time) ([]data.ChartEntry, error) {
+	return db.getUserWeek(userID, entity, week)
+}
+
+func (db *chartDBMock) GetChartRuns(userID uint64, entity data.EntityType, ids []uint64, before time.Time) (map[uint64]data.ChartRun, error) {
+	return db.getChartRuns(userID, entity, ids, before)
+}
+
+func (db *chartDBMock) GetChartPositions(userID uint64, entity data.EntityType, id uint64) ([]data.ChartPosition, error) {
+	return db.getChartPositions(userID, entity, id)
+}
+
+func (db *chartDBMock) GetNumberOnes(userID uint64, entity data.EntityType) ([]data.NumberOne, error) {
+	return db.getNumberOnes(userID, entity)
+}
+
+func TestHandleWeeklyChart(t *testing.T) {
+	now := time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+	week := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	db := &chartDBMock{
+		getUserWeek: func(userID uint64, entity data.EntityType, w time.Time) ([]data.ChartEntry, error) {
+			switch {
+			case w.Equal(week):
+				return []data.ChartEntry{{Rank: 1, ID: 6}, {Rank: 2, ID: 5}, {Rank: 3, ID: 7}}, nil
+			case w.Equal(week.AddDate(0, 0, -7)):
+				return []data.ChartEntry{{Rank: 1, ID: 5}, {Rank: 2, ID: 6}}, nil
+			default:
+				return nil, data.ErrNotFound
+			}
+		},
+		getUserChart: func(userID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+			if limit != data.WEEKLY_CHART_SIZE {
+				t.Fatalf("expected limit %d but got %d", data.WEEKLY_CHART_SIZE, limit)
+			}
+			return []data.ChartEntry{{Rank: 1, ID: 7}}, nil
+		},
+		getChartRuns: func(userID uint64, entity data.EntityType, ids []uint64, before time.Time) (map[uint64]data.ChartRun, error) {
+			return map[uint64]data.ChartRun{
+				5: {Peak: 1, WeeksOnChart: 4},
+				6: {Peak: 2, WeeksOnChart: 3},
+			}, nil
+		},
+	}
+
+	chart, err := HandleWeeklyChart(1, data.TrackEntity, week.AddDate(0, 0, 2), now, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chart.Week.Equal(week) || len(chart.Entries) != 3 {
+		t.Fatalf("expected three entries in the week of %v but got %+v", week, chart)
+	}
+	expected := []struct {
+		movement data.ChartMovement
+		run      data.ChartRun
+	}{
+		{data.ChartMovement{Movement: data.Climbed, LastRank: 2}, data.ChartRun{Peak: 1, WeeksOnChart: 4}},
+		{data.ChartMovement{Movement: data.Fell, LastRank: 1}, data.ChartRun{Peak: 1, WeeksOnChart: 5}},
+		{data.ChartMovement{Movement: data.NewEntry}, data.ChartRun{Peak: 3, WeeksOnChart: 1}},
+	}
+	for i, e := range chart.Entries {
+		if e.Movement == nil || *e.Movement != expected[i].movement || e.Run == nil || *e.Run != expected[i].run {
+			t.Fatalf("expected %+v at %d but got %+v, %+v", expected[i], i, e.Movement, e.Run)
+		}
+	}
+
+	current, err := HandleWeeklyChart(1, data.TrackEntity, time.Time{}, now, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !current.Week.Equal(week.AddDate(0, 0, 7)) || len(current.Entries) != 1 || *current.Entries[0].Movement != (data.ChartMovement{Movement: data.Climbed, LastRank: 3}) {
+		t.Fatalf("expected the current week to be ranked live but got %+v", current)
+	}
+
+	tests := []struct {
+		name   string
+		entity data.EntityType
+		week   time.Time
+		err    error
+	}{
+		{"Unknown entity", "genre", week, ErrBadRequest},
+		{"Next week", data.TrackEntity, now.AddDate(0, 0, 7), ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleWeeklyChart(1, tt.entity, tt.week, now, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
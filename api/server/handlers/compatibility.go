@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	c "tunes-service/cache"
+	d "tunes-service/data"
+)
+
+const (
+	DEFAULT_COMPATIBILITY_MONTHS = 12
+	MAX_COMPATIBILITY_MONTHS     = 120
+	// COMPATIBILITY_VECTOR_SIZE is how many of each user's most played
+	// artists and tracks are compared. The long tail adds little to the score.
+	COMPATIBILITY_VECTOR_SIZE = 1000
+)
+
+// HandleCompatibility compares the tastes of a user and another over their
+// spins of the last months, defaulting to DEFAULT_COMPATIBILITY_MONTHS.
+// Results are cached until either user records a spin.
+func HandleCompatibility(userID, otherID uint64, months int, db d.StatsDB, cache c.Cache) (d.Compatibility, error) {
+	if userID == otherID {
+		return d.Compatibility{}, fmt.Errorf("%w: users are compared with others", ErrBadRequest)
+	}
+	if months == 0 {
+		months = DEFAULT_COMPATIBILITY_MONTHS
+	}
+	if months < 0 || months > MAX_COMPATIBILITY_MONTHS {
+		return d.Compatibility{}, fmt.Errorf("%w: months must be between 1 and %d", ErrBadRequest, MAX_COMPATIBILITY_MONTHS)
+	}
+
+	key := fmt.Sprintf("c-%d-%d-%d-%s-%s", userID, otherID, months, listeningVersion(userID, cache), listeningVersion(otherID, cache))
+	if cachedJSON := cache.Get(key); cachedJSON != "" {
+		var compatibility d.Compatibility
+		if err := json.Unmarshal([]byte(cachedJSON), &compatibility); err == nil {
+			return compatibility, nil
+		}
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, -months, 0)
+	artists, err := db.GetArtistListening(userID, from, to, COMPATIBILITY_VECTOR_SIZE)
+	if err != nil {
+		return d.Compatibility{}, fmt.Errorf("failed to get artist listening: %w", err)
+	}
+	otherArtists, err := db.GetArtistListening(otherID, from, to, COMPATIBILITY_VECTOR_SIZE)
+	if err != nil {
+		return d.Compatibility{}, fmt.Errorf("failed to get artist listening: %w", err)
+	}
+	tracks, err := db.GetTrackListening(userID, from, to, COMPATIBILITY_VECTOR_SIZE)
+	if err != nil {
+		return d.Compatibility{}, fmt.Errorf("failed to get track listening: %w", err)
+	}
+	otherTracks, err := db.GetTrackListening(otherID, from, to, COMPATIBILITY_VECTOR_SIZE)
+	if err != nil {
+		return d.Compatibility{}, fmt.Errorf("failed to get track listening: %w", err)
+	}
+
+	compatibility := d.NewCompatibility(artists, otherArtists, tracks, otherTracks, DEFAULT_CHART_LIMIT)
+	j, _ := json.Marshal(compatibility)
+	cache.Put(key, string(j))
+	return compatibility, nil
+}
+
+// listeningVersion returns the version of a user's spins. Keys of cached
+// results built from their spins include it, so recording a spin, which
+// changes it, invalidates them.
+func listeningVersion(userID uint64, cache c.Cache) string {
+	if v := cache.Get("lv-" + strconv.FormatUint(userID, 10)); v != "" {
+		return v
+	}
+	return touchListening(userID, cache)
+}
+
+// touchListening changes the version of a user's spins.
+func touchListening(userID uint64, cache c.Cache) string {
+	v := strconv.FormatInt(time.Now().UnixNano(), 36)
+	cache.Put("lv-"+strconv.FormatUint(userID, 10), v)
+	return v
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+func TestHandleCompatibility(t *testing.T) {
+	queries := 0
+	db := &statsDBMock{
+		getArtistListening: func(userID uint64, from, to time.Time, limit int) ([]data.ArtistListening, error) {
+			queries++
+			if limit != COMPATIBILITY_VECTOR_SIZE {
+				t.Fatalf("expected limit %d but got %d", COMPATIBILITY_VECTOR_SIZE, limit)
+			}
+			return []data.ArtistListening{{Artist: data.Artist{ID: 1, Name: "Olivia Rodrigo"}, Spins: userID * 10}}, nil
+		},
+		getTrackListening: func(userID uint64, from, to time.Time, limit int) ([]data.TrackListening, error) {
+			return []data.TrackListening{{Track: data.Track{ID: userID}, Spins: 4}}, nil
+		},
+	}
+	entries := map[string]string{}
+	cache := &cacheMock{
+		func(key string) string { return entries[key] },
+		func(key string, value string) { entries[key] = value },
+		func(key string) { delete(entries, key) },
+	}
+
+	c, err := HandleCompatibility(1, 2, 0, db, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ArtistScore != 1 || c.TrackScore != 0 || len(c.SharedArtists) != 1 || c.SharedArtists[0].OtherSpins != 20 {
+		t.Fatalf("expected one shared artist but got %+v", c)
+	}
+
+	if cached, err := HandleCompatibility(1, 2, 0, db, cache); err != nil || queries != 2 || cached.Score != c.Score {
+		t.Fatalf("expected the cached result but got %+v after %d queries: %v", cached, queries, err)
+	}
+	touchListening(2, cache)
+	if _, err := HandleCompatibility(1, 2, 0, db, cache); err != nil || queries != 4 {
+		t.Fatalf("expected a new spin to invalidate the result but got %d queries: %v", queries, err)
+	}
+
+	tests := []struct {
+		name    string
+		otherID uint64
+		months  int
+		err     error
+	}{
+		{"Same user", 1, 0, ErrBadRequest},
+		{"Negative months", 2, -1, ErrBadRequest},
+		{"Too many months", 2, MAX_COMPATIBILITY_MONTHS + 1, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleCompatibility(1, tt.otherID, tt.months, db, cache); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
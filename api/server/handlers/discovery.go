@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	d "tunes-service/data"
+)
+
+const (
+	DEFAULT_FORGOTTEN_MONTHS = 6
+	MAX_FORGOTTEN_MONTHS     = 120
+)
+
+func HandleFirstListens(userID uint64, entity d.EntityType, from, to time.Time, limit int, db d.DiscoveryDB) ([]d.FirstListen, error) {
+	if !entity.IsValid() {
+		return nil, fmt.Errorf("%w: unknown entity %q", ErrBadRequest, entity)
+	}
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	listens, err := db.GetFirstListens(userID, entity, from, to, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get first listens: %w", err)
+	}
+	return listens, nil
+}
+
+func HandleDiscoveries(userID uint64, period d.Period, from, to time.Time, db d.DiscoveryDB) ([]d.Discoveries, error) {
+	if !period.IsValid() {
+		return nil, fmt.Errorf("%w: unknown period %q", ErrBadRequest, period)
+	}
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	discoveries, err := db.GetDiscoveries(userID, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discoveries: %w", err)
+	}
+	return discoveries, nil
+}
+
+func HandleDiversity(userID uint64, from, to time.Time, db d.DiscoveryDB) (d.Diversity, error) {
+	if err := validateRange(from, to); err != nil {
+		return d.Diversity{}, err
+	}
+
+	spins, err := db.GetArtistSpins(userID, from, to)
+	if err != nil {
+		return d.Diversity{}, fmt.Errorf("failed to get artist spins: %w", err)
+	}
+	return d.NewDiversity(spins), nil
+}
+
+// HandleForgottenFavorites returns what a user played a lot but not in the
+// last months, defaulting to DEFAULT_FORGOTTEN_MONTHS.
+func HandleForgottenFavorites(userID uint64, entity d.EntityType, months int, limit int, db d.DiscoveryDB) ([]d.ForgottenFavorite, error) {
+	if !entity.IsValid() {
+		return nil, fmt.Errorf("%w: unknown entity %q", ErrBadRequest, entity)
+	}
+	if months == 0 {
+		months = DEFAULT_FORGOTTEN_MONTHS
+	}
+	if months < 0 || months > MAX_FORGOTTEN_MONTHS {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", ErrBadRequest, MAX_FORGOTTEN_MONTHS)
+	}
+
+	favorites, err := db.GetForgottenFavorites(userID, entity, time.Now().AddDate(0, -months, 0), clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get forgotten favorites: %w", err)
+	}
+	return favorites, nil
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type discoveryDBMock struct {
+	getFirstListens       func(uint64, data.EntityType, time.Time, time.Time, int) ([]data.FirstListen, error)
+	getDiscoveries        func(uint64, data.Period, time.Time, time.Time) ([]data.Discoveries, error)
+	getArtistSpins        func(uint64, time.Time, time.Time) ([]uint64, error)
+	getForgottenFavorites func(uint64, data.EntityType, time.Time, int) ([]data.ForgottenFavorite, error)
+}
+
+func (db *discoveryDBMock) GetFirstListens(userID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.FirstListen, error) {
+	return db.getFirstListens(userID, entity, from, to, limit)
+}
+
+func (db *discoveryDBMock) GetDiscoveries(userID uint64, period data.Period, from, to time.Time) ([]data.Discoveries, error) {
+	return db.getDiscoveries(userID, period, from, to)
+}
+
+func (db *discoveryDBMock) GetArtistSpins(userID uint64, from, to time.Time) ([]uint64, error) {
+	return db.getArtistSpins(userID, from, to)
+}
+
+func (db *discoveryDBMock) GetForgottenFavorites(userID uint64, entity data.EntityType, since time.Time, limit int) ([]data.ForgottenFavorite, error) {
+	return db.getForgottenFavorites(userID, entity, since, limit)
+}
+
+func TestHandleFirstListens(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := &discoveryDBMock{
+		getFirstListens: func(userID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.FirstListen, error) {
+			if limit != DEFAULT_CHART_LIMIT {
+				t.Fatalf("expected limit %d but got %d", DEFAULT_CHART_LIMIT, limit)
+			}
+			return []data.FirstListen{{Entity: entity, ID: 1, Title: "GUTS", Time: from}}, nil
+		},
+	}
+
+	tests := []struct {
+		name   string
+		entity data.EntityType
+		from   time.Time
+		to     time.Time
+		err    error
+	}{
+		{"Should get first listens", data.ProjectEntity, from, to, nil},
+		{"Unknown entity", data.EntityType("label"), from, to, ErrBadRequest},
+		{"Empty range", data.ArtistEntity, to, from, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HandleFirstListens(1, tt.entity, tt.from, tt.to, 0, db)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleDiscoveries(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := &discoveryDBMock{
+		getDiscoveries: func(userID uint64, period data.Period, from, to time.Time) ([]data.Discoveries, error) {
+			return []data.Discoveries{{Start: from, Artists: 2, Tracks: 10, Projects: 3}}, nil
+		},
+	}
+
+	if _, err := HandleDiscoveries(1, data.Week, from, to, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HandleDiscoveries(1, data.Period("fortnight"), from, to, db); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected %v but got %v", ErrBadRequest, err)
+	}
+}
+
+func TestHandleDiversity(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := &discoveryDBMock{
+		getArtistSpins: func(userID uint64, from, to time.Time) ([]uint64, error) {
+			return []uint64{5, 5, 5, 5}, nil
+		},
+	}
+
+	d, err := HandleDiversity(1, from, to, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Artists != 4 || d.Entropy != 2 || d.Gini != 0 {
+		t.Fatalf("expected an even spread over 4 artists but got %+v", d)
+	}
+}
+
+func TestHandleForgottenFavorites(t *testing.T) {
+	tests := []struct {
+		name     string
+		months   int
+		expected int
+		err      error
+	}{
+		{"Should default months", 0, DEFAULT_FORGOTTEN_MONTHS, nil},
+		{"Should keep months", 12, 12, nil},
+		{"Negative months", -1, 0, ErrBadRequest},
+		{"Too many months", MAX_FORGOTTEN_MONTHS + 1, 0, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &discoveryDBMock{
+				getForgottenFavorites: func(userID uint64, entity data.EntityType, since time.Time, limit int) ([]data.ForgottenFavorite, error) {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					expected := time.Now().AddDate(0, -tt.expected, 0)
+					if since.Sub(expected).Abs() > time.Minute {
+						t.Fatalf("expected favorites not played since %v but got %v", expected, since)
+					}
+					return []data.ForgottenFavorite{}, nil
+				},
+			}
+
+			if _, err := HandleForgottenFavorites(1, data.TrackEntity, tt.months, 0, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import "errors"
+
+// Handlers wrap these errors so the server can tell a bad request or a
+// missing entity apart from a failure.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+)
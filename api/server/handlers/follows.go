@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	d "tunes-service/data"
+)
+
+// HandleFollow makes a user follow the named user, or request to when their
+// profile is not public.
+func HandleFollow(userID uint64, name string, db d.FollowDB, pdb d.ProfileDB) (d.FollowStatus, error) {
+	p, err := HandleProfile(name, pdb)
+	if err != nil {
+		return d.NotFollowing, err
+	}
+	if p.ID == userID {
+		return d.NotFollowing, fmt.Errorf("%w: users cannot follow themselves", ErrBadRequest)
+	}
+
+	status, err := db.Follow(userID, p.ID)
+	if err != nil {
+		return d.NotFollowing, fmt.Errorf("failed to follow: %w", err)
+	}
+	return status, nil
+}
+
+func HandleFollowStatus(userID uint64, name string, db d.FollowDB, pdb d.ProfileDB) (d.FollowStatus, error) {
+	p, err := HandleProfile(name, pdb)
+	if err != nil {
+		return d.NotFollowing, err
+	}
+
+	status, err := db.GetFollowStatus(userID, p.ID)
+	if err != nil {
+		return d.NotFollowing, fmt.Errorf("failed to get follow status: %w", err)
+	}
+	return status, nil
+}
+
+// HandleUnfollow stops a user from following the named user, or withdraws
+// their request to.
+func HandleUnfollow(userID uint64, name string, db d.FollowDB, pdb d.ProfileDB) error {
+	p, err := HandleProfile(name, pdb)
+	if err != nil {
+		return err
+	}
+	return unfollow(userID, p.ID, db)
+}
+
+// HandleAcceptFollower accepts the request of the named user to follow a user.
+func HandleAcceptFollower(userID uint64, follower string, db d.FollowDB, pdb d.ProfileDB) error {
+	p, err := HandleProfile(follower, pdb)
+	if err != nil {
+		return err
+	}
+
+	err = db.AcceptFollow(p.ID, userID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to accept follower: %w", err)
+	}
+	return nil
+}
+
+// HandleRemoveFollower removes the named user from a user's followers, or
+// declines their request to follow.
+func HandleRemoveFollower(userID uint64, follower string, db d.FollowDB, pdb d.ProfileDB) error {
+	p, err := HandleProfile(follower, pdb)
+	if err != nil {
+		return err
+	}
+	return unfollow(p.ID, userID, db)
+}
+
+// HandleFollowers returns a page of a user's followers, or of the requests to
+// follow them when the status is pending.
+func HandleFollowers(userID uint64, status d.FollowStatus, before time.Time, limit int, db d.FollowDB) ([]d.Follow, error) {
+	if status != d.Following && status != d.PendingFollow {
+		return nil, fmt.Errorf("%w: unknown follow status %q", ErrBadRequest, status)
+	}
+
+	follows, err := db.GetFollowers(userID, status, pageBefore(before), clampPageLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+	return follows, nil
+}
+
+// HandleFollowing returns a page of the users a user follows, or requested to
+// follow when the status is pending.
+func HandleFollowing(userID uint64, status d.FollowStatus, before time.Time, limit int, db d.FollowDB) ([]d.Follow, error) {
+	if status != d.Following && status != d.PendingFollow {
+		return nil, fmt.Errorf("%w: unknown follow status %q", ErrBadRequest, status)
+	}
+
+	follows, err := db.GetFollowing(userID, status, pageBefore(before), clampPageLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followed users: %w", err)
+	}
+	return follows, nil
+}
+
+// HandleFeed returns a page of the spins and milestones of the users a user
+// follows, latest first, after the last item of the page before.
+func HandleFeed(userID uint64, before d.FeedCursor, limit int, db d.FollowDB) ([]d.FeedItem, error) {
+	switch {
+	case before.Kind != "" && before.Kind != d.SpinFeedItem && before.Kind != d.MilestoneFeedItem:
+		return nil, fmt.Errorf("%w: unknown feed item %q", ErrBadRequest, before.Kind)
+	case before.Kind != "" && before.Time.IsZero():
+		return nil, fmt.Errorf("%w: feed item without a time", ErrBadRequest)
+	}
+	before.Time = pageBefore(before.Time)
+
+	feed, err := db.GetFeed(userID, before, clampPageLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed: %w", err)
+	}
+	return feed, nil
+}
+
+func unfollow(followerID, followeeID uint64, db d.FollowDB) error {
+	err := db.Unfollow(followerID, followeeID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to unfollow: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type followDBMock struct {
+	follow          func(uint64, uint64) (data.FollowStatus, error)
+	unfollow        func(uint64, uint64) error
+	acceptFollow    func(uint64, uint64) error
+	getFollowStatus func(uint64, uint64) (data.FollowStatus, error)
+	getFollowers    func(uint64, data.FollowStatus, time.Time, int) ([]data.Follow, error)
+	getFollowing    func(uint64, data.FollowStatus, time.Time, int) ([]data.Follow, error)
+	getFeed         func(uint64, data.FeedCursor, int) ([]data.FeedItem, error)
+}
+
+func (db *followDBMock) Follow(followerID, followeeID uint64) (data.FollowStatus, error) {
+	return db.follow(followerID, followeeID)
+}
+
+func (db *followDBMock) Unfollow(followerID, followeeID uint64) error {
+	return db.unfollow(followerID, followeeID)
+}
+
+func (db *followDBMock) AcceptFollow(followerID, followeeID uint64) error {
+	return db.acceptFollow(followerID, followeeID)
+}
+
+func (db *followDBMock) GetFollowStatus(followerID, followeeID uint64) (data.FollowStatus, error) {
+	return db.getFollowStatus(followerID, followeeID)
+}
+
+func (db *followDBMock) GetFollowers(userID uint64, status data.FollowStatus, before time.Time, limit int) ([]data.Follow, error) {
+	return db.getFollowers(userID, status, before, limit)
+}
+
+func (db *followDBMock) GetFollowing(userID uint64, status data.FollowStatus, before time.Time, limit int) ([]data.Follow, error) {
+	return db.getFollowing(userID, status, before, limit)
+}
+
+func (db *followDBMock) GetFeed(userID uint64, before data.FeedCursor, limit int) ([]data.FeedItem, error) {
+	return db.getFeed(userID, before, limit)
+}
+
+var followProfiles = &profileDBMock{
+	getProfile: func(name string) (data.Profile, error) {
+		switch name {
+		case "olivia":
+			return data.Profile{ID: 1, Name: name}, nil
+		case "billie":
+			return data.Profile{ID: 2, Name: name}, nil
+		default:
+			return data.Profile{}, data.ErrNotFound
+		}
+	},
+}
+
+func TestHandleFollow(t *testing.T) {
+	db := &followDBMock{
+		follow: func(followerID, followeeID uint64) (data.FollowStatus, error) {
+			if followerID != 1 || followeeID != 2 {
+				t.Fatalf("expected 1 to follow 2 but got %d following %d", followerID, followeeID)
+			}
+			return data.PendingFollow, nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		expected data.FollowStatus
+		err      error
+	}{
+		{"Should request to follow", "billie", data.PendingFollow, nil},
+		{"Following oneself", "olivia", data.NotFollowing, ErrBadRequest},
+		{"Unknown user", "taylor", data.NotFollowing, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := HandleFollow(1, tt.user, db, followProfiles)
+			if !errors.Is(err, tt.err) || status != tt.expected {
+				t.Fatalf("expected %q and error %v but got %q and %v", tt.expected, tt.err, status, err)
+			}
+		})
+	}
+}
+
+func TestHandleRemoveFollower(t *testing.T) {
+	db := &followDBMock{
+		unfollow: func(followerID, followeeID uint64) error {
+			if followerID != 2 || followeeID != 1 {
+				return data.ErrNotFound
+			}
+			return nil
+		},
+	}
+
+	if err := HandleRemoveFollower(1, "billie", db, followProfiles); err != nil {
+		t.Fatal(err)
+	}
+	if err := HandleUnfollow(1, "billie", db, followProfiles); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v but got %v", ErrNotFound, err)
+	}
+}
+
+func TestHandleFollowers(t *testing.T) {
+	db := &followDBMock{
+		getFollowers: func(userID uint64, status data.FollowStatus, before time.Time, limit int) ([]data.Follow, error) {
+			if before.IsZero() || limit != DEFAULT_PAGE_LIMIT {
+				t.Fatalf("expected a default page but got %d before %v", limit, before)
+			}
+			return []data.Follow{}, nil
+		},
+	}
+
+	tests := []struct {
+		name   string
+		status data.FollowStatus
+		err    error
+	}{
+		{"Should list followers", data.Following, nil},
+		{"Should list requests", data.PendingFollow, nil},
+		{"Unknown status", data.FollowStatus("blocked"), ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleFollowers(1, tt.status, time.Time{}, 0, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleFeed(t *testing.T) {
+	at := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		cursor data.FeedCursor
+		err    error
+	}{
+		{"Should start after the last item", data.FeedCursor{Time: at, Kind: data.SpinFeedItem, ID: 7}, nil},
+		{"Should start now", data.FeedCursor{}, nil},
+		{"Unknown item", data.FeedCursor{Time: at, Kind: "follow", ID: 7}, ErrBadRequest},
+		{"Item without a time", data.FeedCursor{Kind: data.MilestoneFeedItem, ID: 7}, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &followDBMock{
+				getFeed: func(userID uint64, before data.FeedCursor, limit int) ([]data.FeedItem, error) {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if before.Kind != tt.cursor.Kind || before.ID != tt.cursor.ID || before.Time.IsZero() {
+						t.Fatalf("expected the feed after %+v but got %+v", tt.cursor, before)
+					}
+					return []data.FeedItem{}, nil
+				},
+			}
+
+			if _, err := HandleFeed(1, tt.cursor, 0, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
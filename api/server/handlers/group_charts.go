@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	d "tunes-service/data"
+)
+
+// MAX_GROUP_CHART_DAYS is the longest range group charts cover.
+const MAX_GROUP_CHART_DAYS = 366
+
+// HandleGroupChart ranks what the members of a group listened to most on the
+// days from one up to another, defaulting to the current week, and the part
+// in it of each member whose stats the viewer may see. Only members read a
+// group's charts.
+func HandleGroupChart(userID, groupID uint64, entity d.EntityType, from, to, now time.Time, limit int, db d.GroupDB, adb d.AccessDB) (d.GroupChart, error) {
+	if !entity.IsValid() {
+		return d.GroupChart{}, fmt.Errorf("%w: unknown chart entity %q", ErrBadRequest, entity)
+	}
+	if _, err := requireGroupRole(groupID, userID, d.MemberRole, db); err != nil {
+		return d.GroupChart{}, err
+	}
+
+	if from.IsZero() {
+		from = d.WeekStart(now)
+	}
+	if to.IsZero() {
+		to = chartDay(now).AddDate(0, 0, 1)
+	}
+	from, to = chartDay(from), chartDay(to)
+	if !from.Before(to) {
+		return d.GroupChart{}, fmt.Errorf("%w: charts start before they end", ErrBadRequest)
+	}
+	if to.Sub(from) > MAX_GROUP_CHART_DAYS*24*time.Hour {
+		return d.GroupChart{}, fmt.Errorf("%w: group charts cover at most %d days", ErrBadRequest, MAX_GROUP_CHART_DAYS)
+	}
+
+	entries, err := db.GetGroupChart(groupID, entity, from, to, clampLimit(limit))
+	if err != nil {
+		return d.GroupChart{}, fmt.Errorf("failed to get group chart: %w", err)
+	}
+	members, err := memberListening(userID, groupID, from, to, db, adb)
+	if err != nil {
+		return d.GroupChart{}, err
+	}
+	return d.GroupChart{Entity: entity, From: from, To: to, Entries: entries, Members: members}, nil
+}
+
+// HandleGroupWeeklyChart returns the chart of a group for the week of a day,
+// defaulting to the current one, with how each entry moved since the week
+// before. Past weeks are read from their snapshots where there are any.
+func HandleGroupWeeklyChart(userID, groupID uint64, entity d.EntityType, week, now time.Time, db d.GroupDB, adb d.AccessDB) (d.GroupChart, error) {
+	if !entity.IsValid() {
+		return d.GroupChart{}, fmt.Errorf("%w: unknown chart entity %q", ErrBadRequest, entity)
+	}
+	if _, err := requireGroupRole(groupID, userID, d.MemberRole, db); err != nil {
+		return d.GroupChart{}, err
+	}
+
+	week, err := chartWeek(week, now)
+	if err != nil {
+		return d.GroupChart{}, err
+	}
+
+	entries, err := groupWeek(groupID, entity, week, db)
+	if err != nil {
+		return d.GroupChart{}, err
+	}
+	lastWeek, err := groupWeek(groupID, entity, week.AddDate(0, 0, -7), db)
+	if err != nil {
+		return d.GroupChart{}, err
+	}
+	d.CompareCharts(entries, lastWeek)
+
+	to := week.AddDate(0, 0, 7)
+	members, err := memberListening(userID, groupID, week, to, db, adb)
+	if err != nil {
+		return d.GroupChart{}, err
+	}
+	return d.GroupChart{Entity: entity, From: week, To: to, Entries: entries, Members: members}, nil
+}
+
+// memberListening returns each member's part in a group's listening, leaving
+// out the members whose profile does not show their stats to the viewer.
+func memberListening(viewerID, groupID uint64, from, to time.Time, db d.GroupChartDB, adb d.AccessDB) ([]d.MemberListening, error) {
+	members, err := db.GetGroupMemberListening(groupID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group member listening: %w", err)
+	}
+
+	visible := []d.MemberListening{}
+	for _, m := range members {
+		_, err := HandleAccess(m.User.Name, viewerID, d.StatsFeature, adb)
+		if errors.Is(err, ErrForbidden) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		visible = append(visible, m)
+	}
+	return visible, nil
+}
+
+// groupWeek returns the stored chart of a group for a week, or ranks the
+// week's listening when there is none yet.
+func groupWeek(groupID uint64, entity d.EntityType, week time.Time, db d.GroupChartDB) ([]d.ChartEntry, error) {
+	entries, err := db.GetGroupWeek(groupID, entity, week)
+	if errors.Is(err, d.ErrNotFound) {
+		entries, err = db.GetGroupChart(groupID, entity, week, week.AddDate(0, 0, 7), d.WEEKLY_CHART_SIZE)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group chart: %w", err)
+	}
+	return entries, nil
+}
+
+// chartWeek returns the start of the week of a day, defaulting to the current
+// week, for weekly charts. Charts of weeks to come are empty.
+func chartWeek(week, now time.Time) (time.Time, error) {
+	if week.IsZero() {
+		week = now
+	}
+	week = d.WeekStart(week)
+	if week.After(d.WeekStart(now)) {
+		return time.Time{}, fmt.Errorf("%w: week %s has not started", ErrBadRequest, week.Format(time.DateOnly))
+	}
+	return week, nil
+}
+
+// chartDay returns the date of a time as midnight UTC, the way charts of days
+// pass it to the database.
+func chartDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	d "tunes-service/data"
+)
+
+const (
+	MAX_GROUP_NAME_LENGTH        = 50
+	MAX_GROUP_DESCRIPTION_LENGTH = 500
+)
+
+// HandleCreateGroup creates a group owned by a user.
+func HandleCreateGroup(userID uint64, g d.Group, db d.GroupDB) (d.Group, error) {
+	if err := validateGroup(g); err != nil {
+		return d.Group{}, err
+	}
+
+	g, err := db.CreateGroup(userID, g)
+	if err != nil {
+		return d.Group{}, fmt.Errorf("failed to create group: %w", err)
+	}
+	return g, nil
+}
+
+func HandleGroup(groupID uint64, db d.GroupDB) (d.Group, error) {
+	g, err := db.GetGroup(groupID)
+	if errors.Is(err, d.ErrNotFound) {
+		return d.Group{}, fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return d.Group{}, fmt.Errorf("failed to get group: %w", err)
+	}
+	return g, nil
+}
+
+// HandleUpdateGroup saves the name, description and openness of a group.
+// Owners and admins edit their groups.
+func HandleUpdateGroup(userID uint64, g d.Group, db d.GroupDB) error {
+	if _, err := requireGroupRole(g.ID, userID, d.AdminRole, db); err != nil {
+		return err
+	}
+	if err := validateGroup(g); err != nil {
+		return err
+	}
+
+	if err := db.UpdateGroup(g); err != nil {
+		return fmt.Errorf("failed to update group: %w", err)
+	}
+	return nil
+}
+
+// HandleDeleteGroup deletes a group with its charts. Only its owner can.
+func HandleDeleteGroup(userID, groupID uint64, db d.GroupDB) error {
+	if _, err := requireGroupRole(groupID, userID, d.OwnerRole, db); err != nil {
+		return err
+	}
+
+	if err := db.DeleteGroup(groupID); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	return nil
+}
+
+// HandleUserGroups returns the groups a user is a member of.
+func HandleUserGroups(userID uint64, db d.GroupDB) ([]d.Group, error) {
+	groups, err := db.GetUserGroups(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	return groups, nil
+}
+
+// HandleGroupMembers returns the members of a group to one of them.
+func HandleGroupMembers(userID, groupID uint64, db d.GroupDB) ([]d.GroupMember, error) {
+	if _, err := requireGroupRole(groupID, userID, d.MemberRole, db); err != nil {
+		return nil, err
+	}
+
+	members, err := db.GetGroupMembers(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group members: %w", err)
+	}
+	return members, nil
+}
+
+// HandleSetGroupRole changes the role of the named member. Only the owner
+// changes roles, and making someone else the owner makes them an admin.
+func HandleSetGroupRole(userID, groupID uint64, name string, role d.GroupRole, db d.GroupDB, pdb d.ProfileDB) error {
+	if !role.IsValid() {
+		return fmt.Errorf("%w: unknown group role %q", ErrBadRequest, role)
+	}
+	if _, err := requireGroupRole(groupID, userID, d.OwnerRole, db); err != nil {
+		return err
+	}
+	p, err := HandleProfile(name, pdb)
+	if err != nil {
+		return err
+	}
+	if p.ID == userID {
+		return fmt.Errorf("%w: owners hand their group over to another member", ErrBadRequest)
+	}
+
+	err = db.SetGroupRole(groupID, p.ID, role)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to set group role: %w", err)
+	}
+	return nil
+}
+
+// HandleRemoveGroupMember removes the named member from a group. Admins
+// remove members, and only the owner removes admins.
+func HandleRemoveGroupMember(userID, groupID uint64, name string, db d.GroupDB, pdb d.ProfileDB) error {
+	role, err := requireGroupRole(groupID, userID, d.AdminRole, db)
+	if err != nil {
+		return err
+	}
+	p, err := HandleProfile(name, pdb)
+	if err != nil {
+		return err
+	}
+	if p.ID == userID {
+		return fmt.Errorf("%w: members leave groups rather than remove themselves", ErrBadRequest)
+	}
+	memberRole, err := db.GetGroupRole(groupID, p.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get group role: %w", err)
+	}
+	if memberRole == "" {
+		return fmt.Errorf("%w: %s is not a member of group %d", ErrNotFound, name, groupID)
+	}
+	if memberRole.AtLeast(role) {
+		return fmt.Errorf("%w: %s is %s of group %d", ErrForbidden, name, memberRole, groupID)
+	}
+
+	return removeGroupMember(groupID, p.ID, db)
+}
+
+// HandleLeaveGroup removes a user from a group. Owners hand the group over or
+// delete it instead.
+func HandleLeaveGroup(userID, groupID uint64, db d.GroupDB) error {
+	role, err := requireGroupRole(groupID, userID, d.MemberRole, db)
+	if err != nil {
+		return err
+	}
+	if role == d.OwnerRole {
+		return fmt.Errorf("%w: owners hand their group over before leaving it", ErrBadRequest)
+	}
+	return removeGroupMember(groupID, userID, db)
+}
+
+// HandleInviteToGroup invites the named user to a group. Owners and admins
+// invite users.
+func HandleInviteToGroup(userID, groupID uint64, name string, db d.GroupDB, pdb d.ProfileDB) error {
+	if _, err := requireGroupRole(groupID, userID, d.AdminRole, db); err != nil {
+		return err
+	}
+	p, err := HandleProfile(name, pdb)
+	if err != nil {
+		return err
+	}
+	role, err := db.GetGroupRole(groupID, p.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get group role: %w", err)
+	}
+	if role != "" {
+		return fmt.Errorf("%w: %s is a member of group %d already", ErrBadRequest, name, groupID)
+	}
+
+	if err := db.InviteToGroup(groupID, p.ID, userID); err != nil {
+		return fmt.Errorf("failed to invite to group: %w", err)
+	}
+	return nil
+}
+
+// HandleDeleteGroupInvite declines a user's own invite to a group, or lets
+// owners and admins withdraw the invite of the named user.
+func HandleDeleteGroupInvite(userID, groupID uint64, name string, db d.GroupDB, pdb d.ProfileDB) error {
+	p, err := HandleProfile(name, pdb)
+	if err != nil {
+		return err
+	}
+	if p.ID != userID {
+		if _, err := requireGroupRole(groupID, userID, d.AdminRole, db); err != nil {
+			return err
+		}
+	}
+
+	err = db.DeleteGroupInvite(groupID, p.ID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete group invite: %w", err)
+	}
+	return nil
+}
+
+// HandleGroupInvites returns the invites a user has not answered yet.
+func HandleGroupInvites(userID uint64, db d.GroupDB) ([]d.GroupInvite, error) {
+	invites, err := db.GetGroupInvites(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group invites: %w", err)
+	}
+	return invites, nil
+}
+
+// HandleJoinGroup makes a user a member of an open group or of a group that
+// invited them. Joining a group again changes nothing.
+func HandleJoinGroup(userID, groupID uint64, db d.GroupDB) error {
+	if _, err := HandleGroup(groupID, db); err != nil {
+		return err
+	}
+	role, err := db.GetGroupRole(groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get group role: %w", err)
+	}
+	if role != "" {
+		return nil
+	}
+
+	joined, err := db.JoinGroup(groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to join group: %w", err)
+	}
+	if !joined {
+		return fmt.Errorf("%w: group %d is invite only", ErrForbidden, groupID)
+	}
+	return nil
+}
+
+// requireGroupRole returns the role of a user in a group if it is at least a
+// role.
+func requireGroupRole(groupID, userID uint64, role d.GroupRole, db d.GroupDB) (d.GroupRole, error) {
+	r, err := db.GetGroupRole(groupID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get group role: %w", err)
+	}
+	if !r.AtLeast(role) {
+		return "", fmt.Errorf("%w: group %d needs the %s role", ErrForbidden, groupID, role)
+	}
+	return r, nil
+}
+
+func removeGroupMember(groupID, userID uint64, db d.GroupDB) error {
+	err := db.RemoveGroupMember(groupID, userID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+	return nil
+}
+
+func validateGroup(g d.Group) error {
+	if strings.TrimSpace(g.Name) == "" {
+		return fmt.Errorf("%w: groups need a name", ErrBadRequest)
+	}
+	if utf8.RuneCountInString(g.Name) > MAX_GROUP_NAME_LENGTH {
+		return fmt.Errorf("%w: group name longer than %d characters", ErrBadRequest, MAX_GROUP_NAME_LENGTH)
+	}
+	if utf8.RuneCountInString(g.Description) > MAX_GROUP_DESCRIPTION_LENGTH {
+		return fmt.Errorf("%w: group description longer than %d characters", ErrBadRequest, MAX_GROUP_DESCRIPTION_LENGTH)
+	}
+	return nil
+}
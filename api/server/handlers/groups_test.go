@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type groupDBMock struct {
+	createGroup             func(uint64, data.Group) (data.Group, error)
+	getGroup                func(uint64) (data.Group, error)
+	updateGroup             func(data.Group) error
+	deleteGroup             func(uint64) error
+	getUserGroups           func(uint64) ([]data.Group, error)
+	getGroupRole            func(uint64, uint64) (data.GroupRole, error)
+	getGroupMembers         func(uint64) ([]data.GroupMember, error)
+	setGroupRole            func(uint64, uint64, data.GroupRole) error
+	removeGroupMember       func(uint64, uint64) error
+	inviteToGroup           func(uint64, uint64, uint64) error
+	deleteGroupInvite       func(uint64, uint64) error
+	getGroupInvites         func(uint64) ([]data.GroupInvite, error)
+	joinGroup               func(uint64, uint64) (bool, error)
+	getGroupChart           func(uint64, data.EntityType, time.Time, time.Time, int) ([]data.ChartEntry, error)
+	getGroupMemberListening func(uint64, time.Time, time.Time) ([]data.MemberListening, error)
+	getUnsnapshottedGroups  func(time.Time, int) ([]uint64, error)
+	saveGroupWeek           func(uint64, time.Time, []data.ChartEntry) error
+	getGroupWeek            func(uint64, data.EntityType, time.Time) ([]data.ChartEntry, error)
+}
+
+func (db *groupDBMock) CreateGroup(ownerID uint64, g data.Group) (data.Group, error) {
+	return db.createGroup(ownerID, g)
+}
+
+func (db *groupDBMock) GetGroup(id uint64) (data.Group, error) {
+	return db.getGroup(id)
+}
+
+func (db *groupDBMock) UpdateGroup(g data.Group) error {
+	return db.updateGroup(g)
+}
+
+func (db *groupDBMock) DeleteGroup(id uint64) error {
+	return db.deleteGroup(id)
+}
+
+func (db *groupDBMock) GetUserGroups(userID uint64) ([]data.Group, error) {
+	return db.getUserGroups(userID)
+}
+
+func (db *groupDBMock) GetGroupRole(groupID, userID uint64) (data.GroupRole, error) {
+	return db.getGroupRole(groupID, userID)
+}
+
+func (db *groupDBMock) GetGroupMembers(groupID uint64) ([]data.GroupMember, error) {
+	return db.getGroupMembers(groupID)
+}
+
+func (db *groupDBMock) SetGroupRole(groupID, userID uint64, role data.GroupRole) error {
+	return db.setGroupRole(groupID, userID, role)
+}
+
+func (db *groupDBMock) RemoveGroupMember(groupID, userID uint64) error {
+	return db.removeGroupMember(groupID, userID)
+}
+
+func (db *groupDBMock) InviteToGroup(groupID, userID, invitedBy uint64) error {
+	return db.inviteToGroup(groupID, userID, invitedBy)
+}
+
+func (db *groupDBMock) DeleteGroupInvite(groupID, userID uint64) error {
+	return db.deleteGroupInvite(groupID, userID)
+}
+
+func (db *groupDBMock) GetGroupInvites(userID uint64) ([]data.GroupInvite, error) {
+	return db.getGroupInvites(userID)
+}
+
+func (db *groupDBMock) JoinGroup(groupID, userID uint64) (bool, error) {
+	return db.joinGroup(groupID, userID)
+}
+
+func (db *groupDBMock) GetGroupChart(groupID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+	return db.getGroupChart(groupID, entity, from, to, limit)
+}
+
+func (db *groupDBMock) GetGroupMemberListening(groupID uint64, from, to time.Time) ([]data.MemberListening, error) {
+	return db.getGroupMemberListening(groupID, from, to)
+}
+
+func (db *groupDBMock) GetUnsnapshottedGroups(week time.Time, limit int) ([]uint64, error) {
+	return db.getUnsnapshottedGroups(week, limit)
+}
+
+func (db *groupDBMock) SaveGroupWeek(groupID uint64, week time.Time, entries []data.ChartEntry) error {
+	return db.saveGroupWeek(groupID, week, entries)
+}
+
+func (db *groupDBMock) GetGroupWeek(groupID uint64, entity data.EntityType, week time.Time) ([]data.ChartEntry, error) {
+	return db.getGroupWeek(groupID, entity, week)
+}
+
+// groupProfiles are the owner, an admin and a member of group 1, and a user
+// outside it.
+var groupProfiles = &profileDBMock{
+	getProfile: func(name string) (data.Profile, error) {
+		for id, n := range []string{"olivia", "billie", "sza", "taylor"} {
+			if n == name {
+				return data.Profile{ID: uint64(id + 1), Name: name}, nil
+			}
+		}
+		return data.Profile{}, data.ErrNotFound
+	},
+}
+
+var groupRoles = map[uint64]data.GroupRole{1: data.OwnerRole, 2: data.AdminRole, 3: data.MemberRole}
+
+func newGroupDBMock() *groupDBMock {
+	return &groupDBMock{
+		getGroup: func(id uint64) (data.Group, error) {
+			if id != 1 {
+				return data.Group{}, data.ErrNotFound
+			}
+			return data.Group{ID: id, Name: "Sad Girls Club"}, nil
+		},
+		getGroupRole: func(groupID, userID uint64) (data.GroupRole, error) {
+			return groupRoles[userID], nil
+		},
+		removeGroupMember: func(groupID, userID uint64) error { return nil },
+	}
+}
+
+func TestHandleCreateGroup(t *testing.T) {
+	db := &groupDBMock{
+		createGroup: func(ownerID uint64, g data.Group) (data.Group, error) {
+			g.ID = 1
+			return g, nil
+		},
+	}
+
+	tests := []struct {
+		name  string
+		group data.Group
+		err   error
+	}{
+		{"Should create group", data.Group{Name: "Sad Girls Club"}, nil},
+		{"Blank name", data.Group{Name: "  "}, ErrBadRequest},
+		{"Long name", data.Group{Name: strings.Repeat("é", MAX_GROUP_NAME_LENGTH+1)}, ErrBadRequest},
+		{"Long description", data.Group{Name: "Sad Girls Club", Description: strings.Repeat("é", MAX_GROUP_DESCRIPTION_LENGTH+1)}, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleCreateGroup(1, tt.group, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleRemoveGroupMember(t *testing.T) {
+	db := newGroupDBMock()
+
+	tests := []struct {
+		name   string
+		userID uint64
+		member string
+		err    error
+	}{
+		{"Owner removes admin", 1, "billie", nil},
+		{"Admin removes member", 2, "sza", nil},
+		{"Admin removes owner", 2, "olivia", ErrForbidden},
+		{"Member removes member", 3, "billie", ErrForbidden},
+		{"Outsider removes member", 4, "sza", ErrForbidden},
+		{"Removing oneself", 2, "billie", ErrBadRequest},
+		{"Removing a non-member", 1, "taylor", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := HandleRemoveGroupMember(tt.userID, 1, tt.member, db, groupProfiles); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleSetGroupRole(t *testing.T) {
+	db := newGroupDBMock()
+	db.setGroupRole = func(groupID, userID uint64, role data.GroupRole) error {
+		if groupRoles[userID] == "" {
+			return data.ErrNotFound
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name   string
+		userID uint64
+		member string
+		role   data.GroupRole
+		err    error
+	}{
+		{"Owner hands over group", 1, "billie", data.OwnerRole, nil},
+		{"Owner makes admin", 1, "sza", data.AdminRole, nil},
+		{"Admin makes admin", 2, "sza", data.AdminRole, ErrForbidden},
+		{"Owner demotes themselves", 1, "olivia", data.MemberRole, ErrBadRequest},
+		{"Unknown role", 1, "sza", "dj", ErrBadRequest},
+		{"Non-member", 1, "taylor", data.AdminRole, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := HandleSetGroupRole(tt.userID, 1, tt.member, tt.role, db, groupProfiles); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleLeaveGroup(t *testing.T) {
+	db := newGroupDBMock()
+
+	if err := HandleLeaveGroup(3, 1, db); err != nil {
+		t.Fatalf("expected members to leave but got %v", err)
+	}
+	if err := HandleLeaveGroup(1, 1, db); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected owners not to leave but got %v", err)
+	}
+	if err := HandleLeaveGroup(4, 1, db); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected outsiders not to leave but got %v", err)
+	}
+}
+
+func TestHandleJoinGroup(t *testing.T) {
+	db := newGroupDBMock()
+	db.joinGroup = func(groupID, userID uint64) (bool, error) {
+		return false, nil
+	}
+
+	if err := HandleJoinGroup(3, 1, db); err != nil {
+		t.Fatalf("expected members to join again but got %v", err)
+	}
+	if err := HandleJoinGroup(4, 1, db); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected uninvited users not to join but got %v", err)
+	}
+	if err := HandleJoinGroup(4, 2, db); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected unknown groups not to be found but got %v", err)
+	}
+}
+
+func TestHandleGroupWeeklyChart(t *testing.T) {
+	now := time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+	week := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	lastWeek := week.AddDate(0, 0, -7)
+
+	db := newGroupDBMock()
+	db.getGroupWeek = func(groupID uint64, entity data.EntityType, w time.Time) ([]data.ChartEntry, error) {
+		if !w.Equal(lastWeek) {
+			return nil, data.ErrNotFound
+		}
+		return []data.ChartEntry{{Rank: 1, ID: 5}, {Rank: 2, ID: 6}}, nil
+	}
+	db.getGroupChart = func(groupID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+		if !from.Equal(week) || !to.Equal(week.AddDate(0, 0, 7)) || limit != data.WEEKLY_CHART_SIZE {
+			t.Fatalf("expected the chart of the current week but got %v to %v, limit %d", from, to, limit)
+		}
+		return []data.ChartEntry{{Rank: 1, ID: 6}, {Rank: 2, ID: 7}}, nil
+	}
+	db.getGroupMemberListening = func(groupID uint64, from, to time.Time) ([]data.MemberListening, error) {
+		return nil, nil
+	}
+
+	chart, err := HandleGroupWeeklyChart(3, 1, data.TrackEntity, time.Time{}, now, db, groupProfiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chart.From.Equal(week) || len(chart.Entries) != 2 {
+		t.Fatalf("expected two entries this week but got %+v", chart)
+	}
+	if m := chart.Entries[0].Movement; m == nil || *m != (data.ChartMovement{Movement: data.Climbed, LastRank: 2}) {
+		t.Fatalf("expected the first entry to climb but got %+v", m)
+	}
+	if m := chart.Entries[1].Movement; m == nil || m.Movement != data.NewEntry {
+		t.Fatalf("expected the second entry to be new but got %+v", m)
+	}
+
+	tests := []struct {
+		name   string
+		userID uint64
+		entity data.EntityType
+		week   time.Time
+		err    error
+	}{
+		{"Outsider", 4, data.TrackEntity, week, ErrForbidden},
+		{"Unknown entity", 3, "genre", week, ErrBadRequest},
+		{"Next week", 3, data.TrackEntity, week.AddDate(0, 0, 7), ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleGroupWeeklyChart(tt.userID, 1, tt.entity, tt.week, now, db, groupProfiles); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleGroupChart(t *testing.T) {
+	now := time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+
+	db := newGroupDBMock()
+	db.getGroupChart = func(groupID uint64, entity data.EntityType, from, to time.Time, limit int) ([]data.ChartEntry, error) {
+		return nil, nil
+	}
+	db.getGroupMemberListening = func(groupID uint64, from, to time.Time) ([]data.MemberListening, error) {
+		return []data.MemberListening{
+			{User: data.UserSummary{ID: 1, Name: "olivia"}, Spins: 4},
+			{User: data.UserSummary{ID: 2, Name: "billie"}, Spins: 3},
+			{User: data.UserSummary{ID: 3, Name: "sza"}, Spins: 2},
+		}, nil
+	}
+	// Only olivia shares her stats, but sza always sees her own.
+	profiles := &profileDBMock{
+		getProfile: func(name string) (data.Profile, error) {
+			p, err := groupProfiles.GetProfile(name)
+			if name == "olivia" {
+				p.Privacy = data.PublicPrivacy
+			}
+			return p, err
+		},
+	}
+
+	chart, err := HandleGroupChart(3, 1, data.ArtistEntity, time.Time{}, time.Time{}, now, 0, db, profiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chart.From.Equal(time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)) || !chart.To.Equal(time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the current week up to today but got %v to %v", chart.From, chart.To)
+	}
+	if len(chart.Members) != 2 || chart.Members[0].User.Name != "olivia" || chart.Members[1].User.Name != "sza" {
+		t.Fatalf("expected only the members sharing their stats but got %+v", chart.Members)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		err      error
+	}{
+		{"Empty range", now, now, ErrBadRequest},
+		{"Too long", now.AddDate(-2, 0, 0), now, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleGroupChart(3, 1, data.ArtistEntity, tt.from, tt.to, now, 0, db, groupProfiles); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	d "tunes-service/data"
+)
+
+const (
+	DEFAULT_PAGE_LIMIT = 50
+	MAX_PAGE_LIMIT     = 200
+)
+
+// HandleHistory returns a user's spins before a time, latest first. Pages
+// continue from the time of the last spin of the previous one.
+func HandleHistory(userID uint64, before time.Time, limit int, db d.HistoryDB) ([]d.HistoryEntry, error) {
+	h, err := db.GetHistory(userID, pageBefore(before), clampPageLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+	return h, nil
+}
+
+// HandleNowPlaying returns the latest spin of a user while its track is still
+// playing.
+func HandleNowPlaying(userID uint64, now time.Time, db d.HistoryDB) (d.HistoryEntry, error) {
+	h, err := db.GetHistory(userID, now.Add(time.Nanosecond), 1)
+	if err != nil {
+		return d.HistoryEntry{}, fmt.Errorf("failed to get now playing: %w", err)
+	}
+	if len(h) == 0 {
+		return d.HistoryEntry{}, fmt.Errorf("%w: no spins", ErrNotFound)
+	}
+
+	if !h[0].IsPlaying(now) {
+		return d.HistoryEntry{}, fmt.Errorf("%w: nothing playing", ErrNotFound)
+	}
+	return h[0], nil
+}
+
+// HandleSessions returns a user's listening sessions that started before a
+// time, latest first, or only the ones they listened to an album in.
+func HandleSessions(userID uint64, before time.Time, limit int, albums bool, db d.SessionDB) ([]d.Session, error) {
+	sessions, err := db.GetSessions(userID, pageBefore(before), clampPageLimit(limit), albums)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// HandleSession returns a listening session of a user with its spins.
+func HandleSession(userID, sessionID uint64, db d.SessionDB) (d.Session, error) {
+	s, err := db.GetSession(userID, sessionID)
+	if errors.Is(err, d.ErrNotFound) {
+		return d.Session{}, fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return d.Session{}, fmt.Errorf("failed to get session: %w", err)
+	}
+	return s, nil
+}
+
+// pageBefore starts pages of items listed latest first at a time, defaulting
+// to now.
+func pageBefore(before time.Time) time.Time {
+	if before.IsZero() {
+		return time.Now()
+	}
+	return before
+}
+
+func clampPageLimit(limit int) int {
+	if limit <= 0 {
+		return DEFAULT_PAGE_LIMIT
+	}
+	return min(limit, MAX_PAGE_LIMIT)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type historyDBMock struct {
+	getHistory          func(uint64, time.Time, int) ([]data.HistoryEntry, error)
+	getUnsessionedUsers func(time.Duration, int) ([]uint64, error)
+	detectSessions      func(uint64, time.Duration) (int, error)
+	getSessions         func(uint64, time.Time, int, bool) ([]data.Session, error)
+	getSession          func(uint64, uint64) (data.Session, error)
+}
+
+func (db *historyDBMock) GetHistory(userID uint64, before time.Time, limit int) ([]data.HistoryEntry, error) {
+	return db.getHistory(userID, before, limit)
+}
+
+func (db *historyDBMock) GetUnsessionedUsers(gap time.Duration, limit int) ([]uint64, error) {
+	return db.getUnsessionedUsers(gap, limit)
+}
+
+func (db *historyDBMock) DetectSessions(userID uint64, gap time.Duration) (int, error) {
+	return db.detectSessions(userID, gap)
+}
+
+func (db *historyDBMock) GetSessions(userID uint64, before time.Time, limit int, albums bool) ([]data.Session, error) {
+	return db.getSessions(userID, before, limit, albums)
+}
+
+func (db *historyDBMock) GetSession(userID, sessionID uint64) (data.Session, error) {
+	return db.getSession(userID, sessionID)
+}
+
+func TestHandleHistory(t *testing.T) {
+	before := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		before   time.Time
+		limit    int
+		expected int
+	}{
+		{"Should default limit", before, 0, DEFAULT_PAGE_LIMIT},
+		{"Should cap limit", before, 1000, MAX_PAGE_LIMIT},
+		{"Should default to now", time.Time{}, 5, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &historyDBMock{
+				getHistory: func(userID uint64, b time.Time, limit int) ([]data.HistoryEntry, error) {
+					if limit != tt.expected {
+						t.Fatalf("expected limit %d but got %d", tt.expected, limit)
+					}
+					if b.IsZero() || (!tt.before.IsZero() && !b.Equal(tt.before)) {
+						t.Fatalf("expected spins before %v but got %v", tt.before, b)
+					}
+					return []data.HistoryEntry{}, nil
+				},
+			}
+
+			if _, err := HandleHistory(1, tt.before, tt.limit, db); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestHandleNowPlaying(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		history []data.HistoryEntry
+		err     error
+	}{
+		{"Should find playing track", []data.HistoryEntry{{Time: now.Add(-3 * time.Minute), Duration: 4 * time.Minute}}, nil},
+		{"Finished track", []data.HistoryEntry{{Time: now.Add(-5 * time.Minute), Duration: 4 * time.Minute}}, ErrNotFound},
+		{"Should wait for track of unknown length", []data.HistoryEntry{{Time: now.Add(-5 * time.Minute)}}, nil},
+		{"Old track of unknown length", []data.HistoryEntry{{Time: now.Add(-data.NOW_PLAYING_WINDOW - time.Minute)}}, ErrNotFound},
+		{"No spins", []data.HistoryEntry{}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &historyDBMock{
+				getHistory: func(userID uint64, before time.Time, limit int) ([]data.HistoryEntry, error) {
+					if limit != 1 || !before.After(now) {
+						t.Fatalf("expected the latest spin but got %d before %v", limit, before)
+					}
+					return tt.history, nil
+				},
+			}
+
+			if _, err := HandleNowPlaying(1, now, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleSessions(t *testing.T) {
+	before := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	db := &historyDBMock{
+		getSessions: func(userID uint64, b time.Time, limit int, albums bool) ([]data.Session, error) {
+			if !b.Equal(before) || limit != DEFAULT_PAGE_LIMIT || !albums {
+				t.Fatalf("expected album sessions before %v but got %d before %v, albums %v", before, limit, b, albums)
+			}
+			return []data.Session{}, nil
+		},
+	}
+
+	if _, err := HandleSessions(1, before, 0, true, db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandleSession(t *testing.T) {
+	db := &historyDBMock{
+		getSession: func(userID, sessionID uint64) (data.Session, error) {
+			if sessionID != 1 {
+				return data.Session{}, data.ErrNotFound
+			}
+			return data.Session{ID: sessionID}, nil
+		},
+	}
+
+	if _, err := HandleSession(1, 1, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HandleSession(1, 2, db); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected error %v but got %v", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	c "tunes-service/cache"
+	d "tunes-service/data"
+)
+
+// HandleMerge merges one artist, track or project, by the plural the route
+// names them by, into another.
+func HandleMerge(entity string, fromID, intoID uint64, db d.MergeDB, cache c.Cache) error {
+	switch entity {
+	case "artists":
+		return HandleMergeArtists(fromID, intoID, db, cache)
+	case "tracks":
+		return HandleMergeTracks(fromID, intoID, db, cache)
+	case "projects":
+		return HandleMergeProjects(fromID, intoID, db, cache)
+	default:
+		return fmt.Errorf("%w: cannot merge %q", ErrBadRequest, entity)
+	}
+}
+
+func HandleMergeArtists(fromID, intoID uint64, db d.MergeDB, cache c.Cache) error {
+	if fromID == intoID {
+		return fmt.Errorf("%w: cannot merge artist into itself", ErrBadRequest)
+	}
+
+	r, err := db.MergeArtists(fromID, intoID)
+	if err != nil {
+		return mergeError("artists", err)
+	}
+
+	invalidate(r, cache)
+	return nil
+}
+
+func HandleMergeTracks(fromKey, intoKey uint64, db d.MergeDB, cache c.Cache) error {
+	if fromKey == intoKey {
+		return fmt.Errorf("%w: cannot merge track into itself", ErrBadRequest)
+	}
+
+	r, err := db.MergeTracks(fromKey, intoKey)
+	if err != nil {
+		return mergeError("tracks", err)
+	}
+
+	invalidate(r, cache)
+	return nil
+}
+
+func HandleMergeProjects(fromKey, intoKey uint64, db d.MergeDB, cache c.Cache) error {
+	if fromKey == intoKey {
+		return fmt.Errorf("%w: cannot merge project into itself", ErrBadRequest)
+	}
+
+	r, err := db.MergeProjects(fromKey, intoKey)
+	if err != nil {
+		return mergeError("projects", err)
+	}
+
+	invalidate(r, cache)
+	return nil
+}
+
+func HandleArtistAlias(name string, artistID uint64, db d.MergeDB, cache c.Cache) error {
+	if name == "" {
+		return fmt.Errorf("%w: alias name is empty", ErrBadRequest)
+	}
+
+	if err := db.CreateArtistAlias(name, artistID); err != nil {
+		if errors.Is(err, d.ErrNotFound) {
+			return fmt.Errorf("%w: %s", ErrNotFound, err)
+		}
+		return fmt.Errorf("failed to create alias: %w", err)
+	}
+
+	cache.Delete("a-" + name)
+	return nil
+}
+
+// mergeError reports a merge of artists, tracks or projects one of which is
+// missing as not found.
+func mergeError(entities string, err error) error {
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	}
+	return fmt.Errorf("failed to merge %s: %w", entities, err)
+}
+
+func invalidate(r d.MergeResult, cache c.Cache) {
+	for _, name := range r.ArtistNames {
+		cache.Delete("a-" + name)
+	}
+	for _, key := range r.TrackIDs {
+		cache.Delete("t-" + strconv.FormatUint(key, 10))
+	}
+	for _, key := range r.ProjectIDs {
+		cache.Delete("p-" + strconv.FormatUint(key, 10))
+	}
+	for _, mbid := range r.MBIDs {
+		cache.Delete("am-" + mbid)
+		cache.Delete("tm-" + mbid)
+		cache.Delete("pm-" + mbid)
+	}
+}
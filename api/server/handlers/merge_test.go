@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"tunes-service/data"
+)
+
+type mergeDBMock struct {
+	mergeArtists      func(uint64, uint64) (data.MergeResult, error)
+	mergeTracks       func(uint64, uint64) (data.MergeResult, error)
+	mergeProjects     func(uint64, uint64) (data.MergeResult, error)
+	createArtistAlias func(string, uint64) error
+}
+
+func (d *mergeDBMock) MergeArtists(fromID, intoID uint64) (data.MergeResult, error) {
+	return d.mergeArtists(fromID, intoID)
+}
+
+func (d *mergeDBMock) MergeTracks(fromKey, intoKey uint64) (data.MergeResult, error) {
+	return d.mergeTracks(fromKey, intoKey)
+}
+
+func (d *mergeDBMock) MergeProjects(fromKey, intoKey uint64) (data.MergeResult, error) {
+	return d.mergeProjects(fromKey, intoKey)
+}
+
+func (d *mergeDBMock) CreateArtistAlias(name string, artistID uint64) error {
+	return d.createArtistAlias(name, artistID)
+}
+
+func TestHandleMergeProjects(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      data.MergeResult
+		err         error
+		want        error
+		invalidated []string
+	}{
+		{
+			"Should invalidate tracks and projects",
+			data.MergeResult{
+				TrackIDs:   []uint64{3},
+				ProjectIDs: []uint64{1, 2},
+			},
+			nil,
+			nil,
+			[]string{"t-3", "p-1", "p-2"},
+		},
+		{
+			"Should not invalidate when merge fails",
+			data.MergeResult{},
+			fmt.Errorf("connection reset"),
+			nil,
+			[]string{},
+		},
+		{
+			"Should report a missing project as not found",
+			data.MergeResult{},
+			fmt.Errorf("%w: project 1", data.ErrNotFound),
+			ErrNotFound,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted := []string{}
+			db := &mergeDBMock{
+				func(uint64, uint64) (data.MergeResult, error) {
+					t.Fatalf("should not call this function")
+					return data.MergeResult{}, nil
+				},
+				func(uint64, uint64) (data.MergeResult, error) {
+					t.Fatalf("should not call this function")
+					return data.MergeResult{}, nil
+				},
+				func(uint64, uint64) (data.MergeResult, error) {
+					return tt.result, tt.err
+				},
+				func(string, uint64) error {
+					t.Fatalf("should not call this function")
+					return nil
+				},
+			}
+			cache := &cacheMock{
+				func(string) string {
+					return ""
+				},
+				func(string, string) {
+				},
+				func(key string) {
+					deleted = append(deleted, key)
+				},
+			}
+
+			err := HandleMergeProjects(1, 2, db, cache)
+			if (err == nil) != (tt.err == nil) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("expected error %v but got %v", tt.want, err)
+			}
+			if !slices.Equal(deleted, tt.invalidated) {
+				t.Fatalf("expected %v to be invalidated but got %v", tt.invalidated, deleted)
+			}
+		})
+	}
+}
+
+func TestHandleMerge(t *testing.T) {
+	db := &mergeDBMock{}
+	cache := &cacheMock{}
+
+	if err := HandleMerge("artists", 1, 1, db, cache); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected a self-merge to be a bad request but got %v", err)
+	}
+	if err := HandleMerge("tags", 1, 2, db, cache); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected an unknown entity to be a bad request but got %v", err)
+	}
+}
+
+func TestHandleArtistAlias(t *testing.T) {
+	deleted := []string{}
+	db := &mergeDBMock{
+		nil,
+		nil,
+		nil,
+		func(name string, artistID uint64) error {
+			if name != "Beyonce" || artistID != 1 {
+				t.Fatalf("unexpected alias %s for %d", name, artistID)
+			}
+			return nil
+		},
+	}
+	cache := &cacheMock{
+		nil,
+		nil,
+		func(key string) {
+			deleted = append(deleted, key)
+		},
+	}
+
+	if err := HandleArtistAlias("Beyonce", 1, db, cache); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deleted, []string{"a-Beyonce"}) {
+		t.Fatalf("expected alias to be invalidated but got %v", deleted)
+	}
+
+	if err := HandleArtistAlias("", 1, db, cache); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected a bad request but got %v", err)
+	}
+
+	db.createArtistAlias = func(string, uint64) error {
+		return fmt.Errorf("%w: artist 2", data.ErrNotFound)
+	}
+	if err := HandleArtistAlias("Beyonce", 2, db, cache); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a missing artist to be not found but got %v", err)
+	}
+}
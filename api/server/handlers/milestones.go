@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"fmt"
+
+	d "tunes-service/data"
+)
+
+func HandleMilestones(userID uint64, db d.MilestoneDB) (d.MilestoneSummary, error) {
+	m, err := db.GetMilestones(userID)
+	if err != nil {
+		return d.MilestoneSummary{}, fmt.Errorf("failed to get milestones: %w", err)
+	}
+	return m, nil
+}
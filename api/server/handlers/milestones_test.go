@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+
+	"tunes-service/data"
+)
+
+func TestHandleMilestones(t *testing.T) {
+	db := &dbMock{}
+	db.getMilestones = func(userID uint64) (data.MilestoneSummary, error) {
+		if userID != 2 {
+			return data.MilestoneSummary{}, fmt.Errorf("connection refused")
+		}
+		return data.MilestoneSummary{
+			Streak:     data.Streak{Current: 3, Longest: 7},
+			Milestones: []data.Milestone{{UserID: userID, Kind: data.StreakMilestone, Value: 7}},
+		}, nil
+	}
+
+	m, err := HandleMilestones(2, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Streak.Current != 3 || len(m.Milestones) != 1 {
+		t.Fatalf("expected a streak of 3 and one milestone but got %+v", m)
+	}
+
+	if _, err := HandleMilestones(3, db); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
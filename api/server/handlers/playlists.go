@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	d "tunes-service/data"
+)
+
+const (
+	MAX_PLAYLIST_NAME_LENGTH     = 100
+	MAX_PLAYLIST_TRACKS          = 1000
+	DEFAULT_SMART_PLAYLIST_LIMIT = 50
+	MAX_SMART_PLAYLIST_LIMIT     = 200
+)
+
+// HandleCreatePlaylist creates a curated playlist owned by a user, or a smart
+// one when it has a rule.
+func HandleCreatePlaylist(userID uint64, p d.Playlist, db d.PlaylistDB) (d.Playlist, error) {
+	if err := validatePlaylist(&p); err != nil {
+		return d.Playlist{}, err
+	}
+
+	p, err := db.CreatePlaylist(userID, p)
+	if err != nil {
+		return d.Playlist{}, fmt.Errorf("failed to create playlist: %w", err)
+	}
+	return p, nil
+}
+
+// HandlePlaylist returns a playlist with its tracks to its owner, or to anyone
+// when it is public. Smart playlists pick their tracks from their owner's
+// listening as of now, so others only see those if they may see the owner's
+// stats.
+func HandlePlaylist(viewerID, playlistID uint64, now time.Time, db d.PlaylistDB, adb d.AccessDB) (d.Playlist, error) {
+	p, err := getPlaylist(playlistID, db)
+	if err != nil {
+		return d.Playlist{}, err
+	}
+	if p.OwnerID != viewerID {
+		if !p.Public {
+			return d.Playlist{}, fmt.Errorf("%w: playlist %d", ErrNotFound, playlistID)
+		}
+		if p.Rule != nil {
+			if _, err := HandleAccess(p.Owner, viewerID, d.StatsFeature, adb); err != nil {
+				return d.Playlist{}, err
+			}
+		}
+	}
+
+	if p.Rule != nil {
+		p.Tracks, err = db.GetSmartPlaylistTracks(p.OwnerID, *p.Rule, now)
+	} else {
+		p.Tracks, err = db.GetPlaylistTracks(playlistID)
+	}
+	if err != nil {
+		return d.Playlist{}, fmt.Errorf("failed to get playlist tracks: %w", err)
+	}
+	return p, nil
+}
+
+// HandleUpdatePlaylist renames a playlist and saves its visibility and, for a
+// smart playlist, its rule. Playlists stay curated or smart.
+func HandleUpdatePlaylist(userID uint64, p d.Playlist, db d.PlaylistDB) error {
+	stored, err := ownPlaylist(userID, p.ID, db)
+	if err != nil {
+		return err
+	}
+	if (stored.Rule == nil) != (p.Rule == nil) {
+		return fmt.Errorf("%w: playlists cannot change between curated and smart", ErrBadRequest)
+	}
+	if err := validatePlaylist(&p); err != nil {
+		return err
+	}
+
+	err = db.UpdatePlaylist(p)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to update playlist: %w", err)
+	}
+	return nil
+}
+
+func HandleDeletePlaylist(userID, playlistID uint64, db d.PlaylistDB) error {
+	if _, err := ownPlaylist(userID, playlistID, db); err != nil {
+		return err
+	}
+
+	err := db.DeletePlaylist(playlistID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete playlist: %w", err)
+	}
+	return nil
+}
+
+// HandleUserPlaylists returns the playlists of the named user, only the public
+// ones unless the viewer is that user. Others only see them if they may see
+// the user's stats.
+func HandleUserPlaylists(viewerID uint64, name string, db d.PlaylistDB, adb d.AccessDB) ([]d.Playlist, error) {
+	userID, err := HandleAccess(name, viewerID, d.StatsFeature, adb)
+	if err != nil {
+		return nil, err
+	}
+
+	playlists, err := db.GetUserPlaylists(userID, viewerID != 0 && viewerID == userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+	return playlists, nil
+}
+
+// HandleAddPlaylistTrack adds a track to the end of a user's curated
+// playlist. Adding a track it has already changes nothing.
+func HandleAddPlaylistTrack(userID, playlistID, trackID uint64, db d.PlaylistDB) error {
+	tracks, err := curatedTracks(userID, playlistID, db)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(tracks, func(t d.PlaylistTrack) bool { return t.ID == trackID }) {
+		return nil
+	}
+	if len(tracks) >= MAX_PLAYLIST_TRACKS {
+		return fmt.Errorf("%w: playlists have at most %d tracks", ErrBadRequest, MAX_PLAYLIST_TRACKS)
+	}
+
+	err = db.AddPlaylistTrack(playlistID, trackID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to add playlist track: %w", err)
+	}
+	return nil
+}
+
+func HandleRemovePlaylistTrack(userID, playlistID, trackID uint64, db d.PlaylistDB) error {
+	if _, err := curatedTracks(userID, playlistID, db); err != nil {
+		return err
+	}
+
+	err := db.RemovePlaylistTrack(playlistID, trackID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to remove playlist track: %w", err)
+	}
+	return nil
+}
+
+// HandleReorderPlaylist puts the tracks of a user's curated playlist in the
+// order of their IDs, which have to be the playlist's tracks, each once.
+func HandleReorderPlaylist(userID, playlistID uint64, trackIDs []uint64, db d.PlaylistDB) error {
+	tracks, err := curatedTracks(userID, playlistID, db)
+	if err != nil {
+		return err
+	}
+	current := make([]uint64, len(tracks))
+	for i, t := range tracks {
+		current[i] = t.ID
+	}
+	order := slices.Clone(trackIDs)
+	slices.Sort(current)
+	slices.Sort(order)
+	if !slices.Equal(current, order) {
+		return fmt.Errorf("%w: reordering needs every track of the playlist once", ErrBadRequest)
+	}
+
+	if err := db.ReorderPlaylist(playlistID, trackIDs); err != nil {
+		return fmt.Errorf("failed to reorder playlist: %w", err)
+	}
+	return nil
+}
+
+func getPlaylist(playlistID uint64, db d.PlaylistDB) (d.Playlist, error) {
+	p, err := db.GetPlaylist(playlistID)
+	if errors.Is(err, d.ErrNotFound) {
+		return d.Playlist{}, fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return d.Playlist{}, fmt.Errorf("failed to get playlist: %w", err)
+	}
+	return p, nil
+}
+
+// ownPlaylist returns a playlist if a user owns it. Others cannot tell
+// private playlists exist.
+func ownPlaylist(userID, playlistID uint64, db d.PlaylistDB) (d.Playlist, error) {
+	p, err := getPlaylist(playlistID, db)
+	if err != nil {
+		return d.Playlist{}, err
+	}
+	if p.OwnerID != userID {
+		if !p.Public {
+			return d.Playlist{}, fmt.Errorf("%w: playlist %d", ErrNotFound, playlistID)
+		}
+		return d.Playlist{}, fmt.Errorf("%w: playlist %d belongs to %s", ErrForbidden, playlistID, p.Owner)
+	}
+	return p, nil
+}
+
+// curatedTracks returns the tracks of a curated playlist a user owns.
+func curatedTracks(userID, playlistID uint64, db d.PlaylistDB) ([]d.PlaylistTrack, error) {
+	p, err := ownPlaylist(userID, playlistID, db)
+	if err != nil {
+		return nil, err
+	}
+	if p.Rule != nil {
+		return nil, fmt.Errorf("%w: smart playlists pick their own tracks", ErrBadRequest)
+	}
+
+	tracks, err := db.GetPlaylistTracks(playlistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist tracks: %w", err)
+	}
+	return tracks, nil
+}
+
+// validatePlaylist checks the name and rule of a playlist, filling in the
+// defaults of the rule.
+func validatePlaylist(p *d.Playlist) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: playlists need a name", ErrBadRequest)
+	}
+	if utf8.RuneCountInString(p.Name) > MAX_PLAYLIST_NAME_LENGTH {
+		return fmt.Errorf("%w: playlist name longer than %d characters", ErrBadRequest, MAX_PLAYLIST_NAME_LENGTH)
+	}
+
+	r := p.Rule
+	if r == nil {
+		return nil
+	}
+	if r.Order == "" {
+		r.Order = d.TimeOrder
+	} else if !r.Order.IsValid() {
+		return fmt.Errorf("%w: unknown smart playlist order %q", ErrBadRequest, r.Order)
+	}
+	if r.Limit == 0 {
+		r.Limit = DEFAULT_SMART_PLAYLIST_LIMIT
+	} else if r.Limit < 0 || r.Limit > MAX_SMART_PLAYLIST_LIMIT {
+		return fmt.Errorf("%w: smart playlists have 1 to %d tracks", ErrBadRequest, MAX_SMART_PLAYLIST_LIMIT)
+	}
+	if r.Days < 0 || r.NotHeardDays < 0 {
+		return fmt.Errorf("%w: negative days in smart playlist rule", ErrBadRequest)
+	}
+	if r.Days > 0 && r.NotHeardDays >= r.Days {
+		return fmt.Errorf("%w: no track can be played in the last %d days but not in the last %d", ErrBadRequest, r.Days, r.NotHeardDays)
+	}
+	return nil
+}
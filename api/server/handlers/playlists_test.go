@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type playlistDBMock struct {
+	createPlaylist         func(uint64, data.Playlist) (data.Playlist, error)
+	getPlaylist            func(uint64) (data.Playlist, error)
+	updatePlaylist         func(data.Playlist) error
+	deletePlaylist         func(uint64) error
+	getUserPlaylists       func(uint64, bool) ([]data.Playlist, error)
+	getPlaylistTracks      func(uint64) ([]data.PlaylistTrack, error)
+	addPlaylistTrack       func(uint64, uint64) error
+	removePlaylistTrack    func(uint64, uint64) error
+	reorderPlaylist        func(uint64, []uint64) error
+	getSmartPlaylistTracks func(uint64, data.SmartRule, time.Time) ([]data.PlaylistTrack, error)
+}
+
+func (db *playlistDBMock) CreatePlaylist(userID uint64, p data.Playlist) (data.Playlist, error) {
+	return db.createPlaylist(userID, p)
+}
+
+func (db *playlistDBMock) GetPlaylist(id uint64) (data.Playlist, error) {
+	return db.getPlaylist(id)
+}
+
+func (db *playlistDBMock) UpdatePlaylist(p data.Playlist) error {
+	return db.updatePlaylist(p)
+}
+
+func (db *playlistDBMock) DeletePlaylist(id uint64) error {
+	return db.deletePlaylist(id)
+}
+
+func (db *playlistDBMock) GetUserPlaylists(userID uint64, private bool) ([]data.Playlist, error) {
+	return db.getUserPlaylists(userID, private)
+}
+
+func (db *playlistDBMock) GetPlaylistTracks(id uint64) ([]data.PlaylistTrack, error) {
+	return db.getPlaylistTracks(id)
+}
+
+func (db *playlistDBMock) AddPlaylistTrack(id, trackID uint64) error {
+	return db.addPlaylistTrack(id, trackID)
+}
+
+func (db *playlistDBMock) RemovePlaylistTrack(id, trackID uint64) error {
+	return db.removePlaylistTrack(id, trackID)
+}
+
+func (db *playlistDBMock) ReorderPlaylist(id uint64, trackIDs []uint64) error {
+	return db.reorderPlaylist(id, trackIDs)
+}
+
+func (db *playlistDBMock) GetSmartPlaylistTracks(userID uint64, rule data.SmartRule, now time.Time) ([]data.PlaylistTrack, error) {
+	return db.getSmartPlaylistTracks(userID, rule, now)
+}
+
+// playlistProfiles are the owner of the playlists, whose profile is private,
+// and another user.
+var playlistProfiles = &profileDBMock{
+	getProfile: func(name string) (data.Profile, error) {
+		for id, n := range []string{"olivia", "billie"} {
+			if n == name {
+				return data.Profile{ID: uint64(id + 1), Name: name, Settings: data.Settings{Privacy: data.PrivatePrivacy}}, nil
+			}
+		}
+		return data.Profile{}, data.ErrNotFound
+	},
+}
+
+// newPlaylistDBMock returns a mock with a private, a public and a smart public
+// playlist of user 1, the curated ones with tracks 10 to 12.
+func newPlaylistDBMock() *playlistDBMock {
+	playlists := map[uint64]data.Playlist{
+		1: {ID: 1, OwnerID: 1, Owner: "olivia", Name: "drafts"},
+		2: {ID: 2, OwnerID: 1, Owner: "olivia", Name: "GUTS", Public: true},
+		3: {ID: 3, OwnerID: 1, Owner: "olivia", Name: "on repeat", Public: true, Rule: &data.SmartRule{Days: 30, Order: data.SpinsOrder, Limit: 25}},
+	}
+	return &playlistDBMock{
+		getPlaylist: func(id uint64) (data.Playlist, error) {
+			p, ok := playlists[id]
+			if !ok {
+				return data.Playlist{}, data.ErrNotFound
+			}
+			return p, nil
+		},
+		getPlaylistTracks: func(id uint64) ([]data.PlaylistTrack, error) {
+			return []data.PlaylistTrack{{Position: 1, ID: 10}, {Position: 2, ID: 11}, {Position: 3, ID: 12}}, nil
+		},
+		getSmartPlaylistTracks: func(userID uint64, rule data.SmartRule, now time.Time) ([]data.PlaylistTrack, error) {
+			return []data.PlaylistTrack{{Position: 1, ID: 12, Spins: 40}}, nil
+		},
+	}
+}
+
+func TestHandleCreatePlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist data.Playlist
+		expected *data.SmartRule
+		err      error
+	}{
+		{"Should create curated playlist", data.Playlist{Name: "GUTS"}, nil, nil},
+		{"Should default smart rule", data.Playlist{Name: "top", Rule: &data.SmartRule{Days: 90}}, &data.SmartRule{Days: 90, Order: data.TimeOrder, Limit: DEFAULT_SMART_PLAYLIST_LIMIT}, nil},
+		{"Should find forgotten tracks", data.Playlist{Name: "forgotten", Rule: &data.SmartRule{MinSpins: 21, NotHeardDays: 365, Order: data.SpinsOrder, Limit: 20}}, &data.SmartRule{MinSpins: 21, NotHeardDays: 365, Order: data.SpinsOrder, Limit: 20}, nil},
+		{"Blank name", data.Playlist{Name: " "}, nil, ErrBadRequest},
+		{"Long name", data.Playlist{Name: strings.Repeat("é", MAX_PLAYLIST_NAME_LENGTH+1)}, nil, ErrBadRequest},
+		{"Unknown order", data.Playlist{Name: "top", Rule: &data.SmartRule{Order: "title"}}, nil, ErrBadRequest},
+		{"Long smart playlist", data.Playlist{Name: "top", Rule: &data.SmartRule{Limit: MAX_SMART_PLAYLIST_LIMIT + 1}}, nil, ErrBadRequest},
+		{"Negative days", data.Playlist{Name: "top", Rule: &data.SmartRule{Days: -1}}, nil, ErrBadRequest},
+		{"Not heard in the whole window", data.Playlist{Name: "top", Rule: &data.SmartRule{Days: 30, NotHeardDays: 30}}, nil, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &playlistDBMock{
+				createPlaylist: func(userID uint64, p data.Playlist) (data.Playlist, error) {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if (p.Rule == nil) != (tt.expected == nil) || (p.Rule != nil && *p.Rule != *tt.expected) {
+						t.Fatalf("expected rule %+v but got %+v", tt.expected, p.Rule)
+					}
+					p.ID = 1
+					return p, nil
+				},
+			}
+
+			if _, err := HandleCreatePlaylist(1, tt.playlist, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandlePlaylist(t *testing.T) {
+	tests := []struct {
+		name       string
+		viewerID   uint64
+		playlistID uint64
+		tracks     int
+		err        error
+	}{
+		{"Should show private playlist to owner", 1, 1, 3, nil},
+		{"Private playlist", 2, 1, 0, ErrNotFound},
+		{"Should show public playlist", 2, 2, 3, nil},
+		{"Should show public playlist to anyone", 0, 2, 3, nil},
+		{"Should evaluate smart playlist for owner", 1, 3, 1, nil},
+		{"Smart playlist of private listening", 2, 3, 0, ErrForbidden},
+		{"Unknown playlist", 1, 4, 0, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := HandlePlaylist(tt.viewerID, tt.playlistID, time.Now(), newPlaylistDBMock(), playlistProfiles)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if len(p.Tracks) != tt.tracks {
+				t.Fatalf("expected %d tracks but got %+v", tt.tracks, p.Tracks)
+			}
+		})
+	}
+}
+
+func TestHandleUserPlaylists(t *testing.T) {
+	tests := []struct {
+		name     string
+		viewerID uint64
+		private  bool
+		err      error
+	}{
+		{"Should list all playlists to owner", 1, true, nil},
+		{"Private profile", 2, false, ErrForbidden},
+		{"Private profile to anyone", 0, false, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &playlistDBMock{
+				getUserPlaylists: func(userID uint64, private bool) ([]data.Playlist, error) {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if userID != 1 || private != tt.private {
+						t.Fatalf("expected playlists of user 1 with private %v but got %d with %v", tt.private, userID, private)
+					}
+					return []data.Playlist{}, nil
+				},
+			}
+
+			if _, err := HandleUserPlaylists(tt.viewerID, "olivia", db, playlistProfiles); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleUpdatePlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   uint64
+		playlist data.Playlist
+		err      error
+	}{
+		{"Should rename playlist", 1, data.Playlist{ID: 1, Name: "GUTS (spilled)"}, nil},
+		{"Should change rule", 1, data.Playlist{ID: 3, Name: "on repeat", Rule: &data.SmartRule{Days: 7}}, nil},
+		{"Curated to smart", 1, data.Playlist{ID: 1, Name: "drafts", Rule: &data.SmartRule{}}, ErrBadRequest},
+		{"Smart to curated", 1, data.Playlist{ID: 3, Name: "on repeat"}, ErrBadRequest},
+		{"Playlist of another user", 2, data.Playlist{ID: 2, Name: "mine now"}, ErrForbidden},
+		{"Private playlist of another user", 2, data.Playlist{ID: 1, Name: "mine now"}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newPlaylistDBMock()
+			db.updatePlaylist = func(p data.Playlist) error { return nil }
+
+			if err := HandleUpdatePlaylist(tt.userID, tt.playlist, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleAddPlaylistTrack(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint64
+		playlistID uint64
+		trackID    uint64
+		added      bool
+		err        error
+	}{
+		{"Should add track", 1, 1, 13, true, nil},
+		{"Should skip track in playlist", 1, 1, 11, false, nil},
+		{"Unknown track", 1, 1, 99, true, ErrNotFound},
+		{"Smart playlist", 1, 3, 13, false, ErrBadRequest},
+		{"Playlist of another user", 2, 2, 13, false, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added := false
+			db := newPlaylistDBMock()
+			db.addPlaylistTrack = func(id, trackID uint64) error {
+				added = true
+				if trackID == 99 {
+					return data.ErrNotFound
+				}
+				return nil
+			}
+
+			if err := HandleAddPlaylistTrack(tt.userID, tt.playlistID, tt.trackID, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if added != tt.added {
+				t.Fatalf("expected the track to be added %v but got %v", tt.added, added)
+			}
+		})
+	}
+}
+
+func TestHandleReorderPlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		trackIDs []uint64
+		err      error
+	}{
+		{"Should reorder tracks", []uint64{12, 10, 11}, nil},
+		{"Missing track", []uint64{12, 10}, ErrBadRequest},
+		{"Repeated track", []uint64{12, 10, 10}, ErrBadRequest},
+		{"Other track", []uint64{12, 10, 13}, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newPlaylistDBMock()
+			db.reorderPlaylist = func(id uint64, trackIDs []uint64) error {
+				if tt.err != nil {
+					t.Fatalf("should not call this function")
+				}
+				if trackIDs[0] != 12 {
+					t.Fatalf("expected the order %v but got %v", tt.trackIDs, trackIDs)
+				}
+				return nil
+			}
+
+			if err := HandleReorderPlaylist(1, 1, tt.trackIDs, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
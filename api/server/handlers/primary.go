@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	c "tunes-service/cache"
+	d "tunes-service/data"
+)
+
+// HandlePinPrimary makes a project the primary project of a track. A userID
+// of 0 pins it for everyone, which keeps the policy from changing it; other
+// users only override it for their own listening.
+func HandlePinPrimary(key, projectID, userID uint64, db d.TunesDB, cache c.Cache) error {
+	var err error
+	if userID == 0 {
+		err = db.SetPrimaryProject(key, projectID, true)
+	} else {
+		err = db.SetUserPrimaryProject(userID, key, projectID)
+	}
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to pin primary project: %w", err)
+	}
+
+	if userID == 0 {
+		t, _ := db.GetTrack(key)
+		forgetTrack(key, t.MBID, cache)
+	}
+	return nil
+}
+
+// HandleUnpinPrimary removes a pin or a user's override. Unpinning for
+// everyone hands the choice back to the policy right away.
+func HandleUnpinPrimary(key, userID uint64, db d.TunesDB, cache c.Cache, policy d.PrimaryPolicy) error {
+	if userID != 0 {
+		if err := db.DeleteUserPrimaryProject(userID, key); err != nil {
+			return fmt.Errorf("failed to remove primary project override: %w", err)
+		}
+		return nil
+	}
+
+	if err := db.UnpinPrimaryProject(key); err != nil {
+		return fmt.Errorf("failed to unpin primary project: %w", err)
+	}
+
+	e, err := HandleExplainPrimary(key, 0, db, policy)
+	if err != nil {
+		return err
+	}
+	if err := db.SetPrimaryProject(key, e.Chosen.ID, false); err != nil {
+		return fmt.Errorf("failed to update primary project: %w", err)
+	}
+
+	t, _ := db.GetTrack(key)
+	forgetTrack(key, t.MBID, cache)
+	return nil
+}
+
+// HandleExplainPrimary explains which project is the primary project of a
+// track for a user, or for everyone when userID is 0.
+func HandleExplainPrimary(key, userID uint64, db d.PrimaryDB, policy d.PrimaryPolicy) (d.PrimaryExplanation, error) {
+	projects, err := db.GetTrackProjects(key)
+	if err != nil {
+		return d.PrimaryExplanation{}, fmt.Errorf("failed to get track projects: %w", err)
+	}
+	if len(projects) == 0 {
+		return d.PrimaryExplanation{}, fmt.Errorf("%w: track %d has no projects", ErrNotFound, key)
+	}
+
+	ctx, err := db.GetPrimaryContext(key, userID)
+	if err != nil {
+		return d.PrimaryExplanation{}, fmt.Errorf("failed to get primary project context: %w", err)
+	}
+
+	return policy.Explain(projects, ctx), nil
+}
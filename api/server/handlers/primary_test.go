@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"tunes-service/data"
+)
+
+func TestHandlePinPrimary(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  uint64
+		project uint64
+		err     error
+	}{
+		{"Should pin for everyone", 0, 2, nil},
+		{"Should override for user", 7, 2, nil},
+		{"Project not on track", 7, 9, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := func(projectID uint64) error {
+				if projectID != 2 {
+					return fmt.Errorf("%w: not on project", data.ErrNotFound)
+				}
+				return nil
+			}
+			db := &dbMock{}
+			db.setPrimary = func(key, projectID uint64, pinned bool) error {
+				if tt.userID != 0 || !pinned {
+					t.Fatalf("expected a pin for everyone")
+				}
+				return link(projectID)
+			}
+			db.setUserPrim = func(userID, key, projectID uint64) error {
+				if userID != tt.userID {
+					t.Fatalf("expected an override for user %d", tt.userID)
+				}
+				return link(projectID)
+			}
+			db.getTrack = func(key uint64) (data.Track, error) {
+				return data.Track{ID: key, MBID: "m"}, nil
+			}
+			deleted := map[string]bool{}
+			cache := &cacheMock{nil, nil, func(key string) {
+				deleted[key] = true
+			}}
+
+			err := HandlePinPrimary(1, tt.project, tt.userID, db, cache)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if (deleted["t-1"] && deleted["tm-m"]) != (tt.userID == 0) {
+				t.Fatalf("expected only pins for everyone to invalidate the cached track")
+			}
+		})
+	}
+}
+
+func TestHandleExplainPrimary(t *testing.T) {
+	db := &dbMock{}
+	db.getTrackProjs = func(key uint64) ([]data.Project, error) {
+		if key != 1 {
+			return []data.Project{}, nil
+		}
+		return []data.Project{
+			{ID: 2, Title: "SOUR", Form: data.Album},
+			{ID: 3, Title: "drivers license", Form: data.Single},
+		}, nil
+	}
+	db.getPrimaryCtx = func(key, userID uint64) (data.PrimaryContext, error) {
+		return data.PrimaryContext{Spins: map[uint64]uint64{3: 4}}, nil
+	}
+
+	e, err := HandleExplainPrimary(1, 7, db, data.DefaultPrimaryPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Chosen.ID != 2 || e.Candidates[1].Rule != "form" || e.Candidates[1].Spins != 4 {
+		t.Fatalf("unexpected explanation %+v", e)
+	}
+
+	if _, err := HandleExplainPrimary(5, 7, db, data.DefaultPrimaryPolicy); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected %v for a track without projects but got %v", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	d "tunes-service/data"
+)
+
+func HandleProfile(name string, db d.ProfileDB) (d.Profile, error) {
+	p, err := db.GetProfile(name)
+	if errors.Is(err, d.ErrNotFound) {
+		return d.Profile{}, fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return d.Profile{}, fmt.Errorf("failed to get profile: %w", err)
+	}
+	return p, nil
+}
+
+// HandlePublicProfile returns the part of the named user's profile anyone can
+// see.
+func HandlePublicProfile(name string, db d.ProfileDB) (d.PublicProfile, error) {
+	p, err := HandleProfile(name, db)
+	if err != nil {
+		return d.PublicProfile{}, err
+	}
+	return p.Public(), nil
+}
+
+// HandleAccess returns the ID of the named user if their profile lets the
+// viewer, 0 when anonymous, see a feature of their listening data.
+func HandleAccess(name string, viewerID uint64, f d.Feature, db d.AccessDB) (uint64, error) {
+	p, err := HandleProfile(name, db)
+	if err != nil {
+		return 0, err
+	}
+	// Only followers-only profiles depend on whether the viewer follows them.
+	status := d.NotFollowing
+	if viewerID != 0 && viewerID != p.ID && p.Privacy == d.FollowersPrivacy {
+		if status, err = db.GetFollowStatus(viewerID, p.ID); err != nil {
+			return 0, fmt.Errorf("failed to get follow status: %w", err)
+		}
+	}
+	if !p.CanView(viewerID, status, f) {
+		return 0, fmt.Errorf("%w: %s of %s", ErrForbidden, f, name)
+	}
+	return p.ID, nil
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"tunes-service/data"
+)
+
+type profileDBMock struct {
+	getProfile      func(string) (data.Profile, error)
+	getSettings     func(uint64) (data.Settings, error)
+	updateSettings  func(uint64, data.Settings) error
+	getFollowStatus func(uint64, uint64) (data.FollowStatus, error)
+}
+
+func (db *profileDBMock) GetProfile(name string) (data.Profile, error) {
+	return db.getProfile(name)
+}
+
+func (db *profileDBMock) GetSettings(userID uint64) (data.Settings, error) {
+	return db.getSettings(userID)
+}
+
+func (db *profileDBMock) UpdateSettings(userID uint64, s data.Settings) error {
+	return db.updateSettings(userID, s)
+}
+
+func (db *profileDBMock) GetFollowStatus(followerID, followeeID uint64) (data.FollowStatus, error) {
+	return db.getFollowStatus(followerID, followeeID)
+}
+
+func TestHandleUpdateSettings(t *testing.T) {
+	valid := data.Settings{TimeZone: "UTC", Privacy: data.PrivatePrivacy}
+	with := func(update func(*data.Settings)) data.Settings {
+		s := valid
+		update(&s)
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		settings data.Settings
+		err      error
+	}{
+		{"Should update time zone", with(func(s *data.Settings) { s.TimeZone = "Europe/Berlin" }), nil},
+		{"Should accept UTC", valid, nil},
+		{"Unknown time zone", with(func(s *data.Settings) { s.TimeZone = "Mars/Olympus_Mons" }), ErrBadRequest},
+		{"Empty time zone", with(func(s *data.Settings) { s.TimeZone = "" }), ErrBadRequest},
+		{"Server time zone", with(func(s *data.Settings) { s.TimeZone = "Local" }), ErrBadRequest},
+		{"Should update profile", with(func(s *data.Settings) {
+			s.DisplayName = "Olivia"
+			s.Bio = "Listening to everything"
+			s.AvatarURL = "https://example.com/olivia.png"
+			s.Country = "US"
+			s.Privacy = data.PublicPrivacy
+			s.HideHistory = true
+		}), nil},
+		{"Unknown privacy", with(func(s *data.Settings) { s.Privacy = "friends" }), ErrBadRequest},
+		{"Long display name", with(func(s *data.Settings) { s.DisplayName = strings.Repeat("é", MAX_DISPLAY_NAME_LENGTH+1) }), ErrBadRequest},
+		{"Long bio", with(func(s *data.Settings) { s.Bio = strings.Repeat("é", MAX_BIO_LENGTH+1) }), ErrBadRequest},
+		{"Lowercase country", with(func(s *data.Settings) { s.Country = "us" }), ErrBadRequest},
+		{"Country name", with(func(s *data.Settings) { s.Country = "USA" }), ErrBadRequest},
+		{"Avatar without scheme", with(func(s *data.Settings) { s.AvatarURL = "example.com/olivia.png" }), ErrBadRequest},
+		{"Avatar with other scheme", with(func(s *data.Settings) { s.AvatarURL = "javascript:alert(1)" }), ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &profileDBMock{
+				updateSettings: func(userID uint64, s data.Settings) error {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if s != tt.settings {
+						t.Fatalf("expected %+v but got %+v", tt.settings, s)
+					}
+					return nil
+				},
+			}
+
+			if err := HandleUpdateSettings(1, tt.settings, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleAccess(t *testing.T) {
+	db := &profileDBMock{
+		getProfile: func(name string) (data.Profile, error) {
+			switch name {
+			case "olivia":
+				return data.Profile{ID: 1, Name: name, Settings: data.Settings{Privacy: data.PublicPrivacy, HideHistory: true}}, nil
+			case "billie":
+				return data.Profile{ID: 2, Name: name, Settings: data.Settings{Privacy: data.PrivatePrivacy}}, nil
+			case "taylor":
+				return data.Profile{ID: 3, Name: name, Settings: data.Settings{Privacy: data.FollowersPrivacy}}, nil
+			default:
+				return data.Profile{}, data.ErrNotFound
+			}
+		},
+		getFollowStatus: func(followerID, followeeID uint64) (data.FollowStatus, error) {
+			if followerID == 1 {
+				return data.Following, nil
+			}
+			return data.PendingFollow, nil
+		},
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		viewerID uint64
+		feature  data.Feature
+		expected uint64
+		err      error
+	}{
+		{"Should allow public stats", "olivia", 0, data.StatsFeature, 1, nil},
+		{"Hidden history", "olivia", 2, data.HistoryFeature, 0, ErrForbidden},
+		{"Private profile", "billie", 1, data.StatsFeature, 0, ErrForbidden},
+		{"Should allow owner", "billie", 2, data.HistoryFeature, 2, nil},
+		{"Should allow followers", "taylor", 1, data.StatsFeature, 3, nil},
+		{"Pending follower", "taylor", 2, data.StatsFeature, 0, ErrForbidden},
+		{"Anonymous viewer", "taylor", 0, data.StatsFeature, 0, ErrForbidden},
+		{"Unknown user", "selena", 1, data.StatsFeature, 0, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, err := HandleAccess(tt.user, tt.viewerID, tt.feature, db)
+			if !errors.Is(err, tt.err) || userID != tt.expected {
+				t.Fatalf("expected %d and error %v but got %d and %v", tt.expected, tt.err, userID, err)
+			}
+		})
+	}
+}
+
+func TestHandlePublicProfile(t *testing.T) {
+	db := &profileDBMock{
+		getProfile: func(name string) (data.Profile, error) {
+			return data.Profile{ID: 2, Name: name, Settings: data.Settings{
+				DisplayName: "Billie",
+				Country:     "US",
+				Privacy:     data.PrivatePrivacy,
+				HideHistory: true,
+			}}, nil
+		},
+	}
+
+	p, err := HandlePublicProfile("billie", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DisplayName != "Billie" || p.Country != "US" || strings.Contains(string(j), "Privacy") || strings.Contains(string(j), "Hide") {
+		t.Fatalf("expected only the public profile but got %s", j)
+	}
+}
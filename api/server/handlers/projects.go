@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	c "tunes-service/cache"
+	d "tunes-service/data"
+)
+
+// HandleSetEdition marks a project as an edition of another. An empty edition
+// and a base of 0 turn it back into an original.
+func HandleSetEdition(key uint64, edition d.Edition, baseKey uint64, db d.TunesDB, cache c.Cache) error {
+	if (edition == "") != (baseKey == 0) {
+		return fmt.Errorf("%w: an edition needs both a kind and a base project", ErrBadRequest)
+	}
+	if edition != "" && !edition.IsValid() {
+		return fmt.Errorf("%w: unknown edition %q", ErrBadRequest, edition)
+	}
+	if baseKey == key {
+		return fmt.Errorf("%w: a project can not be an edition of itself", ErrBadRequest)
+	}
+
+	err := db.SetProjectEdition(key, edition, baseKey)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to set edition: %w", err)
+	}
+
+	p, _ := db.GetProject(key)
+	forgetProject(key, p.MBID, cache)
+	return nil
+}
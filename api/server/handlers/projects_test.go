@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"tunes-service/data"
+)
+
+func TestHandleSetEdition(t *testing.T) {
+	tests := []struct {
+		name    string
+		edition data.Edition
+		base    uint64
+		err     error
+	}{
+		{"Should link deluxe edition", data.Deluxe, 2, nil},
+		{"Should unlink edition", "", 0, nil},
+		{"Unknown edition", "bootleg", 2, ErrBadRequest},
+		{"Edition without base", data.Remaster, 0, ErrBadRequest},
+		{"Edition of itself", data.Reissue, 1, ErrBadRequest},
+		{"Unknown base", data.Reissue, 9, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &dbMock{}
+			db.setEdition = func(key uint64, edition data.Edition, base uint64) error {
+				if base == 9 {
+					return fmt.Errorf("%w: project %d", data.ErrNotFound, base)
+				}
+				return nil
+			}
+			db.getProject = func(key uint64) (data.Project, error) {
+				return data.Project{ID: key, MBID: "m"}, nil
+			}
+			deleted := map[string]bool{}
+			cache := &cacheMock{nil, nil, func(key string) {
+				deleted[key] = true
+			}}
+
+			err := HandleSetEdition(1, tt.edition, tt.base, db, cache)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v but got %v", tt.err, err)
+			}
+			if (deleted["p-1"] && deleted["pm-m"]) != (tt.err == nil) {
+				t.Fatalf("expected the project to be evicted only on success")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	d "tunes-service/data"
+)
+
+const MAX_NOTE_LENGTH = 5000
+
+// HandleRating returns what a user thinks of an artist, track or project,
+// which is nothing yet unless they rated it, made it a favorite or wrote
+// notes on it.
+func HandleRating(userID uint64, entity d.EntityType, id uint64, db d.RatingDB) (d.Rating, error) {
+	if !entity.IsValid() {
+		return d.Rating{}, fmt.Errorf("%w: unknown rating entity %q", ErrBadRequest, entity)
+	}
+
+	r, err := db.GetRating(userID, entity, id)
+	if errors.Is(err, d.ErrNotFound) {
+		return d.Rating{Entity: entity, ID: id}, nil
+	} else if err != nil {
+		return d.Rating{}, fmt.Errorf("failed to get rating: %w", err)
+	}
+	return r, nil
+}
+
+// HandleRate rates an artist, track or project from half a star to
+// d.MAX_STARS in half stars. Rating it 0 takes the rating back.
+func HandleRate(userID uint64, entity d.EntityType, id uint64, stars float64, db d.RatingDB) error {
+	if !entity.IsValid() {
+		return fmt.Errorf("%w: unknown rating entity %q", ErrBadRequest, entity)
+	}
+	halfStars := stars * 2
+	if halfStars != math.Trunc(halfStars) || stars < 0 || stars > d.MAX_STARS {
+		return fmt.Errorf("%w: ratings are 0.5 to %d stars in half stars", ErrBadRequest, d.MAX_STARS)
+	}
+
+	err := db.SetRating(userID, entity, id, int(halfStars))
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to rate %s: %w", entity, err)
+	}
+	return nil
+}
+
+func HandleFavorite(userID uint64, entity d.EntityType, id uint64, favorite bool, db d.RatingDB) error {
+	if !entity.IsValid() {
+		return fmt.Errorf("%w: unknown rating entity %q", ErrBadRequest, entity)
+	}
+
+	err := db.SetFavorite(userID, entity, id, favorite)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to update favorite: %w", err)
+	}
+	return nil
+}
+
+// HandleNote saves a user's notes on a project. Blank notes delete them.
+func HandleNote(userID uint64, entity d.EntityType, id uint64, note string, db d.RatingDB) error {
+	if entity != d.ProjectEntity {
+		return fmt.Errorf("%w: only projects have notes", ErrBadRequest)
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > MAX_NOTE_LENGTH {
+		return fmt.Errorf("%w: notes longer than %d characters", ErrBadRequest, MAX_NOTE_LENGTH)
+	}
+
+	err := db.SetNote(userID, id, note)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to save note: %w", err)
+	}
+	return nil
+}
+
+// HandleRatings returns the top rated artists, tracks or projects of a user
+// with how much they played each. Unrated favorites and notes count as 0
+// stars, so they are listed unless there is a minimum rating.
+func HandleRatings(userID uint64, entity d.EntityType, filter d.RatingFilter, db d.RatingDB) ([]d.Rating, error) {
+	if !entity.IsValid() {
+		return nil, fmt.Errorf("%w: unknown rating entity %q", ErrBadRequest, entity)
+	}
+	if filter.MinStars < 0 || filter.MinStars > d.MAX_STARS {
+		return nil, fmt.Errorf("%w: minimum rating is 0 to %d stars", ErrBadRequest, d.MAX_STARS)
+	}
+	filter.Limit = clampPageLimit(filter.Limit)
+
+	ratings, err := db.GetRatings(userID, entity, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ratings: %w", err)
+	}
+	return ratings, nil
+}
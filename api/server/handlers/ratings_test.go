@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"tunes-service/data"
+)
+
+type ratingDBMock struct {
+	getRating   func(uint64, data.EntityType, uint64) (data.Rating, error)
+	getRatings  func(uint64, data.EntityType, data.RatingFilter) ([]data.Rating, error)
+	setRating   func(uint64, data.EntityType, uint64, int) error
+	setFavorite func(uint64, data.EntityType, uint64, bool) error
+	setNote     func(uint64, uint64, string) error
+}
+
+func (db *ratingDBMock) GetRating(userID uint64, entity data.EntityType, id uint64) (data.Rating, error) {
+	return db.getRating(userID, entity, id)
+}
+
+func (db *ratingDBMock) GetRatings(userID uint64, entity data.EntityType, filter data.RatingFilter) ([]data.Rating, error) {
+	return db.getRatings(userID, entity, filter)
+}
+
+func (db *ratingDBMock) SetRating(userID uint64, entity data.EntityType, id uint64, halfStars int) error {
+	return db.setRating(userID, entity, id, halfStars)
+}
+
+func (db *ratingDBMock) SetFavorite(userID uint64, entity data.EntityType, id uint64, favorite bool) error {
+	return db.setFavorite(userID, entity, id, favorite)
+}
+
+func (db *ratingDBMock) SetNote(userID uint64, projectID uint64, note string) error {
+	return db.setNote(userID, projectID, note)
+}
+
+func TestHandleRating(t *testing.T) {
+	db := &ratingDBMock{
+		getRating: func(userID uint64, entity data.EntityType, id uint64) (data.Rating, error) {
+			if id != 1 {
+				return data.Rating{}, data.ErrNotFound
+			}
+			return data.Rating{Entity: entity, ID: id, Stars: 4.5}, nil
+		},
+	}
+
+	if r, err := HandleRating(1, data.TrackEntity, 1, db); err != nil || r.Stars != 4.5 {
+		t.Fatalf("expected the rating but got %+v: %v", r, err)
+	}
+	if r, err := HandleRating(1, data.TrackEntity, 2, db); err != nil || r.ID != 2 || r.Stars != 0 {
+		t.Fatalf("expected an empty rating but got %+v: %v", r, err)
+	}
+	if _, err := HandleRating(1, "genre", 1, db); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected error %v but got %v", ErrBadRequest, err)
+	}
+}
+
+func TestHandleRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		entity    data.EntityType
+		stars     float64
+		halfStars int
+		err       error
+	}{
+		{"Should rate in half stars", data.ProjectEntity, 3.5, 7, nil},
+		{"Should rate full marks", data.ArtistEntity, data.MAX_STARS, 10, nil},
+		{"Should take rating back", data.TrackEntity, 0, 0, nil},
+		{"Quarter stars", data.TrackEntity, 3.25, 0, ErrBadRequest},
+		{"Negative stars", data.TrackEntity, -0.5, 0, ErrBadRequest},
+		{"Too many stars", data.TrackEntity, 5.5, 0, ErrBadRequest},
+		{"Unknown entity", "genre", 3, 0, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &ratingDBMock{
+				setRating: func(userID uint64, entity data.EntityType, id uint64, halfStars int) error {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if halfStars != tt.halfStars {
+						t.Fatalf("expected %d half stars but got %d", tt.halfStars, halfStars)
+					}
+					return nil
+				},
+			}
+
+			if err := HandleRate(1, tt.entity, 1, tt.stars, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		entity   data.EntityType
+		note     string
+		expected string
+		err      error
+	}{
+		{"Should save note", data.ProjectEntity, "  the bridge on logical\n", "the bridge on logical", nil},
+		{"Should delete blank note", data.ProjectEntity, " ", "", nil},
+		{"Long note", data.ProjectEntity, strings.Repeat("é", MAX_NOTE_LENGTH+1), "", ErrBadRequest},
+		{"Note on track", data.TrackEntity, "catchy", "", ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &ratingDBMock{
+				setNote: func(userID, projectID uint64, note string) error {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if note != tt.expected {
+						t.Fatalf("expected note %q but got %q", tt.expected, note)
+					}
+					return nil
+				},
+			}
+
+			if err := HandleNote(1, tt.entity, 1, tt.note, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleRatings(t *testing.T) {
+	db := &ratingDBMock{
+		getRatings: func(userID uint64, entity data.EntityType, filter data.RatingFilter) ([]data.Rating, error) {
+			if filter.Limit != DEFAULT_PAGE_LIMIT {
+				t.Fatalf("expected limit %d but got %d", DEFAULT_PAGE_LIMIT, filter.Limit)
+			}
+			return []data.Rating{{Entity: entity, ID: 1, Stars: 5}}, nil
+		},
+	}
+
+	if ratings, err := HandleRatings(1, data.ProjectEntity, data.RatingFilter{MinStars: 4}, db); err != nil || len(ratings) != 1 {
+		t.Fatalf("expected the top rated project but got %+v: %v", ratings, err)
+	}
+	if _, err := HandleRatings(1, data.ProjectEntity, data.RatingFilter{MinStars: 6}, db); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected error %v but got %v", ErrBadRequest, err)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	d "tunes-service/data"
+)
+
+// RECOMMENDATION_SEED_DAYS is how far back the listening recommendations
+// start from reaches.
+const RECOMMENDATION_SEED_DAYS = 90
+
+// HandleRecommendations returns artists or tracks a user might like, based on
+// what they listened to lately and what others play in the same sessions.
+func HandleRecommendations(userID uint64, entity d.EntityType, limit int, now time.Time, db d.RecommendationDB) ([]d.Recommendation, error) {
+	if entity != d.ArtistEntity && entity != d.TrackEntity {
+		return nil, fmt.Errorf("%w: no recommendations of %q", ErrBadRequest, entity)
+	}
+
+	since := chartDay(now).AddDate(0, 0, -RECOMMENDATION_SEED_DAYS)
+	recommendations, err := db.GetRecommendations(userID, entity, since, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	return recommendations, nil
+}
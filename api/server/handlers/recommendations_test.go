@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type recommendationDBMock struct {
+	rebuildNeighbors    func(data.EntityType, time.Time, time.Duration) (int64, error)
+	getNeighborsBuiltAt func(data.EntityType) (time.Time, error)
+	getRecommendations  func(uint64, data.EntityType, time.Time, int) ([]data.Recommendation, error)
+}
+
+func (db *recommendationDBMock) RebuildNeighbors(entity data.EntityType, since time.Time, gap time.Duration) (int64, error) {
+	return db.rebuildNeighbors(entity, since, gap)
+}
+
+func (db *recommendationDBMock) GetNeighborsBuiltAt(entity data.EntityType) (time.Time, error) {
+	return db.getNeighborsBuiltAt(entity)
+}
+
+func (db *recommendationDBMock) GetRecommendations(userID uint64, entity data.EntityType, since time.Time, limit int) ([]data.Recommendation, error) {
+	return db.getRecommendations(userID, entity, since, limit)
+}
+
+func TestHandleRecommendations(t *testing.T) {
+	now := time.Date(2024, 3, 31, 18, 0, 0, 0, time.UTC)
+	db := &recommendationDBMock{
+		getRecommendations: func(userID uint64, entity data.EntityType, since time.Time, limit int) ([]data.Recommendation, error) {
+			if !since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("expected the last %d days but got %v", RECOMMENDATION_SEED_DAYS, since)
+			}
+			if limit != DEFAULT_CHART_LIMIT {
+				t.Fatalf("expected limit %d but got %d", DEFAULT_CHART_LIMIT, limit)
+			}
+			return []data.Recommendation{{Entity: entity, ID: 2}}, nil
+		},
+	}
+
+	tests := []struct {
+		name   string
+		entity data.EntityType
+		err    error
+	}{
+		{"Should recommend artists", data.ArtistEntity, nil},
+		{"Should recommend tracks", data.TrackEntity, nil},
+		{"Projects", data.ProjectEntity, ErrBadRequest},
+		{"Unknown entity", "genre", ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := HandleRecommendations(1, tt.entity, 0, now, db); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	d "tunes-service/data"
+)
+
+const (
+	DEFAULT_SEARCH_LIMIT = 10
+	MAX_SEARCH_LIMIT     = 50
+	TYPEAHEAD_LIMIT      = 5
+	MAX_QUERY_LENGTH     = 100
+)
+
+// HandleSearch searches the catalog for an anonymous visitor when userID is
+// 0. Typeahead searches run on every keystroke, so they only match word
+// prefixes and return a few results per kind.
+func HandleSearch(query string, userID uint64, limit int, typeahead bool, db d.SearchDB) (d.SearchResults, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return d.SearchResults{}, fmt.Errorf("%w: empty query", ErrBadRequest)
+	}
+	if utf8.RuneCountInString(query) > MAX_QUERY_LENGTH {
+		return d.SearchResults{}, fmt.Errorf("%w: query is longer than %d characters", ErrBadRequest, MAX_QUERY_LENGTH)
+	}
+
+	switch {
+	case typeahead:
+		limit = TYPEAHEAD_LIMIT
+	case limit <= 0:
+		limit = DEFAULT_SEARCH_LIMIT
+	default:
+		limit = min(limit, MAX_SEARCH_LIMIT)
+	}
+
+	r, err := db.Search(query, userID, limit, !typeahead)
+	if err != nil {
+		return d.SearchResults{}, fmt.Errorf("failed to search: %w", err)
+	}
+	return r, nil
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"tunes-service/data"
+)
+
+type searchDBMock struct {
+	search func(string, uint64, int, bool) (data.SearchResults, error)
+}
+
+func (db *searchDBMock) Search(query string, userID uint64, limit int, fuzzy bool) (data.SearchResults, error) {
+	return db.search(query, userID, limit, fuzzy)
+}
+
+func TestHandleSearch(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		limit     int
+		typeahead bool
+		expected  int
+		fuzzy     bool
+		err       error
+	}{
+		{"Should search with default limit", " olivia gut ", 0, false, DEFAULT_SEARCH_LIMIT, true, nil},
+		{"Should cap limit", "olivia", 500, false, MAX_SEARCH_LIMIT, true, nil},
+		{"Should only match prefixes while typing", "oli", 30, true, TYPEAHEAD_LIMIT, false, nil},
+		{"Empty query", "  ", 0, false, 0, false, ErrBadRequest},
+		{"Long query", strings.Repeat("a", MAX_QUERY_LENGTH+1), 0, false, 0, false, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &searchDBMock{func(query string, userID uint64, limit int, fuzzy bool) (data.SearchResults, error) {
+				if tt.err != nil {
+					t.Fatalf("should not call this function")
+				}
+				if query != strings.TrimSpace(tt.query) || userID != 7 || limit != tt.expected || fuzzy != tt.fuzzy {
+					t.Fatalf("expected %q limited to %d (fuzzy %t) but got %q limited to %d (fuzzy %t)", tt.query, tt.expected, tt.fuzzy, query, limit, fuzzy)
+				}
+				return data.SearchResults{Projects: []data.SearchHit{{Entity: data.ProjectEntity, ID: 1, Title: "GUTS"}}}, nil
+			}}
+
+			r, err := HandleSearch(tt.query, 7, tt.limit, tt.typeahead, db)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v but got %v", tt.err, err)
+			}
+			if tt.err == nil && len(r.Projects) != 1 {
+				t.Fatalf("expected one project but got %+v", r)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	d "tunes-service/data"
+)
+
+const (
+	MAX_DISPLAY_NAME_LENGTH = 50
+	MAX_BIO_LENGTH          = 500
+)
+
+func HandleGetSettings(userID uint64, db d.ProfileDB) (d.Settings, error) {
+	s, err := db.GetSettings(userID)
+	if err != nil {
+		return d.Settings{}, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return s, nil
+}
+
+// HandleUpdateSettings saves a user's settings. The time zone has to be an
+// IANA name such as "Europe/Berlin" or "UTC", the country a two letter code
+// such as "DE" and the avatar an http or https URL.
+func HandleUpdateSettings(userID uint64, s d.Settings, db d.ProfileDB) error {
+	if _, err := time.LoadLocation(s.TimeZone); err != nil || s.TimeZone == "" || s.TimeZone == "Local" {
+		return fmt.Errorf("%w: unknown time zone %q", ErrBadRequest, s.TimeZone)
+	}
+	if !s.Privacy.IsValid() {
+		return fmt.Errorf("%w: unknown privacy %q", ErrBadRequest, s.Privacy)
+	}
+	if utf8.RuneCountInString(s.DisplayName) > MAX_DISPLAY_NAME_LENGTH {
+		return fmt.Errorf("%w: display name longer than %d characters", ErrBadRequest, MAX_DISPLAY_NAME_LENGTH)
+	}
+	if utf8.RuneCountInString(s.Bio) > MAX_BIO_LENGTH {
+		return fmt.Errorf("%w: bio longer than %d characters", ErrBadRequest, MAX_BIO_LENGTH)
+	}
+	if !isCountryCode(s.Country) {
+		return fmt.Errorf("%w: invalid country %q", ErrBadRequest, s.Country)
+	}
+	if s.AvatarURL != "" {
+		u, err := url.Parse(s.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: invalid avatar URL %q", ErrBadRequest, s.AvatarURL)
+		}
+	}
+
+	if err := db.UpdateSettings(userID, s); err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+	return nil
+}
+
+// isCountryCode reports whether a country is empty or looks like an ISO 3166-1
+// alpha-2 code.
+func isCountryCode(country string) bool {
+	if country == "" {
+		return true
+	}
+	if len(country) != 2 {
+		return false
+	}
+	for _, r := range country {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
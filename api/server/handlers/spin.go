@@ -2,62 +2,375 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"tunes-service/art"
 	c "tunes-service/cache"
 	d "tunes-service/data"
+
+	"github.com/google/uuid"
 )
 
 type SpinRequest struct {
-	UserID             uint
-	Time               time.Time
-	TrackTitle         string
-	TrackArtistNames   []string
-	ProjectTitle       string
-	ProjectArtistNames []string
-	ProjectType        string
-	ProjectRelese      time.Time
-}
-
-func HandleSpin(req SpinRequest, db d.TunesDB, cache c.Cache) d.Spin {
-	trackHash := d.CreateHash(req.TrackTitle, req.TrackArtistNames)
-	t := getTrack(trackHash, db, cache)
+	UserID                  uint
+	Time                    time.Time
+	TrackTitle              string
+	TrackArtistNames        []string
+	ProjectTitle            string
+	ProjectArtistNames      []string
+	ProjectType             string
+	ProjectRelese           time.Time
+	TrackMBID               string
+	TrackArtistMBIDs        []string
+	ProjectMBID             string
+	ProjectArtistMBIDs      []string
+	TrackDurationMs         uint
+	MsPlayed                uint
+	ProjectArtURL           string
+	ProjectReleasePrecision string
+}
+
+func HandleSpin(req SpinRequest, db d.TunesDB, cache c.Cache, policy d.PrimaryPolicy, achievements d.Achievements) (d.Spin, error) {
+	sc, err := resolveSpin(req, db, cache)
+	if err != nil {
+		return d.Spin{}, err
+	}
+
+	s, err := db.CreateSpin(req.Time, uint64(req.UserID), sc.track.ID, sc.project.ID, uint64(req.MsPlayed))
+	if err != nil {
+		return d.Spin{}, fmt.Errorf("failed to create spin: %w", err)
+	}
+	recordMilestones(s, db, achievements)
+	touchListening(uint64(s.UserID), cache)
+
+	applyPrimaryPolicy(sc, db, cache, policy)
+	return s, nil
+}
+
+// HandleEditSpin moves a user's spin to the track and project a request names,
+// which are resolved like the ones of a new spin. The spin keeps its time and
+// how long it was played. When the policy counts spins, the track it was moved
+// from may get another primary project too.
+func HandleEditSpin(userID, spinID uint64, req SpinRequest, db d.TunesDB, sdb d.SpinDB, cache c.Cache, policy d.PrimaryPolicy) (d.Spin, error) {
+	sc, err := resolveSpin(req, db, cache)
+	if err != nil {
+		return d.Spin{}, err
+	}
+
+	s, previousTrackID, err := sdb.UpdateSpin(userID, spinID, sc.track.ID, sc.project.ID)
+	if errors.Is(err, d.ErrNotFound) {
+		return d.Spin{}, fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return d.Spin{}, fmt.Errorf("failed to update spin: %w", err)
+	}
+	touchListening(userID, cache)
+
+	applyPrimaryPolicy(sc, db, cache, policy)
+	if policy.CountsSpins() && previousTrackID != sc.track.ID {
+		if t := getTrack(previousTrackID, db, cache); !t.IsEmpty() && updatePrimaryProject(t, db, policy) {
+			forgetTrack(previousTrackID, t.MBID, cache)
+		}
+	}
+	return s, nil
+}
+
+func HandleDeleteSpin(userID, spinID uint64, db d.SpinDB, cache c.Cache) error {
+	err := db.DeleteSpin(userID, spinID)
+	if errors.Is(err, d.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete spin: %w", err)
+	}
+	touchListening(userID, cache)
+	return nil
+}
+
+// HandleDeleteSpins deletes a user's spins from one time up to another and
+// returns how many there were. Both ends are required so that a missing
+// parameter cannot delete everything.
+func HandleDeleteSpins(userID uint64, from, to time.Time, db d.SpinDB, cache c.Cache) (int64, error) {
+	if from.IsZero() || to.IsZero() || !from.Before(to) {
+		return 0, fmt.Errorf("%w: deleting spins needs a range from one time up to a later one", ErrBadRequest)
+	}
+
+	n, err := db.DeleteSpins(userID, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete spins: %w", err)
+	}
+	if n > 0 {
+		touchListening(userID, cache)
+	}
+	return n, nil
+}
+
+// spinCatalog is the track and project a spin request resolved to, with the
+// key the track is cached under and whether the spin linked them.
+type spinCatalog struct {
+	track     d.Track
+	trackKey  uint64
+	project   d.Project
+	isNewLink bool
+}
+
+// resolveSpin finds the track and project a spin request names, creating
+// them and linking the track to the project when they are new.
+func resolveSpin(req SpinRequest, db d.TunesDB, cache c.Cache) (spinCatalog, error) {
+	form, err := d.ParseProjectType(req.ProjectType)
+	if err != nil {
+		return spinCatalog{}, fmt.Errorf("%w: %s", ErrBadRequest, err)
+	}
+	precision := d.DatePrecision(req.ProjectReleasePrecision)
+	if precision == "" {
+		precision = d.DayPrecision
+	} else if !precision.IsValid() {
+		return spinCatalog{}, fmt.Errorf("%w: unknown release precision %q", ErrBadRequest, precision)
+	}
+	if err := validateMBIDs(req); err != nil {
+		return spinCatalog{}, err
+	}
+
+	// Artists are resolved before hashing so that aliased names produce the
+	// same track key as their canonical artist.
+	trackArtists := getArtists(req.TrackArtistNames, req.TrackArtistMBIDs, db, cache)
+	t, trackKey := resolveTrack(d.CreateHash(req.TrackTitle, artistNames(trackArtists)), req.TrackMBID, db, cache)
 	if t.IsEmpty() {
-		artistIDs := []uint64{}
-		for _, artistName := range req.TrackArtistNames {
-			a := getArtist(artistName, db, cache)
-			if a.IsEmpty() {
-				a, _ = db.CreateArtist(artistName)
-			}
-			artistIDs = append(artistIDs, a.ID)
+		t, _ = db.CreateTrack(trackKey, req.TrackTitle, createArtists(trackArtists, db))
+		if req.TrackMBID != "" {
+			db.SetTrackMBID(t.ID, req.TrackMBID)
 		}
-		t, _ = db.CreateTrack(trackHash, req.TrackTitle, artistIDs)
+	}
+	if t.Duration == 0 && req.TrackDurationMs > 0 {
+		db.SetTrackDuration(t.ID, time.Duration(req.TrackDurationMs)*time.Millisecond)
 	}
 
-	projectHash := d.CreateHash(req.ProjectTitle, req.ProjectArtistNames)
-	p := getProject(projectHash, db, cache)
+	projectArtists := getArtists(req.ProjectArtistNames, req.ProjectArtistMBIDs, db, cache)
+	p, projectKey := resolveProject(d.CreateHash(req.ProjectTitle, artistNames(projectArtists)), req.ProjectMBID, db, cache)
 	if p.IsEmpty() {
-		artistIDs := []uint64{}
-		for _, artistName := range req.TrackArtistNames {
-			a := getArtist(artistName, db, cache)
-			if a.IsEmpty() {
-				a, _ = db.CreateArtist(artistName)
-			}
-			artistIDs = append(artistIDs, a.ID)
+		p, _ = db.CreateProject(projectKey, req.ProjectTitle, createArtists(projectArtists, db), form, precision.Truncate(req.ProjectRelese), precision)
+		if req.ProjectMBID != "" {
+			db.SetProjectMBID(p.ID, req.ProjectMBID)
+		}
+		linkEdition(p, artistNames(projectArtists), db, cache)
+	}
+
+	if isArtURL(req.ProjectArtURL) {
+		db.SetProjectArtURL(p.ID, req.ProjectArtURL)
+	}
+
+	isNewLink := !slices.Contains(t.ProjectIDs, p.ID)
+	if isNewLink {
+		db.UpdateTrack(t.ID, p.ID, false)
+	}
+	return spinCatalog{t, trackKey, p, isNewLink}, nil
+}
+
+// validateMBIDs checks that the MusicBrainz identifiers of a spin request are
+// UUIDs, the only ones the catalog stores.
+func validateMBIDs(req SpinRequest) error {
+	mbids := append([]string{req.TrackMBID, req.ProjectMBID}, req.TrackArtistMBIDs...)
+	for _, mbid := range append(mbids, req.ProjectArtistMBIDs...) {
+		if mbid == "" {
+			continue
 		}
-		p, _ = db.CreateProject(projectHash, req.ProjectTitle, artistIDs, d.ProjectType(req.ProjectType), req.ProjectRelese)
+		if _, err := uuid.Parse(mbid); err != nil {
+			return fmt.Errorf("%w: MusicBrainz identifier %q is not a UUID", ErrBadRequest, mbid)
+		}
+	}
+	return nil
+}
+
+// applyPrimaryPolicy chooses the primary project of a spin's track again
+// once the spin is stored, when the track is on a new project or has no
+// primary project yet. Spins from another project can change the ranking of
+// policies that count spins, so for those only spins from the primary project
+// skip the policy.
+func applyPrimaryPolicy(sc spinCatalog, db d.TunesDB, cache c.Cache, policy d.PrimaryPolicy) {
+	unranked := sc.project.ID != 0 && sc.track.PrimaryProjectID == 0
+	recount := policy.CountsSpins() && sc.project.ID != sc.track.PrimaryProjectID
+	if sc.isNewLink || unranked || recount {
+		if updatePrimaryProject(sc.track, db, policy) || sc.isNewLink {
+			forgetTrack(sc.trackKey, sc.track.MBID, cache)
+		}
+	}
+}
+
+// linkEdition links a new deluxe edition, reissue or remaster to its original
+// when the original is already in the catalog.
+func linkEdition(p d.Project, artistNames []string, db d.TunesDB, cache c.Cache) {
+	title, edition := d.ParseEdition(p.Title)
+	if edition == "" {
+		return
+	}
+	if base := getProject(d.CreateHash(title, artistNames), db, cache); !base.IsEmpty() && base.ID != p.ID {
+		db.SetProjectEdition(p.ID, edition, base.ID)
 	}
+}
 
-	if !slices.Contains(t.ProjectIDs, projectHash) {
-		primaryProject, _ := db.GetProject(t.PrimaryProjectID)
+// recordMilestones evaluates the achievements a spin reached against the
+// user's counters right after it.
+func recordMilestones(s d.Spin, db d.MilestoneDB, achievements d.Achievements) {
+	if len(achievements) == 0 {
+		return
+	}
+	progress, err := db.UpdateMilestoneProgress(s)
+	if err != nil {
+		return
+	}
+	if milestones := achievements.Reached(s, progress); len(milestones) > 0 {
+		db.AddMilestones(milestones)
+	}
+}
 
-		db.UpdateTrack(trackHash, projectHash, primaryProject.IsLessPrimaryThan(&p))
+// updatePrimaryProject applies the policy to the projects of a track unless
+// its primary project was pinned, and reports whether the primary changed.
+func updatePrimaryProject(t d.Track, db d.PrimaryDB, policy d.PrimaryPolicy) bool {
+	projects, err := db.GetTrackProjects(t.ID)
+	if err != nil {
+		return false
 	}
+	ctx, err := db.GetPrimaryContext(t.ID, 0)
+	if err != nil || ctx.Pinned != 0 {
+		return false
+	}
+
+	p := policy.Choose(projects, ctx)
+	if p.ID == 0 || p.ID == t.PrimaryProjectID {
+		return false
+	}
+	return db.SetPrimaryProject(t.ID, p.ID, false) == nil
+}
 
-	s, _ := db.CreateSpin(req.Time, uint64(req.UserID), trackHash)
-	return s
+// isArtURL accepts absolute http and https URLs, the only ones the artwork
+// fetcher downloads, unless they name a host that is not on the public
+// internet. The fetcher checks the addresses hosts resolve to again.
+func isArtURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || !art.AllowedURL(u, nil) {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if a, err := netip.ParseAddr(host); err == nil {
+		return art.IsPublicAddr(a)
+	}
+	return host != "localhost" && !strings.HasSuffix(host, ".localhost")
+}
+
+// getArtists resolves each name to its canonical artist, leaving unknown
+// artists with only a name and MusicBrainz identifier.
+func getArtists(names []string, mbids []string, db d.TunesDB, cache c.Cache) []d.Artist {
+	artists := []d.Artist{}
+	for i, name := range names {
+		mbid := ""
+		if i < len(mbids) {
+			mbid = mbids[i]
+		}
+		artists = append(artists, resolveArtist(name, mbid, db, cache))
+	}
+	return artists
+}
+
+// resolveArtist prefers a MusicBrainz identifier match over a name match. A
+// name match is only used when it does not belong to a different artist.
+func resolveArtist(name, mbid string, db d.TunesDB, cache c.Cache) d.Artist {
+	if mbid != "" {
+		if a := getArtistByMBID(mbid, db, cache); !a.IsEmpty() {
+			return a
+		}
+	}
+
+	a := getArtist(name, db, cache)
+	switch {
+	case a.IsEmpty():
+		return d.Artist{Name: name, MBID: mbid}
+	case mbid == "" || a.MBID == mbid:
+		return a
+	case a.MBID == "":
+		db.SetArtistMBID(a.ID, mbid)
+		cache.Delete("a-" + name)
+		a.MBID = mbid
+		return a
+	default:
+		return d.Artist{Name: name, MBID: mbid}
+	}
+}
+
+// resolveTrack returns the existing track for a spin, if any, and the key a
+// new track should be created under. Tracks sharing a title and artist names
+// but not a recording are keyed by their MusicBrainz identifier instead.
+func resolveTrack(hash uint64, mbid string, db d.TunesDB, cache c.Cache) (d.Track, uint64) {
+	if mbid != "" {
+		if t := getTrackByMBID(mbid, db, cache); !t.IsEmpty() {
+			return t, t.ID
+		}
+	}
+
+	t := getTrack(hash, db, cache)
+	switch {
+	case t.IsEmpty() || mbid == "" || t.MBID == mbid:
+		return t, hash
+	case t.MBID == "":
+		db.SetTrackMBID(t.ID, mbid)
+		forgetTrack(hash, mbid, cache)
+		t.MBID = mbid
+		return t, hash
+	default:
+		key := d.CreateHash(mbid, nil)
+		return getTrack(key, db, cache), key
+	}
+}
+
+func resolveProject(hash uint64, mbid string, db d.TunesDB, cache c.Cache) (d.Project, uint64) {
+	if mbid != "" {
+		if p := getProjectByMBID(mbid, db, cache); !p.IsEmpty() {
+			return p, p.ID
+		}
+	}
+
+	p := getProject(hash, db, cache)
+	switch {
+	case p.IsEmpty() || mbid == "" || p.MBID == mbid:
+		return p, hash
+	case p.MBID == "":
+		db.SetProjectMBID(p.ID, mbid)
+		forgetProject(hash, mbid, cache)
+		p.MBID = mbid
+		return p, hash
+	default:
+		key := d.CreateHash(mbid, nil)
+		return getProject(key, db, cache), key
+	}
+}
+
+func createArtists(artists []d.Artist, db d.TunesDB) []uint64 {
+	artistIDs := []uint64{}
+	for i, a := range artists {
+		if a.ID == 0 {
+			created, _ := db.CreateArtist(a.Name)
+			if a.MBID != "" {
+				db.SetArtistMBID(created.ID, a.MBID)
+				created.MBID = a.MBID
+			}
+			a = created
+			artists[i] = a
+		}
+		artistIDs = append(artistIDs, a.ID)
+	}
+	return artistIDs
+}
+
+func artistNames(artists []d.Artist) []string {
+	names := []string{}
+	for _, a := range artists {
+		names = append(names, a.Name)
+	}
+	return names
 }
 
 func getArtist(key string, db d.TunesDB, cache c.Cache) (a d.Artist) {
@@ -104,3 +417,64 @@ func getProject(key uint64, db d.TunesDB, cache c.Cache) (p d.Project) {
 	}
 	return
 }
+
+// forgetTrack drops a track from the cache under both its key and its
+// MusicBrainz identifier, so that neither lookup reads it stale.
+func forgetTrack(key uint64, mbid string, cache c.Cache) {
+	cache.Delete("t-" + strconv.FormatUint(key, 10))
+	if mbid != "" {
+		cache.Delete("tm-" + mbid)
+	}
+}
+
+func forgetProject(key uint64, mbid string, cache c.Cache) {
+	cache.Delete("p-" + strconv.FormatUint(key, 10))
+	if mbid != "" {
+		cache.Delete("pm-" + mbid)
+	}
+}
+
+func getArtistByMBID(mbid string, db d.TunesDB, cache c.Cache) (a d.Artist) {
+	cachedJSON := cache.Get("am-" + mbid)
+	if cachedJSON != "" {
+		json.Unmarshal([]byte(cachedJSON), &a)
+		return
+	}
+
+	a, _ = db.GetArtistByMBID(mbid)
+	if !a.IsEmpty() {
+		j, _ := json.Marshal(a)
+		cache.Put("am-"+mbid, string(j))
+	}
+	return
+}
+
+func getTrackByMBID(mbid string, db d.TunesDB, cache c.Cache) (t d.Track) {
+	cachedJSON := cache.Get("tm-" + mbid)
+	if cachedJSON != "" {
+		json.Unmarshal([]byte(cachedJSON), &t)
+		return
+	}
+
+	t, _ = db.GetTrackByMBID(mbid)
+	if !t.IsEmpty() {
+		j, _ := json.Marshal(t)
+		cache.Put("tm-"+mbid, string(j))
+	}
+	return
+}
+
+func getProjectByMBID(mbid string, db d.TunesDB, cache c.Cache) (p d.Project) {
+	cachedJSON := cache.Get("pm-" + mbid)
+	if cachedJSON != "" {
+		json.Unmarshal([]byte(cachedJSON), &p)
+		return
+	}
+
+	p, _ = db.GetProjectByMBID(mbid)
+	if !p.IsEmpty() {
+		j, _ := json.Marshal(p)
+		cache.Put("pm-"+mbid, string(j))
+	}
+	return
+}
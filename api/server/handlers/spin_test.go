@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -9,8 +10,9 @@ import (
 )
 
 type cacheMock struct {
-	get func(string) string
-	put func(string, string)
+	get    func(string) string
+	put    func(string, string)
+	delete func(string)
 }
 
 func (c *cacheMock) Get(key string) string {
@@ -21,15 +23,37 @@ func (c *cacheMock) Put(key string, json string) {
 	c.put(key, json)
 }
 
+func (c *cacheMock) Delete(key string) {
+	c.delete(key)
+}
+
 type dbMock struct {
 	getArtist     func(string) (data.Artist, error)
 	createArtist  func(string) (data.Artist, error)
 	getTrack      func(uint64) (data.Track, error)
 	createTrack   func(uint64, string, []uint64) (data.Track, error)
 	getProject    func(uint64) (data.Project, error)
-	createProject func(uint64, string, []uint64, data.ProjectType, time.Time) (data.Project, error)
-	createSpin    func(time.Time, uint64, uint64) (data.Spin, error)
+	createProject func(uint64, string, []uint64, data.ProjectType, time.Time, data.DatePrecision) (data.Project, error)
+	createSpin    func(time.Time, uint64, uint64, uint64, uint64) (data.Spin, error)
 	updateTrack   func(uint64, uint64, bool) error
+	getArtistMBID func(string) (data.Artist, error)
+	getTrackMBID  func(string) (data.Track, error)
+	getProjMBID   func(string) (data.Project, error)
+	setArtistMBID func(uint64, string) error
+	setTrackMBID  func(uint64, string) error
+	setProjMBID   func(uint64, string) error
+	setDuration   func(uint64, time.Duration) error
+	setArtURL     func(uint64, string) error
+	getTrackProjs func(uint64) ([]data.Project, error)
+	getPrimaryCtx func(uint64, uint64) (data.PrimaryContext, error)
+	setPrimary    func(uint64, uint64, bool) error
+	unpinPrimary  func(uint64) error
+	setUserPrim   func(uint64, uint64, uint64) error
+	delUserPrim   func(uint64, uint64) error
+	setEdition    func(uint64, data.Edition, uint64) error
+	updProgress   func(data.Spin) (data.MilestoneProgress, error)
+	addMilestones func([]data.Milestone) ([]data.Milestone, error)
+	getMilestones func(uint64) (data.MilestoneSummary, error)
 }
 
 func (d *dbMock) GetArtist(key string) (data.Artist, error) {
@@ -52,18 +76,108 @@ func (d *dbMock) GetProject(key uint64) (data.Project, error) {
 	return d.getProject(key)
 }
 
-func (d *dbMock) CreateProject(key uint64, title string, artistIDs []uint64, projectType data.ProjectType, release time.Time) (data.Project, error) {
-	return d.createProject(key, title, artistIDs, projectType, release)
+func (d *dbMock) CreateProject(key uint64, title string, artistIDs []uint64, projectType data.ProjectType, release time.Time, precision data.DatePrecision) (data.Project, error) {
+	return d.createProject(key, title, artistIDs, projectType, release, precision)
 }
 
-func (d *dbMock) CreateSpin(time time.Time, userID uint64, trackID uint64) (data.Spin, error) {
-	return d.createSpin(time, userID, trackID)
+func (d *dbMock) CreateSpin(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
+	return d.createSpin(time, userID, trackID, projectID, msPlayed)
 }
 
 func (d *dbMock) UpdateTrack(trackID uint64, projectID uint64, isPrimary bool) error {
 	return d.updateTrack(trackID, projectID, isPrimary)
 }
 
+func (d *dbMock) GetArtistByMBID(mbid string) (data.Artist, error) {
+	return d.getArtistMBID(mbid)
+}
+
+func (d *dbMock) GetTrackByMBID(mbid string) (data.Track, error) {
+	return d.getTrackMBID(mbid)
+}
+
+func (d *dbMock) GetProjectByMBID(mbid string) (data.Project, error) {
+	return d.getProjMBID(mbid)
+}
+
+func (d *dbMock) SetArtistMBID(id uint64, mbid string) error {
+	return d.setArtistMBID(id, mbid)
+}
+
+func (d *dbMock) SetTrackMBID(key uint64, mbid string) error {
+	return d.setTrackMBID(key, mbid)
+}
+
+func (d *dbMock) SetProjectMBID(key uint64, mbid string) error {
+	return d.setProjMBID(key, mbid)
+}
+
+func (d *dbMock) SetTrackDuration(key uint64, duration time.Duration) error {
+	return d.setDuration(key, duration)
+}
+
+func (d *dbMock) SetProjectArtURL(key uint64, url string) error {
+	return d.setArtURL(key, url)
+}
+
+func (d *dbMock) GetTrackProjects(key uint64) ([]data.Project, error) {
+	return d.getTrackProjs(key)
+}
+
+func (d *dbMock) GetPrimaryContext(key uint64, userID uint64) (data.PrimaryContext, error) {
+	return d.getPrimaryCtx(key, userID)
+}
+
+func (d *dbMock) SetPrimaryProject(key uint64, projectID uint64, pinned bool) error {
+	return d.setPrimary(key, projectID, pinned)
+}
+
+func (d *dbMock) UnpinPrimaryProject(key uint64) error {
+	return d.unpinPrimary(key)
+}
+
+func (d *dbMock) SetUserPrimaryProject(userID uint64, key uint64, projectID uint64) error {
+	return d.setUserPrim(userID, key, projectID)
+}
+
+func (d *dbMock) DeleteUserPrimaryProject(userID uint64, key uint64) error {
+	return d.delUserPrim(userID, key)
+}
+
+func (d *dbMock) SetProjectEdition(key uint64, edition data.Edition, baseKey uint64) error {
+	return d.setEdition(key, edition, baseKey)
+}
+
+func (d *dbMock) UpdateMilestoneProgress(s data.Spin) (data.MilestoneProgress, error) {
+	return d.updProgress(s)
+}
+
+func (d *dbMock) AddMilestones(milestones []data.Milestone) ([]data.Milestone, error) {
+	return d.addMilestones(milestones)
+}
+
+func (d *dbMock) GetMilestones(userID uint64) (data.MilestoneSummary, error) {
+	return d.getMilestones(userID)
+}
+
+type spinDBMock struct {
+	updateSpin  func(uint64, uint64, uint64, uint64) (data.Spin, uint64, error)
+	deleteSpin  func(uint64, uint64) error
+	deleteSpins func(uint64, time.Time, time.Time) (int64, error)
+}
+
+func (db *spinDBMock) UpdateSpin(userID, id, trackID, projectID uint64) (data.Spin, uint64, error) {
+	return db.updateSpin(userID, id, trackID, projectID)
+}
+
+func (db *spinDBMock) DeleteSpin(userID, id uint64) error {
+	return db.deleteSpin(userID, id)
+}
+
+func (db *spinDBMock) DeleteSpins(userID uint64, from, to time.Time) (int64, error) {
+	return db.deleteSpins(userID, from, to)
+}
+
 func TestHandleSpin(t *testing.T) {
 	release, _ := time.Parse("02/01/2006", "09/08/2023")
 	spinTime := time.Now()
@@ -89,6 +203,14 @@ func TestHandleSpin(t *testing.T) {
 				},
 				string(data.Album),
 				release,
+				"",
+				nil,
+				"",
+				nil,
+				0,
+				0,
+				"",
+				"",
 			},
 			&dbMock{
 				func(string) (data.Artist, error) {
@@ -102,6 +224,7 @@ func TestHandleSpin(t *testing.T) {
 				},
 				func(key uint64, title string, artistIDs []uint64) (data.Track, error) {
 					return data.Track{
+						ID:               key,
 						Title:            title,
 						ProjectIDs:       []uint64{},
 						PrimaryProjectID: 0,
@@ -110,24 +233,54 @@ func TestHandleSpin(t *testing.T) {
 				func(uint64) (data.Project, error) {
 					return data.Project{}, nil
 				},
-				func(key uint64, title string, artistIDs []uint64, projectType data.ProjectType, release time.Time) (data.Project, error) {
+				func(key uint64, title string, artistIDs []uint64, projectType data.ProjectType, release time.Time, precision data.DatePrecision) (data.Project, error) {
 					return data.Project{
+						ID:      key,
 						Title:   title,
 						Form:    projectType,
 						Release: release,
 					}, nil
 				},
-				func(time time.Time, userID uint64, trackID uint64) (data.Spin, error) {
+				func(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
 					return data.Spin{
-						ID:      uint(1),
-						UserID:  uint(userID),
-						Time:    time,
-						TrackID: uint(trackID),
+						ID:        uint(1),
+						UserID:    uint(userID),
+						Time:      time,
+						TrackID:   uint(trackID),
+						MsPlayed:  uint(msPlayed),
+						ProjectID: uint(projectID),
 					}, nil
 				},
 				func(trackID uint64, projectID uint64, isPrimary bool) error {
 					return nil
 				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				func(uint64) ([]data.Project, error) {
+					return []data.Project{{ID: 2371983535859309014, Title: "GUTS", Form: data.Album, Release: release}}, nil
+				},
+				func(uint64, uint64) (data.PrimaryContext, error) {
+					return data.PrimaryContext{}, nil
+				},
+				func(key uint64, projectID uint64, pinned bool) error {
+					if projectID != 2371983535859309014 || pinned {
+						t.Fatalf("expected project 2371983535859309014 to become primary but got %d", projectID)
+					}
+					return nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
 			},
 			&cacheMock{
 				func(string) string {
@@ -135,12 +288,18 @@ func TestHandleSpin(t *testing.T) {
 				},
 				func(string, string) {
 				},
+				func(key string) {
+					if key != "t-908849726797084829" {
+						t.Fatalf("expected linked track to be deleted from cache but got %s", key)
+					}
+				},
 			},
 			data.Spin{
-				ID:      1,
-				UserID:  1,
-				Time:    spinTime,
-				TrackID: 908849726797084829,
+				ID:        1,
+				UserID:    1,
+				Time:      spinTime,
+				TrackID:   908849726797084829,
+				ProjectID: 2371983535859309014,
 			},
 		},
 		{
@@ -158,6 +317,14 @@ func TestHandleSpin(t *testing.T) {
 				},
 				string(data.Album),
 				release,
+				"",
+				nil,
+				"",
+				nil,
+				0,
+				0,
+				"",
+				"",
 			},
 			&dbMock{
 				func(string) (data.Artist, error) {
@@ -180,21 +347,41 @@ func TestHandleSpin(t *testing.T) {
 					t.FailNow()
 					return data.Project{}, nil
 				},
-				func(uint64, string, []uint64, data.ProjectType, time.Time) (data.Project, error) {
+				func(uint64, string, []uint64, data.ProjectType, time.Time, data.DatePrecision) (data.Project, error) {
 					t.FailNow()
 					return data.Project{}, nil
 				},
-				func(time time.Time, userID uint64, trackID uint64) (data.Spin, error) {
+				func(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
 					return data.Spin{
-						ID:      uint(1),
-						UserID:  uint(userID),
-						Time:    time,
-						TrackID: uint(trackID),
+						ID:        uint(1),
+						UserID:    uint(userID),
+						Time:      time,
+						TrackID:   uint(trackID),
+						MsPlayed:  uint(msPlayed),
+						ProjectID: uint(projectID),
 					}, nil
 				},
 				func(trackID uint64, projectID uint64, isPrimary bool) error {
 					return nil
 				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
 			},
 			&cacheMock{
 				func(key string) string {
@@ -206,6 +393,7 @@ func TestHandleSpin(t *testing.T) {
 						return string(j)
 					} else if key == "t-908849726797084829" {
 						j, _ := json.Marshal(data.Track{
+							ID:               908849726797084829,
 							Title:            "bad idea right?",
 							ProjectIDs:       []uint64{2371983535859309014},
 							PrimaryProjectID: 2371983535859309014,
@@ -213,6 +401,7 @@ func TestHandleSpin(t *testing.T) {
 						return string(j)
 					} else if key == "p-2371983535859309014" {
 						j, _ := json.Marshal(data.Project{
+							ID:      2371983535859309014,
 							Title:   "GUTS",
 							Form:    data.Album,
 							Release: release,
@@ -223,22 +412,941 @@ func TestHandleSpin(t *testing.T) {
 				},
 				func(string, string) {
 				},
+				func(string) {
+					t.Fatalf("should not call this function")
+				},
+			},
+			data.Spin{
+				ID:        1,
+				UserID:    1,
+				Time:      spinTime,
+				TrackID:   908849726797084829,
+				ProjectID: 2371983535859309014,
+			},
+		},
+		{
+			"Should hash aliased artists by their canonical name",
+			SpinRequest{
+				1,
+				spinTime,
+				"Halo",
+				[]string{
+					"Beyonce",
+				},
+				"I Am... Sasha Fierce",
+				[]string{
+					"Beyonce",
+				},
+				string(data.Album),
+				release,
+				"",
+				nil,
+				"",
+				nil,
+				0,
+				0,
+				"",
+				"",
+			},
+			&dbMock{
+				func(string) (data.Artist, error) {
+					return data.Artist{ID: 1, Name: "Beyoncé"}, nil
+				},
+				func(key string) (data.Artist, error) {
+					t.Fatalf("should not call this function")
+					return data.Artist{}, nil
+				},
+				func(key uint64) (data.Track, error) {
+					if key != data.CreateHash("Halo", []string{"Beyoncé"}) {
+						return data.Track{}, nil
+					}
+					return data.Track{
+						ID:               key,
+						Title:            "Halo",
+						ProjectIDs:       []uint64{2},
+						PrimaryProjectID: 2,
+					}, nil
+				},
+				func(uint64, string, []uint64) (data.Track, error) {
+					t.Fatalf("should not call this function")
+					return data.Track{}, nil
+				},
+				func(uint64) (data.Project, error) {
+					return data.Project{ID: 2, Title: "I Am... Sasha Fierce", Form: data.Album, Release: release}, nil
+				},
+				func(uint64, string, []uint64, data.ProjectType, time.Time, data.DatePrecision) (data.Project, error) {
+					t.Fatalf("should not call this function")
+					return data.Project{}, nil
+				},
+				func(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
+					return data.Spin{
+						ID:        uint(1),
+						UserID:    uint(userID),
+						Time:      time,
+						TrackID:   uint(trackID),
+						MsPlayed:  uint(msPlayed),
+						ProjectID: uint(projectID),
+					}, nil
+				},
+				func(trackID uint64, projectID uint64, isPrimary bool) error {
+					t.Fatalf("should not call this function")
+					return nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			},
+			&cacheMock{
+				func(string) string {
+					return ""
+				},
+				func(string, string) {
+				},
+				func(string) {
+					t.Fatalf("should not call this function")
+				},
+			},
+			data.Spin{
+				ID:        1,
+				UserID:    1,
+				Time:      spinTime,
+				TrackID:   uint(data.CreateHash("Halo", []string{"Beyoncé"})),
+				ProjectID: 2,
+			},
+		},
+		{
+			"Should prefer MBID match over hash",
+			SpinRequest{
+				1,
+				spinTime,
+				"bad idea right? - Live",
+				[]string{
+					"Olivia Rodrigo",
+				},
+				"GUTS",
+				[]string{
+					"Olivia Rodrigo",
+				},
+				string(data.Album),
+				release,
+				"f2b5c0a3-6c6a-4f1f-9d52-6f0d5a1b2c3d",
+				nil,
+				"",
+				nil,
+				0,
+				0,
+				"",
+				"",
+			},
+			&dbMock{
+				func(string) (data.Artist, error) {
+					return data.Artist{ID: 1, Name: "Olivia Rodrigo"}, nil
+				},
+				nil,
+				func(uint64) (data.Track, error) {
+					t.Fatalf("should not call this function")
+					return data.Track{}, nil
+				},
+				nil,
+				func(uint64) (data.Project, error) {
+					return data.Project{ID: 2, Title: "GUTS", Form: data.Album, Release: release}, nil
+				},
+				nil,
+				func(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
+					return data.Spin{
+						ID:        uint(1),
+						UserID:    uint(userID),
+						Time:      time,
+						TrackID:   uint(trackID),
+						MsPlayed:  uint(msPlayed),
+						ProjectID: uint(projectID),
+					}, nil
+				},
+				nil,
+				nil,
+				func(mbid string) (data.Track, error) {
+					return data.Track{
+						ID:               42,
+						Title:            "bad idea right?",
+						ProjectIDs:       []uint64{2},
+						PrimaryProjectID: 2,
+						MBID:             mbid,
+					}, nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			},
+			&cacheMock{
+				func(string) string {
+					return ""
+				},
+				func(string, string) {
+				},
+				func(string) {
+					t.Fatalf("should not call this function")
+				},
+			},
+			data.Spin{
+				ID:        1,
+				UserID:    1,
+				Time:      spinTime,
+				TrackID:   42,
+				ProjectID: 2,
+			},
+		},
+		{
+			"Should key colliding recordings by MBID",
+			SpinRequest{
+				1,
+				spinTime,
+				"Intro",
+				[]string{
+					"Nirvana",
+				},
+				"Nevermind",
+				[]string{
+					"Nirvana",
+				},
+				string(data.Album),
+				release,
+				"0e3b9a3e-2c4d-4bb8-a1e7-7f0a6c2e9b11",
+				[]string{
+					"5b11f4ce-a62d-471e-81fc-a69a8278c7da",
+				},
+				"",
+				nil,
+				0,
+				0,
+				"",
+				"",
+			},
+			&dbMock{
+				func(string) (data.Artist, error) {
+					return data.Artist{}, nil
+				},
+				func(key string) (data.Artist, error) {
+					return data.Artist{ID: 1, Name: key}, nil
+				},
+				func(key uint64) (data.Track, error) {
+					if key != data.CreateHash("Intro", []string{"Nirvana"}) {
+						return data.Track{}, data.ErrNotFound
+					}
+					return data.Track{
+						ID:               data.CreateHash("Intro", []string{"Nirvana"}),
+						Title:            "Intro",
+						ProjectIDs:       []uint64{2},
+						PrimaryProjectID: 2,
+						MBID:             "9a1c2b3d-0000-4000-8000-000000000000",
+					}, nil
+				},
+				func(key uint64, title string, artistIDs []uint64) (data.Track, error) {
+					if key != data.CreateHash("0e3b9a3e-2c4d-4bb8-a1e7-7f0a6c2e9b11", nil) {
+						t.Fatalf("expected track to be keyed by its mbid")
+					}
+					return data.Track{ID: key, Title: title}, nil
+				},
+				func(uint64) (data.Project, error) {
+					return data.Project{ID: 2, Title: "Nevermind", Form: data.Album, Release: release}, nil
+				},
+				nil,
+				func(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
+					return data.Spin{
+						ID:        uint(1),
+						UserID:    uint(userID),
+						Time:      time,
+						TrackID:   uint(trackID),
+						MsPlayed:  uint(msPlayed),
+						ProjectID: uint(projectID),
+					}, nil
+				},
+				func(trackID uint64, projectID uint64, isPrimary bool) error {
+					return nil
+				},
+				func(string) (data.Artist, error) {
+					return data.Artist{}, nil
+				},
+				func(string) (data.Track, error) {
+					return data.Track{}, nil
+				},
+				nil,
+				func(id uint64, mbid string) error {
+					return nil
+				},
+				func(key uint64, mbid string) error {
+					return nil
+				},
+				nil,
+				nil,
+				nil,
+				func(uint64) ([]data.Project, error) {
+					return []data.Project{{ID: 2, Title: "Nevermind", Form: data.Album, Release: release}}, nil
+				},
+				func(uint64, uint64) (data.PrimaryContext, error) {
+					return data.PrimaryContext{}, nil
+				},
+				func(key uint64, projectID uint64, pinned bool) error {
+					if projectID != 2 || pinned {
+						t.Fatalf("expected project 2 to become primary but got %d", projectID)
+					}
+					return nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			},
+			&cacheMock{
+				func(string) string {
+					return ""
+				},
+				func(string, string) {
+				},
+				func(string) {
+				},
+			},
+			data.Spin{
+				ID:        1,
+				UserID:    1,
+				Time:      spinTime,
+				TrackID:   uint(data.CreateHash("0e3b9a3e-2c4d-4bb8-a1e7-7f0a6c2e9b11", nil)),
+				ProjectID: 2,
+			},
+		},
+		{
+			"Should record play time, track duration and artwork URL",
+			SpinRequest{
+				1,
+				spinTime,
+				"bad idea right?",
+				[]string{
+					"Olivia Rodrigo",
+				},
+				"GUTS",
+				[]string{
+					"Olivia Rodrigo",
+				},
+				string(data.Album),
+				release,
+				"",
+				nil,
+				"",
+				nil,
+				184000,
+				90000,
+				"https://coverartarchive.org/release/guts/front",
+				"",
+			},
+			&dbMock{
+				func(string) (data.Artist, error) {
+					return data.Artist{ID: 1, Name: "Olivia Rodrigo"}, nil
+				},
+				nil,
+				func(key uint64) (data.Track, error) {
+					return data.Track{
+						ID:               key,
+						Title:            "bad idea right?",
+						ProjectIDs:       []uint64{2},
+						PrimaryProjectID: 2,
+					}, nil
+				},
+				nil,
+				func(uint64) (data.Project, error) {
+					return data.Project{ID: 2, Title: "GUTS", Form: data.Album, Release: release}, nil
+				},
+				nil,
+				func(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
+					return data.Spin{
+						ID:        uint(1),
+						UserID:    uint(userID),
+						Time:      time,
+						TrackID:   uint(trackID),
+						MsPlayed:  uint(msPlayed),
+						ProjectID: uint(projectID),
+					}, nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				func(key uint64, duration time.Duration) error {
+					if duration != 184*time.Second {
+						t.Fatalf("expected duration of 184s but got %s", duration)
+					}
+					return nil
+				},
+				func(key uint64, url string) error {
+					if key != 2 {
+						t.Fatalf("expected artwork URL for project 2 but got %d", key)
+					}
+					return nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			},
+			&cacheMock{
+				func(string) string {
+					return ""
+				},
+				func(string, string) {
+				},
+				func(string) {
+					t.Fatalf("should not call this function")
+				},
 			},
 			data.Spin{
-				ID:      1,
-				UserID:  1,
-				Time:    spinTime,
-				TrackID: 908849726797084829,
+				ID:        1,
+				UserID:    1,
+				Time:      spinTime,
+				TrackID:   908849726797084829,
+				MsPlayed:  90000,
+				ProjectID: 2,
+			},
+		},
+		{
+			"Should prefer original project when spun from it",
+			SpinRequest{
+				1,
+				spinTime,
+				"bad idea right?",
+				[]string{
+					"Olivia Rodrigo",
+				},
+				"GUTS",
+				[]string{
+					"Olivia Rodrigo",
+				},
+				string(data.Album),
+				release,
+				"",
+				nil,
+				"",
+				nil,
+				0,
+				0,
+				"",
+				"",
+			},
+			&dbMock{
+				func(string) (data.Artist, error) {
+					return data.Artist{ID: 1, Name: "Olivia Rodrigo"}, nil
+				},
+				nil,
+				func(key uint64) (data.Track, error) {
+					return data.Track{
+						ID:               key,
+						Title:            "bad idea right?",
+						ProjectIDs:       []uint64{2, 3, 4},
+						PrimaryProjectID: 3,
+						Duration:         184 * time.Second,
+					}, nil
+				},
+				nil,
+				func(uint64) (data.Project, error) {
+					return data.Project{ID: 2, Title: "GUTS", Form: data.Album, Release: release}, nil
+				},
+				nil,
+				func(time time.Time, userID uint64, trackID uint64, projectID uint64, msPlayed uint64) (data.Spin, error) {
+					return data.Spin{
+						ID:        uint(1),
+						UserID:    uint(userID),
+						Time:      time,
+						TrackID:   uint(trackID),
+						ProjectID: uint(projectID),
+					}, nil
+				},
+				func(uint64, uint64, bool) error {
+					t.Fatalf("should not call this function")
+					return nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				func(uint64) ([]data.Project, error) {
+					return []data.Project{
+						{ID: 2, Title: "GUTS", Form: data.Album, Release: release},
+						{ID: 3, Title: "GUTS (spilled)", Form: data.Album, Release: release.AddDate(1, 0, 0)},
+						{ID: 4, Title: "GUTS (Deluxe)", Form: data.Album, Release: release.AddDate(-1, 0, 0)},
+					}, nil
+				},
+				func(uint64, uint64) (data.PrimaryContext, error) {
+					return data.PrimaryContext{}, nil
+				},
+				func(key uint64, projectID uint64, pinned bool) error {
+					if projectID != 2 {
+						t.Fatalf("expected earliest original project 2 to become primary but got %d", projectID)
+					}
+					return nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			},
+			&cacheMock{
+				func(string) string {
+					return ""
+				},
+				func(string, string) {
+				},
+				func(key string) {
+					if key != "t-908849726797084829" {
+						t.Fatalf("expected track to be deleted from cache but got %s", key)
+					}
+				},
+			},
+			data.Spin{
+				ID:        1,
+				UserID:    1,
+				Time:      spinTime,
+				TrackID:   908849726797084829,
+				ProjectID: 2,
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actual := HandleSpin(tt.input, tt.db, tt.cache)
+			actual, err := HandleSpin(tt.input, tt.db, tt.cache, data.DefaultPrimaryPolicy, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if actual != tt.expected {
 				t.Fatalf("expected %+v but got %+v", tt.expected, actual)
 			}
 		})
 	}
 }
+
+func TestHandleSpinProjects(t *testing.T) {
+	artists := []string{"Olivia Rodrigo"}
+	baseKey := data.CreateHash("GUTS", artists)
+	noCache := &cacheMock{func(string) string { return "" }, func(string, string) {}, func(string) {}}
+
+	t.Run("Should reject unknown project types and precisions", func(t *testing.T) {
+		for _, req := range []SpinRequest{
+			{ProjectTitle: "GUTS", ProjectType: "box set"},
+			{ProjectTitle: "GUTS", ProjectType: "album", ProjectReleasePrecision: "decade"},
+		} {
+			if _, err := HandleSpin(req, &dbMock{}, noCache, data.DefaultPrimaryPolicy, nil); !errors.Is(err, ErrBadRequest) {
+				t.Fatalf("expected %v but got %v", ErrBadRequest, err)
+			}
+		}
+	})
+
+	t.Run("Should reject malformed MusicBrainz identifiers", func(t *testing.T) {
+		for _, req := range []SpinRequest{
+			{ProjectType: "album", TrackMBID: "not-a-uuid"},
+			{ProjectType: "album", ProjectMBID: "0e3b9a3e"},
+			{ProjectType: "album", TrackArtistMBIDs: []string{"nirvana"}},
+		} {
+			if _, err := HandleSpin(req, &dbMock{}, noCache, data.DefaultPrimaryPolicy, nil); !errors.Is(err, ErrBadRequest) {
+				t.Fatalf("expected %v but got %v", ErrBadRequest, err)
+			}
+		}
+	})
+
+	t.Run("Should link deluxe edition to its original", func(t *testing.T) {
+		linked := false
+		db := &dbMock{}
+		db.getArtist = func(name string) (data.Artist, error) {
+			return data.Artist{ID: 1, Name: name}, nil
+		}
+		db.getTrack = func(key uint64) (data.Track, error) {
+			return data.Track{ID: key, Title: "obsessed", ProjectIDs: []uint64{baseKey}, PrimaryProjectID: baseKey}, nil
+		}
+		db.getProject = func(key uint64) (data.Project, error) {
+			if key == baseKey {
+				return data.Project{ID: key, Title: "GUTS", Form: data.Album}, nil
+			}
+			return data.Project{}, nil
+		}
+		db.createProject = func(key uint64, title string, artistIDs []uint64, form data.ProjectType, release time.Time, precision data.DatePrecision) (data.Project, error) {
+			if form != data.Album || precision != data.YearPrecision || !release.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("expected an album released in 2024 but got %s %s %s", form, release, precision)
+			}
+			return data.Project{ID: key, Title: title, Form: form, Release: release, ReleasePrecision: precision}, nil
+		}
+		db.setEdition = func(key uint64, edition data.Edition, base uint64) error {
+			if edition != data.Deluxe || base != baseKey {
+				t.Fatalf("expected deluxe edition of %d but got %s of %d", baseKey, edition, base)
+			}
+			linked = true
+			return nil
+		}
+		db.updateTrack = func(uint64, uint64, bool) error { return nil }
+		db.createSpin = func(time time.Time, userID, trackID, projectID, msPlayed uint64) (data.Spin, error) {
+			return data.Spin{ID: 1, TrackID: uint(trackID), ProjectID: uint(projectID)}, nil
+		}
+		db.getTrackProjs = func(uint64) ([]data.Project, error) { return []data.Project{}, nil }
+		db.getPrimaryCtx = func(uint64, uint64) (data.PrimaryContext, error) { return data.PrimaryContext{}, nil }
+
+		req := SpinRequest{
+			TrackTitle:              "obsessed",
+			TrackArtistNames:        artists,
+			ProjectTitle:            "GUTS (Deluxe)",
+			ProjectArtistNames:      artists,
+			ProjectType:             " Album ",
+			ProjectRelese:           time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC),
+			ProjectReleasePrecision: "year",
+		}
+		if _, err := HandleSpin(req, db, noCache, data.DefaultPrimaryPolicy, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !linked {
+			t.Fatalf("expected the edition to be linked")
+		}
+	})
+}
+
+func TestResolveTrackByMBIDKey(t *testing.T) {
+	mbid := "0e3b9a3e-2c4d-4bb8-a1e7-7f0a6c2e9b11"
+	hash := data.CreateHash("Intro", []string{"Nirvana"})
+	mbidKey := data.CreateHash(mbid, nil)
+	noCache := &cacheMock{func(string) string { return "" }, func(string, string) {}, func(string) {}}
+
+	db := &dbMock{}
+	db.getTrackMBID = func(string) (data.Track, error) { return data.Track{}, data.ErrNotFound }
+	db.getTrack = func(key uint64) (data.Track, error) {
+		switch key {
+		case hash:
+			return data.Track{ID: hash, Title: "Intro", MBID: "9a1c2b3d-0000-4000-8000-000000000000"}, nil
+		case mbidKey:
+			return data.Track{ID: mbidKey, Title: "Intro"}, nil
+		}
+		return data.Track{}, data.ErrNotFound
+	}
+
+	track, key := resolveTrack(hash, mbid, db, noCache)
+	if key != mbidKey || track.ID != mbidKey {
+		t.Fatalf("expected the track keyed by its MBID but got %+v under %d", track, key)
+	}
+}
+
+func TestApplyPrimaryPolicy(t *testing.T) {
+	spinsPolicy, err := data.ParsePrimaryPolicy("spins,original")
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := data.Track{ID: 1, ProjectIDs: []uint64{5, 6}, PrimaryProjectID: 5}
+
+	tests := []struct {
+		name      string
+		catalog   spinCatalog
+		policy    data.PrimaryPolicy
+		evaluated bool
+	}{
+		{"Should rank a new project", spinCatalog{track, 1, data.Project{ID: 7}, true}, data.DefaultPrimaryPolicy, true},
+		{"Should rank the projects of a track without primary", spinCatalog{data.Track{ID: 1, ProjectIDs: []uint64{6}}, 1, data.Project{ID: 6}, false}, data.DefaultPrimaryPolicy, true},
+		{"Should count spins from another project", spinCatalog{track, 1, data.Project{ID: 6}, false}, spinsPolicy, true},
+		{"Spin from another project without counting spins", spinCatalog{track, 1, data.Project{ID: 6}, false}, data.DefaultPrimaryPolicy, false},
+		{"Spin from the primary project", spinCatalog{track, 1, data.Project{ID: 5}, false}, spinsPolicy, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluated := false
+			db := &dbMock{}
+			db.getTrackProjs = func(uint64) ([]data.Project, error) {
+				evaluated = true
+				return []data.Project{}, nil
+			}
+			db.getPrimaryCtx = func(uint64, uint64) (data.PrimaryContext, error) { return data.PrimaryContext{}, nil }
+			noCache := &cacheMock{func(string) string { return "" }, func(string, string) {}, func(string) {}}
+
+			applyPrimaryPolicy(tt.catalog, db, noCache, tt.policy)
+			if evaluated != tt.evaluated {
+				t.Fatalf("expected the policy to be evaluated %t but got %t", tt.evaluated, evaluated)
+			}
+		})
+	}
+
+	t.Run("Should evict the track cached by MusicBrainz identifier", func(t *testing.T) {
+		db := &dbMock{}
+		db.getTrackProjs = func(uint64) ([]data.Project, error) { return []data.Project{}, nil }
+		db.getPrimaryCtx = func(uint64, uint64) (data.PrimaryContext, error) { return data.PrimaryContext{}, nil }
+		deleted := map[string]bool{}
+		cache := &cacheMock{nil, nil, func(key string) { deleted[key] = true }}
+
+		mbidTrack := data.Track{ID: 1, MBID: "m", ProjectIDs: []uint64{5}, PrimaryProjectID: 5}
+		applyPrimaryPolicy(spinCatalog{mbidTrack, 1, data.Project{ID: 7}, true}, db, cache, data.DefaultPrimaryPolicy)
+		if !deleted["t-1"] || !deleted["tm-m"] {
+			t.Fatalf("expected the track to be evicted under both keys but got %v", deleted)
+		}
+	})
+}
+
+func TestHandleSpinMilestones(t *testing.T) {
+	noCache := &cacheMock{func(string) string { return "" }, func(string, string) {}, func(string) {}}
+	db := &dbMock{}
+	db.getArtist = func(name string) (data.Artist, error) {
+		return data.Artist{ID: 1, Name: name}, nil
+	}
+	db.getTrack = func(key uint64) (data.Track, error) {
+		return data.Track{ID: key, Title: "vampire", ProjectIDs: []uint64{5}, PrimaryProjectID: 5}, nil
+	}
+	db.getProject = func(key uint64) (data.Project, error) {
+		return data.Project{ID: 5, Title: "GUTS", Form: data.Album}, nil
+	}
+	db.createSpin = func(time time.Time, userID, trackID, projectID, msPlayed uint64) (data.Spin, error) {
+		return data.Spin{ID: 1000, UserID: uint(userID), Time: time, TrackID: uint(trackID), ProjectID: uint(projectID)}, nil
+	}
+	db.updProgress = func(s data.Spin) (data.MilestoneProgress, error) {
+		return data.MilestoneProgress{Spins: 1000, TrackSpins: 12, ArtistSpins: map[uint64]uint64{1: 40}}, nil
+	}
+	var added []data.Milestone
+	db.addMilestones = func(milestones []data.Milestone) ([]data.Milestone, error) {
+		added = milestones
+		return milestones, nil
+	}
+
+	req := SpinRequest{
+		UserID:             2,
+		Time:               time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+		TrackTitle:         "vampire",
+		TrackArtistNames:   []string{"Olivia Rodrigo"},
+		ProjectTitle:       "GUTS",
+		ProjectArtistNames: []string{"Olivia Rodrigo"},
+		ProjectType:        "album",
+	}
+	if _, err := HandleSpin(req, db, noCache, data.DefaultPrimaryPolicy, data.DefaultAchievements); err != nil {
+		t.Fatal(err)
+	}
+	// Thresholds the counters passed before come again and are dropped by
+	// the database.
+	reached := false
+	for _, m := range added {
+		if m.SpinID != 1000 || m.UserID != 2 {
+			t.Fatalf("expected milestones of spin 1000 but got %+v", m)
+		}
+		reached = reached || (m.Kind == data.SpinsMilestone && m.Value == 1000)
+	}
+	if !reached {
+		t.Fatalf("expected the 1000th spin milestone but got %+v", added)
+	}
+}
+
+func TestHandleEditSpin(t *testing.T) {
+	spinsPolicy, err := data.ParsePrimaryPolicy("spins,original")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		spinID   uint64
+		policy   data.PrimaryPolicy
+		touched  bool
+		reranked bool
+		err      error
+	}{
+		{"Should move spin to resolved track", 1, data.DefaultPrimaryPolicy, true, false, nil},
+		{"Should re-rank the previous track when counting spins", 1, spinsPolicy, true, true, nil},
+		{"Spin of another user", 2, spinsPolicy, false, false, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &dbMock{}
+			db.getArtist = func(name string) (data.Artist, error) {
+				return data.Artist{ID: 1, Name: name}, nil
+			}
+			db.getTrack = func(key uint64) (data.Track, error) {
+				return data.Track{ID: key, Title: "vampire", ProjectIDs: []uint64{5}, PrimaryProjectID: 5}, nil
+			}
+			db.getProject = func(key uint64) (data.Project, error) {
+				return data.Project{ID: 5, Title: "GUTS", Form: data.Album}, nil
+			}
+			reranked := false
+			db.getTrackProjs = func(key uint64) ([]data.Project, error) {
+				if key != 9 {
+					t.Fatalf("expected the previous track to be ranked but got %d", key)
+				}
+				reranked = true
+				return []data.Project{}, nil
+			}
+			db.getPrimaryCtx = func(uint64, uint64) (data.PrimaryContext, error) { return data.PrimaryContext{}, nil }
+			sdb := &spinDBMock{
+				updateSpin: func(userID, id, trackID, projectID uint64) (data.Spin, uint64, error) {
+					if id != 1 {
+						return data.Spin{}, 0, data.ErrNotFound
+					}
+					if projectID != 5 {
+						t.Fatalf("expected the spin to move to project 5 but got %d", projectID)
+					}
+					return data.Spin{ID: uint(id), UserID: uint(userID), TrackID: uint(trackID), ProjectID: uint(projectID)}, 9, nil
+				},
+			}
+			touched := false
+			cache := &cacheMock{
+				func(string) string { return "" },
+				func(key string, value string) {
+					if key == "lv-1" {
+						touched = true
+					}
+				},
+				func(string) {},
+			}
+
+			req := SpinRequest{
+				TrackTitle:         "vampire",
+				TrackArtistNames:   []string{"Olivia Rodrigo"},
+				ProjectTitle:       "GUTS",
+				ProjectArtistNames: []string{"Olivia Rodrigo"},
+				ProjectType:        "album",
+			}
+			if _, err := HandleEditSpin(1, tt.spinID, req, db, sdb, cache, tt.policy); !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if touched != tt.touched {
+				t.Fatalf("expected the listening version to change %v but got %v", tt.touched, touched)
+			}
+			if reranked != tt.reranked {
+				t.Fatalf("expected the previous track to be ranked %v but got %v", tt.reranked, reranked)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteSpins(t *testing.T) {
+	from := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		from    time.Time
+		to      time.Time
+		deleted int64
+		err     error
+	}{
+		{"Should delete spins in range", from, from.Add(time.Hour), 12, nil},
+		{"Missing start", time.Time{}, from, 0, ErrBadRequest},
+		{"Missing end", from, time.Time{}, 0, ErrBadRequest},
+		{"Empty range", from, from, 0, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &spinDBMock{
+				deleteSpins: func(userID uint64, from, to time.Time) (int64, error) {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					return 12, nil
+				},
+			}
+			cache := &cacheMock{func(string) string { return "" }, func(string, string) {}, func(string) {}}
+
+			n, err := HandleDeleteSpins(1, tt.from, tt.to, db, cache)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if n != tt.deleted {
+				t.Fatalf("expected %d spins deleted but got %d", tt.deleted, n)
+			}
+		})
+	}
+}
+
+func TestIsArtURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"https://coverartarchive.org/release/1/front", true},
+		{"http://93.184.216.34/front.jpg", true},
+		{"ftp://example.com/front.jpg", false},
+		{"http://localhost:8080/admin", false},
+		{"http://127.0.0.1/front.jpg", false},
+		{"http://169.254.169.254/latest/meta-data/", false},
+		{"http://[::1]/front.jpg", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if ok := isArtURL(tt.url); ok != tt.expected {
+				t.Fatalf("expected %v but got %v", tt.expected, ok)
+			}
+		})
+	}
+}
+
+func TestHandleSpinFailure(t *testing.T) {
+	noCache := &cacheMock{func(string) string { return "" }, func(string, string) {}, func(string) {
+		t.Fatalf("should not call this function")
+	}}
+	db := &dbMock{}
+	db.getArtist = func(name string) (data.Artist, error) {
+		return data.Artist{ID: 1, Name: name}, nil
+	}
+	db.getTrack = func(key uint64) (data.Track, error) {
+		return data.Track{ID: key, Title: "vampire", ProjectIDs: []uint64{5}, PrimaryProjectID: 6}, nil
+	}
+	db.getProject = func(key uint64) (data.Project, error) {
+		return data.Project{ID: 5, Title: "GUTS", Form: data.Album}, nil
+	}
+	db.createSpin = func(time.Time, uint64, uint64, uint64, uint64) (data.Spin, error) {
+		return data.Spin{}, errors.New("connection refused")
+	}
+
+	req := SpinRequest{
+		UserID:             2,
+		TrackTitle:         "vampire",
+		TrackArtistNames:   []string{"Olivia Rodrigo"},
+		ProjectTitle:       "GUTS",
+		ProjectArtistNames: []string{"Olivia Rodrigo"},
+		ProjectType:        "album",
+	}
+	_, err := HandleSpin(req, db, noCache, data.DefaultPrimaryPolicy, data.DefaultAchievements)
+	if err == nil || errors.Is(err, ErrBadRequest) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected an internal error but got %v", err)
+	}
+}
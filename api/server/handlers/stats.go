@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	d "tunes-service/data"
+)
+
+const (
+	DEFAULT_CHART_LIMIT = 10
+	MAX_CHART_LIMIT     = 100
+	CALENDAR_LEVELS     = 4
+	MAX_CALENDAR_DAYS   = 5 * 366
+)
+
+func HandleListeningTime(userID uint64, period d.Period, from, to time.Time, db d.StatsDB) ([]d.ListeningTime, error) {
+	if !period.IsValid() {
+		return nil, fmt.Errorf("%w: unknown period %q", ErrBadRequest, period)
+	}
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	lt, err := db.GetListeningTime(userID, period, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listening time: %w", err)
+	}
+	return lt, nil
+}
+
+func HandleArtistListening(userID uint64, from, to time.Time, limit int, db d.StatsDB) ([]d.ArtistListening, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	al, err := db.GetArtistListening(userID, from, to, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist listening time: %w", err)
+	}
+	return al, nil
+}
+
+func HandleProjectListening(userID uint64, from, to time.Time, limit int, db d.StatsDB) ([]d.ProjectListening, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	pl, err := db.GetProjectListening(userID, from, to, clampLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project listening time: %w", err)
+	}
+	return pl, nil
+}
+
+// HandleListeningClock returns a user's listening by hour of each weekday in
+// their time zone, Sunday first and including the hours without spins.
+func HandleListeningClock(userID uint64, from, to time.Time, db d.StatsDB) ([]d.HourListening, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	hl, err := db.GetHourlyListening(userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hourly listening: %w", err)
+	}
+
+	clock := make([]d.HourListening, 7*24)
+	for i := range clock {
+		clock[i].Weekday, clock[i].Hour = i/24, i%24
+	}
+	for _, l := range hl {
+		clock[l.Weekday*24+l.Hour] = l
+	}
+	return clock, nil
+}
+
+// HandleCalendar returns a user's listening on every day of a range in their
+// time zone. A range without a start covers the year before its end.
+func HandleCalendar(userID uint64, from, to time.Time, db d.StatsDB, sdb d.ProfileDB) ([]d.CalendarDay, error) {
+	if from.IsZero() {
+		from = to.AddDate(-1, 0, 0)
+	}
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+	if to.Sub(from) > MAX_CALENDAR_DAYS*24*time.Hour {
+		return nil, fmt.Errorf("%w: calendars cover at most %d days", ErrBadRequest, MAX_CALENDAR_DAYS)
+	}
+
+	s, err := sdb.GetSettings(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone: %w", err)
+	}
+
+	lt, err := db.GetListeningTime(userID, d.Day, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listening time: %w", err)
+	}
+	days := map[string]d.ListeningTime{}
+	var busiest uint64
+	for _, l := range lt {
+		days[l.Start.In(loc).Format(time.DateOnly)] = l
+		busiest = max(busiest, l.Spins)
+	}
+
+	calendar := []d.CalendarDay{}
+	start := from.In(loc)
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		l := days[day.Format(time.DateOnly)]
+		level := 0
+		if l.Spins > 0 {
+			level = int((CALENDAR_LEVELS*l.Spins + busiest - 1) / busiest)
+		}
+		calendar = append(calendar, d.CalendarDay{Date: day, Spins: l.Spins, MsPlayed: l.MsPlayed, Level: level})
+	}
+	return calendar, nil
+}
+
+func validateRange(from, to time.Time) error {
+	if !from.Before(to) {
+		return fmt.Errorf("%w: range must start before it ends", ErrBadRequest)
+	}
+	return nil
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DEFAULT_CHART_LIMIT
+	}
+	return min(limit, MAX_CHART_LIMIT)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type statsDBMock struct {
+	getListeningTime    func(uint64, data.Period, time.Time, time.Time) ([]data.ListeningTime, error)
+	getArtistListening  func(uint64, time.Time, time.Time, int) ([]data.ArtistListening, error)
+	getProjectListening func(uint64, time.Time, time.Time, int) ([]data.ProjectListening, error)
+	getTrackListening   func(uint64, time.Time, time.Time, int) ([]data.TrackListening, error)
+	getNewArtists       func(uint64, time.Time, time.Time) ([]data.ArtistListening, error)
+	getHourlyListening  func(uint64, time.Time, time.Time) ([]data.HourListening, error)
+	getOnRepeat         func(uint64, time.Time, time.Time, int) ([]data.TrackListening, error)
+}
+
+func (db *statsDBMock) GetListeningTime(userID uint64, period data.Period, from, to time.Time) ([]data.ListeningTime, error) {
+	return db.getListeningTime(userID, period, from, to)
+}
+
+func (db *statsDBMock) GetArtistListening(userID uint64, from, to time.Time, limit int) ([]data.ArtistListening, error) {
+	return db.getArtistListening(userID, from, to, limit)
+}
+
+func (db *statsDBMock) GetProjectListening(userID uint64, from, to time.Time, limit int) ([]data.ProjectListening, error) {
+	return db.getProjectListening(userID, from, to, limit)
+}
+
+func (db *statsDBMock) GetTrackListening(userID uint64, from, to time.Time, limit int) ([]data.TrackListening, error) {
+	return db.getTrackListening(userID, from, to, limit)
+}
+
+func (db *statsDBMock) GetNewArtists(userID uint64, from, to time.Time) ([]data.ArtistListening, error) {
+	return db.getNewArtists(userID, from, to)
+}
+
+func (db *statsDBMock) GetHourlyListening(userID uint64, from, to time.Time) ([]data.HourListening, error) {
+	return db.getHourlyListening(userID, from, to)
+}
+
+func (db *statsDBMock) GetOnRepeat(userID uint64, from, to time.Time, limit int) ([]data.TrackListening, error) {
+	return db.getOnRepeat(userID, from, to, limit)
+}
+
+func TestHandleListeningTime(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := &statsDBMock{
+		func(userID uint64, period data.Period, from, to time.Time) ([]data.ListeningTime, error) {
+			return []data.ListeningTime{{Start: from, Spins: 2, MsPlayed: 360000}}, nil
+		},
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+	}
+
+	tests := []struct {
+		name   string
+		period data.Period
+		from   time.Time
+		to     time.Time
+		err    error
+	}{
+		{"Should get listening time", data.Month, from, to, nil},
+		{"Unknown period", data.Period("fortnight"), from, to, ErrBadRequest},
+		{"Empty range", data.Day, to, from, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := HandleListeningTime(1, tt.period, tt.from, tt.to, db)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHandleArtistListening(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		limit    int
+		expected int
+	}{
+		{"Should default limit", 0, DEFAULT_CHART_LIMIT},
+		{"Should cap limit", 1000, MAX_CHART_LIMIT},
+		{"Should keep limit", 5, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &statsDBMock{
+				nil,
+				func(userID uint64, from, to time.Time, limit int) ([]data.ArtistListening, error) {
+					if limit != tt.expected {
+						t.Fatalf("expected limit %d but got %d", tt.expected, limit)
+					}
+					return []data.ArtistListening{}, nil
+				},
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			}
+
+			if _, err := HandleArtistListening(1, from, to, tt.limit, db); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestHandleListeningClock(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := &statsDBMock{
+		getHourlyListening: func(userID uint64, from, to time.Time) ([]data.HourListening, error) {
+			return []data.HourListening{{Weekday: 5, Hour: 23, Spins: 4}}, nil
+		},
+	}
+
+	clock, err := HandleListeningClock(1, from, to, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clock) != 7*24 || clock[5*24+23].Spins != 4 || clock[5*24+22].Hour != 22 || clock[5*24+22].Weekday != 5 {
+		t.Fatalf("expected every hour of the week with 4 spins on Fridays at 11pm but got %+v", clock)
+	}
+
+	if _, err := HandleListeningClock(1, to, from, db); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected %v but got %v", ErrBadRequest, err)
+	}
+}
+
+func TestHandleCalendar(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clocks in New York went forward on March 10, 2024.
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, newYork) }
+	db := &statsDBMock{
+		getListeningTime: func(userID uint64, period data.Period, from, to time.Time) ([]data.ListeningTime, error) {
+			return []data.ListeningTime{
+				{Start: day(9).UTC(), Spins: 4, MsPlayed: 720000},
+				{Start: day(10).UTC(), Spins: 1, MsPlayed: 180000},
+			}, nil
+		},
+	}
+	sdb := &profileDBMock{
+		getSettings: func(userID uint64) (data.Settings, error) {
+			return data.Settings{TimeZone: "America/New_York"}, nil
+		},
+	}
+
+	calendar, err := HandleCalendar(1, day(8), day(12), db, sdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := []int{0, 4, 1, 0}
+	if len(calendar) != len(levels) {
+		t.Fatalf("expected %d days but got %+v", len(levels), calendar)
+	}
+	for i, level := range levels {
+		if !calendar[i].Date.Equal(day(8+i)) || calendar[i].Level != level {
+			t.Fatalf("expected %v at level %d but got %+v", day(8+i), level, calendar[i])
+		}
+	}
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		err  error
+	}{
+		{"Should default to a year", time.Time{}, day(12), nil},
+		{"Empty range", day(12), day(8), ErrBadRequest},
+		{"Too long", day(8).AddDate(-10, 0, 0), day(12), ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar, err := HandleCalendar(1, tt.from, tt.to, db, sdb)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if tt.err == nil && len(calendar) != 366 {
+				t.Fatalf("expected a leap year of days but got %d", len(calendar))
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	d "tunes-service/data"
+)
+
+const MAX_TAG_LENGTH = 64
+
+// HandleAddTag tags an entity. A userID of 0 adds a global tag, which only
+// admins and enrichment should do.
+func HandleAddTag(entity d.EntityType, key uint64, name string, userID uint64, db d.TagDB) (d.Tag, error) {
+	name, err := validateTag(entity, name)
+	if err != nil {
+		return d.Tag{}, err
+	}
+
+	t, err := db.AddTag(entity, key, name, userID)
+	if err != nil {
+		return d.Tag{}, fmt.Errorf("failed to add tag: %w", err)
+	}
+	return t, nil
+}
+
+func HandleRemoveTag(entity d.EntityType, key uint64, name string, userID uint64, db d.TagDB) error {
+	name, err := validateTag(entity, name)
+	if err != nil {
+		return err
+	}
+
+	if err := db.RemoveTag(entity, key, name, userID); err != nil {
+		return fmt.Errorf("failed to remove tag: %w", err)
+	}
+	return nil
+}
+
+func HandleGetTags(entity d.EntityType, key uint64, userID uint64, db d.TagDB) ([]d.Tag, error) {
+	if !entity.IsValid() {
+		return nil, fmt.Errorf("%w: unknown entity %q", ErrBadRequest, entity)
+	}
+
+	tags, err := db.GetTags(entity, key, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// HandleTagChart ranks the tags of a user's listening. Their own tags are
+// only shown to themselves.
+func HandleTagChart(viewerID, userID uint64, from, to time.Time, limit int, db d.TagDB) ([]d.TagListening, error) {
+	if err := validateRange(from, to); err != nil {
+		return nil, err
+	}
+
+	chart, err := db.GetTagChart(userID, from, to, clampLimit(limit), viewerID != 0 && viewerID == userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag chart: %w", err)
+	}
+	return chart, nil
+}
+
+// validateTag checks the entity and normalizes the tag name so "Rock" and
+// " rock" are the same tag.
+func validateTag(entity d.EntityType, name string) (string, error) {
+	if !entity.IsValid() {
+		return "", fmt.Errorf("%w: unknown entity %q", ErrBadRequest, entity)
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || len(name) > MAX_TAG_LENGTH {
+		return "", fmt.Errorf("%w: invalid tag %q", ErrBadRequest, name)
+	}
+	return name, nil
+}
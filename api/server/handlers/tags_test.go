@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"tunes-service/data"
+)
+
+type tagDBMock struct {
+	addTag      func(data.EntityType, uint64, string, uint64) (data.Tag, error)
+	removeTag   func(data.EntityType, uint64, string, uint64) error
+	getTags     func(data.EntityType, uint64, uint64) ([]data.Tag, error)
+	getTagChart func(uint64, time.Time, time.Time, int, bool) ([]data.TagListening, error)
+}
+
+func (db *tagDBMock) AddTag(entity data.EntityType, key uint64, name string, userID uint64) (data.Tag, error) {
+	return db.addTag(entity, key, name, userID)
+}
+
+func (db *tagDBMock) RemoveTag(entity data.EntityType, key uint64, name string, userID uint64) error {
+	return db.removeTag(entity, key, name, userID)
+}
+
+func (db *tagDBMock) GetTags(entity data.EntityType, key uint64, userID uint64) ([]data.Tag, error) {
+	return db.getTags(entity, key, userID)
+}
+
+func (db *tagDBMock) GetTagChart(userID uint64, from, to time.Time, limit int, private bool) ([]data.TagListening, error) {
+	return db.getTagChart(userID, from, to, limit, private)
+}
+
+func TestHandleAddTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		entity   data.EntityType
+		tag      string
+		expected string
+		err      error
+	}{
+		{"Should normalize tag", data.ArtistEntity, "  Shoegaze ", "shoegaze", nil},
+		{"Unknown entity", data.EntityType("label"), "rock", "", ErrBadRequest},
+		{"Empty tag", data.TrackEntity, "   ", "", ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &tagDBMock{
+				func(entity data.EntityType, key uint64, name string, userID uint64) (data.Tag, error) {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if name != tt.expected {
+						t.Fatalf("expected tag %q but got %q", tt.expected, name)
+					}
+					return data.Tag{ID: 1, Name: name, UserID: userID}, nil
+				},
+				nil,
+				nil,
+				nil,
+			}
+
+			tag, err := HandleAddTag(tt.entity, 1, tt.tag, 7, db)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if err == nil && tag.UserID != 7 {
+				t.Fatalf("expected user tag but got %+v", tag)
+			}
+		})
+	}
+}
+
+func TestHandleTagChart(t *testing.T) {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		viewer  uint64
+		from    time.Time
+		to      time.Time
+		limit   int
+		private bool
+		err     error
+	}{
+		{"Should get own tag chart", 1, from, to, 0, true, nil},
+		{"Should hide user tags from others", 2, from, to, 0, false, nil},
+		{"Should hide user tags from logged out viewers", 0, from, to, 0, false, nil},
+		{"Empty range", 1, to, from, 0, false, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &tagDBMock{
+				nil,
+				nil,
+				nil,
+				func(userID uint64, from, to time.Time, limit int, private bool) ([]data.TagListening, error) {
+					if tt.err != nil {
+						t.Fatalf("should not call this function")
+					}
+					if private != tt.private {
+						t.Fatalf("expected private %t but got %t", tt.private, private)
+					}
+					if limit != DEFAULT_CHART_LIMIT {
+						t.Fatalf("expected limit %d but got %d", DEFAULT_CHART_LIMIT, limit)
+					}
+					return []data.TagListening{{Tag: data.Tag{ID: 1, Name: "rock"}, Spins: 3}}, nil
+				},
+			}
+
+			_, err := HandleTagChart(tt.viewer, 1, tt.from, tt.to, tt.limit, db)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	d "tunes-service/data"
+)
+
+// HandleWrapped returns a user's year in review. Reports are generated in the
+// background, so a year without one is not found until it is ready.
+func HandleWrapped(userID uint64, year int, db d.WrappedDB) (d.Wrapped, error) {
+	if year < 1 || year > 9999 {
+		return d.Wrapped{}, fmt.Errorf("%w: invalid year %d", ErrBadRequest, year)
+	}
+
+	w, err := db.GetWrapped(userID, year)
+	if errors.Is(err, d.ErrNotFound) {
+		return d.Wrapped{}, fmt.Errorf("%w: %s", ErrNotFound, err)
+	} else if err != nil {
+		return d.Wrapped{}, fmt.Errorf("failed to get wrapped report: %w", err)
+	}
+	return w, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"tunes-service/data"
+)
+
+type wrappedDBMock struct {
+	getStaleWrapped func(int, int) ([]data.StaleWrapped, error)
+	saveWrapped     func(uint64, data.Wrapped) error
+	getWrapped      func(uint64, int) (data.Wrapped, error)
+}
+
+func (db *wrappedDBMock) GetStaleWrapped(lastYear int, limit int) ([]data.StaleWrapped, error) {
+	return db.getStaleWrapped(lastYear, limit)
+}
+
+func (db *wrappedDBMock) SaveWrapped(userID uint64, w data.Wrapped) error {
+	return db.saveWrapped(userID, w)
+}
+
+func (db *wrappedDBMock) GetWrapped(userID uint64, year int) (data.Wrapped, error) {
+	return db.getWrapped(userID, year)
+}
+
+func TestHandleWrapped(t *testing.T) {
+	db := &wrappedDBMock{
+		getWrapped: func(userID uint64, year int) (data.Wrapped, error) {
+			switch year {
+			case 2023:
+				return data.Wrapped{Year: year, Spins: 16}, nil
+			case 2024:
+				return data.Wrapped{}, fmt.Errorf("%w: wrapped report of %d", data.ErrNotFound, year)
+			}
+			t.Fatalf("should not call this function")
+			return data.Wrapped{}, nil
+		},
+	}
+
+	tests := []struct {
+		name string
+		year int
+		err  error
+	}{
+		{"report", 2023, nil},
+		{"not generated", 2024, ErrNotFound},
+		{"invalid year", 0, ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := HandleWrapped(1, tt.year, db)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v but got %v", tt.err, err)
+			}
+			if tt.err == nil && w.Spins != 16 {
+				t.Fatalf("expected the report of %d but got %+v", tt.year, w)
+			}
+		})
+	}
+}
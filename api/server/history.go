@@ -0,0 +1,76 @@
+package server
+
+import (
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerHistoryRoutes(app *fiber.App, db data.HistoryDB, pdb data.AccessDB) {
+	app.Get("/api/users/:name/history", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.HistoryFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		before, err := parseTime(c.Query("before"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		h, err := handlers.HandleHistory(userID, before, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(h)
+	})
+
+	app.Get("/api/users/:name/history/sessions", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.HistoryFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		before, err := parseTime(c.Query("before"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		sessions, err := handlers.HandleSessions(userID, before, c.QueryInt("limit"), c.QueryBool("albums"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(sessions)
+	})
+
+	app.Get("/api/users/:name/history/sessions/:id", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.HistoryFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		sessionID, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		s, err := handlers.HandleSession(userID, sessionID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(s)
+	})
+
+	app.Get("/api/users/:name/now-playing", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.NowPlayingFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		h, err := handlers.HandleNowPlaying(userID, time.Now(), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(h)
+	})
+}
@@ -3,14 +3,51 @@ package middleware
 import (
 	"os"
 
+	"tunes-service/auth"
+
 	jwtware "github.com/gofiber/contrib/jwt"
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 func JWTMiddleware() func(*fiber.Ctx) error {
 	return jwtware.New(jwtware.Config{
 		SigningKey: jwtware.SigningKey{
-			Key: []byte(os.Getenv("SECRET_KEY")),
+			Key: []byte(os.Getenv("SECRET_TOKEN")),
+		},
+	})
+}
+
+// OptionalJWTMiddleware validates the token of requests that send one and
+// lets anonymous requests through, for public routes that show more to users
+// who are logged in.
+func OptionalJWTMiddleware() func(*fiber.Ctx) error {
+	return jwtware.New(jwtware.Config{
+		SigningKey: jwtware.SigningKey{
+			Key: []byte(os.Getenv("SECRET_TOKEN")),
+		},
+		Filter: func(c *fiber.Ctx) bool {
+			return c.Get(fiber.HeaderAuthorization) == ""
 		},
 	})
 }
+
+// AdminMiddleware must run after JWTMiddleware.
+func AdminMiddleware() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if !Claims(c).Admin {
+			return c.SendStatus(fiber.StatusForbidden)
+		}
+		return c.Next()
+	}
+}
+
+// Claims returns the claims of the token validated by JWTMiddleware, or
+// empty claims on routes the middleware does not cover.
+func Claims(c *fiber.Ctx) auth.Claims {
+	t, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return auth.Claims{}
+	}
+	return auth.ClaimsFromToken(t)
+}
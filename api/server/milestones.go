@@ -0,0 +1,23 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerMilestoneRoutes(app *fiber.App, db data.MilestoneDB, pdb data.AccessDB) {
+	app.Get("/api/users/:name/milestones", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		m, err := handlers.HandleMilestones(userID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(m)
+	})
+}
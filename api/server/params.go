@@ -0,0 +1,106 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+	"tunes-service/server/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sendError maps the errors handlers wrap to a response status.
+func sendError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, handlers.ErrBadRequest):
+		return c.SendStatus(fiber.StatusBadRequest)
+	case errors.Is(err, handlers.ErrUnauthorized):
+		return c.SendStatus(fiber.StatusUnauthorized)
+	case errors.Is(err, handlers.ErrForbidden):
+		return c.SendStatus(fiber.StatusForbidden)
+	case errors.Is(err, handlers.ErrNotFound):
+		return c.SendStatus(fiber.StatusNotFound)
+	default:
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+}
+
+// requestUserID returns the ID of the user who sent the request, for routes
+// that need one to be logged in.
+func requestUserID(c *fiber.Ctx) (uint64, error) {
+	claims := middleware.Claims(c)
+	if claims.UserID == 0 || claims.Name == "" {
+		return 0, handlers.ErrUnauthorized
+	}
+	return claims.UserID, nil
+}
+
+// routeSelf returns the ID of the user named in the route if they sent the
+// request, for routes users can only use on their own account.
+func routeSelf(c *fiber.Ctx) (uint64, error) {
+	userID, err := requestUserID(c)
+	if err != nil {
+		return 0, err
+	}
+	if c.Params("name") != middleware.Claims(c).Name {
+		return 0, handlers.ErrForbidden
+	}
+	return userID, nil
+}
+
+// routeUserID returns the ID of the user named in the route if their profile
+// lets the requester see a feature of their listening data. Users reading
+// their own skip the lookup.
+func routeUserID(c *fiber.Ctx, db data.AccessDB, f data.Feature) (uint64, error) {
+	if userID, err := routeSelf(c); err == nil {
+		return userID, nil
+	}
+	return handlers.HandleAccess(c.Params("name"), middleware.Claims(c).UserID, f, db)
+}
+
+// parseRange reads the from and to query parameters as dates or timestamps,
+// defaulting to all time up until now.
+func parseRange(c *fiber.Ctx) (from, to time.Time, err error) {
+	to = time.Now()
+	if from, err = parseTime(c.Query("from"), from); err != nil {
+		return
+	}
+	to, err = parseTime(c.Query("to"), to)
+	return
+}
+
+func parseTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid time %q", handlers.ErrBadRequest, value)
+}
+
+func parseID(c *fiber.Ctx, param string) (uint64, error) {
+	id, err := strconv.ParseUint(c.Params(param), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s", handlers.ErrBadRequest, param)
+	}
+	return id, nil
+}
+
+// parseQueryID reads an ID from the query, which is zero when it has none.
+func parseQueryID(c *fiber.Ctx, key string) (uint64, error) {
+	if c.Query(key) == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(c.Query(key), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid %s", handlers.ErrBadRequest, key)
+	}
+	return id, nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+	"tunes-service/server/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type playlistPayload struct {
+	Name   string
+	Public bool
+	Rule   *data.SmartRule
+}
+
+func registerPlaylistRoutes(app *fiber.App, db data.PlaylistDB, pdb data.AccessDB) {
+	app.Post("/api/playlists", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := playlistPayload{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		p, err := handlers.HandleCreatePlaylist(userID, data.Playlist{Name: payload.Name, Public: payload.Public, Rule: payload.Rule}, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(p)
+	})
+
+	app.Get("/api/playlists/:id", func(c *fiber.Ctx) error {
+		playlistID, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		p, err := handlers.HandlePlaylist(middleware.Claims(c).UserID, playlistID, time.Now(), db, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(p)
+	})
+
+	app.Put("/api/playlists/:id", func(c *fiber.Ctx) error {
+		userID, playlistID, err := playlistRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := playlistPayload{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		p := data.Playlist{ID: playlistID, Name: payload.Name, Public: payload.Public, Rule: payload.Rule}
+		if err := handlers.HandleUpdatePlaylist(userID, p, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Delete("/api/playlists/:id", func(c *fiber.Ctx) error {
+		userID, playlistID, err := playlistRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleDeletePlaylist(userID, playlistID, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Post("/api/playlists/:id/tracks", func(c *fiber.Ctx) error {
+		userID, playlistID, err := playlistRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := struct {
+			TrackID uint64
+		}{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleAddPlaylistTrack(userID, playlistID, payload.TrackID, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Put("/api/playlists/:id/tracks", func(c *fiber.Ctx) error {
+		userID, playlistID, err := playlistRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := struct {
+			TrackIDs []uint64
+		}{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleReorderPlaylist(userID, playlistID, payload.TrackIDs, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Delete("/api/playlists/:id/tracks/:track", func(c *fiber.Ctx) error {
+		userID, playlistID, err := playlistRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		trackID, err := parseID(c, "track")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleRemovePlaylistTrack(userID, playlistID, trackID, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Get("/api/users/:name/playlists", func(c *fiber.Ctx) error {
+		playlists, err := handlers.HandleUserPlaylists(middleware.Claims(c).UserID, c.Params("name"), db, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(playlists)
+	})
+}
+
+// playlistRoute returns the ID of the requester and of the playlist in the
+// route.
+func playlistRoute(c *fiber.Ctx) (userID, playlistID uint64, err error) {
+	if userID, err = requestUserID(c); err != nil {
+		return
+	}
+	playlistID, err = parseID(c, "id")
+	return
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"tunes-service/cache"
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+	"tunes-service/server/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerPrimaryRoutes lets users override the primary project of a track for
+// their own listening and admins pin it for everyone.
+func registerPrimaryRoutes(app *fiber.App, db data.TunesDB, cache cache.Cache, policy data.PrimaryPolicy) {
+	pin := func(userID func(c *fiber.Ctx) (uint64, error)) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			payload := struct {
+				ProjectID uint64
+			}{}
+
+			key, err := parseID(c, "id")
+			if err != nil {
+				return sendError(c, err)
+			}
+			if err := c.BodyParser(&payload); err != nil {
+				return c.SendStatus(fiber.StatusBadRequest)
+			}
+			user, err := userID(c)
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			if err := handlers.HandlePinPrimary(key, payload.ProjectID, user, db, cache); err != nil {
+				return sendError(c, err)
+			}
+			return c.SendStatus(fiber.StatusOK)
+		}
+	}
+
+	unpin := func(userID func(c *fiber.Ctx) (uint64, error)) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			key, err := parseID(c, "id")
+			if err != nil {
+				return sendError(c, err)
+			}
+			user, err := userID(c)
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			if err := handlers.HandleUnpinPrimary(key, user, db, cache, policy); err != nil {
+				return sendError(c, err)
+			}
+			return c.SendStatus(fiber.StatusOK)
+		}
+	}
+
+	everyone := func(c *fiber.Ctx) (uint64, error) { return 0, nil }
+
+	app.Get("/api/tracks/:id/primary", func(c *fiber.Ctx) error {
+		key, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		e, err := handlers.HandleExplainPrimary(key, middleware.Claims(c).UserID, db, policy)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(e)
+	})
+	app.Put("/api/tracks/:id/primary", pin(requestUserID))
+	app.Delete("/api/tracks/:id/primary", unpin(requestUserID))
+
+	app.Put("/api/admin/tracks/:id/primary", pin(everyone))
+	app.Delete("/api/admin/tracks/:id/primary", unpin(everyone))
+}
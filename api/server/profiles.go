@@ -0,0 +1,18 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerProfileRoutes(app *fiber.App, db data.ProfileDB) {
+	app.Get("/api/users/:name", func(c *fiber.Ctx) error {
+		p, err := handlers.HandlePublicProfile(c.Params("name"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(p)
+	})
+}
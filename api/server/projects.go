@@ -0,0 +1,31 @@
+package server
+
+import (
+	"tunes-service/cache"
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerProjectRoutes(app *fiber.App, db data.TunesDB, cache cache.Cache) {
+	app.Put("/api/admin/projects/:id/edition", func(c *fiber.Ctx) error {
+		payload := struct {
+			Edition       data.Edition
+			BaseProjectID uint64
+		}{}
+
+		key, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleSetEdition(key, payload.Edition, payload.BaseProjectID, db, cache); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+}
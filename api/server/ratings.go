@@ -0,0 +1,111 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerRatingRoutes serves the requester's own ratings under /api/ratings
+// and the ratings of users under /api/users. Routes name the entity in
+// plural, e.g. /api/ratings/projects/1.
+func registerRatingRoutes(app *fiber.App, db data.RatingDB, pdb data.AccessDB) {
+	app.Get("/api/ratings/:entity/:id", func(c *fiber.Ctx) error {
+		userID, id, err := ratingRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		r, err := handlers.HandleRating(userID, routeEntity(c), id, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(r)
+	})
+
+	app.Put("/api/ratings/:entity/:id", func(c *fiber.Ctx) error {
+		userID, id, err := ratingRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := struct {
+			Stars float64
+		}{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleRate(userID, routeEntity(c), id, payload.Stars, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	setFavorite := func(favorite bool) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			userID, id, err := ratingRoute(c)
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			if err := handlers.HandleFavorite(userID, routeEntity(c), id, favorite, db); err != nil {
+				return sendError(c, err)
+			}
+			return c.SendStatus(fiber.StatusOK)
+		}
+	}
+
+	app.Put("/api/ratings/:entity/:id/favorite", setFavorite(true))
+	app.Delete("/api/ratings/:entity/:id/favorite", setFavorite(false))
+
+	app.Put("/api/ratings/:entity/:id/note", func(c *fiber.Ctx) error {
+		userID, id, err := ratingRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		payload := struct {
+			Note string
+		}{}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleNote(userID, routeEntity(c), id, payload.Note, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Get("/api/users/:name/ratings/:entity", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		filter := data.RatingFilter{
+			MinStars:  c.QueryFloat("min_stars"),
+			Favorites: c.QueryBool("favorites"),
+			Limit:     c.QueryInt("limit"),
+		}
+		if maxSpins := c.QueryInt("max_spins", -1); maxSpins >= 0 {
+			spins := uint64(maxSpins)
+			filter.MaxSpins = &spins
+		}
+
+		ratings, err := handlers.HandleRatings(userID, routeEntity(c), filter, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(ratings)
+	})
+}
+
+// ratingRoute returns the ID of the requester and of the artist, track or
+// project in the route.
+func ratingRoute(c *fiber.Ctx) (userID, id uint64, err error) {
+	if userID, err = requestUserID(c); err != nil {
+		return
+	}
+	id, err = parseID(c, "id")
+	return
+}
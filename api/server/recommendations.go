@@ -0,0 +1,27 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerRecommendationRoutes(app *fiber.App, db data.RecommendationDB) {
+	app.Get("/api/recommendations", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		entity := data.EntityType(strings.TrimSuffix(c.Query("entity", "artists"), "s"))
+
+		recommendations, err := handlers.HandleRecommendations(userID, entity, c.QueryInt("limit"), time.Now(), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(recommendations)
+	})
+}
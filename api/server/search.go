@@ -0,0 +1,21 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+	"tunes-service/server/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerSearchRoutes serves catalog search. Like the catalog pages it is
+// public, and results the user played often rank higher.
+func registerSearchRoutes(app *fiber.App, db data.SearchDB) {
+	app.Get("/api/search", middleware.OptionalJWTMiddleware(), func(c *fiber.Ctx) error {
+		r, err := handlers.HandleSearch(c.Query("q"), middleware.Claims(c).UserID, c.QueryInt("limit"), c.QueryBool("typeahead"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(r)
+	})
+}
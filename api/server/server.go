@@ -2,20 +2,31 @@ package server
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 
 	"tunes-service/cache"
 	"tunes-service/data"
 	"tunes-service/server/handlers"
 	"tunes-service/server/middleware"
+	"tunes-service/storage"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func RunServer(db data.DB, adb data.AuthDB, cache cache.Cache) {
+func RunServer(db data.DB, adb data.AuthDB, cache cache.Cache, store storage.Store, policy data.PrimaryPolicy, achievements data.Achievements) {
 	app := fiber.New()
 
 	app.Use("/api/spin", middleware.JWTMiddleware())
+	app.Use("/api/admin", middleware.JWTMiddleware(), middleware.AdminMiddleware())
+	app.Use("/api/users", middleware.OptionalJWTMiddleware())
+	app.Use("/api/tags", middleware.JWTMiddleware())
+	app.Use("/api/tracks/:id/primary", middleware.JWTMiddleware())
+	app.Use("/api/feed", middleware.JWTMiddleware())
+	app.Use("/api/groups", middleware.JWTMiddleware())
+	app.Use("/api/recommendations", middleware.JWTMiddleware())
+	app.Use("/api/playlists", middleware.OptionalJWTMiddleware())
+	app.Use("/api/ratings", middleware.JWTMiddleware())
 
 	app.Post("/api/register", func(c *fiber.Ctx) error {
 		payload := struct {
@@ -83,7 +94,7 @@ func RunServer(db data.DB, adb data.AuthDB, cache cache.Cache) {
 		if at == "" || rt == "" {
 			return c.SendStatus(fiber.StatusBadRequest)
 		}
-		if newAccessToken, err := handlers.HandleRefresh(strings.Replace(at, "Bearer ", "", 1), rt, adb); err != nil {
+		if newAccessToken, err := handlers.HandleRefresh(strings.Replace(at, "Bearer ", "", 1), rt, db, adb); err != nil {
 			return c.SendStatus(fiber.StatusInternalServerError)
 		} else {
 			j, _ := json.Marshal(struct {
@@ -101,16 +112,85 @@ func RunServer(db data.DB, adb data.AuthDB, cache cache.Cache) {
 	})
 
 	app.Post("/api/spin", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
 		req := handlers.SpinRequest{}
 
-		err := c.BodyParser(&req)
+		err = c.BodyParser(&req)
 		if err != nil {
 			return err
 		}
+		// Spins are recorded for whoever sent them. Older clients still name
+		// the user in the body, which has to be the same one.
+		if req.UserID != 0 && uint64(req.UserID) != userID {
+			return c.SendStatus(fiber.StatusForbidden)
+		}
+		req.UserID = uint(userID)
+
+		if _, err := handlers.HandleSpin(req, db, cache, policy, achievements); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Post("/api/admin/:entity/merge", func(c *fiber.Ctx) error {
+		payload := struct {
+			From uint64
+			Into uint64
+		}{}
+
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleMerge(c.Params("entity"), payload.From, payload.Into, db, cache); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Post("/api/admin/artists/:id/aliases", func(c *fiber.Ctx) error {
+		payload := struct {
+			Name string
+		}{}
 
-		handlers.HandleSpin(req, db, cache)
+		id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+		if err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleArtistAlias(payload.Name, id, db, cache); err != nil {
+			return sendError(c, err)
+		}
 		return c.SendStatus(fiber.StatusOK)
 	})
 
+	registerStatsRoutes(app, db, db)
+	registerDiscoveryRoutes(app, db, db)
+	registerTagRoutes(app, db, db)
+	registerArtRoutes(app, db, store)
+	registerPrimaryRoutes(app, db, cache, policy)
+	registerSpinRoutes(app, db, db, cache, policy)
+	registerProjectRoutes(app, db, cache)
+	registerCatalogRoutes(app, db)
+	registerSearchRoutes(app, db)
+	registerMilestoneRoutes(app, db, db)
+	registerWrappedRoutes(app, db, db)
+	registerProfileRoutes(app, db)
+	registerSettingsRoutes(app, db)
+	registerHistoryRoutes(app, db, db)
+	registerFollowRoutes(app, db, db)
+	registerCompatibilityRoutes(app, db, db, cache)
+	registerGroupRoutes(app, db, db)
+	registerChartRoutes(app, db, db)
+	registerRecommendationRoutes(app, db)
+	registerPlaylistRoutes(app, db, db)
+	registerRatingRoutes(app, db, db)
+
 	app.Listen(":8080")
 }
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerSettingsRoutes(app *fiber.App, db data.ProfileDB) {
+	app.Get("/api/users/:name/settings", func(c *fiber.Ctx) error {
+		userID, err := routeSelf(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		s, err := handlers.HandleGetSettings(userID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(s)
+	})
+
+	app.Put("/api/users/:name/settings", func(c *fiber.Ctx) error {
+		userID, err := routeSelf(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		// Fields missing from the body keep their current values.
+		payload, err := handlers.HandleGetSettings(userID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		if err := c.BodyParser(&payload); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		if err := handlers.HandleUpdateSettings(userID, payload, db); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"time"
+
+	"tunes-service/cache"
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerSpinRoutes lets users fix their own spins: move one to another
+// track, delete one or delete every spin in a range.
+func registerSpinRoutes(app *fiber.App, db data.TunesDB, sdb data.SpinDB, cache cache.Cache, policy data.PrimaryPolicy) {
+	app.Put("/api/spin/:id", func(c *fiber.Ctx) error {
+		userID, spinID, err := spinRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		req := handlers.SpinRequest{}
+		if err := c.BodyParser(&req); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+
+		s, err := handlers.HandleEditSpin(userID, spinID, req, db, sdb, cache, policy)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(s)
+	})
+
+	app.Delete("/api/spin/:id", func(c *fiber.Ctx) error {
+		userID, spinID, err := spinRoute(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		if err := handlers.HandleDeleteSpin(userID, spinID, sdb, cache); err != nil {
+			return sendError(c, err)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	app.Delete("/api/spin", func(c *fiber.Ctx) error {
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, err := parseTime(c.Query("from"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+		to, err := parseTime(c.Query("to"), time.Time{})
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		n, err := handlers.HandleDeleteSpins(userID, from, to, sdb, cache)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(struct{ Deleted int64 }{n})
+	})
+}
+
+// spinRoute returns the ID of the requester and of the spin in the route.
+func spinRoute(c *fiber.Ctx) (userID, spinID uint64, err error) {
+	if userID, err = requestUserID(c); err != nil {
+		return
+	}
+	spinID, err = parseID(c, "id")
+	return
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerStatsRoutes(app *fiber.App, db data.StatsDB, pdb data.AccessDB) {
+	app.Get("/api/users/:name/stats/listening", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		lt, err := handlers.HandleListeningTime(userID, data.Period(c.Query("period", string(data.Day))), from, to, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(lt)
+	})
+
+	app.Get("/api/users/:name/stats/listening/artists", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		al, err := handlers.HandleArtistListening(userID, from, to, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(al)
+	})
+
+	app.Get("/api/users/:name/stats/listening/projects", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		pl, err := handlers.HandleProjectListening(userID, from, to, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(pl)
+	})
+
+	app.Get("/api/users/:name/stats/clock", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		clock, err := handlers.HandleListeningClock(userID, from, to, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(clock)
+	})
+
+	app.Get("/api/users/:name/stats/calendar", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		calendar, err := handlers.HandleCalendar(userID, from, to, db, pdb)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(calendar)
+	})
+}
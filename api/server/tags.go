@@ -0,0 +1,104 @@
+package server
+
+import (
+	"strings"
+
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerTagRoutes serves user tags under /api/tags and global tags under
+// /api/admin/tags. Routes name the entity in plural, e.g. /api/tags/artists/1.
+func registerTagRoutes(app *fiber.App, db data.TagDB, pdb data.AccessDB) {
+	addTag := func(userID func(c *fiber.Ctx) (uint64, error)) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			payload := struct {
+				Name string
+			}{}
+
+			key, err := parseID(c, "id")
+			if err != nil {
+				return sendError(c, err)
+			}
+			if err := c.BodyParser(&payload); err != nil {
+				return c.SendStatus(fiber.StatusBadRequest)
+			}
+			user, err := userID(c)
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			t, err := handlers.HandleAddTag(routeEntity(c), key, payload.Name, user, db)
+			if err != nil {
+				return sendError(c, err)
+			}
+			return c.JSON(t)
+		}
+	}
+
+	removeTag := func(userID func(c *fiber.Ctx) (uint64, error)) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			key, err := parseID(c, "id")
+			if err != nil {
+				return sendError(c, err)
+			}
+			user, err := userID(c)
+			if err != nil {
+				return sendError(c, err)
+			}
+
+			if err := handlers.HandleRemoveTag(routeEntity(c), key, c.Params("tag"), user, db); err != nil {
+				return sendError(c, err)
+			}
+			return c.SendStatus(fiber.StatusOK)
+		}
+	}
+
+	globalTags := func(c *fiber.Ctx) (uint64, error) { return 0, nil }
+
+	app.Get("/api/tags/:entity/:id", func(c *fiber.Ctx) error {
+		key, err := parseID(c, "id")
+		if err != nil {
+			return sendError(c, err)
+		}
+		userID, err := requestUserID(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		tags, err := handlers.HandleGetTags(routeEntity(c), key, userID, db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(tags)
+	})
+	app.Post("/api/tags/:entity/:id", addTag(requestUserID))
+	app.Delete("/api/tags/:entity/:id/:tag", removeTag(requestUserID))
+
+	app.Post("/api/admin/tags/:entity/:id", addTag(globalTags))
+	app.Delete("/api/admin/tags/:entity/:id/:tag", removeTag(globalTags))
+
+	app.Get("/api/users/:name/charts/tags", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		from, to, err := parseRange(c)
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		viewerID, _ := requestUserID(c)
+		chart, err := handlers.HandleTagChart(viewerID, userID, from, to, c.QueryInt("limit"), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(chart)
+	})
+}
+
+func routeEntity(c *fiber.Ctx) data.EntityType {
+	return data.EntityType(strings.TrimSuffix(c.Params("entity"), "s"))
+}
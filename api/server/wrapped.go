@@ -0,0 +1,27 @@
+package server
+
+import (
+	"tunes-service/data"
+	"tunes-service/server/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerWrappedRoutes(app *fiber.App, db data.WrappedDB, pdb data.AccessDB) {
+	app.Get("/api/users/:name/wrapped/:year", func(c *fiber.Ctx) error {
+		userID, err := routeUserID(c, pdb, data.StatsFeature)
+		if err != nil {
+			return sendError(c, err)
+		}
+		year, err := parseID(c, "year")
+		if err != nil {
+			return sendError(c, err)
+		}
+
+		w, err := handlers.HandleWrapped(userID, int(min(year, 10000)), db)
+		if err != nil {
+			return sendError(c, err)
+		}
+		return c.JSON(w)
+	})
+}
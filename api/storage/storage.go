@@ -0,0 +1,12 @@
+package storage
+
+import "errors"
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs by key. Keys are slash separated paths.
+type Store interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) ([]byte, error)
+	Exists(key string) (bool, error)
+}